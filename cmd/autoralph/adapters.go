@@ -5,7 +5,9 @@ import (
 	"fmt"
 	"os/exec"
 	"path/filepath"
+	"slices"
 	"strings"
+	"time"
 
 	"github.com/uesteibar/ralph/internal/autoralph/build"
 	"github.com/uesteibar/ralph/internal/autoralph/checks"
@@ -26,6 +28,9 @@ import (
 	"github.com/uesteibar/ralph/internal/gitops"
 	"github.com/uesteibar/ralph/internal/loop"
 	"github.com/uesteibar/ralph/internal/prd"
+	"github.com/uesteibar/ralph/internal/report"
+	"github.com/uesteibar/ralph/internal/sandbox"
+	"github.com/uesteibar/ralph/internal/secrets"
 	"github.com/uesteibar/ralph/internal/shell"
 	"github.com/uesteibar/ralph/internal/workspace"
 )
@@ -50,6 +55,7 @@ var (
 	_ rebase.BranchPuller          = (*branchPullerAdapter)(nil)
 	_ ghpoller.GitHubClient        = (*ghclient.Client)(nil)
 	_ invoker.EventInvoker         = (*claudeInvoker)(nil)
+	_ pr.RunReporter               = (*runReporterAdapter)(nil)
 )
 
 // claudeInvoker wraps claude.Invoke to satisfy the Invoker interface used by
@@ -58,33 +64,49 @@ type claudeInvoker struct {
 	// DisallowedTools prevents the AI from using specific tools.
 	// Used to block write operations during read-only phases like refinement.
 	DisallowedTools []string
+	// Phase selects the sandbox network policy and tool policy of the
+	// project's ralph.yaml.
+	Phase string
 }
 
 func (c *claudeInvoker) Invoke(ctx context.Context, prompt, dir string, maxTurns int) (string, error) {
-	return claude.Invoke(ctx, claude.InvokeOpts{
-		Prompt:          prompt,
-		Dir:             dir,
-		Print:           true,
-		MaxTurns:        maxTurns,
-		DisallowedTools: c.DisallowedTools,
-	})
+	return c.InvokeWithEvents(ctx, prompt, dir, maxTurns, nil)
 }
 
 func (c *claudeInvoker) InvokeWithEvents(ctx context.Context, prompt, dir string, maxTurns int, handler events.EventHandler) (string, error) {
+	project := projectConfig(dir)
+	policy, err := sandbox.New(project.Sandbox, c.Phase, dir)
+	if err != nil {
+		return "", err
+	}
+	tools := project.ToolPolicy[c.Phase]
 	return claude.Invoke(ctx, claude.InvokeOpts{
 		Prompt:          prompt,
 		Dir:             dir,
 		Print:           true,
 		MaxTurns:        maxTurns,
-		DisallowedTools: c.DisallowedTools,
+		AllowedTools:    tools.AllowRules(),
+		DisallowedTools: append(slices.Clone(c.DisallowedTools), tools.DenyRules()...),
 		EventHandler:    handler,
+		Sandbox:         policy,
 	})
 }
 
+// projectConfig returns the ralph.yaml governing dir. Projects without a
+// readable config get the zero config: no sandbox and no tool policy.
+func projectConfig(dir string) config.Config {
+	cfg, err := config.Discover(dir)
+	if err != nil {
+		return config.Config{}
+	}
+	return *cfg
+}
+
 // loopRunnerAdapter wraps loop.Run to satisfy worker.LoopRunner.
 type loopRunnerAdapter struct{}
 
 func (l *loopRunnerAdapter) Run(ctx context.Context, cfg worker.LoopConfig) error {
+	project := projectConfig(cfg.WorkDir)
 	return loop.Run(ctx, loop.Config{
 		MaxIterations: cfg.MaxIterations,
 		WorkDir:       cfg.WorkDir,
@@ -95,6 +117,11 @@ func (l *loopRunnerAdapter) Run(ctx context.Context, cfg worker.LoopConfig) erro
 		KnowledgePath: cfg.KnowledgePath,
 		Verbose:       cfg.Verbose,
 		EventHandler:  cfg.EventHandler,
+		Workspace:     cfg.Workspace,
+		Hooks:         project.Hooks,
+		Sandbox:       project.Sandbox,
+		ToolPolicy:    project.ToolPolicy,
+		Guardrails:    project.Guardrails,
 	})
 }
 
@@ -171,7 +198,7 @@ type workspaceCreatorAdapter struct {
 	pullFn func(ctx context.Context, r *shell.Runner, branch string) error
 }
 
-func (w *workspaceCreatorAdapter) Create(ctx context.Context, repoPath string, ws workspace.Workspace, base string, copyPatterns []string) error {
+func (w *workspaceCreatorAdapter) Create(ctx context.Context, repoPath string, ws workspace.Workspace, base string, opts workspace.CreateOptions) error {
 	r := &shell.Runner{Dir: repoPath}
 	// Prune stale worktree registrations before creating to avoid
 	// "already registered worktree" errors from previous failed attempts.
@@ -183,7 +210,7 @@ func (w *workspaceCreatorAdapter) Create(ctx context.Context, repoPath string, w
 		}
 	}
 
-	return workspace.CreateWorkspace(ctx, r, repoPath, ws, base, copyPatterns)
+	return workspace.CreateWorkspace(ctx, repoPath, ws, base, opts)
 }
 
 // gitPullerAdapter resolves the default base branch and pulls it via
@@ -250,6 +277,26 @@ func (p *prdReaderAdapter) ReadPRD(path string) (pr.PRDInfo, error) {
 	}, nil
 }
 
+// runReporterAdapter wraps report.Collect and report.Summary to satisfy
+// pr.RunReporter.
+type runReporterAdapter struct{}
+
+func (a *runReporterAdapter) RunSummary(ctx context.Context, projectLocalPath, workspaceName, base string) (string, error) {
+	wsPath := workspace.WorkspacePath(projectLocalPath, workspaceName)
+	r, err := report.Collect(ctx, report.Source{
+		Workspace:    workspaceName,
+		PRDPath:      workspace.PRDPathForWorkspace(projectLocalPath, workspaceName),
+		ProgressPath: workspace.ProgressPathForWorkspace(projectLocalPath, workspaceName),
+		LogsDir:      filepath.Join(wsPath, "logs"),
+		WorkDir:      workspace.TreePath(projectLocalPath, workspaceName),
+		Base:         base,
+	}, time.Now())
+	if err != nil {
+		return "", err
+	}
+	return report.Summary(r)
+}
+
 // gitOpsAdapter wraps gitops functions to satisfy feedback.GitOps, pr.GitPusher,
 // pr.DiffStatter, and pr.Rebaser.
 type gitOpsAdapter struct {
@@ -273,9 +320,30 @@ func (g *gitOpsAdapter) Commit(ctx context.Context, workDir, message string) err
 
 func (g *gitOpsAdapter) PushBranch(ctx context.Context, workDir, branch string) error {
 	r := &shell.Runner{Dir: workDir, Env: g.gitEnv()}
+	if err := scanBeforePush(ctx, r, branch); err != nil {
+		return err
+	}
 	return gitops.PushBranch(ctx, r, branch)
 }
 
+// scanBeforePush scans the commits of branch that no remote has yet for
+// secrets, honouring the project's allowlist. Every push AutoRalph makes goes
+// through it, so findings return a *pr.SecretsError and nothing is pushed.
+func scanBeforePush(ctx context.Context, r *shell.Runner, branch string) error {
+	allow, err := secrets.LoadRepoAllowlist(ctx, r)
+	if err != nil {
+		return err
+	}
+	findings, err := secrets.ScanUnpushed(ctx, r, branch, allow)
+	if err != nil {
+		return fmt.Errorf("scanning for secrets: %w", err)
+	}
+	if len(findings) > 0 {
+		return &pr.SecretsError{Findings: findings}
+	}
+	return nil
+}
+
 func (g *gitOpsAdapter) HeadSHA(ctx context.Context, workDir string) (string, error) {
 	r := &shell.Runner{Dir: workDir, Env: g.gitEnv()}
 	out, err := r.Run(ctx, "git", "rev-parse", "HEAD")
@@ -350,6 +418,9 @@ func (g *gitOpsAdapter) IsAncestor(ctx context.Context, workDir, ancestor, desce
 
 func (g *gitOpsAdapter) ForcePushBranch(ctx context.Context, workDir, branch string) error {
 	r := &shell.Runner{Dir: workDir}
+	if err := scanBeforePush(ctx, r, branch); err != nil {
+		return err
+	}
 	return gitops.ForcePushBranch(ctx, r, branch)
 }
 
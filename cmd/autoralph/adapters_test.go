@@ -2,10 +2,12 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
 	"os"
 	"os/exec"
+	"path/filepath"
 	"strings"
 	"testing"
 	"time"
@@ -17,7 +19,10 @@ import (
 	"github.com/uesteibar/ralph/internal/autoralph/github"
 	"github.com/uesteibar/ralph/internal/autoralph/invoker"
 	"github.com/uesteibar/ralph/internal/autoralph/orchestrator"
+	"github.com/uesteibar/ralph/internal/autoralph/pr"
 	"github.com/uesteibar/ralph/internal/autoralph/rebase"
+	"github.com/uesteibar/ralph/internal/autoralph/worker"
+	"github.com/uesteibar/ralph/internal/prd"
 	"github.com/uesteibar/ralph/internal/shell"
 	"github.com/uesteibar/ralph/internal/workspace"
 )
@@ -148,7 +153,7 @@ func TestWorkspaceCreatorAdapter_Create_PullsBeforeCreateWorkspace(t *testing.T)
 
 	// Create will fail on the actual git operations (no real repo), but we
 	// can verify pull was called first by checking order before the error.
-	_ = adapter.Create(context.Background(), t.TempDir(), workspace.Workspace{Name: "test-ws"}, "main", nil)
+	_ = adapter.Create(context.Background(), t.TempDir(), workspace.Workspace{Name: "test-ws"}, "main", workspace.CreateOptions{})
 
 	if len(callOrder) == 0 {
 		t.Fatal("expected pullFn to be called")
@@ -170,7 +175,7 @@ func TestWorkspaceCreatorAdapter_Create_ProceedsWhenPullFails(t *testing.T) {
 	// Even though pull fails, Create should still attempt workspace creation.
 	// It will fail on actual git ops, but that's fine — we're testing that
 	// pullFn failure doesn't prevent the call from proceeding.
-	_ = adapter.Create(context.Background(), t.TempDir(), workspace.Workspace{Name: "test-ws"}, "main", nil)
+	_ = adapter.Create(context.Background(), t.TempDir(), workspace.Workspace{Name: "test-ws"}, "main", workspace.CreateOptions{})
 
 	if !pullCalled {
 		t.Fatal("expected pullFn to be called")
@@ -183,7 +188,7 @@ func TestWorkspaceCreatorAdapter_Create_SkipsPullWhenNilFn(t *testing.T) {
 	}
 
 	// Should not panic — nil pullFn is simply skipped.
-	_ = adapter.Create(context.Background(), t.TempDir(), workspace.Workspace{Name: "test-ws"}, "main", nil)
+	_ = adapter.Create(context.Background(), t.TempDir(), workspace.Workspace{Name: "test-ws"}, "main", workspace.CreateOptions{})
 }
 
 func TestRebaseRunnerAdapter_RunRebase_BuildsCorrectCommand(t *testing.T) {
@@ -286,6 +291,44 @@ func TestGitOpsAdapter_Commit_UsesConfiguredIdentity(t *testing.T) {
 	}
 }
 
+func TestGitOpsAdapter_Pushes_BlockSecrets(t *testing.T) {
+	dir := initTestRepo(t)
+	ctx := context.Background()
+	remote := t.TempDir()
+	git := func(dir string, args ...string) string {
+		t.Helper()
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		out, err := cmd.CombinedOutput()
+		if err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+		return strings.TrimSpace(string(out))
+	}
+	git(remote, "init", "--bare")
+	branch := git(dir, "rev-parse", "--abbrev-ref", "HEAD")
+	git(dir, "remote", "add", "origin", remote)
+	git(dir, "push", "origin", branch)
+
+	os.WriteFile(dir+"/.env", []byte("KEY=AKIA"+"IOSFODNN7EXAMPLE\n"), 0644)
+	git(dir, "add", "-A")
+	git(dir, "commit", "-m", "add key")
+
+	adapter := &gitOpsAdapter{gitAuthorName: "ralph", gitAuthorEmail: "ralph@test.dev"}
+	for name, push := range map[string]func(context.Context, string, string) error{
+		"PushBranch":      adapter.PushBranch,
+		"ForcePushBranch": adapter.ForcePushBranch,
+	} {
+		var secretsErr *pr.SecretsError
+		if err := push(ctx, dir, branch); !errors.As(err, &secretsErr) {
+			t.Errorf("%s error = %v, want a SecretsError", name, err)
+		}
+	}
+	if got, want := git(remote, "rev-parse", branch), git(dir, "rev-parse", "HEAD~1"); got != want {
+		t.Errorf("remote %s = %s, want it left at %s", branch, got, want)
+	}
+}
+
 func TestGitOpsAdapter_HeadSHA_UsesConfiguredIdentity(t *testing.T) {
 	dir := initTestRepo(t)
 	ctx := context.Background()
@@ -441,7 +484,7 @@ func TestEndToEnd_CheckFailure_FixApplied_ChecksPass(t *testing.T) {
 	sm.Register(orchestrator.Transition{
 		From: orchestrator.StateFixingChecks,
 		To:   orchestrator.StateInReview,
-		Action: func(issue db.Issue, database *db.DB) error {
+		Action: func(_ context.Context, issue db.Issue, database *db.DB) error {
 			actionExecuted = true
 			return nil
 		},
@@ -640,3 +683,48 @@ func runSinglePollCycle(t *testing.T, p *ghpoller.Poller, ctx context.Context) {
 	defer cancel()
 	p.Run(ctx)
 }
+
+func TestLoopRunnerAdapter_Run_FiresProjectHooks(t *testing.T) {
+	repo := initTestRepo(t)
+	out := filepath.Join(t.TempDir(), "hook.txt")
+	os.MkdirAll(filepath.Join(repo, ".ralph"), 0755)
+	// Keep the tree clean so the loop can finish.
+	if err := os.WriteFile(filepath.Join(repo, ".git", "info", "exclude"), []byte(".ralph/\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	yaml := "project: Test\nrepo:\n  default_base: main\nhooks:\n  run_finished:\n" +
+		"    - command: 'echo \"$RALPH_HOOK_EVENT $RALPH_WORKSPACE\" > " + out + "'\n"
+	if err := os.WriteFile(filepath.Join(repo, ".ralph", "ralph.yaml"), []byte(yaml), 0644); err != nil {
+		t.Fatal(err)
+	}
+	tree := workspace.TreePath(repo, "login")
+	os.MkdirAll(tree, 0755)
+	prdPath := workspace.PRDPathForWorkspace(repo, "login")
+	done := &prd.PRD{
+		UserStories:      []prd.Story{{ID: "US-001", Title: "Done", Passes: true}},
+		IntegrationTests: []prd.IntegrationTest{{ID: "IT-001", Description: "Done", Passes: true}},
+	}
+	if err := prd.Write(prdPath, done); err != nil {
+		t.Fatal(err)
+	}
+
+	// Everything passes, so the loop finishes without invoking Claude.
+	err := (&loopRunnerAdapter{}).Run(context.Background(), worker.LoopConfig{
+		MaxIterations: 1,
+		WorkDir:       tree,
+		PRDPath:       prdPath,
+		ProgressPath:  workspace.ProgressPathForWorkspace(repo, "login"),
+		Workspace:     "login",
+	})
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+
+	data, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatalf("run_finished hook did not run: %v", err)
+	}
+	if got := strings.TrimSpace(string(data)); got != "run_finished login" {
+		t.Errorf("hook saw %q, want %q", got, "run_finished login")
+	}
+}
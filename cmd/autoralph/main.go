@@ -16,12 +16,14 @@ import (
 	"github.com/uesteibar/ralph/internal/autoralph/checks"
 	"github.com/uesteibar/ralph/internal/autoralph/complete"
 	"github.com/uesteibar/ralph/internal/claude"
+	"github.com/uesteibar/ralph/internal/commands"
 	"github.com/uesteibar/ralph/internal/autoralph/credentials"
 	"github.com/uesteibar/ralph/internal/autoralph/db"
 	"github.com/uesteibar/ralph/internal/autoralph/feedback"
 	"github.com/uesteibar/ralph/internal/autoralph/ghpoller"
 	ghclient "github.com/uesteibar/ralph/internal/autoralph/github"
 	"github.com/uesteibar/ralph/internal/autoralph/linear"
+	"github.com/uesteibar/ralph/internal/autoralph/metrics"
 	"github.com/uesteibar/ralph/internal/autoralph/orchestrator"
 	"github.com/uesteibar/ralph/internal/autoralph/poller"
 	"github.com/uesteibar/ralph/internal/autoralph/pr"
@@ -30,7 +32,9 @@ import (
 	"github.com/uesteibar/ralph/internal/autoralph/refine"
 	"github.com/uesteibar/ralph/internal/autoralph/server"
 	"github.com/uesteibar/ralph/internal/autoralph/worker"
+	"github.com/uesteibar/ralph/internal/config"
 	"github.com/uesteibar/ralph/internal/gitops"
+	"github.com/uesteibar/ralph/internal/tracing"
 	"github.com/uesteibar/ralph/internal/workspace"
 )
 
@@ -66,6 +70,9 @@ func main() {
 	switch subcmd {
 	case "serve":
 		err = runServe(rest)
+	case "_hook":
+		// Git hooks in the workspaces AutoRalph creates call back into it.
+		err = commands.Hook(rest)
 	case "--version", "version":
 		fmt.Println("autoralph " + version)
 		return
@@ -128,6 +135,13 @@ func runServe(args []string) error {
 	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
 	defer stop()
 
+	// Export traces when OTEL_EXPORTER_OTLP_ENDPOINT or RALPH_TRACE_FILE is set.
+	shutdownTracing, err := tracing.Setup(ctx, config.TracingConfig{}, "autoralph")
+	if err != nil {
+		return fmt.Errorf("setting up tracing: %w", err)
+	}
+	defer shutdownTracing(context.WithoutCancel(ctx))
+
 	// --- 2. Open database ---
 	dbPath, err := db.DefaultPath()
 	if err != nil {
@@ -138,6 +152,7 @@ func runServe(args []string) error {
 		return fmt.Errorf("opening database: %w", err)
 	}
 	defer database.Close()
+	database.OnTransition(metrics.CountTransition)
 
 	// --- 3. Load and sync project configs ---
 	configDir := credentials.DefaultPath()
@@ -281,11 +296,11 @@ func runServe(args []string) error {
 	sm := orchestrator.New(database)
 
 	if hasLinear {
-		invoker := &claudeInvoker{}
 		// readOnlyInvoker blocks write tools so the AI can only read the
 		// codebase during refinement and iteration — no code changes.
 		readOnlyInvoker := &claudeInvoker{
 			DisallowedTools: []string{"Edit", "Write", "Bash", "NotebookEdit"},
+			Phase:           config.PhaseRefine,
 		}
 		cfgLoader := &configLoaderAdapter{}
 		puller := &gitPullerAdapter{
@@ -311,7 +326,7 @@ func runServe(args []string) error {
 		sm.Register(orchestrator.Transition{
 			From: orchestrator.StateQueued,
 			To:   orchestrator.StateRefining,
-			Action: func(issue db.Issue, database *db.DB) error {
+			Action: func(ctx context.Context, issue db.Issue, database *db.DB) error {
 				lc, err := registry.mustLinear(issue.ProjectID)
 				if err != nil {
 					return err
@@ -322,7 +337,7 @@ func runServe(args []string) error {
 					Projects:     database,
 					GitPuller:    puller,
 					OnBuildEvent: onBuildEvent,
-				})(issue, database)
+				})(ctx, issue, database)
 			},
 		})
 
@@ -354,7 +369,7 @@ func runServe(args []string) error {
 				}
 				return approve.IsApproval(cc)(issue)
 			},
-			Action: func(issue db.Issue, database *db.DB) error {
+			Action: func(ctx context.Context, issue db.Issue, database *db.DB) error {
 				lc, err := registry.mustLinear(issue.ProjectID)
 				if err != nil {
 					return err
@@ -363,7 +378,7 @@ func runServe(args []string) error {
 					Comments: lc,
 					Projects: database,
 					Reactor:  lc,
-				})(issue, database)
+				})(ctx, issue, database)
 			},
 		})
 
@@ -378,7 +393,7 @@ func runServe(args []string) error {
 				}
 				return approve.IsIteration(cc)(issue)
 			},
-			Action: func(issue db.Issue, database *db.DB) error {
+			Action: func(ctx context.Context, issue db.Issue, database *db.DB) error {
 				lc, err := registry.mustLinear(issue.ProjectID)
 				if err != nil {
 					return err
@@ -390,7 +405,7 @@ func runServe(args []string) error {
 					GitPuller:    puller,
 					Reactor:      lc,
 					OnBuildEvent: onBuildEvent,
-				})(issue, database)
+				})(ctx, issue, database)
 			},
 		})
 
@@ -398,19 +413,19 @@ func runServe(args []string) error {
 		sm.Register(orchestrator.Transition{
 			From: orchestrator.StateApproved,
 			To:   orchestrator.StateBuilding,
-			Action: func(issue db.Issue, database *db.DB) error {
+			Action: func(ctx context.Context, issue db.Issue, database *db.DB) error {
 				lc, err := registry.mustLinear(issue.ProjectID)
 				if err != nil {
 					return err
 				}
 				return build.NewAction(build.Config{
-					Invoker:    invoker,
+					Invoker:    &claudeInvoker{Phase: config.PhasePlan},
 					Workspace:  &workspaceCreatorAdapter{pullFn: gitops.PullFFOnly},
 					ConfigLoad: &configLoaderAdapter{},
 					Linear:     &buildLinearUpdater{client: lc},
 					PRDRead:    &buildPRDReaderAdapter{},
 					Projects:   database,
-				})(issue, database)
+				})(ctx, issue, database)
 			},
 		})
 
@@ -420,7 +435,7 @@ func runServe(args []string) error {
 				From:      orchestrator.StateAddressingFeedback,
 				To:        orchestrator.StateInReview,
 				Condition: feedback.IsAddressingFeedback,
-				Action: func(issue db.Issue, database *db.DB) error {
+				Action: func(ctx context.Context, issue db.Issue, database *db.DB) error {
 					gc, err := registry.mustGitHub(issue.ProjectID)
 					if err != nil {
 						return err
@@ -431,7 +446,7 @@ func runServe(args []string) error {
 						gitAuthorEmail: gitEmail,
 					}
 					return feedback.NewAction(feedback.Config{
-						Invoker:       invoker,
+						Invoker:       &claudeInvoker{Phase: config.PhaseFeedback},
 						Comments:      gc,
 						Reviews:       gc,
 						IssueComments: gc,
@@ -445,7 +460,7 @@ func runServe(args []string) error {
 						BranchPuller:  &branchPullerAdapter{},
 						OnBuildEvent:  onBuildEvent,
 						TrustedUser:   registry.githubUsername(issue.ProjectID),
-					})(issue, database)
+					})(ctx, issue, database)
 				},
 			})
 
@@ -453,7 +468,7 @@ func runServe(args []string) error {
 			sm.Register(orchestrator.Transition{
 				From: orchestrator.StateFixingChecks,
 				To:   orchestrator.StateInReview,
-				Action: func(issue db.Issue, database *db.DB) error {
+				Action: func(ctx context.Context, issue db.Issue, database *db.DB) error {
 					gc, err := registry.mustGitHub(issue.ProjectID)
 					if err != nil {
 						return err
@@ -464,7 +479,7 @@ func runServe(args []string) error {
 						gitAuthorEmail: gitEmail,
 					}
 					return checks.NewAction(checks.Config{
-						Invoker:      invoker,
+						Invoker:      &claudeInvoker{Phase: config.PhaseFixChecks},
 						CheckRuns:    gc,
 						Logs:         gc,
 						PRs:          gc,
@@ -474,7 +489,7 @@ func runServe(args []string) error {
 						ConfigLoad:   &configLoaderAdapter{},
 						BranchPuller: &branchPullerAdapter{},
 						OnBuildEvent: onBuildEvent,
-					})(issue, database)
+					})(ctx, issue, database)
 				},
 			})
 
@@ -501,7 +516,7 @@ func runServe(args []string) error {
 	// --- 7. PR and complete actions ---
 	var prAction worker.PRCreator
 	if hasLinear && hasGitHub {
-		prAction = &prActionAdapter{fn: func(issue db.Issue, database *db.DB) error {
+		prAction = &prActionAdapter{fn: func(ctx context.Context, issue db.Issue, database *db.DB) error {
 			lc, err := registry.mustLinear(issue.ProjectID)
 			if err != nil {
 				return err
@@ -516,7 +531,7 @@ func runServe(args []string) error {
 				gitAuthorEmail: gitEmail,
 			}
 			return pr.NewAction(pr.Config{
-				Invoker:    &claudeInvoker{},
+				Invoker:    &claudeInvoker{Phase: config.PhasePR},
 				Git:        gitOps,
 				Diff:       gitOps,
 				PRD:        &prdReaderAdapter{},
@@ -525,13 +540,14 @@ func runServe(args []string) error {
 				Projects:   database,
 				ConfigLoad: &configLoaderAdapter{},
 				Rebase:     gitOps,
-			})(issue, database)
+				Report:     &runReporterAdapter{},
+			})(ctx, issue, database)
 		}}
 	}
 
 	var completeAction ghpoller.CompleteFunc
 	if hasLinear {
-		completeAction = func(issue db.Issue, database *db.DB) error {
+		completeAction = func(ctx context.Context, issue db.Issue, database *db.DB) error {
 			lc, err := registry.mustLinear(issue.ProjectID)
 			if err != nil {
 				return err
@@ -540,7 +556,7 @@ func runServe(args []string) error {
 				Workspace: &workspaceRemoverAdapter{},
 				Linear:    &completeLinearUpdater{client: lc},
 				Projects:  database,
-			})(issue, database)
+			})(ctx, issue, database)
 		}
 	}
 
@@ -606,6 +622,11 @@ func runServe(args []string) error {
 		CCUsageProvider:  ccPoller,
 		Wake:             wake,
 		ModelName:        modelName,
+		Metrics: metrics.Handler(metrics.Sources{
+			DB:      database,
+			Workers: dispatcher,
+			CCUsage: ccPoller,
+		}),
 	}
 	srv, err := server.New(addr, cfg)
 	if err != nil {
@@ -824,10 +845,8 @@ func dispatchAsync(
 
 	actionFn := func(actionCtx context.Context) error {
 		// Run the action.
-		if tr.Action != nil {
-			if err := tr.Action(issue, database); err != nil {
-				return fmt.Errorf("running transition action: %w", err)
-			}
+		if err := tr.RunAction(actionCtx, issue, database); err != nil {
+			return fmt.Errorf("running transition action: %w", err)
 		}
 
 		// Transition state in a transaction (mirrors sm.Execute post-action logic).
@@ -840,7 +859,7 @@ func dispatchAsync(
 			if err := tx.UpdateIssue(current); err != nil {
 				return fmt.Errorf("updating issue state: %w", err)
 			}
-			if err := tx.LogActivity(
+			if err := tx.RecordTransition(
 				issue.ID,
 				"state_change",
 				string(tr.From),
@@ -930,9 +949,9 @@ func isTerminalState(state string) bool {
 
 // prActionAdapter wraps a pr action function to satisfy worker.PRCreator.
 type prActionAdapter struct {
-	fn func(issue db.Issue, database *db.DB) error
+	fn func(ctx context.Context, issue db.Issue, database *db.DB) error
 }
 
-func (a *prActionAdapter) CreatePR(issue db.Issue, database *db.DB) error {
-	return a.fn(issue, database)
+func (a *prActionAdapter) CreatePR(ctx context.Context, issue db.Issue, database *db.DB) error {
+	return a.fn(ctx, issue, database)
 }
@@ -144,7 +144,7 @@ func TestDispatchAsync_RunsActionAndTransitionsState(t *testing.T) {
 	tr := orchestrator.Transition{
 		From: orchestrator.StateAddressingFeedback,
 		To:   orchestrator.StateInReview,
-		Action: func(i db.Issue, d *db.DB) error {
+		Action: func(_ context.Context, i db.Issue, d *db.DB) error {
 			actionCalled = true
 			return nil
 		},
@@ -205,7 +205,7 @@ func TestDispatchAsync_SkipsWhenAlreadyRunning(t *testing.T) {
 	tr := orchestrator.Transition{
 		From: orchestrator.StateAddressingFeedback,
 		To:   orchestrator.StateInReview,
-		Action: func(i db.Issue, d *db.DB) error {
+		Action: func(_ context.Context, i db.Issue, d *db.DB) error {
 			mu.Lock()
 			actionCount++
 			mu.Unlock()
@@ -258,7 +258,7 @@ func TestDispatchAsync_ActionError_SetsFailedState(t *testing.T) {
 	tr := orchestrator.Transition{
 		From: orchestrator.StateFixingChecks,
 		To:   orchestrator.StateInReview,
-		Action: func(i db.Issue, d *db.DB) error {
+		Action: func(_ context.Context, i db.Issue, d *db.DB) error {
 			return fmt.Errorf("AI invocation failed")
 		},
 	}
@@ -295,7 +295,7 @@ func TestDispatchAsync_RebaseTransition_TransitionsToSameState(t *testing.T) {
 	tr := orchestrator.Transition{
 		From: orchestrator.StateInReview,
 		To:   orchestrator.StateInReview,
-		Action: func(i db.Issue, d *db.DB) error {
+		Action: func(_ context.Context, i db.Issue, d *db.DB) error {
 			return nil
 		},
 	}
@@ -328,7 +328,7 @@ func TestDispatchAsync_WithHub_BroadcastsWithoutPanic(t *testing.T) {
 	tr := orchestrator.Transition{
 		From: orchestrator.StateAddressingFeedback,
 		To:   orchestrator.StateInReview,
-		Action: func(i db.Issue, d *db.DB) error {
+		Action: func(_ context.Context, i db.Issue, d *db.DB) error {
 			return nil
 		},
 	}
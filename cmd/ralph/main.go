@@ -15,23 +15,34 @@ func usage() {
 Usage:
   ralph init                                     Scaffold .ralph/ directory and config
   ralph validate [--project-config path]         Validate project configuration
-  ralph run [--project-config path] [--max-iterations n] [--workspace name] [--no-tui]   Run the agent loop
+  ralph run [--project-config path] [--max-iterations n] [--workspace name] [--no-tui] [--story id | --until id | --skip-qa | --qa-only]   Run the agent loop
   ralph chat [--project-config path] [--continue] [--workspace name]   Ad-hoc Claude session
   ralph switch [name] [--project-config path]    Switch workspace (interactive picker if no name)
-  ralph rebase [branch] [--project-config path] [--workspace name]   Rebase onto base branch
-  ralph new <name> [--project-config path]                Alias for ralph workspaces new
+  ralph rebase [branch] [--stack] [--project-config path] [--workspace name]   Rebase onto base branch (or the whole stack)
+  ralph new <name> [--on parent] [--from-branch b | --from-pr n]  Alias for ralph workspaces new
   ralph eject [--project-config path]              Export prompt templates to .ralph/prompts/ for customization
   ralph tui [--project-config path]            Multi-workspace overview TUI
   ralph attach [--project-config path] [--workspace name] [--no-tui]  Attach to a running daemon's viewer
   ralph stop [<name>] [--project-config path] [--workspace name]   Stop a running daemon
-  ralph done [--project-config path] [--workspace name]   Squash-merge and clean up
+  ralph done [--strategy squash|rebase|merge] [--check] [--push] [--pr] [--workspace name]   Merge and clean up, or open a PR
   ralph status [--project-config path] [--short] Show workspace and story progress
   ralph overview [--project-config path]         Show progress across all workspaces
-  ralph workspaces new <name> [--project-config path]   Create a new workspace
+  ralph log [--project-config path] [--workspace name]   List stories with the commits that implemented them
+  ralph logs [--workspace name] [--story id] [--type t] [--grep re] [--since d] [--json] [--follow]   Search the event logs of past runs
+  ralph logs replay [--workspace name] [--story id] [--speed n]   Play a recorded session back in the TUI
+  ralph report [--workspace name] [--format md|html] [--output file]   Write a report of the run: stories, commands, tokens, commits
+  ralph revert-story <story-id> [--workspace name]   Revert a story's commits and mark it as not passing
+  ralph workspaces new <name> [--on parent] [--from-branch b | --from-pr n]   Create a new workspace (optionally stacked, or on an existing branch or PR)
   ralph workspaces list [--project-config path]  List all workspaces
   ralph workspaces switch <name>                 Switch to a workspace
   ralph workspaces remove <name>                 Remove a workspace
   ralph workspaces prune [--project-config path]  Remove all done workspaces
+  ralph workspaces fork <name> <variant> [--at story]  Fork a workspace to try an alternative implementation
+  ralph workspaces compare <a> <b> [--checks]    Compare two workspace variants side by side
+  ralph workspaces archive <name> [--output file]  Bundle a workspace into a tarball
+  ralph workspaces restore <file>                Recreate a workspace from an archive
+  ralph workspaces gc [name...]                  Compress and prune workspace logs
+  ralph prd repair [--workspace name]            Restore a corrupted PRD from its last good backup
   ralph check [--tail N] <command> [args...]       Run command with compact output, log full output
   ralph shell-init                               Print shell integration (eval in .bashrc/.zshrc)
 
@@ -41,6 +52,9 @@ Flags:
   --workspace         Workspace name to run in (resolves workDir and prdPath)
   --short             Short output for shell prompt embedding (status command only)
   --no-tui            Disable TUI and use plain-text output (run command only)
+  --story, --until    Work on one story, or stop after a story, without QA (run command only)
+  --skip-qa           Stop when all stories pass, without the QA phase (run command only)
+  --qa-only           Run only the QA phase (run command only)
   --continue          Resume the most recent conversation (chat command only)
 `)
 }
@@ -75,6 +89,14 @@ func main() {
 		err = commands.Status(rest)
 	case "overview":
 		err = commands.Overview(rest)
+	case "log":
+		err = commands.Log(rest)
+	case "logs":
+		err = commands.Logs(rest)
+	case "report":
+		err = commands.Report(rest)
+	case "revert-story":
+		err = commands.RevertStory(rest)
 	case "switch":
 		err = commands.Switch(rest)
 	case "rebase":
@@ -100,6 +122,8 @@ func main() {
 		err = commands.ShellInit(rest)
 	case "_daemon":
 		err = commands.Daemon(rest)
+	case "_hook":
+		err = commands.Hook(rest)
 	case "--version", "version":
 		fmt.Println("ralph " + version)
 		return
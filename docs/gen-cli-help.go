@@ -25,19 +25,24 @@ type command struct {
 var commands = []command{
 	{Name: "init", Description: "Scaffold .ralph/ directory and config", Usage: "ralph init"},
 	{Name: "validate", Description: "Validate project configuration", Usage: "ralph validate [--project-config path]"},
-	{Name: "run", Description: "Run the agent loop", Usage: "ralph run [--project-config path] [--max-iterations n] [--workspace name] [--no-tui]"},
+	{Name: "run", Description: "Run the agent loop", Usage: "ralph run [--project-config path] [--max-iterations n] [--workspace name] [--no-tui] [--story id | --until id | --skip-qa | --qa-only]"},
 	{Name: "chat", Description: "Ad-hoc Claude session", Usage: "ralph chat [--project-config path] [--continue] [--workspace name]"},
 	{Name: "switch", Description: "Switch workspace (interactive picker if no name)", Usage: "ralph switch [name] [--project-config path]"},
-	{Name: "rebase", Description: "Rebase onto base branch", Usage: "ralph rebase [branch] [--project-config path] [--workspace name]"},
-	{Name: "new", Description: "Create a new workspace (alias for `ralph workspaces new`)", Usage: "ralph new <name> [--project-config path]"},
+	{Name: "rebase", Description: "Rebase onto base branch", Usage: "ralph rebase [branch] [--stack] [--project-config path] [--workspace name]"},
+	{Name: "new", Description: "Create a new workspace (alias for `ralph workspaces new`)", Usage: "ralph new <name> [--on parent] [--from-branch branch | --from-pr number] [--project-config path]"},
 	{Name: "eject", Description: "Export prompt templates to .ralph/prompts/ for customization", Usage: "ralph eject [--project-config path]"},
 	{Name: "tui", Description: "Multi-workspace overview TUI", Usage: "ralph tui [--project-config path]"},
 	{Name: "attach", Description: "Attach to a running daemon's viewer", Usage: "ralph attach [--project-config path] [--workspace name] [--no-tui]"},
 	{Name: "stop", Description: "Stop a running daemon", Usage: "ralph stop [<name>] [--project-config path] [--workspace name]"},
-	{Name: "done", Description: "Squash-merge and clean up", Usage: "ralph done [--project-config path] [--workspace name]"},
+	{Name: "done", Description: "Merge into the base branch and clean up", Usage: "ralph done [--strategy squash|rebase|merge] [--check] [--push] [--pr] [--project-config path] [--workspace name]"},
 	{Name: "status", Description: "Show workspace and story progress", Usage: "ralph status [--project-config path] [--short]"},
 	{Name: "overview", Description: "Show progress across all workspaces", Usage: "ralph overview [--project-config path]"},
-	{Name: "workspaces", Description: "Manage workspaces (new, list, switch, remove, prune)", Usage: "ralph workspaces <subcommand> [args...]", SkipHelp: true},
+	{Name: "log", Description: "List stories with the commits that implemented them", Usage: "ralph log [--project-config path] [--workspace name]"},
+	{Name: "logs", Description: "Search the event logs of past runs, or replay one in the TUI with `ralph logs replay [--speed n]`", Usage: "ralph logs [replay] [--project-config path] [--workspace name] [--story id] [--type ToolUse,...] [--grep pattern] [--since 2h] [--json] [--follow]"},
+	{Name: "report", Description: "Write a Markdown or HTML report of the run: stories, commands, check results, tokens, commits and diff stats", Usage: "ralph report [--project-config path] [--workspace name] [--format md|html] [--output file]"},
+	{Name: "revert-story", Description: "Revert a story's commits and mark it as not passing", Usage: "ralph revert-story <story-id> [--project-config path] [--workspace name]"},
+	{Name: "workspaces", Description: "Manage workspaces (new, list, switch, remove, prune, fork, compare, archive, restore, gc)", Usage: "ralph workspaces <subcommand> [args...]", SkipHelp: true},
+	{Name: "prd repair", Description: "Restore a corrupted PRD from its last known good backup", Usage: "ralph prd repair [--project-config path] [--workspace name]", SkipHelp: true},
 	{Name: "check", Description: "Run command with compact output, log full output", Usage: "ralph check [--tail N] <command> [args...]", SkipHelp: true},
 	{Name: "shell-init", Description: "Print shell integration (eval in .bashrc/.zshrc)", Usage: "ralph shell-init", SkipHelp: true},
 }
@@ -77,11 +82,16 @@ func main() {
 			sb.WriteString("**Subcommands:**\n\n")
 			sb.WriteString("| Subcommand | Description |\n")
 			sb.WriteString("|------------|-------------|\n")
-			sb.WriteString("| `new <name>` | Create a new workspace |\n")
+			sb.WriteString("| `new <name> [--on parent] [--from-branch branch \\| --from-pr number]` | Create a new workspace, optionally stacked on another or on an existing branch or pull request |\n")
 			sb.WriteString("| `list` | List all workspaces |\n")
 			sb.WriteString("| `switch <name>` | Switch to a workspace |\n")
 			sb.WriteString("| `remove <name>` | Remove a workspace |\n")
-			sb.WriteString("| `prune` | Remove all done workspaces |\n\n")
+			sb.WriteString("| `prune` | Remove all done workspaces |\n")
+			sb.WriteString("| `fork <name> <variant> [--at story-id]` | Fork a workspace into a variant, optionally from the commit of an earlier story |\n")
+			sb.WriteString("| `compare <a> <b> [--checks]` | Compare two variants: diff size, stories, test results, token usage, and optionally quality checks |\n")
+			sb.WriteString("| `archive <name> [--output file]` | Write the branch (as a git bundle), PRD, progress, run status, logs and registry entry to a tarball |\n")
+			sb.WriteString("| `restore <file>` | Recreate a workspace's worktree and registry entry from an archive |\n")
+			sb.WriteString("| `gc [name...]` | Compress closed logs and apply the `logs:` retention policy (all workspaces by default) |\n\n")
 		}
 	}
 
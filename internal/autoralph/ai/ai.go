@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"text/template"
 )
 
@@ -129,6 +130,42 @@ func RenderPRDescription(data PRDescriptionData, overrideDir string) (string, er
 	return render("templates/pr_description.md", data, overrideDir)
 }
 
+// ParsePRDescription splits the pr_description response into title (first line) and body (rest).
+func ParsePRDescription(output string) (string, string) {
+	output = strings.TrimSpace(output)
+	parts := strings.SplitN(output, "\n", 2)
+	title := strings.TrimSpace(parts[0])
+	var body string
+	if len(parts) > 1 {
+		body = strings.TrimSpace(parts[1])
+	}
+	return title, body
+}
+
+// CapDiffStats caps the diff stats output to maxEntries file entries plus the
+// final summary line. If there are more entries, a marker is inserted.
+func CapDiffStats(stats string, maxEntries int) string {
+	if stats == "" {
+		return stats
+	}
+	lines := strings.Split(stats, "\n")
+	// The last line is the summary (e.g. " 80 files changed, ...").
+	// Everything before it is a file entry.
+	if len(lines) <= maxEntries+1 {
+		return stats
+	}
+
+	summary := lines[len(lines)-1]
+	omitted := len(lines) - 1 - maxEntries
+	marker := fmt.Sprintf("[... %d file entries omitted ...]", omitted)
+
+	result := make([]string, 0, maxEntries+2)
+	result = append(result, lines[:maxEntries]...)
+	result = append(result, marker)
+	result = append(result, summary)
+	return strings.Join(result, "\n")
+}
+
 // RenderAddressFeedback renders the prompt for addressing review feedback.
 func RenderAddressFeedback(data AddressFeedbackData, overrideDir string) (string, error) {
 	return render("templates/address_feedback.md", data, overrideDir)
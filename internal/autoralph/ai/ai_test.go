@@ -1,6 +1,7 @@
 package ai
 
 import (
+	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
@@ -972,3 +973,117 @@ func TestRenderRefineIssue_WithoutContextPrefix_RendersIssueSection(t *testing.T
 		t.Error("output should not contain context prefix when not set")
 	}
 }
+
+func TestParsePRDescription_TitleAndBody(t *testing.T) {
+	input := "feat(auth): add login\n\n## Summary\n- Added login flow"
+	title, body := ParsePRDescription(input)
+	if title != "feat(auth): add login" {
+		t.Errorf("expected title %q, got %q", "feat(auth): add login", title)
+	}
+	if !strings.Contains(body, "## Summary") {
+		t.Errorf("expected body to contain ## Summary, got: %s", body)
+	}
+}
+
+func TestParsePRDescription_TitleOnly(t *testing.T) {
+	title, body := ParsePRDescription("feat: quick fix")
+	if title != "feat: quick fix" {
+		t.Errorf("expected title %q, got %q", "feat: quick fix", title)
+	}
+	if body != "" {
+		t.Errorf("expected empty body, got %q", body)
+	}
+}
+
+func TestParsePRDescription_TrimsWhitespace(t *testing.T) {
+	input := "\n  feat: something  \n\n  body text  \n"
+	title, body := ParsePRDescription(input)
+	if title != "feat: something" {
+		t.Errorf("expected title %q, got %q", "feat: something", title)
+	}
+	if body != "body text" {
+		t.Errorf("expected body %q, got %q", "body text", body)
+	}
+}
+
+// --- CapDiffStats tests ---
+
+func TestCapDiffStats_UnderLimit(t *testing.T) {
+	var lines []string
+	for i := 0; i < 20; i++ {
+		lines = append(lines, fmt.Sprintf(" file%d.go | %d +++", i, i+1))
+	}
+	lines = append(lines, " 20 files changed, 100 insertions(+), 50 deletions(-)")
+	stats := strings.Join(lines, "\n")
+
+	result := CapDiffStats(stats, 50)
+	if result != stats {
+		t.Error("expected diff stats with 20 entries to be returned unchanged")
+	}
+}
+
+func TestCapDiffStats_ExactlyAtLimit(t *testing.T) {
+	var lines []string
+	for i := 0; i < 50; i++ {
+		lines = append(lines, fmt.Sprintf(" file%d.go | %d +++", i, i+1))
+	}
+	lines = append(lines, " 50 files changed, 300 insertions(+), 100 deletions(-)")
+	stats := strings.Join(lines, "\n")
+
+	result := CapDiffStats(stats, 50)
+	if result != stats {
+		t.Error("expected diff stats with exactly 50 entries to be returned unchanged")
+	}
+}
+
+func TestCapDiffStats_OverLimit_CapsAndKeepsSummary(t *testing.T) {
+	var lines []string
+	for i := 0; i < 80; i++ {
+		lines = append(lines, fmt.Sprintf(" file%d.go | %d +++", i, i+1))
+	}
+	summaryLine := " 80 files changed, 500 insertions(+), 200 deletions(-)"
+	lines = append(lines, summaryLine)
+	stats := strings.Join(lines, "\n")
+
+	result := CapDiffStats(stats, 50)
+	resultLines := strings.Split(result, "\n")
+
+	// First 50 file entries preserved
+	for i := 0; i < 50; i++ {
+		expected := fmt.Sprintf(" file%d.go | %d +++", i, i+1)
+		if resultLines[i] != expected {
+			t.Errorf("line %d: expected %q, got %q", i, expected, resultLines[i])
+		}
+	}
+
+	// Truncation marker
+	if !strings.Contains(resultLines[50], "... 30 file entries omitted ...") {
+		t.Errorf("expected truncation marker, got %q", resultLines[50])
+	}
+
+	// Summary line preserved at the end
+	lastLine := resultLines[len(resultLines)-1]
+	if lastLine != summaryLine {
+		t.Errorf("expected summary line %q, got %q", summaryLine, lastLine)
+	}
+
+	// Total: 50 entries + 1 marker + 1 summary = 52
+	if len(resultLines) != 52 {
+		t.Errorf("expected 52 lines, got %d", len(resultLines))
+	}
+}
+
+func TestCapDiffStats_EmptyInput(t *testing.T) {
+	result := CapDiffStats("", 50)
+	if result != "" {
+		t.Errorf("expected empty result, got %q", result)
+	}
+}
+
+func TestCapDiffStats_SummaryOnly(t *testing.T) {
+	stats := " 1 file changed, 5 insertions(+)"
+	result := CapDiffStats(stats, 50)
+	if result != stats {
+		t.Error("expected single-line stats unchanged")
+	}
+}
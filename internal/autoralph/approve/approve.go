@@ -149,9 +149,9 @@ func IsIteration(comments CommentClient) func(issue db.Issue) bool {
 
 // NewApprovalAction returns an ActionFunc that stores the plan text and updates
 // the LastCommentID when an approval is detected.
-func NewApprovalAction(cfg Config) func(issue db.Issue, database *db.DB) error {
-	return func(issue db.Issue, database *db.DB) error {
-		cs, err := cfg.Comments.FetchIssueComments(context.Background(), issue.LinearIssueID)
+func NewApprovalAction(cfg Config) func(ctx context.Context, issue db.Issue, database *db.DB) error {
+	return func(ctx context.Context, issue db.Issue, database *db.DB) error {
+		cs, err := cfg.Comments.FetchIssueComments(ctx, issue.LinearIssueID)
 		if err != nil {
 			return fmt.Errorf("fetching comments for approval: %w", err)
 		}
@@ -170,7 +170,7 @@ func NewApprovalAction(cfg Config) func(issue db.Issue, database *db.DB) error {
 
 		// React to the approval comment before processing.
 		if cfg.Reactor != nil && approvalCommentID != "" {
-			if err := cfg.Reactor.ReactToComment(context.Background(), approvalCommentID, "👀"); err != nil {
+			if err := cfg.Reactor.ReactToComment(ctx, approvalCommentID, "👀"); err != nil {
 				slog.Warn("failed to react to approval comment", "comment_id", approvalCommentID, "error", err)
 			}
 		}
@@ -205,20 +205,20 @@ func NewApprovalAction(cfg Config) func(issue db.Issue, database *db.DB) error {
 // is empty (first refinement), the full description and all comments are sent.
 // When the user's comment is a threaded reply, the response is posted in the
 // same thread.
-func NewIterationAction(cfg Config) func(issue db.Issue, database *db.DB) error {
-	return func(issue db.Issue, database *db.DB) error {
+func NewIterationAction(cfg Config) func(ctx context.Context, issue db.Issue, database *db.DB) error {
+	return func(ctx context.Context, issue db.Issue, database *db.DB) error {
 		project, err := cfg.Projects.GetProject(issue.ProjectID)
 		if err != nil {
 			return fmt.Errorf("loading project: %w", err)
 		}
 
 		if cfg.GitPuller != nil {
-			if pullErr := cfg.GitPuller.PullDefaultBase(context.Background(), project.LocalPath, project.RalphConfigPath); pullErr != nil {
+			if pullErr := cfg.GitPuller.PullDefaultBase(ctx, project.LocalPath, project.RalphConfigPath); pullErr != nil {
 				_ = database.LogActivity(issue.ID, "warning", "", "", fmt.Sprintf("git pull --ff-only failed: %v", pullErr))
 			}
 		}
 
-		cs, err := cfg.Comments.FetchIssueComments(context.Background(), issue.LinearIssueID)
+		cs, err := cfg.Comments.FetchIssueComments(ctx, issue.LinearIssueID)
 		if err != nil {
 			return fmt.Errorf("fetching comments for iteration: %w", err)
 		}
@@ -228,7 +228,7 @@ func NewIterationAction(cfg Config) func(issue db.Issue, database *db.DB) error
 		// React 👀 to each new comment before invoking AI.
 		if cfg.Reactor != nil {
 			for _, c := range newComments {
-				if err := cfg.Reactor.ReactToComment(context.Background(), c.ID, "👀"); err != nil {
+				if err := cfg.Reactor.ReactToComment(ctx, c.ID, "👀"); err != nil {
 					slog.Warn("failed to react to comment", "comment_id", c.ID, "error", err)
 				}
 			}
@@ -277,7 +277,7 @@ func NewIterationAction(cfg Config) func(issue db.Issue, database *db.DB) error
 		}
 
 		handler := eventlog.New(database, issue.ID, nil, cfg.OnBuildEvent)
-		response, err := cfg.Invoker.InvokeWithEvents(context.Background(), prompt, project.LocalPath, maxTurnsIteration, handler)
+		response, err := cfg.Invoker.InvokeWithEvents(ctx, prompt, project.LocalPath, maxTurnsIteration, handler)
 		if err != nil {
 			return fmt.Errorf("invoking AI: %w", err)
 		}
@@ -293,9 +293,9 @@ func NewIterationAction(cfg Config) func(issue db.Issue, database *db.DB) error
 		threadParent := findThreadParent(newComments)
 		var posted linear.Comment
 		if threadParent != "" {
-			posted, err = cfg.Comments.PostReply(context.Background(), issue.LinearIssueID, threadParent, responseWithHint)
+			posted, err = cfg.Comments.PostReply(ctx, issue.LinearIssueID, threadParent, responseWithHint)
 		} else {
-			posted, err = cfg.Comments.PostComment(context.Background(), issue.LinearIssueID, responseWithHint)
+			posted, err = cfg.Comments.PostComment(ctx, issue.LinearIssueID, responseWithHint)
 		}
 		if err != nil {
 			return fmt.Errorf("posting reply: %w", err)
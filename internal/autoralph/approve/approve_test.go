@@ -276,7 +276,7 @@ func TestApprovalAction_StoresPlanText(t *testing.T) {
 
 	action := NewApprovalAction(Config{Comments: client})
 
-	err := action(issue, d)
+	err := action(context.Background(), issue, d)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -306,7 +306,7 @@ func TestApprovalAction_UpdatesLastCommentID(t *testing.T) {
 
 	action := NewApprovalAction(Config{Comments: client})
 
-	err := action(issue, d)
+	err := action(context.Background(), issue, d)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -333,7 +333,7 @@ func TestApprovalAction_LogsActivity(t *testing.T) {
 
 	action := NewApprovalAction(Config{Comments: client})
 
-	err := action(issue, d)
+	err := action(context.Background(), issue, d)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -363,7 +363,7 @@ func TestApprovalAction_FetchError_ReturnsError(t *testing.T) {
 
 	action := NewApprovalAction(Config{Comments: client})
 
-	err := action(issue, d)
+	err := action(context.Background(), issue, d)
 	if err == nil {
 		t.Fatal("expected error when fetching comments fails")
 	}
@@ -393,7 +393,7 @@ func TestIterationAction_IncrementalSendsOnlyNewCommentsByDefault(t *testing.T)
 		Projects: d,
 	})
 
-	err := action(issue, d)
+	err := action(context.Background(), issue, d)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -462,7 +462,7 @@ func TestIterationAction_PostsAIResponse(t *testing.T) {
 				Projects: d,
 			})
 
-			err := action(issue, d)
+			err := action(context.Background(), issue, d)
 			if err != nil {
 				t.Fatalf("unexpected error: %v", err)
 			}
@@ -517,7 +517,7 @@ func TestIterationAction_UpdatesLastCommentID(t *testing.T) {
 		Projects: d,
 	})
 
-	err := action(issue, d)
+	err := action(context.Background(), issue, d)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -550,7 +550,7 @@ func TestIterationAction_LogsActivity(t *testing.T) {
 		Projects: d,
 	})
 
-	err := action(issue, d)
+	err := action(context.Background(), issue, d)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -589,7 +589,7 @@ func TestIterationAction_AIError_ReturnsError(t *testing.T) {
 		Projects: d,
 	})
 
-	err := action(issue, d)
+	err := action(context.Background(), issue, d)
 	if err == nil {
 		t.Fatal("expected error when AI invocation fails")
 	}
@@ -617,7 +617,7 @@ func TestIterationAction_PostError_ReturnsError(t *testing.T) {
 		Projects: d,
 	})
 
-	err := action(issue, d)
+	err := action(context.Background(), issue, d)
 	if err == nil {
 		t.Fatal("expected error when posting comment fails")
 	}
@@ -664,7 +664,7 @@ func TestIterationAction_PullsBeforeInvokingAI(t *testing.T) {
 		GitPuller: puller,
 	})
 
-	err = action(issue, d)
+	err = action(context.Background(), issue, d)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -719,7 +719,7 @@ func TestIterationAction_PullFails_LogsWarningAndProceeds(t *testing.T) {
 		GitPuller: puller,
 	})
 
-	err = action(issue, d)
+	err = action(context.Background(), issue, d)
 	if err != nil {
 		t.Fatalf("expected no error when pull fails, got: %v", err)
 	}
@@ -764,7 +764,7 @@ func TestIterationAction_NilGitPuller_SkipsPull(t *testing.T) {
 		// GitPuller is nil — should be skipped
 	})
 
-	err := action(issue, d)
+	err := action(context.Background(), issue, d)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -952,7 +952,7 @@ func TestIterationAction_ReactsToNewComments(t *testing.T) {
 		Reactor:  reactor,
 	})
 
-	err := action(issue, d)
+	err := action(context.Background(), issue, d)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -993,7 +993,7 @@ func TestIterationAction_ReactsBeforeAIInvocation(t *testing.T) {
 		Reactor:  reactor,
 	})
 
-	err := action(issue, d)
+	err := action(context.Background(), issue, d)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -1028,7 +1028,7 @@ func TestIterationAction_ReactionError_DoesNotBlockAction(t *testing.T) {
 		Reactor:  reactor,
 	})
 
-	err := action(issue, d)
+	err := action(context.Background(), issue, d)
 	if err != nil {
 		t.Fatalf("expected no error when reaction fails, got: %v", err)
 	}
@@ -1061,7 +1061,7 @@ func TestIterationAction_NilReactor_DoesNotPanic(t *testing.T) {
 		// Reactor is nil — should not panic
 	})
 
-	err := action(issue, d)
+	err := action(context.Background(), issue, d)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -1085,7 +1085,7 @@ func TestApprovalAction_ReactsToApprovalComment(t *testing.T) {
 
 	action := NewApprovalAction(Config{Comments: client, Reactor: reactor})
 
-	err := action(issue, d)
+	err := action(context.Background(), issue, d)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -1112,7 +1112,7 @@ func TestApprovalAction_ReactionError_DoesNotBlockAction(t *testing.T) {
 
 	action := NewApprovalAction(Config{Comments: client, Reactor: reactor})
 
-	err := action(issue, d)
+	err := action(context.Background(), issue, d)
 	if err != nil {
 		t.Fatalf("expected no error when reaction fails, got: %v", err)
 	}
@@ -1140,7 +1140,7 @@ func TestApprovalAction_NilReactor_DoesNotPanic(t *testing.T) {
 
 	action := NewApprovalAction(Config{Comments: client})
 
-	err := action(issue, d)
+	err := action(context.Background(), issue, d)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -1175,7 +1175,7 @@ func TestIterationAction_UsesInvokeWithEvents(t *testing.T) {
 		Projects: d,
 	})
 
-	err := action(issue, d)
+	err := action(context.Background(), issue, d)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -1210,7 +1210,7 @@ func TestIterationAction_EventsStreamedToDB(t *testing.T) {
 		Projects: d,
 	})
 
-	err := action(issue, d)
+	err := action(context.Background(), issue, d)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -1261,7 +1261,7 @@ func TestIterationAction_OnBuildEventCallback(t *testing.T) {
 		OnBuildEvent: onBuildEvent,
 	})
 
-	err := action(issue, d)
+	err := action(context.Background(), issue, d)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -1291,7 +1291,7 @@ func TestApprovalAction_Unchanged_NoEventInvoker(t *testing.T) {
 	// ApprovalAction does not use the Invoker at all.
 	action := NewApprovalAction(Config{Comments: client})
 
-	err := action(issue, d)
+	err := action(context.Background(), issue, d)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -1324,7 +1324,7 @@ func TestIterationAction_PassesMaxTurns(t *testing.T) {
 		Projects: d,
 	})
 
-	if err := action(issue, d); err != nil {
+	if err := action(context.Background(), issue, d); err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
 
@@ -1424,7 +1424,7 @@ func TestIterationAction_IncrementalSendsOnlyNewComments(t *testing.T) {
 		Projects: d,
 	})
 
-	err := action(issue, d)
+	err := action(context.Background(), issue, d)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -1471,7 +1471,7 @@ func TestIterationAction_IncrementalHasContextPrefix(t *testing.T) {
 		Projects: d,
 	})
 
-	err := action(issue, d)
+	err := action(context.Background(), issue, d)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -1500,7 +1500,7 @@ func TestIterationAction_IncrementalOmitsDescription(t *testing.T) {
 		Projects: d,
 	})
 
-	err := action(issue, d)
+	err := action(context.Background(), issue, d)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -1532,7 +1532,7 @@ func TestIterationAction_FirstRefinementSendsAllComments(t *testing.T) {
 		Projects: d,
 	})
 
-	err := action(issue, d)
+	err := action(context.Background(), issue, d)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
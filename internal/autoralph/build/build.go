@@ -28,7 +28,7 @@ type Invoker interface {
 // WorkspaceCreator creates a Ralph workspace. Wraps workspace.CreateWorkspace
 // to allow testing without git operations.
 type WorkspaceCreator interface {
-	Create(ctx context.Context, repoPath string, ws workspace.Workspace, base string, copyPatterns []string) error
+	Create(ctx context.Context, repoPath string, ws workspace.Workspace, base string, opts workspace.CreateOptions) error
 }
 
 // ConfigLoader loads a Ralph config from a file path.
@@ -80,8 +80,8 @@ type Config struct {
 // 4. Read the PRD from disk for metadata
 // 5. Store workspace_name and branch_name in the DB
 // 6. Update Linear issue state to "In Progress" (non-fatal)
-func NewAction(cfg Config) func(issue db.Issue, database *db.DB) error {
-	return func(issue db.Issue, database *db.DB) error {
+func NewAction(cfg Config) func(ctx context.Context, issue db.Issue, database *db.DB) error {
+	return func(ctx context.Context, issue db.Issue, database *db.DB) error {
 		project, err := cfg.Projects.GetProject(issue.ProjectID)
 		if err != nil {
 			return fmt.Errorf("loading project: %w", err)
@@ -109,11 +109,15 @@ func NewAction(cfg Config) func(issue db.Issue, database *db.DB) error {
 				CreatedAt: time.Now().UTC(),
 			}
 			if err := cfg.Workspace.Create(
-				context.Background(),
+				ctx,
 				project.LocalPath,
 				ws,
 				ralphCfg.Repo.DefaultBase,
-				ralphCfg.CopyToWorktree,
+				workspace.CreateOptions{
+					CopyPatterns: ralphCfg.CopyToWorktree,
+					Hooks:        ralphCfg.Hooks.WorkspaceCreated,
+					ToolPolicy:   ralphCfg.ToolPolicy[config.PhaseStory],
+				},
 			); err != nil {
 				return fmt.Errorf("creating workspace: %w", err)
 			}
@@ -136,7 +140,7 @@ func NewAction(cfg Config) func(issue db.Issue, database *db.DB) error {
 				return fmt.Errorf("rendering PRD prompt: %w", err)
 			}
 
-			if _, err := cfg.Invoker.Invoke(context.Background(), prompt, project.LocalPath, maxTurnsBuild); err != nil {
+			if _, err := cfg.Invoker.Invoke(ctx, prompt, project.LocalPath, maxTurnsBuild); err != nil {
 				return fmt.Errorf("invoking AI for PRD generation: %w", err)
 			}
 		}
@@ -154,7 +158,7 @@ func NewAction(cfg Config) func(issue db.Issue, database *db.DB) error {
 
 		// Linear state update is non-fatal — the important thing is the
 		// DB transition to BUILDING so the worker can pick it up.
-		if err := updateLinearState(cfg.Linear, issue.LinearIssueID, project.LinearTeamID, "In Progress"); err != nil {
+		if err := updateLinearState(ctx, cfg.Linear, issue.LinearIssueID, project.LinearTeamID, "In Progress"); err != nil {
 			_ = database.LogActivity(issue.ID, "warning", "", "", "Failed to update Linear state: "+err.Error())
 		}
 
@@ -174,15 +178,15 @@ func NewAction(cfg Config) func(issue db.Issue, database *db.DB) error {
 
 // updateLinearState fetches workflow states for the team and updates the issue
 // to the state matching the given name.
-func updateLinearState(client LinearStateUpdater, issueID, teamID, stateName string) error {
-	states, err := client.FetchWorkflowStates(context.Background(), teamID)
+func updateLinearState(ctx context.Context, client LinearStateUpdater, issueID, teamID, stateName string) error {
+	states, err := client.FetchWorkflowStates(ctx, teamID)
 	if err != nil {
 		return fmt.Errorf("fetching workflow states: %w", err)
 	}
 
 	for _, s := range states {
 		if s.Name == stateName {
-			return client.UpdateIssueState(context.Background(), issueID, s.ID)
+			return client.UpdateIssueState(ctx, issueID, s.ID)
 		}
 	}
 
@@ -81,8 +81,8 @@ type wsCreateCall struct {
 	copyPatterns []string
 }
 
-func (m *mockWorkspaceCreator) Create(ctx context.Context, repoPath string, ws workspace.Workspace, base string, copyPatterns []string) error {
-	m.calls = append(m.calls, wsCreateCall{repoPath: repoPath, ws: ws, base: base, copyPatterns: copyPatterns})
+func (m *mockWorkspaceCreator) Create(ctx context.Context, repoPath string, ws workspace.Workspace, base string, opts workspace.CreateOptions) error {
+	m.calls = append(m.calls, wsCreateCall{repoPath: repoPath, ws: ws, base: base, copyPatterns: opts.CopyPatterns})
 	return m.err
 }
 
@@ -208,7 +208,7 @@ func TestBuildAction_CreatesWorkspace(t *testing.T) {
 	wsc := cfg.Workspace.(*mockWorkspaceCreator)
 
 	action := NewAction(cfg)
-	err := action(issue, d)
+	err := action(context.Background(), issue, d)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -237,7 +237,7 @@ func TestBuildAction_InvokesAIWithPRDPrompt(t *testing.T) {
 	invoker := cfg.Invoker.(*mockInvoker)
 
 	action := NewAction(cfg)
-	err := action(issue, d)
+	err := action(context.Background(), issue, d)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -267,7 +267,7 @@ func TestBuildAction_ReadsPRDAfterAIWrites(t *testing.T) {
 	cfg.Projects = d
 
 	action := NewAction(cfg)
-	err := action(issue, d)
+	err := action(context.Background(), issue, d)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -301,7 +301,7 @@ func TestBuildAction_StoresWorkspaceInfoInDB(t *testing.T) {
 	cfg.Projects = d
 
 	action := NewAction(cfg)
-	err := action(issue, d)
+	err := action(context.Background(), issue, d)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -327,7 +327,7 @@ func TestBuildAction_UpdatesLinearState(t *testing.T) {
 	linear := cfg.Linear.(*mockLinearState)
 
 	action := NewAction(cfg)
-	err := action(issue, d)
+	err := action(context.Background(), issue, d)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -352,7 +352,7 @@ func TestBuildAction_LogsActivity(t *testing.T) {
 	cfg.Projects = d
 
 	action := NewAction(cfg)
-	err := action(issue, d)
+	err := action(context.Background(), issue, d)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -400,7 +400,7 @@ func TestBuildAction_ConfigLoadError_ReturnsError(t *testing.T) {
 	cfg.ConfigLoad = &mockConfigLoader{err: fmt.Errorf("config file not found")}
 
 	action := NewAction(cfg)
-	err := action(issue, d)
+	err := action(context.Background(), issue, d)
 	if err == nil {
 		t.Fatal("expected error when config loading fails")
 	}
@@ -418,7 +418,7 @@ func TestBuildAction_WorkspaceCreateError_ReturnsError(t *testing.T) {
 	cfg.Workspace = &mockWorkspaceCreator{err: fmt.Errorf("git worktree failed")}
 
 	action := NewAction(cfg)
-	err := action(issue, d)
+	err := action(context.Background(), issue, d)
 	if err == nil {
 		t.Fatal("expected error when workspace creation fails")
 	}
@@ -436,7 +436,7 @@ func TestBuildAction_AIError_ReturnsError(t *testing.T) {
 	cfg.Invoker = &mockInvoker{err: fmt.Errorf("AI service unavailable")}
 
 	action := NewAction(cfg)
-	err := action(issue, d)
+	err := action(context.Background(), issue, d)
 	if err == nil {
 		t.Fatal("expected error when AI invocation fails")
 	}
@@ -454,7 +454,7 @@ func TestBuildAction_PRDReadError_ReturnsError(t *testing.T) {
 	cfg.PRDRead = &mockPRDReader{err: fmt.Errorf("PRD file not found")}
 
 	action := NewAction(cfg)
-	err := action(issue, d)
+	err := action(context.Background(), issue, d)
 	if err == nil {
 		t.Fatal("expected error when PRD read fails")
 	}
@@ -477,7 +477,7 @@ func TestBuildAction_LinearStateNotFound_IsNonFatal(t *testing.T) {
 	}
 
 	action := NewAction(cfg)
-	err := action(issue, d)
+	err := action(context.Background(), issue, d)
 	if err != nil {
 		t.Fatalf("expected no error (Linear state update is non-fatal), got: %v", err)
 	}
@@ -526,7 +526,7 @@ func TestBuildAction_IncludesKnowledgePath(t *testing.T) {
 	invoker := cfg.Invoker.(*mockInvoker)
 
 	action := NewAction(cfg)
-	err := action(issue, d)
+	err := action(context.Background(), issue, d)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -546,7 +546,7 @@ func TestBuildAction_PassesMaxTurns(t *testing.T) {
 	inv := cfg.Invoker.(*mockInvoker)
 
 	action := NewAction(cfg)
-	if err := action(issue, d); err != nil {
+	if err := action(context.Background(), issue, d); err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
 
@@ -84,14 +84,13 @@ type Config struct {
 // It fetches failed check run details and logs, invokes AI to fix them, commits and pushes
 // changes. When loop protection triggers (max attempts reached), it posts a PR comment and
 // transitions to paused.
-func NewAction(cfg Config) func(issue db.Issue, database *db.DB) error {
+func NewAction(cfg Config) func(ctx context.Context, issue db.Issue, database *db.DB) error {
 	maxAttempts := cfg.MaxAttempts
 	if maxAttempts <= 0 {
 		maxAttempts = 3
 	}
 
-	return func(issue db.Issue, database *db.DB) error {
-		ctx := context.Background()
+	return func(ctx context.Context, issue db.Issue, database *db.DB) error {
 
 		project, err := cfg.Projects.GetProject(issue.ProjectID)
 		if err != nil {
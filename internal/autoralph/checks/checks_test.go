@@ -235,7 +235,7 @@ func TestNewAction_FetchesFailedChecksAndInvokesAI(t *testing.T) {
 	cfg, inv, _, _, _, _, _ := defaultMocks(project)
 
 	action := NewAction(cfg)
-	err := action(issue, d)
+	err := action(context.Background(), issue, d)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -269,7 +269,7 @@ func TestNewAction_CommitsAndPushesChanges(t *testing.T) {
 	cfg, _, _, _, _, _, git := defaultMocks(project)
 
 	action := NewAction(cfg)
-	err := action(issue, d)
+	err := action(context.Background(), issue, d)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -304,7 +304,7 @@ func TestNewAction_IncrementsCheckFixAttempts(t *testing.T) {
 	cfg, _, _, _, _, _, _ := defaultMocks(project)
 
 	action := NewAction(cfg)
-	err := action(issue, d)
+	err := action(context.Background(), issue, d)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -328,7 +328,7 @@ func TestNewAction_LogsChecksStartAndFinish(t *testing.T) {
 	cfg, _, _, _, _, _, _ := defaultMocks(project)
 
 	action := NewAction(cfg)
-	err := action(issue, d)
+	err := action(context.Background(), issue, d)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -369,7 +369,7 @@ func TestNewAction_NothingToCommit_HandlesGracefully(t *testing.T) {
 	git.commitErr = errors.New("nothing to commit")
 
 	action := NewAction(cfg)
-	err := action(issue, d)
+	err := action(context.Background(), issue, d)
 	if err != nil {
 		t.Fatalf("expected no error for nothing-to-commit, got: %v", err)
 	}
@@ -395,7 +395,7 @@ func TestNewAction_LoopExhaustion_PostsCommentAndPauses(t *testing.T) {
 	cfg, _, _, _, _, commenter, _ := defaultMocks(project)
 
 	action := NewAction(cfg)
-	err := action(issue, d)
+	err := action(context.Background(), issue, d)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -456,7 +456,7 @@ func TestNewAction_TruncatesLogs(t *testing.T) {
 	}
 
 	action := NewAction(cfg)
-	err := action(issue, d)
+	err := action(context.Background(), issue, d)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -495,7 +495,7 @@ func TestNewAction_MissingLogs_HandlesGracefully(t *testing.T) {
 	logFetcher.logs = map[int64][]byte{}
 
 	action := NewAction(cfg)
-	err := action(issue, d)
+	err := action(context.Background(), issue, d)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -517,7 +517,7 @@ func TestNewAction_FetchPRError(t *testing.T) {
 	prFetcher.err = errors.New("github 500")
 
 	action := NewAction(cfg)
-	err := action(issue, d)
+	err := action(context.Background(), issue, d)
 	if err == nil {
 		t.Fatal("expected error")
 	}
@@ -534,7 +534,7 @@ func TestNewAction_FetchCheckRunsError(t *testing.T) {
 	checkRuns.err = errors.New("github 500")
 
 	action := NewAction(cfg)
-	err := action(issue, d)
+	err := action(context.Background(), issue, d)
 	if err == nil {
 		t.Fatal("expected error")
 	}
@@ -551,7 +551,7 @@ func TestNewAction_AIError(t *testing.T) {
 	inv.err = errors.New("AI timeout")
 
 	action := NewAction(cfg)
-	err := action(issue, d)
+	err := action(context.Background(), issue, d)
 	if err == nil {
 		t.Fatal("expected error")
 	}
@@ -568,7 +568,7 @@ func TestNewAction_PushError(t *testing.T) {
 	git.pushErr = errors.New("push rejected")
 
 	action := NewAction(cfg)
-	err := action(issue, d)
+	err := action(context.Background(), issue, d)
 	if err == nil {
 		t.Fatal("expected error")
 	}
@@ -585,7 +585,7 @@ func TestNewAction_ProjectNotFound(t *testing.T) {
 	cfg.Projects = &mockProjectGetter{err: errors.New("not found")}
 
 	action := NewAction(cfg)
-	err := action(issue, d)
+	err := action(context.Background(), issue, d)
 	if err == nil {
 		t.Fatal("expected error")
 	}
@@ -602,7 +602,7 @@ func TestNewAction_RealCommitError(t *testing.T) {
 	git.commitErr = errors.New("fatal: unable to write tree")
 
 	action := NewAction(cfg)
-	err := action(issue, d)
+	err := action(context.Background(), issue, d)
 	if err == nil {
 		t.Fatal("expected error")
 	}
@@ -619,7 +619,7 @@ func TestNewAction_LoopExhaustion_PostCommentError_StillPauses(t *testing.T) {
 	commenter.err = errors.New("github 500")
 
 	action := NewAction(cfg)
-	err := action(issue, d)
+	err := action(context.Background(), issue, d)
 	// Comment failure should not block pausing
 	if err != nil {
 		t.Fatalf("expected no error even with comment failure, got: %v", err)
@@ -740,7 +740,7 @@ func TestNewAction_WithConfigLoader_IncludesQualityChecksInPrompt(t *testing.T)
 	}
 
 	action := NewAction(cfg)
-	err := action(issue, d)
+	err := action(context.Background(), issue, d)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -760,7 +760,7 @@ func TestNewAction_WithConfigLoader_Error_ReturnsError(t *testing.T) {
 	cfg.ConfigLoad = &mockConfigLoader{err: errors.New("config not found")}
 
 	action := NewAction(cfg)
-	err := action(issue, d)
+	err := action(context.Background(), issue, d)
 	if err == nil {
 		t.Fatal("expected error")
 	}
@@ -778,7 +778,7 @@ func TestNewAction_WithoutConfigLoader_SkipsQualityChecks(t *testing.T) {
 	cfg.ConfigLoad = nil
 
 	action := NewAction(cfg)
-	err := action(issue, d)
+	err := action(context.Background(), issue, d)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -795,7 +795,7 @@ func TestNewAction_PassesEventHandlerToInvoker(t *testing.T) {
 	cfg, inv, _, _, _, _, _ := defaultMocks(project)
 
 	action := NewAction(cfg)
-	err := action(issue, d)
+	err := action(context.Background(), issue, d)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -818,7 +818,7 @@ func TestNewAction_EventHandlerLogsBuildEvents(t *testing.T) {
 	}
 
 	action := NewAction(cfg)
-	err := action(issue, d)
+	err := action(context.Background(), issue, d)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -863,7 +863,7 @@ func TestNewAction_EventHandlerForwardsToUpstream(t *testing.T) {
 	}}
 
 	action := NewAction(cfg)
-	err := action(issue, d)
+	err := action(context.Background(), issue, d)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -898,7 +898,7 @@ func TestNewAction_IncludesKnowledgePath(t *testing.T) {
 	cfg, inv, _, _, _, _, _ := defaultMocks(project)
 
 	action := NewAction(cfg)
-	err := action(issue, d)
+	err := action(context.Background(), issue, d)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -921,7 +921,7 @@ func TestNewAction_PullsBranchBeforeAIInvocation(t *testing.T) {
 	cfg.BranchPuller = puller
 
 	action := NewAction(cfg)
-	err := action(issue, d)
+	err := action(context.Background(), issue, d)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -949,7 +949,7 @@ func TestNewAction_PullBranchError_ReturnsErrorWithoutAI(t *testing.T) {
 	cfg.BranchPuller = puller
 
 	action := NewAction(cfg)
-	err := action(issue, d)
+	err := action(context.Background(), issue, d)
 	if err == nil {
 		t.Fatal("expected error from PullBranch")
 	}
@@ -983,7 +983,7 @@ func TestNewAction_PullBranchCalledBeforeInvoke(t *testing.T) {
 	cfg.Invoker = &orderTrackingInvoker{inner: cfg.Invoker, orderLog: &order}
 
 	action := NewAction(cfg)
-	err := action(issue, d)
+	err := action(context.Background(), issue, d)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -1035,7 +1035,7 @@ func TestNewAction_PassesMaxTurns(t *testing.T) {
 	cfg, inv, _, _, _, _, _ := defaultMocks(project)
 
 	action := NewAction(cfg)
-	if err := action(issue, d); err != nil {
+	if err := action(context.Background(), issue, d); err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
 
@@ -45,8 +45,8 @@ type Config struct {
 //
 // Workspace removal and Linear update errors are non-fatal — the issue still
 // transitions to completed since the PR is already merged.
-func NewAction(cfg Config) func(issue db.Issue, database *db.DB) error {
-	return func(issue db.Issue, database *db.DB) error {
+func NewAction(cfg Config) func(ctx context.Context, issue db.Issue, database *db.DB) error {
+	return func(ctx context.Context, issue db.Issue, database *db.DB) error {
 		project, err := cfg.Projects.GetProject(issue.ProjectID)
 		if err != nil {
 			return fmt.Errorf("loading project: %w", err)
@@ -54,13 +54,13 @@ func NewAction(cfg Config) func(issue db.Issue, database *db.DB) error {
 
 		// Delete workspace (non-fatal on error).
 		if issue.WorkspaceName != "" {
-			if err := cfg.Workspace.RemoveWorkspace(context.Background(), project.LocalPath, issue.WorkspaceName); err != nil {
+			if err := cfg.Workspace.RemoveWorkspace(ctx, project.LocalPath, issue.WorkspaceName); err != nil {
 				slog.Warn("removing workspace", "issue_id", issue.ID, "workspace", issue.WorkspaceName, "error", err)
 			}
 		}
 
 		// Update Linear issue state to "Done" (non-fatal on error).
-		updateLinearState(cfg.Linear, issue.LinearIssueID, project.LinearTeamID, "Done")
+		updateLinearState(ctx, cfg.Linear, issue.LinearIssueID, project.LinearTeamID, "Done")
 
 		// Transition issue to completed.
 		fromState := issue.State
@@ -85,8 +85,8 @@ func NewAction(cfg Config) func(issue db.Issue, database *db.DB) error {
 
 // updateLinearState fetches workflow states for the team and updates the issue
 // to the state matching the given name. Errors are logged but not returned.
-func updateLinearState(client LinearStateUpdater, issueID, teamID, stateName string) {
-	states, err := client.FetchWorkflowStates(context.Background(), teamID)
+func updateLinearState(ctx context.Context, client LinearStateUpdater, issueID, teamID, stateName string) {
+	states, err := client.FetchWorkflowStates(ctx, teamID)
 	if err != nil {
 		slog.Warn("fetching workflow states for completion", "issue_id", issueID, "error", err)
 		return
@@ -94,7 +94,7 @@ func updateLinearState(client LinearStateUpdater, issueID, teamID, stateName str
 
 	for _, s := range states {
 		if s.Name == stateName {
-			if err := client.UpdateIssueState(context.Background(), issueID, s.ID); err != nil {
+			if err := client.UpdateIssueState(ctx, issueID, s.ID); err != nil {
 				slog.Warn("updating Linear state for completion", "issue_id", issueID, "error", err)
 			}
 			return
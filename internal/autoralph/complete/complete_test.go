@@ -105,7 +105,7 @@ func TestComplete_UpdatesIssueStateToCompleted(t *testing.T) {
 		Projects:  d,
 	})
 
-	if err := action(issue, d); err != nil {
+	if err := action(context.Background(), issue, d); err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
 
@@ -134,7 +134,7 @@ func TestComplete_DeletesWorkspace(t *testing.T) {
 		Projects:  d,
 	})
 
-	if err := action(issue, d); err != nil {
+	if err := action(context.Background(), issue, d); err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
 
@@ -165,7 +165,7 @@ func TestComplete_UpdatesLinearStateToDone(t *testing.T) {
 		Projects:  d,
 	})
 
-	if err := action(issue, d); err != nil {
+	if err := action(context.Background(), issue, d); err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
 
@@ -196,7 +196,7 @@ func TestComplete_LogsActivity(t *testing.T) {
 		Projects:  d,
 	})
 
-	if err := action(issue, d); err != nil {
+	if err := action(context.Background(), issue, d); err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
 
@@ -239,7 +239,7 @@ func TestComplete_WorkspaceRemoveError_NonFatal(t *testing.T) {
 	})
 
 	// Workspace removal error should not prevent completion
-	if err := action(issue, d); err != nil {
+	if err := action(context.Background(), issue, d); err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
 
@@ -267,7 +267,7 @@ func TestComplete_LinearUpdateError_NonFatal(t *testing.T) {
 	})
 
 	// Linear update error should not prevent completion
-	if err := action(issue, d); err != nil {
+	if err := action(context.Background(), issue, d); err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
 
@@ -293,7 +293,7 @@ func TestComplete_LinearFetchStatesError_NonFatal(t *testing.T) {
 		Projects:  d,
 	})
 
-	if err := action(issue, d); err != nil {
+	if err := action(context.Background(), issue, d); err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
 
@@ -326,7 +326,7 @@ func TestComplete_ProjectLoadError_ReturnsFatal(t *testing.T) {
 		Projects:  &mockProjectGetter{err: fmt.Errorf("project not found")},
 	})
 
-	err := action(issue, d)
+	err := action(context.Background(), issue, d)
 	if err == nil {
 		t.Fatal("expected error for missing project")
 	}
@@ -360,7 +360,7 @@ func TestComplete_NoWorkspaceName_SkipsRemoval(t *testing.T) {
 		Projects:  d,
 	})
 
-	if err := action(issue, d); err != nil {
+	if err := action(context.Background(), issue, d); err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
 
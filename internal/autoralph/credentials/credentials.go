@@ -137,6 +137,53 @@ func Resolve(configDir, profileName string) (Credentials, error) {
 	return creds, nil
 }
 
+// ResolveGithub returns only the GitHub credentials, for callers that don't
+// talk to Linear. GITHUB_TOKEN wins; otherwise the token or GitHub App of the
+// named (or default) profile in the credentials file is used.
+func ResolveGithub(configDir, profileName string) (Credentials, error) {
+	if envGithub := os.Getenv("GITHUB_TOKEN"); envGithub != "" {
+		return Credentials{GithubToken: envGithub}, nil
+	}
+
+	filePath := filepath.Join(configDir, "credentials.yaml")
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return Credentials{}, fmt.Errorf("GITHUB_TOKEN not set and credentials file not found: %s", filePath)
+		}
+		return Credentials{}, fmt.Errorf("reading credentials file: %w", err)
+	}
+
+	var cf credentialsFile
+	if err := yaml.Unmarshal(data, &cf); err != nil {
+		return Credentials{}, fmt.Errorf("parsing credentials file: %w", err)
+	}
+	if profileName == "" {
+		profileName = cf.DefaultProfile
+	}
+	if profileName == "" {
+		return Credentials{}, fmt.Errorf("GITHUB_TOKEN not set and no default_profile set in %s", filePath)
+	}
+	profile, ok := cf.Profiles[profileName]
+	if !ok {
+		return Credentials{}, fmt.Errorf("profile %q not found in %s", profileName, filePath)
+	}
+	if err := validateGithubAppFields(profile); err != nil {
+		return Credentials{}, fmt.Errorf("profile %q: %w", profileName, err)
+	}
+
+	creds := Credentials{
+		GithubToken:             profile.GithubToken,
+		GithubAppClientID:       profile.GithubAppClientID,
+		GithubAppInstallationID: profile.GithubAppInstallationID,
+		GithubAppPrivateKeyPath: profile.GithubAppPrivateKeyPath,
+	}
+	if creds.GithubToken == "" && !creds.HasGithubApp() {
+		return Credentials{}, fmt.Errorf("profile %q has no github_token or GitHub App credentials", profileName)
+	}
+	return creds, nil
+}
+
 func gitAuthorNameWithDefault(v string) string {
 	if v == "" {
 		return "autoralph"
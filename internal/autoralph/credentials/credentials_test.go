@@ -533,3 +533,49 @@ func TestDefaultPath(t *testing.T) {
 		t.Errorf("DefaultPath() = %q, want %q", got, want)
 	}
 }
+
+func TestResolveGithub_EnvTokenWithoutFile(t *testing.T) {
+	t.Setenv("GITHUB_TOKEN", "env-github")
+
+	creds, err := ResolveGithub(t.TempDir(), "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if creds.GithubToken != "env-github" {
+		t.Errorf("GithubToken = %q, want %q", creds.GithubToken, "env-github")
+	}
+}
+
+func TestResolveGithub_ProfileWithoutLinearKey(t *testing.T) {
+	dir := t.TempDir()
+	writeCredentialsFile(t, dir, `
+default_profile: work
+profiles:
+  work:
+    github_token: yaml-github
+`)
+	t.Setenv("GITHUB_TOKEN", "")
+
+	creds, err := ResolveGithub(dir, "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if creds.GithubToken != "yaml-github" {
+		t.Errorf("GithubToken = %q, want %q", creds.GithubToken, "yaml-github")
+	}
+}
+
+func TestResolveGithub_NoGithubCredentials(t *testing.T) {
+	dir := t.TempDir()
+	writeCredentialsFile(t, dir, `
+default_profile: work
+profiles:
+  work:
+    linear_api_key: yaml-linear
+`)
+	t.Setenv("GITHUB_TOKEN", "")
+
+	if _, err := ResolveGithub(dir, ""); err == nil {
+		t.Fatal("expected error when profile has no GitHub credentials")
+	}
+}
@@ -33,6 +33,25 @@ func (tx *Tx) LogActivity(issueID, eventType, fromState, toState, detail string)
 	return nil
 }
 
+// RecordTransition logs an issue's state change in the activity log and
+// reports it to the OnTransition hook. The hook is called even when logging
+// fails, since the state has already changed.
+func (db *DB) RecordTransition(issueID, eventType, fromState, toState, detail string) error {
+	err := db.LogActivity(issueID, eventType, fromState, toState, detail)
+	db.transitioned(fromState, toState)
+	return err
+}
+
+// RecordTransition is like DB.RecordTransition, but the hook is only called
+// once the transaction commits.
+func (tx *Tx) RecordTransition(issueID, eventType, fromState, toState, detail string) error {
+	if err := tx.LogActivity(issueID, eventType, fromState, toState, detail); err != nil {
+		return err
+	}
+	tx.transitions = append(tx.transitions, transition{from: fromState, to: toState})
+	return nil
+}
+
 func (db *DB) ListActivity(issueID string, limit, offset int) ([]ActivityEntry, error) {
 	rows, err := db.conn.Query(`
 		SELECT id, issue_id, event_type, from_state, to_state, detail, created_at
@@ -11,7 +11,8 @@ import (
 )
 
 type DB struct {
-	conn *sql.DB
+	conn         *sql.DB
+	onTransition func(from, to string)
 }
 
 type Project struct {
@@ -167,6 +168,19 @@ func (db *DB) Close() error {
 	return db.conn.Close()
 }
 
+// OnTransition registers fn to be called for every state change recorded
+// with RecordTransition, once it is committed. It must be called before the
+// database is shared.
+func (db *DB) OnTransition(fn func(from, to string)) {
+	db.onTransition = fn
+}
+
+func (db *DB) transitioned(from, to string) {
+	if db.onTransition != nil {
+		db.onTransition(from, to)
+	}
+}
+
 // Tx runs fn within a database transaction. If fn returns an error, the
 // transaction is rolled back; otherwise it is committed.
 func (db *DB) Tx(fn func(tx *Tx) error) error {
@@ -174,14 +188,24 @@ func (db *DB) Tx(fn func(tx *Tx) error) error {
 	if err != nil {
 		return fmt.Errorf("beginning transaction: %w", err)
 	}
-	if err := fn(&Tx{tx: sqlTx}); err != nil {
+	tx := &Tx{tx: sqlTx}
+	if err := fn(tx); err != nil {
 		sqlTx.Rollback()
 		return err
 	}
-	return sqlTx.Commit()
+	if err := sqlTx.Commit(); err != nil {
+		return err
+	}
+	for _, t := range tx.transitions {
+		db.transitioned(t.from, t.to)
+	}
+	return nil
 }
 
 // Tx wraps a sql.Tx for use within transactional operations.
 type Tx struct {
-	tx *sql.Tx
+	tx          *sql.Tx
+	transitions []transition // reported once the transaction commits
 }
+
+type transition struct{ from, to string }
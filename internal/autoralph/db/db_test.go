@@ -440,6 +440,37 @@ func TestLogActivity_AndListActivity(t *testing.T) {
 	}
 }
 
+func TestRecordTransition_ReportsCommittedTransitions(t *testing.T) {
+	d := testDB(t)
+	p := createTestProject(t, d)
+	issue, _ := d.CreateIssue(Issue{ProjectID: p.ID, Title: "Test", State: "queued"})
+
+	var got []string
+	d.OnTransition(func(from, to string) { got = append(got, from+"->"+to) })
+
+	if err := d.RecordTransition(issue.ID, "state_change", "queued", "refining", "Started refinement"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := d.Tx(func(tx *Tx) error {
+		return tx.RecordTransition(issue.ID, "state_change", "refining", "approved", "Plan approved")
+	}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	d.Tx(func(tx *Tx) error {
+		tx.RecordTransition(issue.ID, "state_change", "approved", "building", "Rolled back")
+		return fmt.Errorf("rollback")
+	})
+
+	want := []string{"queued->refining", "refining->approved"}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("transitions = %v, want %v", got, want)
+	}
+	entries, _ := d.ListActivity(issue.ID, 10, 0)
+	if len(entries) != 2 {
+		t.Errorf("expected 2 activity entries, got %d", len(entries))
+	}
+}
+
 func TestListActivity_Pagination(t *testing.T) {
 	d := testDB(t)
 	p := createTestProject(t, d)
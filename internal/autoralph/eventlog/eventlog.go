@@ -4,6 +4,7 @@ import (
 	"fmt"
 
 	"github.com/uesteibar/ralph/internal/autoralph/db"
+	"github.com/uesteibar/ralph/internal/autoralph/metrics"
 	"github.com/uesteibar/ralph/internal/events"
 )
 
@@ -29,10 +30,16 @@ func New(database *db.DB, issueID string, upstream events.EventHandler, onBuildE
 
 // Handle processes an event: formats it, logs non-empty results to the DB,
 // invokes the onBuildEvent callback, and forwards to the upstream handler.
+// Commits are logged as commit_created so they also show in the issue
+// timeline, which leaves out build events.
 func (h *Handler) Handle(e events.Event) {
 	detail := FormatDetail(e)
 	if detail != "" {
-		_ = h.db.LogActivity(h.issueID, "build_event", "", "", detail)
+		eventType := "build_event"
+		if _, ok := e.(events.CommitCreated); ok {
+			eventType = "commit_created"
+		}
+		_ = h.db.LogActivity(h.issueID, eventType, "", "", detail)
 
 		if h.onBuildEvent != nil {
 			h.onBuildEvent(h.issueID, detail)
@@ -41,6 +48,8 @@ func (h *Handler) Handle(e events.Event) {
 
 	if ev, ok := e.(events.InvocationDone); ok && (ev.InputTokens > 0 || ev.OutputTokens > 0) {
 		_ = h.db.IncrementTokens(h.issueID, ev.InputTokens, ev.OutputTokens)
+		metrics.Tokens.WithLabelValues("input").Add(float64(ev.InputTokens))
+		metrics.Tokens.WithLabelValues("output").Add(float64(ev.OutputTokens))
 	}
 
 	if h.upstream != nil {
@@ -53,10 +62,39 @@ func (h *Handler) Handle(e events.Event) {
 func FormatDetail(e events.Event) string {
 	switch ev := e.(type) {
 	case events.ToolUse:
+		prefix := "→"
+		if ev.Subagent != "" {
+			prefix = fmt.Sprintf("↳ [%s]", ev.Subagent)
+		}
+		if ev.Detail != "" {
+			return fmt.Sprintf("%s %s %s", prefix, ev.Name, ev.Detail)
+		}
+		return fmt.Sprintf("%s %s", prefix, ev.Name)
+	case events.ToolResult:
+		// Successful results would double the log; the ToolUse line covers them.
+		if !ev.IsError {
+			return ""
+		}
 		if ev.Detail != "" {
-			return fmt.Sprintf("→ %s %s", ev.Name, ev.Detail)
+			return fmt.Sprintf("✗ %s %s failed: %s", ev.Name, ev.Detail, ev.Output)
 		}
-		return fmt.Sprintf("→ %s", ev.Name)
+		return fmt.Sprintf("✗ %s failed: %s", ev.Name, ev.Output)
+	case events.SessionInit:
+		return fmt.Sprintf("Session %s started (%s)", ev.SessionID, ev.Model)
+	case events.FileEdited:
+		return fmt.Sprintf("✎ %s (+%d -%d)", ev.Path, ev.Added, ev.Removed)
+	case events.CommitCreated:
+		sha := ev.SHA[:min(len(ev.SHA), 7)]
+		stats := fmt.Sprintf("%d files, +%d -%d", ev.FilesChanged, ev.Insertions, ev.Deletions)
+		if ev.StoryID != "" {
+			return fmt.Sprintf("Commit %s created for %s: %s (%s)", sha, ev.StoryID, ev.Subject, stats)
+		}
+		return fmt.Sprintf("Commit %s created: %s (%s)", sha, ev.Subject, stats)
+	case events.ToolDenied:
+		if ev.Detail != "" {
+			return fmt.Sprintf("✗ %s %s denied by tool policy", ev.Name, ev.Detail)
+		}
+		return fmt.Sprintf("✗ %s denied by tool policy", ev.Name)
 	case events.IterationStart:
 		return fmt.Sprintf("Iteration %d/%d started", ev.Iteration, ev.MaxIterations)
 	case events.StoryStarted:
@@ -70,7 +108,13 @@ func FormatDetail(e events.Event) string {
 	case events.InvocationDone:
 		base := fmt.Sprintf("Invocation done: %d turns in %dms", ev.NumTurns, ev.DurationMS)
 		if ev.InputTokens > 0 || ev.OutputTokens > 0 {
-			return fmt.Sprintf("%s (%d in / %d out tokens)", base, ev.InputTokens, ev.OutputTokens)
+			base = fmt.Sprintf("%s (%d in / %d out tokens)", base, ev.InputTokens, ev.OutputTokens)
+		}
+		if ev.CacheCreationTokens > 0 || ev.CacheReadTokens > 0 {
+			base = fmt.Sprintf("%s (cache: %d written / %d read)", base, ev.CacheCreationTokens, ev.CacheReadTokens)
+		}
+		if ev.CostUSD > 0 {
+			base = fmt.Sprintf("%s $%.4f", base, ev.CostUSD)
 		}
 		return base
 	default:
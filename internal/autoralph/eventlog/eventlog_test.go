@@ -24,6 +24,14 @@ func TestFormatDetail_ToolUse_WithoutDetail(t *testing.T) {
 	}
 }
 
+func TestFormatDetail_ToolDenied(t *testing.T) {
+	got := eventlog.FormatDetail(events.ToolDenied{Name: "Bash", Detail: "git push"})
+	want := "✗ Bash git push denied by tool policy"
+	if got != want {
+		t.Errorf("FormatDetail(ToolDenied) = %q, want %q", got, want)
+	}
+}
+
 func TestFormatDetail_IterationStart(t *testing.T) {
 	got := eventlog.FormatDetail(events.IterationStart{Iteration: 3, MaxIterations: 10})
 	want := "Iteration 3/10 started"
@@ -98,6 +106,40 @@ func TestFormatDetail_InvocationDone_ZeroTokens(t *testing.T) {
 	}
 }
 
+func TestFormatDetail_InvocationDone_WithCacheAndCost(t *testing.T) {
+	got := eventlog.FormatDetail(events.InvocationDone{
+		NumTurns:            5,
+		DurationMS:          2345,
+		InputTokens:         1200,
+		OutputTokens:        800,
+		CacheCreationTokens: 300,
+		CacheReadTokens:     9000,
+		CostUSD:             0.1234,
+	})
+	want := "Invocation done: 5 turns in 2345ms (1200 in / 800 out tokens) (cache: 300 written / 9000 read) $0.1234"
+	if got != want {
+		t.Errorf("FormatDetail(InvocationDone with cache) = %q, want %q", got, want)
+	}
+}
+
+func TestFormatDetail_StreamDetails(t *testing.T) {
+	tests := []struct {
+		event events.Event
+		want  string
+	}{
+		{events.ToolResult{Name: "Read", Output: "package main"}, ""},
+		{events.ToolResult{Name: "Bash", Detail: "go test", IsError: true, Output: "FAIL"}, "✗ Bash go test failed: FAIL"},
+		{events.SessionInit{SessionID: "sess-1", Model: "claude-sonnet-4-5"}, "Session sess-1 started (claude-sonnet-4-5)"},
+		{events.FileEdited{Path: "main.go", Added: 3, Removed: 1}, "✎ main.go (+3 -1)"},
+		{events.ToolUse{Name: "Grep", Detail: `"auth"`, Subagent: "Explore"}, `↳ [Explore] Grep "auth"`},
+	}
+	for _, tt := range tests {
+		if got := eventlog.FormatDetail(tt.event); got != tt.want {
+			t.Errorf("FormatDetail(%T) = %q, want %q", tt.event, got, tt.want)
+		}
+	}
+}
+
 func TestFormatDetail_UnknownEvent_ReturnsEmpty(t *testing.T) {
 	got := eventlog.FormatDetail(events.PRDRefresh{})
 	if got != "" {
@@ -153,6 +195,24 @@ func TestHandler_Handle_LogsActivity(t *testing.T) {
 	}
 }
 
+func TestHandler_Handle_LogsCommitsToTimeline(t *testing.T) {
+	d := setupTestDB(t)
+
+	h := eventlog.New(d, "issue-1", nil, nil)
+	h.Handle(events.CommitCreated{SHA: "abc1234def", Subject: "feat(US-002): Logout", StoryID: "US-002", FilesChanged: 3, Insertions: 10, Deletions: 2})
+
+	entries, err := d.ListTimelineActivity("issue-1", 10, 0)
+	if err != nil {
+		t.Fatalf("listing activity: %v", err)
+	}
+	if len(entries) != 1 || entries[0].EventType != "commit_created" {
+		t.Fatalf("timeline = %+v, want one commit_created entry", entries)
+	}
+	if want := "Commit abc1234 created for US-002: feat(US-002): Logout (3 files, +10 -2)"; entries[0].Detail != want {
+		t.Errorf("detail = %q, want %q", entries[0].Detail, want)
+	}
+}
+
 func TestHandler_Handle_CallsOnBuildEvent(t *testing.T) {
 	d := setupTestDB(t)
 
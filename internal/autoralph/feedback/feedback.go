@@ -142,9 +142,8 @@ func IsAddressingFeedback(issue db.Issue) bool {
 // It fetches feedback from three sources (line comments, review bodies, and
 // general PR comments), invokes AI with the address_feedback.md prompt,
 // commits and pushes changes, then replies via the appropriate channel.
-func NewAction(cfg Config) func(issue db.Issue, database *db.DB) error {
-	return func(issue db.Issue, database *db.DB) error {
-		ctx := context.Background()
+func NewAction(cfg Config) func(ctx context.Context, issue db.Issue, database *db.DB) error {
+	return func(ctx context.Context, issue db.Issue, database *db.DB) error {
 
 		project, err := cfg.Projects.GetProject(issue.ProjectID)
 		if err != nil {
@@ -240,7 +240,7 @@ func TestNewAction_InvokesAIWithFeedbackPrompt(t *testing.T) {
 	cfg, inv, _, _, _ := defaultMocks(project)
 
 	action := NewAction(cfg)
-	err := action(issue, d)
+	err := action(context.Background(), issue, d)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -266,7 +266,7 @@ func TestNewAction_CommitsAndPushesChanges(t *testing.T) {
 	cfg, _, _, _, git := defaultMocks(project)
 
 	action := NewAction(cfg)
-	err := action(issue, d)
+	err := action(context.Background(), issue, d)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -301,7 +301,7 @@ func TestNewAction_RepliesToEachComment(t *testing.T) {
 	cfg, _, _, replier, _ := defaultMocks(project)
 
 	action := NewAction(cfg)
-	err := action(issue, d)
+	err := action(context.Background(), issue, d)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -340,7 +340,7 @@ func TestNewAction_LogsActivity(t *testing.T) {
 	cfg, _, _, _, _ := defaultMocks(project)
 
 	action := NewAction(cfg)
-	err := action(issue, d)
+	err := action(context.Background(), issue, d)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -375,7 +375,7 @@ func TestNewAction_NoComments_SkipsAIAndCommit(t *testing.T) {
 	fetcher.comments = nil
 
 	action := NewAction(cfg)
-	err := action(issue, d)
+	err := action(context.Background(), issue, d)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -402,7 +402,7 @@ func TestNewAction_FetchCommentsError(t *testing.T) {
 	fetcher.err = errors.New("github 500")
 
 	action := NewAction(cfg)
-	err := action(issue, d)
+	err := action(context.Background(), issue, d)
 	if err == nil {
 		t.Fatal("expected error")
 	}
@@ -419,7 +419,7 @@ func TestNewAction_AIError(t *testing.T) {
 	inv.err = errors.New("AI timeout")
 
 	action := NewAction(cfg)
-	err := action(issue, d)
+	err := action(context.Background(), issue, d)
 	if err == nil {
 		t.Fatal("expected error")
 	}
@@ -436,7 +436,7 @@ func TestNewAction_NothingToCommit_SucceedsWithNoCommitRef(t *testing.T) {
 	git.commitErr = errors.New("nothing to commit")
 
 	action := NewAction(cfg)
-	err := action(issue, d)
+	err := action(context.Background(), issue, d)
 	if err != nil {
 		t.Fatalf("expected no error for nothing-to-commit, got: %v", err)
 	}
@@ -469,7 +469,7 @@ func TestNewAction_RealCommitError(t *testing.T) {
 	git.commitErr = errors.New("fatal: unable to write tree")
 
 	action := NewAction(cfg)
-	err := action(issue, d)
+	err := action(context.Background(), issue, d)
 	if err == nil {
 		t.Fatal("expected error")
 	}
@@ -486,7 +486,7 @@ func TestNewAction_PushError(t *testing.T) {
 	git.pushErr = errors.New("push rejected")
 
 	action := NewAction(cfg)
-	err := action(issue, d)
+	err := action(context.Background(), issue, d)
 	if err == nil {
 		t.Fatal("expected error")
 	}
@@ -503,7 +503,7 @@ func TestNewAction_ReplyError(t *testing.T) {
 	replier.err = errors.New("github 403")
 
 	action := NewAction(cfg)
-	err := action(issue, d)
+	err := action(context.Background(), issue, d)
 	if err == nil {
 		t.Fatal("expected error")
 	}
@@ -520,7 +520,7 @@ func TestNewAction_ProjectNotFound(t *testing.T) {
 	cfg.Projects = &mockProjectGetter{err: errors.New("not found")}
 
 	action := NewAction(cfg)
-	err := action(issue, d)
+	err := action(context.Background(), issue, d)
 	if err == nil {
 		t.Fatal("expected error")
 	}
@@ -542,7 +542,7 @@ func TestNewAction_GroupsReplyThreads(t *testing.T) {
 	}
 
 	action := NewAction(cfg)
-	err := action(issue, d)
+	err := action(context.Background(), issue, d)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -576,7 +576,7 @@ func TestNewAction_HeadSHAError_UsesUnknown(t *testing.T) {
 	git.headErr = errors.New("git error")
 
 	action := NewAction(cfg)
-	err := action(issue, d)
+	err := action(context.Background(), issue, d)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -603,7 +603,7 @@ func TestNewAction_WithConfigLoader_IncludesQualityChecksInPrompt(t *testing.T)
 	}
 
 	action := NewAction(cfg)
-	err := action(issue, d)
+	err := action(context.Background(), issue, d)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -623,7 +623,7 @@ func TestNewAction_WithConfigLoader_Error_ReturnsError(t *testing.T) {
 	cfg.ConfigLoad = &mockConfigLoader{err: errors.New("config not found")}
 
 	action := NewAction(cfg)
-	err := action(issue, d)
+	err := action(context.Background(), issue, d)
 	if err == nil {
 		t.Fatal("expected error")
 	}
@@ -641,7 +641,7 @@ func TestNewAction_WithoutConfigLoader_SkipsQualityChecks(t *testing.T) {
 	cfg.ConfigLoad = nil
 
 	action := NewAction(cfg)
-	err := action(issue, d)
+	err := action(context.Background(), issue, d)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -658,7 +658,7 @@ func TestNewAction_PassesEventHandlerToInvoker(t *testing.T) {
 	cfg, inv, _, _, _ := defaultMocks(project)
 
 	action := NewAction(cfg)
-	err := action(issue, d)
+	err := action(context.Background(), issue, d)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -681,7 +681,7 @@ func TestNewAction_EventHandlerLogsBuildEvents(t *testing.T) {
 	}
 
 	action := NewAction(cfg)
-	err := action(issue, d)
+	err := action(context.Background(), issue, d)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -726,7 +726,7 @@ func TestNewAction_EventHandlerForwardsToUpstream(t *testing.T) {
 	}}
 
 	action := NewAction(cfg)
-	err := action(issue, d)
+	err := action(context.Background(), issue, d)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -761,7 +761,7 @@ func TestNewAction_LogsFeedbackStartAndFinish(t *testing.T) {
 	cfg, _, _, _, _ := defaultMocks(project)
 
 	action := NewAction(cfg)
-	err := action(issue, d)
+	err := action(context.Background(), issue, d)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -802,7 +802,7 @@ func TestNewAction_NoComments_LogsStartButSkipsFinish(t *testing.T) {
 	fetcher.comments = nil
 
 	action := NewAction(cfg)
-	err := action(issue, d)
+	err := action(context.Background(), issue, d)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -876,7 +876,7 @@ func TestNewAction_ReactsToTopLevelComments(t *testing.T) {
 	cfg.Reactor = reactor
 
 	action := NewAction(cfg)
-	err := action(issue, d)
+	err := action(context.Background(), issue, d)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -930,7 +930,7 @@ func TestNewAction_ReactsBeforeAIInvocation(t *testing.T) {
 	}
 
 	action := NewAction(cfg)
-	err := action(issue, d)
+	err := action(context.Background(), issue, d)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -987,7 +987,7 @@ func TestNewAction_ReactionError_NonFatal(t *testing.T) {
 	cfg.Reactor = reactor
 
 	action := NewAction(cfg)
-	err := action(issue, d)
+	err := action(context.Background(), issue, d)
 	if err != nil {
 		t.Fatalf("expected no error despite reaction failure, got: %v", err)
 	}
@@ -1006,7 +1006,7 @@ func TestNewAction_NilReactor_Safe(t *testing.T) {
 	cfg.Reactor = nil
 
 	action := NewAction(cfg)
-	err := action(issue, d)
+	err := action(context.Background(), issue, d)
 	if err != nil {
 		t.Fatalf("expected no error with nil reactor, got: %v", err)
 	}
@@ -1048,7 +1048,7 @@ func TestNewAction_ReviewBody_IncludedInPrompt(t *testing.T) {
 	cfg.PRCommenter = &mockPRCommenter{}
 
 	action := NewAction(cfg)
-	err := action(issue, d)
+	err := action(context.Background(), issue, d)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -1079,7 +1079,7 @@ func TestNewAction_IssueComment_IncludedInPrompt(t *testing.T) {
 	cfg.PRCommenter = &mockPRCommenter{}
 
 	action := NewAction(cfg)
-	err := action(issue, d)
+	err := action(context.Background(), issue, d)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -1109,7 +1109,7 @@ func TestNewAction_OnlyReviewBody_ProcessedCorrectly(t *testing.T) {
 	cfg.PRCommenter = prCommenter
 
 	action := NewAction(cfg)
-	err := action(issue, d)
+	err := action(context.Background(), issue, d)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -1159,7 +1159,7 @@ func TestNewAction_MixedSources_AllIncluded(t *testing.T) {
 	cfg.PRCommenter = prCommenter
 
 	action := NewAction(cfg)
-	err := action(issue, d)
+	err := action(context.Background(), issue, d)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -1216,7 +1216,7 @@ func TestNewAction_MultipleNonInline_SingleConsolidatedComment(t *testing.T) {
 	cfg.PRCommenter = prCommenter
 
 	action := NewAction(cfg)
-	err := action(issue, d)
+	err := action(context.Background(), issue, d)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -1252,7 +1252,7 @@ func TestNewAction_OnlyInlineComments_NoPRComment(t *testing.T) {
 	cfg.PRCommenter = prCommenter
 
 	action := NewAction(cfg)
-	err := action(issue, d)
+	err := action(context.Background(), issue, d)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -1282,7 +1282,7 @@ func TestNewAction_BotReviewBody_Filtered(t *testing.T) {
 	}
 
 	action := NewAction(cfg)
-	err := action(issue, d)
+	err := action(context.Background(), issue, d)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -1307,7 +1307,7 @@ func TestNewAction_EmptyReviewBody_Filtered(t *testing.T) {
 	}
 
 	action := NewAction(cfg)
-	err := action(issue, d)
+	err := action(context.Background(), issue, d)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -1332,7 +1332,7 @@ func TestNewAction_ApprovedReviewBody_Filtered(t *testing.T) {
 	}
 
 	action := NewAction(cfg)
-	err := action(issue, d)
+	err := action(context.Background(), issue, d)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -1357,7 +1357,7 @@ func TestNewAction_BotIssueComment_Filtered(t *testing.T) {
 	}
 
 	action := NewAction(cfg)
-	err := action(issue, d)
+	err := action(context.Background(), issue, d)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -1378,7 +1378,7 @@ func TestNewAction_FetchReviewsError(t *testing.T) {
 	cfg.Reviews = &mockReviewFetcher{err: errors.New("github 500")}
 
 	action := NewAction(cfg)
-	err := action(issue, d)
+	err := action(context.Background(), issue, d)
 	if err == nil {
 		t.Fatal("expected error")
 	}
@@ -1397,7 +1397,7 @@ func TestNewAction_FetchIssueCommentsError(t *testing.T) {
 	cfg.IssueComments = &mockIssueCommentFetcher{err: errors.New("github 500")}
 
 	action := NewAction(cfg)
-	err := action(issue, d)
+	err := action(context.Background(), issue, d)
 	if err == nil {
 		t.Fatal("expected error")
 	}
@@ -1421,7 +1421,7 @@ func TestNewAction_PRCommentError(t *testing.T) {
 	cfg.PRCommenter = &mockPRCommenter{err: errors.New("github 403")}
 
 	action := NewAction(cfg)
-	err := action(issue, d)
+	err := action(context.Background(), issue, d)
 	if err == nil {
 		t.Fatal("expected error")
 	}
@@ -1447,7 +1447,7 @@ func TestNewAction_IssueCommentReaction(t *testing.T) {
 	cfg.PRCommenter = &mockPRCommenter{}
 
 	action := NewAction(cfg)
-	err := action(issue, d)
+	err := action(context.Background(), issue, d)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -1475,7 +1475,7 @@ func TestNewAction_NilOptionalInterfaces_Safe(t *testing.T) {
 	cfg.IssueReactor = nil
 
 	action := NewAction(cfg)
-	err := action(issue, d)
+	err := action(context.Background(), issue, d)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -1502,7 +1502,7 @@ func TestNewAction_NoLineComments_ReviewBodyOnly_NoReviewReply(t *testing.T) {
 	cfg.PRCommenter = prCommenter
 
 	action := NewAction(cfg)
-	err := action(issue, d)
+	err := action(context.Background(), issue, d)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -1533,7 +1533,7 @@ func TestNewAction_NilPRCommenter_SkipsGeneralReply(t *testing.T) {
 	cfg.PRCommenter = nil // no PR commenter
 
 	action := NewAction(cfg)
-	err := action(issue, d)
+	err := action(context.Background(), issue, d)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -1570,7 +1570,7 @@ func TestNewAction_FeedbackCountIncludesAllSources(t *testing.T) {
 	cfg.PRCommenter = &mockPRCommenter{}
 
 	action := NewAction(cfg)
-	err := action(issue, d)
+	err := action(context.Background(), issue, d)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -1611,7 +1611,7 @@ func TestNewAction_IncludesKnowledgePath(t *testing.T) {
 		ConfigLoad:   &mockConfigLoader{cfg: &config.Config{}},
 	})
 
-	err := action(issue, d)
+	err := action(context.Background(), issue, d)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -1634,7 +1634,7 @@ func TestNewAction_PullsBranchBeforeAIInvocation(t *testing.T) {
 	cfg.BranchPuller = puller
 
 	action := NewAction(cfg)
-	err := action(issue, d)
+	err := action(context.Background(), issue, d)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -1662,7 +1662,7 @@ func TestNewAction_PullBranchError_ReturnsErrorWithoutAI(t *testing.T) {
 	cfg.BranchPuller = puller
 
 	action := NewAction(cfg)
-	err := action(issue, d)
+	err := action(context.Background(), issue, d)
 	if err == nil {
 		t.Fatal("expected error from PullBranch")
 	}
@@ -1696,7 +1696,7 @@ func TestNewAction_PullBranchCalledBeforeInvoke(t *testing.T) {
 	cfg.Invoker = &orderTrackingInvoker{inner: cfg.Invoker, orderLog: &order}
 
 	action := NewAction(cfg)
-	err := action(issue, d)
+	err := action(context.Background(), issue, d)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -1790,7 +1790,7 @@ func TestNewAction_PassesMaxTurns(t *testing.T) {
 	cfg, inv, _, _, _ := defaultMocks(project)
 
 	action := NewAction(cfg)
-	if err := action(issue, d); err != nil {
+	if err := action(context.Background(), issue, d); err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
 
@@ -1900,7 +1900,7 @@ func TestNewAction_ReplyThreadsInPrompt(t *testing.T) {
 	}
 
 	action := NewAction(cfg)
-	err := action(issue, d)
+	err := action(context.Background(), issue, d)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -1995,7 +1995,7 @@ func TestNewAction_TrustedUserAnnotation_InPrompt(t *testing.T) {
 	}
 
 	action := NewAction(cfg)
-	err := action(issue, d)
+	err := action(context.Background(), issue, d)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -2043,7 +2043,7 @@ func TestNewAction_NoTrustAnnotation_WhenTrustedUserEmpty(t *testing.T) {
 	}
 
 	action := NewAction(cfg)
-	err := action(issue, d)
+	err := action(context.Background(), issue, d)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -2197,7 +2197,7 @@ func TestNewAction_NoReplies_BackwardCompatible(t *testing.T) {
 	}
 
 	action := NewAction(cfg)
-	err := action(issue, d)
+	err := action(context.Background(), issue, d)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -2237,7 +2237,7 @@ func TestNewAction_DynamicCommitMessage(t *testing.T) {
 **Response:** Update error message format`
 
 	action := NewAction(cfg)
-	err := action(issue, d)
+	err := action(context.Background(), issue, d)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -2273,7 +2273,7 @@ func TestNewAction_FallbackCommitMessage_UnstructuredResponse(t *testing.T) {
 	inv.response = "I looked at the code and made some fixes."
 
 	action := NewAction(cfg)
-	err := action(issue, d)
+	err := action(context.Background(), issue, d)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -2316,7 +2316,7 @@ func TestNewAction_EndToEnd_ThreadsTrustDynamicCommit(t *testing.T) {
 **Response:** Added input validation for edge cases`
 
 	action := NewAction(cfg)
-	err := action(issue, d)
+	err := action(context.Background(), issue, d)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
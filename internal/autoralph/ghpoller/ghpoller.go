@@ -10,6 +10,7 @@ import (
 
 	"github.com/uesteibar/ralph/internal/autoralph/db"
 	"github.com/uesteibar/ralph/internal/autoralph/github"
+	"github.com/uesteibar/ralph/internal/autoralph/metrics"
 	"github.com/uesteibar/ralph/internal/autoralph/orchestrator"
 )
 
@@ -58,7 +59,7 @@ type ProjectInfo struct {
 
 // CompleteFunc is called when a PR merge is detected. It handles workspace
 // cleanup, Linear state update, and issue completion.
-type CompleteFunc func(issue db.Issue, database *db.DB) error
+type CompleteFunc func(ctx context.Context, issue db.Issue, database *db.DB) error
 
 // Poller polls GitHub for PR reviews and merge status on tracked issues.
 type Poller struct {
@@ -106,6 +107,9 @@ func (p *Poller) Run(ctx context.Context) {
 
 // poll executes a single poll cycle across all projects.
 func (p *Poller) poll(ctx context.Context) {
+	start := time.Now()
+	defer func() { metrics.PollDuration.WithLabelValues(metrics.GitHub).Observe(time.Since(start).Seconds()) }()
+
 	for _, proj := range p.projects {
 		if ctx.Err() != nil {
 			return
@@ -150,12 +154,13 @@ func (p *Poller) checkIssue(ctx context.Context, proj ProjectInfo, issue db.Issu
 	merged, err := proj.GitHub.IsPRMerged(ctx, proj.GithubOwner, proj.GithubRepo, issue.PRNumber)
 	if err != nil {
 		p.logger.Warn("checking PR merged", "issue_id", issue.ID, "pr", issue.PRNumber, "error", err)
+		metrics.APIErrors.WithLabelValues(metrics.GitHub).Inc()
 		return
 	}
 
 	if merged {
 		if p.complete != nil {
-			if err := p.complete(issue, p.db); err != nil {
+			if err := p.complete(ctx, issue, p.db); err != nil {
 				p.logger.Warn("completing issue", "issue_id", issue.ID, "error", err)
 			}
 		} else {
@@ -174,6 +179,7 @@ func (p *Poller) checkIssue(ctx context.Context, proj ProjectInfo, issue db.Issu
 	pr, err := proj.GitHub.FetchPR(ctx, proj.GithubOwner, proj.GithubRepo, issue.PRNumber)
 	if err != nil {
 		p.logger.Warn("fetching PR", "issue_id", issue.ID, "pr", issue.PRNumber, "error", err)
+		metrics.APIErrors.WithLabelValues(metrics.GitHub).Inc()
 		return
 	}
 
@@ -191,6 +197,7 @@ func (p *Poller) checkIssue(ctx context.Context, proj ProjectInfo, issue db.Issu
 		checkRuns, err := proj.GitHub.FetchCheckRuns(ctx, proj.GithubOwner, proj.GithubRepo, headSHA)
 		if err != nil {
 			p.logger.Warn("fetching check runs", "issue_id", issue.ID, "ref", headSHA, "error", err)
+			metrics.APIErrors.WithLabelValues(metrics.GitHub).Inc()
 			return
 		}
 
@@ -232,6 +239,7 @@ func (p *Poller) checkIssue(ctx context.Context, proj ProjectInfo, issue db.Issu
 		events, err := proj.GitHub.FetchTimeline(ctx, proj.GithubOwner, proj.GithubRepo, issue.PRNumber)
 		if err != nil {
 			p.logger.Warn("fetching PR timeline", "issue_id", issue.ID, "pr", issue.PRNumber, "error", err)
+			metrics.APIErrors.WithLabelValues(metrics.GitHub).Inc()
 			// Fall back to direct user ID check only (empty delegated map).
 		} else {
 			delegated = trustedReviewerIDs(events, proj.TrustedUserID)
@@ -242,6 +250,7 @@ func (p *Poller) checkIssue(ctx context.Context, proj ProjectInfo, issue db.Issu
 	reviews, err := proj.GitHub.FetchPRReviews(ctx, proj.GithubOwner, proj.GithubRepo, issue.PRNumber)
 	if err != nil {
 		p.logger.Warn("fetching PR reviews", "issue_id", issue.ID, "pr", issue.PRNumber, "error", err)
+		metrics.APIErrors.WithLabelValues(metrics.GitHub).Inc()
 		return
 	}
 
@@ -311,8 +320,7 @@ func (p *Poller) transitionIssue(issue db.Issue, toState, eventType, detail stri
 		p.logger.Warn("updating issue state", "issue_id", issue.ID, "error", err)
 		return
 	}
-
-	if err := p.db.LogActivity(issue.ID, eventType, fromState, toState, detail); err != nil {
+	if err := p.db.RecordTransition(issue.ID, eventType, fromState, toState, detail); err != nil {
 		p.logger.Warn("logging activity", "issue_id", issue.ID, "error", err)
 	}
 }
@@ -596,7 +596,7 @@ func TestPollProject_MergedPR_CallsCompleteFunc(t *testing.T) {
 
 	var completeCalled bool
 	var completedIssueID string
-	completeFn := func(iss db.Issue, database *db.DB) error {
+	completeFn := func(_ context.Context, iss db.Issue, database *db.DB) error {
 		completeCalled = true
 		completedIssueID = iss.ID
 		// Simulate what the real complete action does
@@ -1296,7 +1296,7 @@ func TestPollProject_FailedIssueWithMergedPR_TransitionsToCompleted(t *testing.T
 	mock := &mockGitHub{merged: true}
 
 	var completeCalled bool
-	completeFn := func(iss db.Issue, database *db.DB) error {
+	completeFn := func(_ context.Context, iss db.Issue, database *db.DB) error {
 		completeCalled = true
 		iss.State = string(orchestrator.StateCompleted)
 		return database.UpdateIssue(iss)
@@ -12,7 +12,7 @@ import (
 	"time"
 
 	gh "github.com/google/go-github/v68/github"
-	"github.com/uesteibar/ralph/internal/autoralph/retry"
+	"github.com/uesteibar/ralph/internal/retry"
 
 	"github.com/bradleyfalzon/ghinstallation/v2"
 	jwt "github.com/golang-jwt/jwt/v4"
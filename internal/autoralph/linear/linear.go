@@ -12,7 +12,7 @@ import (
 	"strings"
 	"time"
 
-	"github.com/uesteibar/ralph/internal/autoralph/retry"
+	"github.com/uesteibar/ralph/internal/retry"
 )
 
 var uuidRegexp = regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}$`)
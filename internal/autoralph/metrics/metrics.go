@@ -0,0 +1,165 @@
+// Package metrics defines AutoRalph's Prometheus metrics and serves them in
+// the Prometheus text format. Counters and histograms are package-level and
+// updated where things happen; gauges are read from their sources on each
+// scrape.
+package metrics
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+
+	"github.com/uesteibar/ralph/internal/autoralph/ccusage"
+	"github.com/uesteibar/ralph/internal/autoralph/db"
+)
+
+// Pollers and APIs, as used in metric labels.
+const (
+	Linear = "linear"
+	GitHub = "github"
+)
+
+var (
+	// Transitions counts issue state changes.
+	Transitions = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "autoralph_transitions_total",
+		Help: "Issue state transitions, by from and to state.",
+	}, []string{"from", "to"})
+
+	// ActionDuration times transition actions and builds.
+	ActionDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "autoralph_action_duration_seconds",
+		Help:    "Duration of transition actions and builds, by the transition they run for.",
+		Buckets: []float64{1, 5, 15, 30, 60, 120, 300, 600, 1200, 1800, 3600, 7200},
+	}, []string{"from", "to"})
+
+	// ActionFailures counts transition actions and builds that failed.
+	ActionFailures = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "autoralph_action_failures_total",
+		Help: "Failed transition actions and builds, by the transition they run for.",
+	}, []string{"from", "to"})
+
+	// PollDuration times a poll cycle across all projects.
+	PollDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "autoralph_poll_duration_seconds",
+		Help:    "Duration of a poll cycle across all projects, by poller.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"poller"})
+
+	// APIErrors counts failed Linear and GitHub API calls.
+	APIErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "autoralph_api_errors_total",
+		Help: "Failed Linear and GitHub API calls, by API.",
+	}, []string{"api"})
+
+	// Tokens counts the tokens Claude consumed for AutoRalph's issues.
+	Tokens = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "autoralph_tokens_total",
+		Help: "Tokens consumed by Claude invocations, by direction (input or output).",
+	}, []string{"direction"})
+)
+
+// CountTransition counts an issue state change. Register it with
+// db.DB.OnTransition so every recorded transition is counted.
+func CountTransition(from, to string) {
+	Transitions.WithLabelValues(from, to).Inc()
+}
+
+// Workers reports how many build worker slots are in use.
+type Workers interface {
+	ActiveCount() int
+	MaxWorkers() int
+}
+
+// CCUsage provides the latest Claude Code usage percentages.
+type CCUsage interface {
+	Current() []ccusage.UsageGroup
+}
+
+// Sources are what the scrape-time gauges are read from. Any may be nil.
+type Sources struct {
+	DB      *db.DB
+	Workers Workers
+	CCUsage CCUsage
+}
+
+// Handler returns an http.Handler serving every metric, with the gauges read
+// from src.
+func Handler(src Sources) http.Handler {
+	reg := prometheus.NewRegistry()
+	reg.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		Transitions, ActionDuration, ActionFailures, PollDuration, APIErrors, Tokens,
+		&gaugeCollector{src: src},
+	)
+	return promhttp.HandlerFor(reg, promhttp.HandlerOpts{})
+}
+
+var (
+	issuesDesc = prometheus.NewDesc("autoralph_issues",
+		"Issues, by project and state.", []string{"project", "state"}, nil)
+	workersActiveDesc = prometheus.NewDesc("autoralph_workers_active",
+		"Build worker slots in use.", nil, nil)
+	workersMaxDesc = prometheus.NewDesc("autoralph_workers_max",
+		"Build worker slots available.", nil, nil)
+	ccusageDesc = prometheus.NewDesc("autoralph_ccusage_percent",
+		"Claude Code usage as reported by ccstats, by group and limit.", []string{"group", "limit"}, nil)
+)
+
+// gaugeCollector reads the gauges from their sources on each scrape.
+type gaugeCollector struct {
+	src Sources
+}
+
+func (c *gaugeCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- issuesDesc
+	ch <- workersActiveDesc
+	ch <- workersMaxDesc
+	ch <- ccusageDesc
+}
+
+func (c *gaugeCollector) Collect(ch chan<- prometheus.Metric) {
+	if c.src.DB != nil {
+		c.collectIssues(ch)
+	}
+	if c.src.Workers != nil {
+		ch <- prometheus.MustNewConstMetric(workersActiveDesc, prometheus.GaugeValue, float64(c.src.Workers.ActiveCount()))
+		ch <- prometheus.MustNewConstMetric(workersMaxDesc, prometheus.GaugeValue, float64(c.src.Workers.MaxWorkers()))
+	}
+	if c.src.CCUsage != nil {
+		for _, g := range c.src.CCUsage.Current() {
+			for _, l := range g.Lines {
+				ch <- prometheus.MustNewConstMetric(ccusageDesc, prometheus.GaugeValue, float64(l.Percentage), g.GroupLabel, l.Label)
+			}
+		}
+	}
+}
+
+// collectIssues counts the issues of each project by state. A scrape that
+// can't read the database reports no issue counts rather than failing.
+func (c *gaugeCollector) collectIssues(ch chan<- prometheus.Metric) {
+	projects, err := c.src.DB.ListProjects()
+	if err != nil {
+		return
+	}
+	issues, err := c.src.DB.ListIssues(db.IssueFilter{})
+	if err != nil {
+		return
+	}
+
+	names := make(map[string]string, len(projects))
+	for _, p := range projects {
+		names[p.ID] = p.Name
+	}
+	type key struct{ project, state string }
+	counts := make(map[key]int)
+	for _, iss := range issues {
+		counts[key{names[iss.ProjectID], iss.State}]++
+	}
+	for k, n := range counts {
+		ch <- prometheus.MustNewConstMetric(issuesDesc, prometheus.GaugeValue, float64(n), k.project, k.state)
+	}
+}
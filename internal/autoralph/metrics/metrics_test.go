@@ -0,0 +1,92 @@
+package metrics
+
+import (
+	"io"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/uesteibar/ralph/internal/autoralph/ccusage"
+	"github.com/uesteibar/ralph/internal/autoralph/db"
+)
+
+type fakeWorkers struct{ active, max int }
+
+func (w fakeWorkers) ActiveCount() int { return w.active }
+func (w fakeWorkers) MaxWorkers() int  { return w.max }
+
+type fakeCCUsage []ccusage.UsageGroup
+
+func (u fakeCCUsage) Current() []ccusage.UsageGroup { return u }
+
+func scrape(t *testing.T, src Sources) string {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	Handler(src).ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	if rec.Code != 200 {
+		t.Fatalf("GET /metrics = %d", rec.Code)
+	}
+	body, _ := io.ReadAll(rec.Body)
+	return string(body)
+}
+
+func TestHandler_ReportsGauges(t *testing.T) {
+	d, err := db.Open(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { d.Close() })
+	p, err := d.CreateProject(db.Project{Name: "web", LocalPath: "/tmp/web"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, state := range []string{"queued", "queued", "building"} {
+		if _, err := d.CreateIssue(db.Issue{ProjectID: p.ID, Identifier: "WEB-" + string(rune('1'+i)), State: state}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	out := scrape(t, Sources{
+		DB:      d,
+		Workers: fakeWorkers{active: 1, max: 2},
+		CCUsage: fakeCCUsage{{GroupLabel: "Current session", Lines: []ccusage.UsageLine{{Label: "Opus", Percentage: 42}}}},
+	})
+
+	for _, want := range []string{
+		`autoralph_issues{project="web",state="queued"} 2`,
+		`autoralph_issues{project="web",state="building"} 1`,
+		`autoralph_workers_active 1`,
+		`autoralph_workers_max 2`,
+		`autoralph_ccusage_percent{group="Current session",limit="Opus"} 42`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("metrics lack %s", want)
+		}
+	}
+}
+
+func TestHandler_ReportsCounters(t *testing.T) {
+	Transitions.WithLabelValues("approved", "building").Inc()
+	ActionFailures.WithLabelValues("queued", "refining").Inc()
+	APIErrors.WithLabelValues(GitHub).Inc()
+	Tokens.WithLabelValues("output").Add(1500)
+	PollDuration.WithLabelValues(Linear).Observe(0.2)
+
+	out := scrape(t, Sources{})
+
+	for _, want := range []string{
+		`autoralph_transitions_total{from="approved",to="building"} 1`,
+		`autoralph_action_failures_total{from="queued",to="refining"} 1`,
+		`autoralph_api_errors_total{api="github"} 1`,
+		`autoralph_tokens_total{direction="output"} 1500`,
+		`autoralph_poll_duration_seconds_count{poller="linear"} 1`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("metrics lack %s", want)
+		}
+	}
+	if strings.Contains(out, "autoralph_issues") {
+		t.Error("expected no issue gauges without a database")
+	}
+}
@@ -1,9 +1,13 @@
 package orchestrator
 
 import (
+	"context"
 	"fmt"
+	"time"
 
 	"github.com/uesteibar/ralph/internal/autoralph/db"
+	"github.com/uesteibar/ralph/internal/autoralph/metrics"
+	"github.com/uesteibar/ralph/internal/tracing"
 )
 
 // IssueState represents a state in the issue lifecycle.
@@ -46,10 +50,12 @@ func ValidState(s IssueState) bool {
 type ConditionFunc func(issue db.Issue) bool
 
 // ActionFunc performs the side-effect of a transition (e.g. call AI, post comment).
-// It receives the issue and the database for any writes it needs to make.
+// It receives the issue and the database for any writes it needs to make, and
+// a context carrying the transition's span, so the work it does is traced
+// under it.
 // Actions run outside the state-transition transaction to avoid holding a
 // write lock during long-running operations like AI invocations.
-type ActionFunc func(issue db.Issue, database *db.DB) error
+type ActionFunc func(ctx context.Context, issue db.Issue, database *db.DB) error
 
 // Transition defines a valid state change in the issue lifecycle.
 type Transition struct {
@@ -59,6 +65,30 @@ type Transition struct {
 	Action    ActionFunc
 }
 
+// RunAction runs the transition's Action, if any, for issue, traced as an
+// orchestrator.Transition span under ctx and timed in the action metrics.
+func (t Transition) RunAction(ctx context.Context, issue db.Issue, database *db.DB) (err error) {
+	if t.Action == nil {
+		return nil
+	}
+	ctx, span := tracing.Start(ctx, "orchestrator.Transition",
+		tracing.AttrIssueID.String(issue.ID),
+		tracing.AttrIssueIdentifier.String(issue.Identifier),
+		tracing.AttrFromState.String(string(t.From)),
+		tracing.AttrToState.String(string(t.To)),
+	)
+	start := time.Now()
+	defer func() {
+		tracing.End(span, err)
+		metrics.ActionDuration.WithLabelValues(string(t.From), string(t.To)).Observe(time.Since(start).Seconds())
+		if err != nil {
+			metrics.ActionFailures.WithLabelValues(string(t.From), string(t.To)).Inc()
+		}
+	}()
+
+	return t.Action(ctx, issue, database)
+}
+
 // StateMachine holds registered transitions and evaluates/executes them.
 type StateMachine struct {
 	transitions []Transition
@@ -111,10 +141,8 @@ func (sm *StateMachine) Execute(t Transition, issue db.Issue) error {
 
 	// Run the action outside any transaction so long-running operations
 	// (AI calls, API calls) don't hold a SQLite write lock.
-	if t.Action != nil {
-		if err := t.Action(issue, sm.database); err != nil {
-			return fmt.Errorf("running transition action: %w", err)
-		}
+	if err := t.RunAction(context.Background(), issue, sm.database); err != nil {
+		return fmt.Errorf("running transition action: %w", err)
 	}
 
 	// Short transaction for the state update + activity log.
@@ -132,7 +160,7 @@ func (sm *StateMachine) Execute(t Transition, issue db.Issue) error {
 			return fmt.Errorf("updating issue state: %w", err)
 		}
 
-		if err := tx.LogActivity(
+		if err := tx.RecordTransition(
 			issue.ID,
 			"state_change",
 			string(t.From),
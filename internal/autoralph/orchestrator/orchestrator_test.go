@@ -1,10 +1,17 @@
 package orchestrator
 
 import (
+	"context"
 	"fmt"
 	"path/filepath"
 	"testing"
 
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+
 	"github.com/uesteibar/ralph/internal/autoralph/db"
 )
 
@@ -268,7 +275,7 @@ func TestExecute_RunsAction(t *testing.T) {
 	tr := Transition{
 		From: StateQueued,
 		To:   StateRefining,
-		Action: func(i db.Issue, database *db.DB) error {
+		Action: func(_ context.Context, i db.Issue, database *db.DB) error {
 			actionCalled = true
 			if i.ID != issue.ID {
 				t.Errorf("action received wrong issue ID: %q", i.ID)
@@ -292,7 +299,7 @@ func TestExecute_ActionError_RollsBack(t *testing.T) {
 	tr := Transition{
 		From: StateQueued,
 		To:   StateRefining,
-		Action: func(db.Issue, *db.DB) error {
+		Action: func(context.Context, db.Issue, *db.DB) error {
 			return fmt.Errorf("action failed")
 		},
 	}
@@ -312,6 +319,46 @@ func TestExecute_ActionError_RollsBack(t *testing.T) {
 	}
 }
 
+func TestExecute_TracesAction(t *testing.T) {
+	sr := tracetest.NewSpanRecorder()
+	prev := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(sr)))
+	t.Cleanup(func() { otel.SetTracerProvider(prev) })
+
+	d := testDB(t)
+	sm := New(d)
+	issue := createTestIssue(t, d, "queued")
+
+	var actionSpan trace.SpanContext
+	tr := Transition{
+		From: StateQueued,
+		To:   StateRefining,
+		Action: func(ctx context.Context, _ db.Issue, _ *db.DB) error {
+			actionSpan = trace.SpanContextFromContext(ctx)
+			return fmt.Errorf("action failed")
+		},
+	}
+	sm.Execute(tr, issue)
+
+	spans := sr.Ended()
+	if len(spans) != 1 || spans[0].Name() != "orchestrator.Transition" {
+		t.Fatalf("expected one orchestrator.Transition span, got %v", spans)
+	}
+	attrs := make(map[string]string)
+	for _, kv := range spans[0].Attributes() {
+		attrs[string(kv.Key)] = kv.Value.AsString()
+	}
+	if attrs["autoralph.issue.identifier"] != "PROJ-42" || attrs["autoralph.state.to"] != "refining" {
+		t.Errorf("attributes = %v", attrs)
+	}
+	if spans[0].Status().Code != codes.Error {
+		t.Errorf("status = %v, want error", spans[0].Status().Code)
+	}
+	if actionSpan.SpanID() != spans[0].SpanContext().SpanID() {
+		t.Errorf("action ran outside the transition span")
+	}
+}
+
 func TestExecute_WrongCurrentState_ReturnsError(t *testing.T) {
 	d := testDB(t)
 	sm := New(d)
@@ -348,7 +395,7 @@ func TestExecute_ActionCanWriteToDB(t *testing.T) {
 	tr := Transition{
 		From: StateQueued,
 		To:   StateRefining,
-		Action: func(i db.Issue, database *db.DB) error {
+		Action: func(_ context.Context, i db.Issue, database *db.DB) error {
 			return database.LogActivity(i.ID, "ai_invocation", "", "", "Called AI for refinement")
 		},
 	}
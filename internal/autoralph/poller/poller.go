@@ -10,6 +10,7 @@ import (
 
 	"github.com/uesteibar/ralph/internal/autoralph/db"
 	"github.com/uesteibar/ralph/internal/autoralph/linear"
+	"github.com/uesteibar/ralph/internal/autoralph/metrics"
 	"github.com/uesteibar/ralph/internal/autoralph/orchestrator"
 )
 
@@ -67,6 +68,9 @@ func (p *Poller) Run(ctx context.Context) {
 
 // poll executes a single poll cycle across all projects.
 func (p *Poller) poll(ctx context.Context) {
+	start := time.Now()
+	defer func() { metrics.PollDuration.WithLabelValues(metrics.Linear).Observe(time.Since(start).Seconds()) }()
+
 	for _, proj := range p.projects {
 		if ctx.Err() != nil {
 			return
@@ -81,6 +85,7 @@ func (p *Poller) pollProject(ctx context.Context, proj ProjectInfo) {
 	issues, err := proj.LinearClient.FetchAssignedIssues(ctx, proj.LinearTeamID, proj.LinearAssigneeID, proj.LinearProjectID, proj.LinearLabel)
 	if err != nil {
 		p.logger.Warn("poll failed", "project_id", proj.ProjectID, "error", err)
+		metrics.APIErrors.WithLabelValues(metrics.Linear).Inc()
 		return
 	}
 
@@ -2,11 +2,13 @@ package pr
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"strings"
 
 	"github.com/uesteibar/ralph/internal/autoralph/ai"
 	"github.com/uesteibar/ralph/internal/autoralph/db"
+	"github.com/uesteibar/ralph/internal/secrets"
 	"github.com/uesteibar/ralph/internal/workspace"
 )
 
@@ -72,6 +74,18 @@ type ConflictError struct {
 	Files []string
 }
 
+// SecretsError is returned by a push when the commits to push look like they
+// contain secrets. Nothing is pushed.
+type SecretsError struct {
+	Findings []secrets.Finding
+}
+
+// RunReporter summarizes the run that built a workspace, for the end of the
+// PR description.
+type RunReporter interface {
+	RunSummary(ctx context.Context, projectLocalPath, workspaceName, base string) (string, error)
+}
+
 // PRResult holds the result of creating a GitHub PR.
 type PRResult struct {
 	Number  int
@@ -97,6 +111,14 @@ func (e *ConflictError) Error() string {
 	return fmt.Sprintf("merge conflicts in %d files: %s", len(e.Files), strings.Join(e.Files, ", "))
 }
 
+func (e *SecretsError) Error() string {
+	found := make([]string, len(e.Findings))
+	for i, f := range e.Findings {
+		found[i] = f.String()
+	}
+	return fmt.Sprintf("push blocked, %d possible secrets: %s", len(e.Findings), strings.Join(found, "; "))
+}
+
 // Config holds the dependencies for the PR creation action.
 type Config struct {
 	Invoker     Invoker
@@ -107,16 +129,16 @@ type Config struct {
 	Linear      LinearPoster
 	Projects    ProjectGetter
 	ConfigLoad  ConfigLoader
-	Rebase      Rebaser // optional: when set, attempts rebase on push failure
+	Rebase      Rebaser     // optional: when set, attempts rebase on push failure
+	Report      RunReporter // optional: when set, appends a run report to the PR description
 	OverrideDir string
 }
 
 // NewAction returns a function that creates a GitHub PR for a completed build.
 // It pushes the branch, generates a PR description via AI, creates the PR,
 // stores PR info in the issue, and posts a Linear comment with the PR link.
-func NewAction(cfg Config) func(issue db.Issue, database *db.DB) error {
-	return func(issue db.Issue, database *db.DB) error {
-		ctx := context.Background()
+func NewAction(cfg Config) func(ctx context.Context, issue db.Issue, database *db.DB) error {
+	return func(ctx context.Context, issue db.Issue, database *db.DB) error {
 
 		project, err := cfg.Projects.GetProject(issue.ProjectID)
 		if err != nil {
@@ -138,7 +160,7 @@ func NewAction(cfg Config) func(issue db.Issue, database *db.DB) error {
 		if err != nil {
 			diffStats = "(diff stats unavailable)"
 		} else {
-			diffStats = capDiffStats(diffStats, 50)
+			diffStats = ai.CapDiffStats(diffStats, 50)
 		}
 
 		prdPath := workspace.PRDPathForWorkspace(project.LocalPath, issue.WorkspaceName)
@@ -170,7 +192,15 @@ func NewAction(cfg Config) func(issue db.Issue, database *db.DB) error {
 			return fmt.Errorf("invoking AI for PR description: %w", err)
 		}
 
-		title, body := parsePROutput(aiOutput)
+		title, body := ai.ParsePRDescription(aiOutput)
+
+		if cfg.Report != nil {
+			// The report is a nice-to-have: a PR without it beats no PR.
+			summary, err := cfg.Report.RunSummary(ctx, project.LocalPath, issue.WorkspaceName, "origin/"+defaultBase)
+			if err == nil && summary != "" {
+				body += "\n\n" + summary
+			}
+		}
 
 		// Idempotent: check for existing open PR before creating
 		existingPR, err := cfg.GitHub.FindOpenPR(ctx,
@@ -226,52 +256,18 @@ func NewAction(cfg Config) func(issue db.Issue, database *db.DB) error {
 	}
 }
 
-// parsePROutput splits the AI response into title (first line) and body (rest).
-func parsePROutput(output string) (string, string) {
-	output = strings.TrimSpace(output)
-	parts := strings.SplitN(output, "\n", 2)
-	title := strings.TrimSpace(parts[0])
-	var body string
-	if len(parts) > 1 {
-		body = strings.TrimSpace(parts[1])
-	}
-	return title, body
-}
-
-// capDiffStats caps the diff stats output to maxEntries file entries plus the
-// final summary line. If there are more entries, a marker is inserted.
-func capDiffStats(stats string, maxEntries int) string {
-	if stats == "" {
-		return stats
-	}
-	lines := strings.Split(stats, "\n")
-	// The last line is the summary (e.g. " 80 files changed, ...").
-	// Everything before it is a file entry.
-	if len(lines) <= maxEntries+1 {
-		return stats
-	}
-
-	summary := lines[len(lines)-1]
-	omitted := len(lines) - 1 - maxEntries
-	marker := fmt.Sprintf("[... %d file entries omitted ...]", omitted)
-
-	result := make([]string, 0, maxEntries+2)
-	result = append(result, lines[:maxEntries]...)
-	result = append(result, marker)
-	result = append(result, summary)
-	return strings.Join(result, "\n")
-}
-
 // pushWithRebase attempts to push the branch. If push fails and a Rebaser is
 // configured, it fetches the base, rebases, and retries. If the rebase results
-// in conflicts, it aborts the rebase and returns a ConflictError.
+// in conflicts, it aborts the rebase and returns a ConflictError. A push
+// blocked by a SecretsError is returned as is: rebasing won't help.
 func pushWithRebase(ctx context.Context, cfg Config, treePath, branch, base string) error {
 	pushErr := cfg.Git.PushBranch(ctx, treePath, branch)
 	if pushErr == nil {
 		return nil
 	}
 
-	if cfg.Rebase == nil {
+	var secretsErr *SecretsError
+	if cfg.Rebase == nil || errors.As(pushErr, &secretsErr) {
 		return fmt.Errorf("pushing branch: %w", pushErr)
 	}
 
@@ -9,6 +9,7 @@ import (
 	"testing"
 
 	"github.com/uesteibar/ralph/internal/autoralph/db"
+	"github.com/uesteibar/ralph/internal/secrets"
 )
 
 func testDB(t *testing.T) *db.DB {
@@ -217,7 +218,7 @@ func TestNewAction_PushesBranch(t *testing.T) {
 	cfg.Projects = d
 
 	action := NewAction(cfg)
-	err := action(issue, d)
+	err := action(context.Background(), issue, d)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -243,7 +244,7 @@ func TestNewAction_InvokesAIWithPRPrompt(t *testing.T) {
 	cfg.Projects = d
 
 	action := NewAction(cfg)
-	err := action(issue, d)
+	err := action(context.Background(), issue, d)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -273,7 +274,7 @@ func TestNewAction_CreatesGitHubPR(t *testing.T) {
 	cfg.Projects = d
 
 	action := NewAction(cfg)
-	err := action(issue, d)
+	err := action(context.Background(), issue, d)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -310,7 +311,7 @@ func TestNewAction_StoresPRInfoInDB(t *testing.T) {
 	cfg.Projects = d
 
 	action := NewAction(cfg)
-	err := action(issue, d)
+	err := action(context.Background(), issue, d)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -335,7 +336,7 @@ func TestNewAction_PostsLinearComment(t *testing.T) {
 	cfg.Projects = d
 
 	action := NewAction(cfg)
-	err := action(issue, d)
+	err := action(context.Background(), issue, d)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -362,7 +363,7 @@ func TestNewAction_UpdatesLastCommentID(t *testing.T) {
 	cfg.Projects = d
 
 	action := NewAction(cfg)
-	err := action(issue, d)
+	err := action(context.Background(), issue, d)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -384,7 +385,7 @@ func TestNewAction_LogsActivity(t *testing.T) {
 	cfg.Projects = d
 
 	action := NewAction(cfg)
-	err := action(issue, d)
+	err := action(context.Background(), issue, d)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -417,7 +418,7 @@ func TestNewAction_PushError(t *testing.T) {
 	git.err = errors.New("push rejected")
 
 	action := NewAction(cfg)
-	err := action(issue, d)
+	err := action(context.Background(), issue, d)
 	if err == nil {
 		t.Fatal("expected error")
 	}
@@ -435,7 +436,7 @@ func TestNewAction_AIError(t *testing.T) {
 	inv.err = errors.New("AI timeout")
 
 	action := NewAction(cfg)
-	err := action(issue, d)
+	err := action(context.Background(), issue, d)
 	if err == nil {
 		t.Fatal("expected error")
 	}
@@ -453,7 +454,7 @@ func TestNewAction_GitHubPRError(t *testing.T) {
 	gh.err = errors.New("422 validation failed")
 
 	action := NewAction(cfg)
-	err := action(issue, d)
+	err := action(context.Background(), issue, d)
 	if err == nil {
 		t.Fatal("expected error")
 	}
@@ -471,7 +472,7 @@ func TestNewAction_LinearPostError(t *testing.T) {
 	linear.err = errors.New("linear 500")
 
 	action := NewAction(cfg)
-	err := action(issue, d)
+	err := action(context.Background(), issue, d)
 	if err == nil {
 		t.Fatal("expected error")
 	}
@@ -489,7 +490,7 @@ func TestNewAction_PRDReadError(t *testing.T) {
 	prdReader.err = errors.New("prd not found")
 
 	action := NewAction(cfg)
-	err := action(issue, d)
+	err := action(context.Background(), issue, d)
 	if err == nil {
 		t.Fatal("expected error")
 	}
@@ -507,7 +508,7 @@ func TestNewAction_ConfigLoadError(t *testing.T) {
 	cfgLoader.err = errors.New("config not found")
 
 	action := NewAction(cfg)
-	err := action(issue, d)
+	err := action(context.Background(), issue, d)
 	if err == nil {
 		t.Fatal("expected error")
 	}
@@ -525,7 +526,7 @@ func TestNewAction_DiffStatsErrorFallback(t *testing.T) {
 	diff.err = errors.New("no upstream")
 
 	action := NewAction(cfg)
-	err := action(issue, d)
+	err := action(context.Background(), issue, d)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -543,7 +544,7 @@ func TestNewAction_ReadsPRDFromCorrectPath(t *testing.T) {
 	cfg.Projects = d
 
 	action := NewAction(cfg)
-	err := action(issue, d)
+	err := action(context.Background(), issue, d)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -563,7 +564,7 @@ func TestNewAction_UsesDefaultBaseFromConfig(t *testing.T) {
 	cfgLoader.base = "develop"
 
 	action := NewAction(cfg)
-	err := action(issue, d)
+	err := action(context.Background(), issue, d)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -576,38 +577,6 @@ func TestNewAction_UsesDefaultBaseFromConfig(t *testing.T) {
 	}
 }
 
-func TestParsePROutput_TitleAndBody(t *testing.T) {
-	input := "feat(auth): add login\n\n## Summary\n- Added login flow"
-	title, body := parsePROutput(input)
-	if title != "feat(auth): add login" {
-		t.Errorf("expected title %q, got %q", "feat(auth): add login", title)
-	}
-	if !strings.Contains(body, "## Summary") {
-		t.Errorf("expected body to contain ## Summary, got: %s", body)
-	}
-}
-
-func TestParsePROutput_TitleOnly(t *testing.T) {
-	title, body := parsePROutput("feat: quick fix")
-	if title != "feat: quick fix" {
-		t.Errorf("expected title %q, got %q", "feat: quick fix", title)
-	}
-	if body != "" {
-		t.Errorf("expected empty body, got %q", body)
-	}
-}
-
-func TestParsePROutput_TrimsWhitespace(t *testing.T) {
-	input := "\n  feat: something  \n\n  body text  \n"
-	title, body := parsePROutput(input)
-	if title != "feat: something" {
-		t.Errorf("expected title %q, got %q", "feat: something", title)
-	}
-	if body != "body text" {
-		t.Errorf("expected body %q, got %q", "body text", body)
-	}
-}
-
 func TestNewAction_ProjectNotFound(t *testing.T) {
 	d := testDB(t)
 	_ = createTestProject(t, d)
@@ -620,7 +589,7 @@ func TestNewAction_ProjectNotFound(t *testing.T) {
 	}
 
 	action := NewAction(cfg)
-	err := action(issue, d)
+	err := action(context.Background(), issue, d)
 	if err == nil {
 		t.Fatal("expected error")
 	}
@@ -640,7 +609,7 @@ func TestNewAction_IdempotentPR_ExistingPRFound(t *testing.T) {
 	gh.findPR = &PRResult{Number: 99, HTMLURL: "https://github.com/owner/repo/pull/99"}
 
 	action := NewAction(cfg)
-	err := action(issue, d)
+	err := action(context.Background(), issue, d)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -677,7 +646,7 @@ func TestNewAction_MergeConflict_ReturnConflictError(t *testing.T) {
 	}
 
 	action := NewAction(cfg)
-	err := action(issue, d)
+	err := action(context.Background(), issue, d)
 	if err == nil {
 		t.Fatal("expected error")
 	}
@@ -691,6 +660,31 @@ func TestNewAction_MergeConflict_ReturnConflictError(t *testing.T) {
 	}
 }
 
+func TestNewAction_SecretsFound_DoesNotRebase(t *testing.T) {
+	d := testDB(t)
+	project := createTestProject(t, d)
+	issue := createTestIssue(t, d, project)
+	cfg, _, git, _, _, _, _, _ := defaultConfig()
+	cfg.Projects = d
+	git.err = fmt.Errorf("pushing: %w", &SecretsError{Findings: []secrets.Finding{
+		{Path: ".env", Line: 1, Rule: "AWS access key", Match: "AKIA" + "IOSFODNN7EXAMPLE"},
+	}})
+	cfg.Rebase = &mockRebaser{fetchErr: errors.New("rebase must not be attempted")}
+
+	err := NewAction(cfg)(context.Background(), issue, d)
+
+	var secretsErr *SecretsError
+	if !errors.As(err, &secretsErr) {
+		t.Fatalf("expected SecretsError, got: %T: %v", err, err)
+	}
+	if !strings.Contains(err.Error(), ".env:1: AWS access key (AKIA…, 20 chars)") {
+		t.Errorf("error = %q, want the redacted finding", err)
+	}
+	if len(git.calls) != 1 {
+		t.Errorf("push calls = %d, want 1 with no retry", len(git.calls))
+	}
+}
+
 func TestNewAction_PushFailsRebaseSucceeds(t *testing.T) {
 	d := testDB(t)
 	project := createTestProject(t, d)
@@ -717,7 +711,7 @@ func TestNewAction_PushFailsRebaseSucceeds(t *testing.T) {
 	}
 
 	action := NewAction(cfg)
-	err := action(issue, d)
+	err := action(context.Background(), issue, d)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -735,7 +729,7 @@ func TestNewAction_PassesMaxTurns(t *testing.T) {
 	cfg.Projects = d
 
 	action := NewAction(cfg)
-	if err := action(issue, d); err != nil {
+	if err := action(context.Background(), issue, d); err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
 
@@ -753,88 +747,6 @@ func (m *trackingPusher) PushBranch(_ context.Context, workDir, branch string) e
 	return m.pushFunc()
 }
 
-// --- capDiffStats tests ---
-
-func TestCapDiffStats_UnderLimit(t *testing.T) {
-	var lines []string
-	for i := 0; i < 20; i++ {
-		lines = append(lines, fmt.Sprintf(" file%d.go | %d +++", i, i+1))
-	}
-	lines = append(lines, " 20 files changed, 100 insertions(+), 50 deletions(-)")
-	stats := strings.Join(lines, "\n")
-
-	result := capDiffStats(stats, 50)
-	if result != stats {
-		t.Error("expected diff stats with 20 entries to be returned unchanged")
-	}
-}
-
-func TestCapDiffStats_ExactlyAtLimit(t *testing.T) {
-	var lines []string
-	for i := 0; i < 50; i++ {
-		lines = append(lines, fmt.Sprintf(" file%d.go | %d +++", i, i+1))
-	}
-	lines = append(lines, " 50 files changed, 300 insertions(+), 100 deletions(-)")
-	stats := strings.Join(lines, "\n")
-
-	result := capDiffStats(stats, 50)
-	if result != stats {
-		t.Error("expected diff stats with exactly 50 entries to be returned unchanged")
-	}
-}
-
-func TestCapDiffStats_OverLimit_CapsAndKeepsSummary(t *testing.T) {
-	var lines []string
-	for i := 0; i < 80; i++ {
-		lines = append(lines, fmt.Sprintf(" file%d.go | %d +++", i, i+1))
-	}
-	summaryLine := " 80 files changed, 500 insertions(+), 200 deletions(-)"
-	lines = append(lines, summaryLine)
-	stats := strings.Join(lines, "\n")
-
-	result := capDiffStats(stats, 50)
-	resultLines := strings.Split(result, "\n")
-
-	// First 50 file entries preserved
-	for i := 0; i < 50; i++ {
-		expected := fmt.Sprintf(" file%d.go | %d +++", i, i+1)
-		if resultLines[i] != expected {
-			t.Errorf("line %d: expected %q, got %q", i, expected, resultLines[i])
-		}
-	}
-
-	// Truncation marker
-	if !strings.Contains(resultLines[50], "... 30 file entries omitted ...") {
-		t.Errorf("expected truncation marker, got %q", resultLines[50])
-	}
-
-	// Summary line preserved at the end
-	lastLine := resultLines[len(resultLines)-1]
-	if lastLine != summaryLine {
-		t.Errorf("expected summary line %q, got %q", summaryLine, lastLine)
-	}
-
-	// Total: 50 entries + 1 marker + 1 summary = 52
-	if len(resultLines) != 52 {
-		t.Errorf("expected 52 lines, got %d", len(resultLines))
-	}
-}
-
-func TestCapDiffStats_EmptyInput(t *testing.T) {
-	result := capDiffStats("", 50)
-	if result != "" {
-		t.Errorf("expected empty result, got %q", result)
-	}
-}
-
-func TestCapDiffStats_SummaryOnly(t *testing.T) {
-	stats := " 1 file changed, 5 insertions(+)"
-	result := capDiffStats(stats, 50)
-	if result != stats {
-		t.Error("expected single-line stats unchanged")
-	}
-}
-
 func TestNewAction_CapsDiffStats(t *testing.T) {
 	d := testDB(t)
 	project := createTestProject(t, d)
@@ -851,7 +763,7 @@ func TestNewAction_CapsDiffStats(t *testing.T) {
 	diff.stats = strings.Join(lines, "\n")
 
 	action := NewAction(cfg)
-	err := action(issue, d)
+	err := action(context.Background(), issue, d)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -876,3 +788,53 @@ func TestNewAction_CapsDiffStats(t *testing.T) {
 	}
 }
 
+type mockRunReporter struct {
+	summary string
+	err     error
+	calls   []string
+}
+
+func (m *mockRunReporter) RunSummary(_ context.Context, projectLocalPath, workspaceName, base string) (string, error) {
+	m.calls = append(m.calls, workspaceName+"@"+base)
+	return m.summary, m.err
+}
+
+func TestNewAction_AppendsRunReport(t *testing.T) {
+	d := testDB(t)
+	project := createTestProject(t, d)
+	issue := createTestIssue(t, d, project)
+	cfg, _, _, _, _, gh, _, _ := defaultConfig()
+	cfg.Projects = d
+	reporter := &mockRunReporter{summary: "<details>run report</details>"}
+	cfg.Report = reporter
+
+	if err := NewAction(cfg)(context.Background(), issue, d); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(reporter.calls) != 1 || reporter.calls[0] != "proj-42@origin/main" {
+		t.Errorf("reporter calls = %v", reporter.calls)
+	}
+	if len(gh.calls) != 1 {
+		t.Fatalf("expected 1 create PR call, got %d", len(gh.calls))
+	}
+	if !strings.HasSuffix(gh.calls[0].body, "\n\n<details>run report</details>") {
+		t.Errorf("expected the run report at the end of the body, got %q", gh.calls[0].body)
+	}
+}
+
+func TestNewAction_RunReportErrorStillCreatesPR(t *testing.T) {
+	d := testDB(t)
+	project := createTestProject(t, d)
+	issue := createTestIssue(t, d, project)
+	cfg, _, _, _, _, gh, _, _ := defaultConfig()
+	cfg.Projects = d
+	cfg.Report = &mockRunReporter{err: errors.New("no PRD")}
+
+	if err := NewAction(cfg)(context.Background(), issue, d); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(gh.calls) != 1 || strings.Contains(gh.calls[0].body, "run report") {
+		t.Errorf("expected a PR without a report, got %+v", gh.calls)
+	}
+}
@@ -100,9 +100,8 @@ func NeedsRebase(cfg Config) func(issue db.Issue) bool {
 // NewAction returns an ActionFunc that rebases the issue's branch onto the
 // latest base branch and force pushes. It invokes ralph rebase as a subprocess
 // for AI-powered conflict resolution.
-func NewAction(cfg Config) func(issue db.Issue, database *db.DB) error {
-	return func(issue db.Issue, database *db.DB) error {
-		ctx := context.Background()
+func NewAction(cfg Config) func(ctx context.Context, issue db.Issue, database *db.DB) error {
+	return func(ctx context.Context, issue db.Issue, database *db.DB) error {
 
 		project, err := cfg.Projects.GetProject(issue.ProjectID)
 		if err != nil {
@@ -330,7 +330,7 @@ func TestNewAction_Success_RebasesAndForcePushes(t *testing.T) {
 	}
 
 	action := NewAction(cfg)
-	err := action(issue, d)
+	err := action(context.Background(), issue, d)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -378,7 +378,7 @@ func TestNewAction_LogsRebaseStartAndFinish(t *testing.T) {
 	}
 
 	action := NewAction(cfg)
-	err := action(issue, d)
+	err := action(context.Background(), issue, d)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -430,7 +430,7 @@ func TestNewAction_RebaseError_ReturnsError(t *testing.T) {
 	}
 
 	action := NewAction(cfg)
-	err := action(issue, d)
+	err := action(context.Background(), issue, d)
 	if err == nil {
 		t.Fatal("expected error when rebase fails")
 	}
@@ -474,7 +474,7 @@ func TestNewAction_ForcePushError_ReturnsError(t *testing.T) {
 	}
 
 	action := NewAction(cfg)
-	err := action(issue, d)
+	err := action(context.Background(), issue, d)
 	if err == nil {
 		t.Fatal("expected error when force push fails")
 	}
@@ -493,7 +493,7 @@ func TestNewAction_ProjectNotFound_ReturnsError(t *testing.T) {
 	cfg := Config{Projects: projects}
 
 	action := NewAction(cfg)
-	err := action(issue, d)
+	err := action(context.Background(), issue, d)
 	if err == nil {
 		t.Fatal("expected error when project not found")
 	}
@@ -516,7 +516,7 @@ func TestNewAction_DefaultBaseError_ReturnsError(t *testing.T) {
 	}
 
 	action := NewAction(cfg)
-	err := action(issue, d)
+	err := action(context.Background(), issue, d)
 	if err == nil {
 		t.Fatal("expected error when default base resolution fails")
 	}
@@ -547,7 +547,7 @@ func TestNewAction_PullsBranchBeforeRebase(t *testing.T) {
 	}
 
 	action := NewAction(cfg)
-	err := action(issue, d)
+	err := action(context.Background(), issue, d)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -584,7 +584,7 @@ func TestNewAction_PullError_ReturnsErrorWithoutRebase(t *testing.T) {
 	}
 
 	action := NewAction(cfg)
-	err := action(issue, d)
+	err := action(context.Background(), issue, d)
 	if err == nil {
 		t.Fatal("expected error when pull fails")
 	}
@@ -626,7 +626,7 @@ func TestNewAction_PullBeforeRebase_Ordering(t *testing.T) {
 	}
 
 	action := NewAction(cfg)
-	err := action(issue, d)
+	err := action(context.Background(), issue, d)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
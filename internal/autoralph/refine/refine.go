@@ -43,15 +43,15 @@ type Config struct {
 // NewAction returns an orchestrator ActionFunc that performs AI issue refinement.
 // It renders the refine_issue.md prompt with the issue's title and description,
 // invokes the AI, posts the response as a Linear comment, and logs the activity.
-func NewAction(cfg Config) func(issue db.Issue, database *db.DB) error {
-	return func(issue db.Issue, database *db.DB) error {
+func NewAction(cfg Config) func(ctx context.Context, issue db.Issue, database *db.DB) error {
+	return func(ctx context.Context, issue db.Issue, database *db.DB) error {
 		project, err := cfg.Projects.GetProject(issue.ProjectID)
 		if err != nil {
 			return fmt.Errorf("loading project: %w", err)
 		}
 
 		if cfg.GitPuller != nil {
-			if pullErr := cfg.GitPuller.PullDefaultBase(context.Background(), project.LocalPath, project.RalphConfigPath); pullErr != nil {
+			if pullErr := cfg.GitPuller.PullDefaultBase(ctx, project.LocalPath, project.RalphConfigPath); pullErr != nil {
 				_ = database.LogActivity(issue.ID, "warning", "", "", fmt.Sprintf("git pull --ff-only failed: %v", pullErr))
 			}
 		}
@@ -68,7 +68,7 @@ func NewAction(cfg Config) func(issue db.Issue, database *db.DB) error {
 		}
 
 		handler := eventlog.New(database, issue.ID, nil, cfg.OnBuildEvent)
-		response, err := cfg.Invoker.InvokeWithEvents(context.Background(), prompt, project.LocalPath, maxTurnsRefine, handler)
+		response, err := cfg.Invoker.InvokeWithEvents(ctx, prompt, project.LocalPath, maxTurnsRefine, handler)
 		if err != nil {
 			return fmt.Errorf("invoking AI: %w", err)
 		}
@@ -79,7 +79,7 @@ func NewAction(cfg Config) func(issue db.Issue, database *db.DB) error {
 			body += approve.ApprovalHint
 		}
 
-		commentID, err := cfg.Poster.PostComment(context.Background(), issue.LinearIssueID, body)
+		commentID, err := cfg.Poster.PostComment(ctx, issue.LinearIssueID, body)
 		if err != nil {
 			return fmt.Errorf("posting comment: %w", err)
 		}
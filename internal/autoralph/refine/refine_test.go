@@ -110,7 +110,7 @@ func TestRefineAction_InvokesAIWithPrompt(t *testing.T) {
 		Projects: d,
 	})
 
-	err := action(issue, d)
+	err := action(context.Background(), issue, d)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -167,7 +167,7 @@ func TestRefineAction_PostsCommentOnLinear(t *testing.T) {
 				Projects: d,
 			})
 
-			err := action(issue, d)
+			err := action(context.Background(), issue, d)
 			if err != nil {
 				t.Fatalf("unexpected error: %v", err)
 			}
@@ -216,7 +216,7 @@ func TestRefineAction_LogsActivity(t *testing.T) {
 		Projects: d,
 	})
 
-	err := action(issue, d)
+	err := action(context.Background(), issue, d)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -250,7 +250,7 @@ func TestRefineAction_AIError_ReturnsError(t *testing.T) {
 		Projects: d,
 	})
 
-	err := action(issue, d)
+	err := action(context.Background(), issue, d)
 	if err == nil {
 		t.Fatal("expected error when AI invocation fails")
 	}
@@ -275,7 +275,7 @@ func TestRefineAction_PostCommentError_ReturnsError(t *testing.T) {
 		Projects: d,
 	})
 
-	err := action(issue, d)
+	err := action(context.Background(), issue, d)
 	if err == nil {
 		t.Fatal("expected error when posting comment fails")
 	}
@@ -298,7 +298,7 @@ func TestRefineAction_WithOverrideDir(t *testing.T) {
 		OverrideDir: "/nonexistent/path", // falls back to embedded
 	})
 
-	err := action(issue, d)
+	err := action(context.Background(), issue, d)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -338,7 +338,7 @@ func TestRefineAction_PullsBeforeInvokingAI(t *testing.T) {
 		GitPuller: puller,
 	})
 
-	err = action(issue, d)
+	err = action(context.Background(), issue, d)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -386,7 +386,7 @@ func TestRefineAction_PullFails_LogsWarningAndProceeds(t *testing.T) {
 		GitPuller: puller,
 	})
 
-	err = action(issue, d)
+	err = action(context.Background(), issue, d)
 	if err != nil {
 		t.Fatalf("expected no error when pull fails, got: %v", err)
 	}
@@ -425,7 +425,7 @@ func TestRefineAction_NilGitPuller_SkipsPull(t *testing.T) {
 		// GitPuller is nil — should be skipped
 	})
 
-	err := action(issue, d)
+	err := action(context.Background(), issue, d)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -462,7 +462,7 @@ func TestRefineAction_EmptyDescription_StillWorks(t *testing.T) {
 		Projects: d,
 	})
 
-	err = action(issue, d)
+	err = action(context.Background(), issue, d)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -485,7 +485,7 @@ func TestRefineAction_IncludesKnowledgePath(t *testing.T) {
 		Projects: d,
 	})
 
-	err := action(issue, d)
+	err := action(context.Background(), issue, d)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -509,7 +509,7 @@ func TestRefineAction_UsesInvokeWithEvents(t *testing.T) {
 		Projects: d,
 	})
 
-	err := action(issue, d)
+	err := action(context.Background(), issue, d)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -538,7 +538,7 @@ func TestRefineAction_EventsStreamedToDB(t *testing.T) {
 		Projects: d,
 	})
 
-	err := action(issue, d)
+	err := action(context.Background(), issue, d)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -583,7 +583,7 @@ func TestRefineAction_OnBuildEventCallback(t *testing.T) {
 		OnBuildEvent: onBuildEvent,
 	})
 
-	err := action(issue, d)
+	err := action(context.Background(), issue, d)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -612,7 +612,7 @@ func TestRefineAction_PassesMaxTurns(t *testing.T) {
 		Projects: d,
 	})
 
-	if err := action(issue, d); err != nil {
+	if err := action(context.Background(), issue, d); err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
 
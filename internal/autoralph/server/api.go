@@ -424,7 +424,7 @@ func (h *apiHandler) handlePauseIssue(w http.ResponseWriter, r *http.Request) {
 		writeError(w, http.StatusInternalServerError, "failed to update issue")
 		return
 	}
-	h.db.LogActivity(issue.ID, "state_change", previousState, "paused", "Issue paused via API")
+	h.db.RecordTransition(issue.ID, "state_change", previousState, "paused", "Issue paused via API")
 
 	// Cancel any running worker/action for this issue so the agent stops.
 	if h.buildChecker != nil {
@@ -474,7 +474,7 @@ func (h *apiHandler) handleResumeIssue(w http.ResponseWriter, r *http.Request) {
 		writeError(w, http.StatusInternalServerError, "failed to update issue")
 		return
 	}
-	h.db.LogActivity(issue.ID, "state_change", "paused", resumeState, "Issue resumed via API")
+	h.db.RecordTransition(issue.ID, "state_change", "paused", resumeState, "Issue resumed via API")
 	h.notifyWake()
 
 	writeJSON(w, http.StatusOK, map[string]string{"status": "resumed", "state": resumeState})
@@ -521,7 +521,7 @@ func (h *apiHandler) handleRetryIssue(w http.ResponseWriter, r *http.Request) {
 		writeError(w, http.StatusInternalServerError, "failed to update issue")
 		return
 	}
-	h.db.LogActivity(issue.ID, "state_change", "failed", retryState, "Issue retried via API")
+	h.db.RecordTransition(issue.ID, "state_change", "failed", retryState, "Issue retried via API")
 	h.notifyWake()
 
 	writeJSON(w, http.StatusOK, map[string]string{"status": "retrying", "state": retryState})
@@ -763,7 +763,7 @@ func (h *apiHandler) handleTransitionIssue(w http.ResponseWriter, r *http.Reques
 		writeError(w, http.StatusInternalServerError, "failed to update issue")
 		return
 	}
-	h.db.LogActivity(issue.ID, "state_change", previousState, body.TargetState, "Manual transition via API")
+	h.db.RecordTransition(issue.ID, "state_change", previousState, body.TargetState, "Manual transition via API")
 	h.notifyWake()
 
 	writeJSON(w, http.StatusOK, map[string]string{"status": "transitioned", "from_state": previousState, "to_state": body.TargetState})
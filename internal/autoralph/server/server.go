@@ -64,6 +64,8 @@ type Config struct {
 	LinearURL string
 	// GithubURL overrides the GitHub API endpoint (for mock servers in E2E tests).
 	GithubURL string
+	// Metrics serves GET /metrics in the Prometheus text format. Optional.
+	Metrics http.Handler
 }
 
 // Server wraps the autoralph HTTP server.
@@ -128,6 +130,10 @@ func (s *Server) registerRoutes(cfg Config) {
 		s.mux.HandleFunc("GET /api/ws", cfg.Hub.ServeWS)
 	}
 
+	if cfg.Metrics != nil {
+		s.mux.Handle("GET /metrics", cfg.Metrics)
+	}
+
 	// Catch-all for unregistered /api/ routes — return 404.
 	s.mux.HandleFunc("/api/", func(w http.ResponseWriter, r *http.Request) {
 		http.NotFound(w, r)
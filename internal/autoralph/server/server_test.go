@@ -180,3 +180,22 @@ func TestServer_DevMode_APINotProxied(t *testing.T) {
 		t.Fatalf("expected status 'ok', got %q", body["status"])
 	}
 }
+
+func TestServer_MetricsEndpoint_ServesHandler(t *testing.T) {
+	srv := newTestServer(t, server.Config{
+		Metrics: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			io.WriteString(w, "autoralph_workers_active 1\n")
+		}),
+	})
+
+	resp, err := http.Get("http://" + srv.Addr() + "/metrics")
+	if err != nil {
+		t.Fatalf("GET /metrics failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK || !strings.Contains(string(body), "autoralph_workers_active 1") {
+		t.Fatalf("GET /metrics = %d %q", resp.StatusCode, body)
+	}
+}
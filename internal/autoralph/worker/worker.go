@@ -6,15 +6,18 @@ import (
 	"fmt"
 	"log/slog"
 	"sync"
+	"time"
 
 	"github.com/uesteibar/ralph/internal/autoralph/db"
 	"github.com/uesteibar/ralph/internal/autoralph/eventlog"
+	"github.com/uesteibar/ralph/internal/autoralph/metrics"
 	"github.com/uesteibar/ralph/internal/autoralph/pr"
 	"github.com/uesteibar/ralph/internal/events"
 	"github.com/uesteibar/ralph/internal/gitops"
 	"github.com/uesteibar/ralph/internal/knowledge"
 	"github.com/uesteibar/ralph/internal/runstate"
 	"github.com/uesteibar/ralph/internal/shell"
+	"github.com/uesteibar/ralph/internal/tracing"
 	"github.com/uesteibar/ralph/internal/workspace"
 )
 
@@ -31,6 +34,8 @@ type LoopConfig struct {
 	KnowledgePath string
 	Verbose       bool
 	EventHandler  events.EventHandler
+	// Workspace is the workspace name passed to lifecycle hooks.
+	Workspace string
 }
 
 // LoopRunner abstracts the Ralph build loop. The real implementation wraps
@@ -46,7 +51,7 @@ type ProjectGetter interface {
 
 // PRCreator creates a GitHub PR for a completed build.
 type PRCreator interface {
-	CreatePR(issue db.Issue, database *db.DB) error
+	CreatePR(ctx context.Context, issue db.Issue, database *db.DB) error
 }
 
 // Config holds the dependencies for the build worker dispatcher.
@@ -179,6 +184,11 @@ func (d *Dispatcher) ActiveCount() int {
 	return len(d.active)
 }
 
+// MaxWorkers returns how many workers may run at once.
+func (d *Dispatcher) MaxWorkers() int {
+	return d.maxWorkers
+}
+
 // DispatchAction starts a goroutine that runs an arbitrary action function for
 // the given issue. It reuses the same semaphore and per-issue tracking as
 // Dispatch to prevent concurrent actions on the same issue. On failure,
@@ -235,6 +245,16 @@ func (d *Dispatcher) runAction(ctx context.Context, cancel context.CancelFunc, i
 		return
 	}
 
+	// Secrets found before a push pause the issue, as they do for the PR.
+	var secretsErr *pr.SecretsError
+	if errors.As(actionErr, &secretsErr) {
+		if current, err := d.db.GetIssue(issue.ID); err == nil {
+			issue = current
+		}
+		d.handleSecrets(issue, secretsErr)
+		return
+	}
+
 	d.handleActionFailure(issue, actionErr)
 }
 
@@ -258,7 +278,7 @@ func (d *Dispatcher) handleActionFailure(issue db.Issue, actionErr error) {
 		d.logger.Error("updating issue to failed after action", "issue", issue.ID, "error", err)
 		return
 	}
-	if err := d.db.LogActivity(issue.ID, "action_failed", fromState, "failed", actionErr.Error()); err != nil {
+	if err := d.db.RecordTransition(issue.ID, "action_failed", fromState, "failed", actionErr.Error()); err != nil {
 		d.logger.Error("logging action_failed activity", "issue", issue.ID, "error", err)
 	}
 }
@@ -339,9 +359,18 @@ func (d *Dispatcher) run(ctx context.Context, cancel context.CancelFunc, issue d
 		QualityChecks: nil, // loaded by loop from Ralph config
 		KnowledgePath: knowledge.Dir(workDir),
 		EventHandler:  handler,
+		Workspace:     issue.WorkspaceName,
 	}
 
-	runErr := d.runner.Run(ctx, loopCfg)
+	buildCtx, span := tracing.Start(ctx, "worker.build",
+		tracing.AttrIssueID.String(issue.ID),
+		tracing.AttrIssueIdentifier.String(issue.Identifier),
+		tracing.AttrWorkspace.String(issue.WorkspaceName),
+	)
+	start := time.Now()
+	runErr := d.runner.Run(buildCtx, loopCfg)
+	tracing.End(span, runErr)
+	elapsed := time.Since(start)
 
 	// Write status file for ralph tui compatibility.
 	switch {
@@ -353,23 +382,36 @@ func (d *Dispatcher) run(ctx context.Context, cancel context.CancelFunc, issue d
 		runstate.WriteStatus(wsPath, runstate.Status{Result: runstate.ResultFailed, Error: runErr.Error()})
 	}
 
-	if runErr == nil {
-		d.handleSuccess(issue)
-		return
-	}
-
-	// Context cancellation: clean exit, issue stays in BUILDING
-	if errors.Is(runErr, context.Canceled) || errors.Is(runErr, context.DeadlineExceeded) {
+	switch {
+	case runErr == nil:
+		d.handleSuccess(ctx, issue)
+	case errors.Is(runErr, context.Canceled) || errors.Is(runErr, context.DeadlineExceeded):
+		// Context cancellation: clean exit, issue stays in BUILDING
 		d.logger.Info("build cancelled", "issue", issue.ID)
-		return
+	default:
+		d.handleFailure(issue, runErr)
 	}
+	d.recordBuild(issue.ID, elapsed)
+}
 
-	d.handleFailure(issue, runErr)
+// recordBuild times a finished build in the action metrics, labelled with
+// the state the issue actually reached. A build that leaves the issue
+// anywhere but in review (or still building, when cancelled) is counted as
+// failed.
+func (d *Dispatcher) recordBuild(issueID string, elapsed time.Duration) {
+	to := "building"
+	if current, err := d.db.GetIssue(issueID); err == nil {
+		to = current.State
+	}
+	metrics.ActionDuration.WithLabelValues("building", to).Observe(elapsed.Seconds())
+	if to != "in_review" && to != "building" {
+		metrics.ActionFailures.WithLabelValues("building", to).Inc()
+	}
 }
 
-func (d *Dispatcher) handleSuccess(issue db.Issue) {
+func (d *Dispatcher) handleSuccess(ctx context.Context, issue db.Issue) {
 	if d.pr != nil {
-		if err := d.pr.CreatePR(issue, d.db); err != nil {
+		if err := d.pr.CreatePR(ctx, issue, d.db); err != nil {
 			d.logger.Error("creating PR", "issue", issue.ID, "error", err)
 			// Re-read issue from DB since CreatePR may have partially updated it.
 			fresh, readErr := d.db.GetIssue(issue.ID)
@@ -382,6 +424,11 @@ func (d *Dispatcher) handleSuccess(issue db.Issue) {
 				d.handleConflict(fresh, conflictErr)
 				return
 			}
+			var secretsErr *pr.SecretsError
+			if errors.As(err, &secretsErr) {
+				d.handleSecrets(fresh, secretsErr)
+				return
+			}
 			d.handleFailure(fresh, fmt.Errorf("creating PR: %w", err))
 			return
 		}
@@ -399,7 +446,7 @@ func (d *Dispatcher) handleSuccess(issue db.Issue) {
 		d.logger.Error("updating issue to in_review", "issue", issue.ID, "error", err)
 		return
 	}
-	if err := d.db.LogActivity(issue.ID, "build_completed", "building", "in_review", "Build completed successfully"); err != nil {
+	if err := d.db.RecordTransition(issue.ID, "build_completed", "building", "in_review", "Build completed successfully"); err != nil {
 		d.logger.Error("logging build_completed activity", "issue", issue.ID, "error", err)
 	}
 }
@@ -411,11 +458,26 @@ func (d *Dispatcher) handleConflict(issue db.Issue, conflictErr *pr.ConflictErro
 		d.logger.Error("updating issue to paused", "issue", issue.ID, "error", err)
 		return
 	}
-	if err := d.db.LogActivity(issue.ID, "merge_conflict", "building", "paused", conflictErr.Error()); err != nil {
+	if err := d.db.RecordTransition(issue.ID, "merge_conflict", "building", "paused", conflictErr.Error()); err != nil {
 		d.logger.Error("logging merge_conflict activity", "issue", issue.ID, "error", err)
 	}
 }
 
+// handleSecrets pauses an issue whose branch was not pushed because it looks
+// like it contains secrets; a human has to clean up the history.
+func (d *Dispatcher) handleSecrets(issue db.Issue, secretsErr *pr.SecretsError) {
+	from := issue.State
+	issue.State = "paused"
+	issue.ErrorMessage = secretsErr.Error()
+	if err := d.db.UpdateIssue(issue); err != nil {
+		d.logger.Error("updating issue to paused", "issue", issue.ID, "error", err)
+		return
+	}
+	if err := d.db.RecordTransition(issue.ID, "secrets_found", from, "paused", secretsErr.Error()); err != nil {
+		d.logger.Error("logging secrets_found activity", "issue", issue.ID, "error", err)
+	}
+}
+
 func (d *Dispatcher) handleFailure(issue db.Issue, buildErr error) {
 	// Re-read to avoid overwriting a concurrent completed/paused transition.
 	current, err := d.db.GetIssue(issue.ID)
@@ -435,7 +497,7 @@ func (d *Dispatcher) handleFailure(issue db.Issue, buildErr error) {
 		d.logger.Error("updating issue to failed", "issue", issue.ID, "error", err)
 		return
 	}
-	if err := d.db.LogActivity(issue.ID, "build_failed", "building", "failed", buildErr.Error()); err != nil {
+	if err := d.db.RecordTransition(issue.ID, "build_failed", "building", "failed", buildErr.Error()); err != nil {
 		d.logger.Error("logging build_failed activity", "issue", issue.ID, "error", err)
 	}
 }
@@ -2,6 +2,7 @@ package worker
 
 import (
 	"context"
+	"fmt"
 	"errors"
 	"os"
 	"path/filepath"
@@ -10,10 +11,14 @@ import (
 	"testing"
 	"time"
 
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
 	"github.com/uesteibar/ralph/internal/autoralph/db"
 	"github.com/uesteibar/ralph/internal/autoralph/eventlog"
+	"github.com/uesteibar/ralph/internal/autoralph/metrics"
 	"github.com/uesteibar/ralph/internal/autoralph/pr"
 	"github.com/uesteibar/ralph/internal/events"
+	"github.com/uesteibar/ralph/internal/secrets"
 	"github.com/uesteibar/ralph/internal/shell"
 )
 
@@ -694,7 +699,7 @@ type mockPRCreator struct {
 	err error
 }
 
-func (m *mockPRCreator) CreatePR(issue db.Issue, database *db.DB) error {
+func (m *mockPRCreator) CreatePR(_ context.Context, issue db.Issue, database *db.DB) error {
 	return m.err
 }
 
@@ -712,6 +717,8 @@ func TestDispatcher_Dispatch_ConflictError_TransitionsToPaused(t *testing.T) {
 		Projects:   d,
 		PR:         prCreator,
 	})
+	failures := metrics.ActionFailures.WithLabelValues("building", "paused")
+	before := testutil.ToFloat64(failures)
 
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
@@ -728,6 +735,9 @@ func TestDispatcher_Dispatch_ConflictError_TransitionsToPaused(t *testing.T) {
 	if updated.State != "paused" {
 		t.Errorf("expected state %q, got %q", "paused", updated.State)
 	}
+	if got := testutil.ToFloat64(failures) - before; got != 1 {
+		t.Errorf("expected the build counted as failed into paused, got %v", got)
+	}
 	if !strings.Contains(updated.ErrorMessage, "merge conflicts") {
 		t.Errorf("expected error message to contain 'merge conflicts', got %q", updated.ErrorMessage)
 	}
@@ -747,6 +757,50 @@ func TestDispatcher_Dispatch_ConflictError_TransitionsToPaused(t *testing.T) {
 	}
 }
 
+func TestDispatcher_Dispatch_SecretsError_TransitionsToPaused(t *testing.T) {
+	d := testDB(t)
+	project := createTestProject(t, d)
+	issue := createTestIssue(t, d, project, "building")
+
+	prCreator := &mockPRCreator{err: &pr.SecretsError{Findings: []secrets.Finding{
+		{Path: ".env", Line: 1, Rule: "AWS access key", Match: "AKIA" + "IOSFODNN7EXAMPLE"},
+	}}}
+	disp := New(Config{
+		DB:         d,
+		MaxWorkers: 1,
+		LoopRunner: &mockLoopRunner{},
+		Projects:   d,
+		PR:         prCreator,
+	})
+
+	if err := disp.Dispatch(context.Background(), issue); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	disp.Wait()
+
+	updated, err := d.GetIssue(issue.ID)
+	if err != nil {
+		t.Fatalf("getting issue: %v", err)
+	}
+	if updated.State != "paused" || !strings.Contains(updated.ErrorMessage, "possible secrets") {
+		t.Errorf("issue = %q / %q, want paused with the findings", updated.State, updated.ErrorMessage)
+	}
+
+	entries, err := d.ListActivity(issue.ID, 10, 0)
+	if err != nil {
+		t.Fatalf("listing activity: %v", err)
+	}
+	found := false
+	for _, e := range entries {
+		if e.EventType == "secrets_found" {
+			found = true
+		}
+	}
+	if !found {
+		t.Error("expected secrets_found activity entry")
+	}
+}
+
 func TestDispatcher_Dispatch_PRFailure_TransitionsToFailed(t *testing.T) {
 	d := testDB(t)
 	project := createTestProject(t, d)
@@ -1233,6 +1287,35 @@ func TestDispatcher_DispatchAction_RunsActionAndCleansUp(t *testing.T) {
 	}
 }
 
+func TestDispatcher_DispatchAction_SecretsError_Pauses(t *testing.T) {
+	d := testDB(t)
+	project := createTestProject(t, d)
+	issue := createTestIssue(t, d, project, "addressing_feedback")
+
+	disp := New(Config{DB: d, MaxWorkers: 1, LoopRunner: &mockLoopRunner{}, Projects: d})
+	err := disp.DispatchAction(context.Background(), issue, func(ctx context.Context) error {
+		return fmt.Errorf("pushing changes: %w", &pr.SecretsError{Findings: []secrets.Finding{
+			{Path: ".env", Line: 1, Rule: "AWS access key", Match: "AKIA" + "IOSFODNN7EXAMPLE"},
+		}})
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	disp.Wait()
+
+	updated, err := d.GetIssue(issue.ID)
+	if err != nil {
+		t.Fatalf("getting issue: %v", err)
+	}
+	if updated.State != "paused" || !strings.Contains(updated.ErrorMessage, "possible secrets") {
+		t.Errorf("issue = %q / %q, want paused with the findings", updated.State, updated.ErrorMessage)
+	}
+	entries, _ := d.ListActivity(issue.ID, 10, 0)
+	if len(entries) == 0 || entries[0].EventType != "secrets_found" || entries[0].FromState != "addressing_feedback" {
+		t.Errorf("activity = %+v, want secrets_found from addressing_feedback", entries)
+	}
+}
+
 func TestDispatcher_DispatchAction_ReusesExistingSemaphore(t *testing.T) {
 	d := testDB(t)
 	project := createTestProject(t, d)
@@ -4,7 +4,6 @@ import (
 	"bufio"
 	"bytes"
 	"context"
-	"encoding/json"
 	"fmt"
 	"io"
 	"os"
@@ -15,8 +14,13 @@ import (
 	"strings"
 	"time"
 
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
 	"github.com/uesteibar/ralph/internal/events"
+	"github.com/uesteibar/ralph/internal/sandbox"
 	"github.com/uesteibar/ralph/internal/shell"
+	"github.com/uesteibar/ralph/internal/tracing"
 )
 
 const completeSignal = "<promise>COMPLETE</promise>"
@@ -48,16 +52,33 @@ type InvokeOpts struct {
 	// Use this to prevent write operations during read-only phases like refinement.
 	DisallowedTools []string
 
+	// AllowedTools turns on the allow list: only these tools and permission
+	// rules (e.g. "Bash(go test:*)") may be used, every other call is denied.
+	// Empty skips permission checks altogether.
+	AllowedTools []string
+
 	// EventHandler receives structured events during stream processing.
 	// If nil, events are silently discarded.
 	EventHandler events.EventHandler
+
+	// Sandbox confines the Claude process. Nil runs it directly on the host.
+	Sandbox *sandbox.Policy
 }
 
 // Invoke runs the Claude CLI with the given options.
 // In Print mode it streams progress and returns Claude's output.
 // In Interactive mode it blocks until the session ends and returns empty string.
-func Invoke(ctx context.Context, opts InvokeOpts) (string, error) {
+func Invoke(ctx context.Context, opts InvokeOpts) (output string, err error) {
+	ctx, span := tracing.Start(ctx, "claude.Invoke",
+		attribute.Bool("claude.interactive", opts.Interactive),
+		attribute.Int("claude.max_turns", opts.MaxTurns),
+	)
+	defer func() { tracing.End(span, err) }()
+
 	r := &shell.Runner{Dir: opts.Dir}
+	if opts.Sandbox != nil {
+		r.Sandbox = opts.Sandbox
+	}
 
 	if opts.Interactive {
 		args := buildArgs(opts)
@@ -70,49 +91,51 @@ func Invoke(ctx context.Context, opts InvokeOpts) (string, error) {
 
 // streamEvent represents a JSON event from Claude CLI stream-json output.
 type streamEvent struct {
-	Type       string `json:"type"`
-	Subtype    string `json:"subtype,omitempty"`
-	Result     string `json:"result,omitempty"`
-	DurationMS int    `json:"duration_ms,omitempty"`
-	NumTurns   int    `json:"num_turns,omitempty"`
-	Usage struct {
-		InputTokens  int `json:"input_tokens"`
-		OutputTokens int `json:"output_tokens"`
+	Type            string  `json:"type"`
+	Subtype         string  `json:"subtype,omitempty"`
+	Result          string  `json:"result,omitempty"`
+	DurationMS      int     `json:"duration_ms,omitempty"`
+	NumTurns        int     `json:"num_turns,omitempty"`
+	SessionID       string  `json:"session_id,omitempty"`
+	Model           string  `json:"model,omitempty"`
+	TotalCostUSD    float64 `json:"total_cost_usd,omitempty"`
+	ParentToolUseID string  `json:"parent_tool_use_id,omitempty"`
+	Usage           struct {
+		InputTokens              int `json:"input_tokens"`
+		OutputTokens             int `json:"output_tokens"`
+		CacheCreationInputTokens int `json:"cache_creation_input_tokens"`
+		CacheReadInputTokens     int `json:"cache_read_input_tokens"`
 	} `json:"usage"`
+	PermissionDenials []struct {
+		ToolName  string         `json:"tool_name"`
+		ToolInput map[string]any `json:"tool_input,omitempty"`
+	} `json:"permission_denials,omitempty"`
 	Message struct {
-		Content []struct {
-			Type  string         `json:"type"`
-			Text  string         `json:"text,omitempty"`
-			Name  string         `json:"name,omitempty"`
-			Input map[string]any `json:"input,omitempty"`
-		} `json:"content,omitempty"`
+		Content contentBlocks `json:"content,omitempty"`
 	} `json:"message,omitempty"`
 }
 
 // runWithStreamJSON runs Claude with --output-format stream-json and displays progress.
 func runWithStreamJSON(ctx context.Context, opts InvokeOpts) (string, error) {
-	args := []string{
-		"--dangerously-skip-permissions",
+	args := permissionArgs(opts)
+	args = append(args,
 		"--print",
 		"--output-format", "stream-json",
 		"--verbose",
-	}
+	)
 
 	if opts.MaxTurns > 0 {
 		args = append(args, "--max-turns", strconv.Itoa(opts.MaxTurns))
 	}
 
-	if len(opts.DisallowedTools) > 0 {
-		args = append(args, "--disallowedTools", strings.Join(opts.DisallowedTools, ","))
-	}
-
 	// Get absolute working dir for relative path calculation
 	workDir := opts.Dir
 	if workDir == "" {
 		workDir, _ = os.Getwd()
 	}
 
-	cmd := exec.CommandContext(ctx, "claude", args...)
+	name, args := opts.Sandbox.Wrap("claude", args)
+	cmd := exec.CommandContext(ctx, name, args...)
 	cmd.Dir = opts.Dir
 	cmd.Stdin = strings.NewReader(opts.Prompt)
 
@@ -131,62 +154,24 @@ func runWithStreamJSON(ctx context.Context, opts InvokeOpts) (string, error) {
 		return "", fmt.Errorf("starting claude: %w", err)
 	}
 
-	var result string
-	var assistantText strings.Builder
-	var nonJSONLines []string
+	p := newStreamParser(ctx, opts.EventHandler, workDir)
 	scanner := bufio.NewScanner(stdout)
 	// Increase buffer size for large JSON lines
 	buf := make([]byte, 0, 1024*1024)
 	scanner.Buffer(buf, 10*1024*1024)
-
-	var numTurns int
-	var durationMS int
-	var inputTokens int
-	var outputTokens int
-
 	for scanner.Scan() {
-		line := scanner.Text()
-		var ev streamEvent
-		if err := json.Unmarshal([]byte(line), &ev); err != nil {
-			// Capture non-JSON lines — Claude CLI may write error
-			// messages as plain text (e.g. usage limit warnings).
-			if trimmed := strings.TrimSpace(line); trimmed != "" {
-				nonJSONLines = append(nonJSONLines, trimmed)
-			}
-			continue
-		}
-
-		switch ev.Type {
-		case "assistant":
-			for _, content := range ev.Message.Content {
-				if content.Type == "tool_use" {
-					emitEvent(opts.EventHandler, events.ToolUse{
-						Name:   content.Name,
-						Detail: toolDetail(content.Name, content.Input, workDir),
-					})
-				} else if content.Type == "text" && content.Text != "" {
-					assistantText.WriteString(content.Text)
-					assistantText.WriteByte('\n')
-					emitEvent(opts.EventHandler, events.AgentText{Text: content.Text})
-				}
-			}
-		case "result":
-			result = ev.Result
-			numTurns = ev.NumTurns
-			durationMS = ev.DurationMS
-			inputTokens = ev.Usage.InputTokens
-			outputTokens = ev.Usage.OutputTokens
-		}
-	}
-
-	if numTurns > 0 {
-		emitEvent(opts.EventHandler, events.InvocationDone{
-			NumTurns:     numTurns,
-			DurationMS:   durationMS,
-			InputTokens:  inputTokens,
-			OutputTokens: outputTokens,
-		})
-	}
+		p.handleLine(scanner.Text())
+	}
+	p.finish()
+	trace.SpanFromContext(ctx).SetAttributes(
+		tracing.AttrModel.String(p.session.Model),
+		tracing.AttrSessionID.String(p.session.SessionID),
+		tracing.AttrTurns.Int(p.done.NumTurns),
+		tracing.AttrInputTokens.Int(p.done.TotalInputTokens()),
+		tracing.AttrOutputTokens.Int(p.done.OutputTokens),
+		tracing.AttrCacheTokens.Int(p.done.CacheReadTokens),
+		tracing.AttrCostUSD.Float64(p.done.CostUSD),
+	)
 
 	waitErr := cmd.Wait()
 
@@ -194,7 +179,8 @@ func runWithStreamJSON(ctx context.Context, opts InvokeOpts) (string, error) {
 	// may appear in any of: the result event text, the assistant event text
 	// (most common — shown as AgentText in the TUI), stderr, or non-JSON
 	// stdout lines. We check all of them.
-	allOutput := result + "\n" + assistantText.String() + "\n" + stderrBuf.String() + "\n" + strings.Join(nonJSONLines, "\n")
+	result := p.result
+	allOutput := result + "\n" + p.assistantText.String() + "\n" + stderrBuf.String() + "\n" + strings.Join(p.nonJSONLines, "\n")
 	if ulErr := parseUsageLimit(allOutput); ulErr != nil {
 		return result, ulErr
 	}
@@ -231,7 +217,7 @@ func toolDetail(name string, input map[string]any, workDir string) string {
 		if fp, ok := input["file_path"].(string); ok {
 			return relativePath(fp, workDir)
 		}
-	case "Write":
+	case "Write", "MultiEdit":
 		if fp, ok := input["file_path"].(string); ok {
 			return relativePath(fp, workDir)
 		}
@@ -456,9 +442,7 @@ func ModelName() string {
 }
 
 func buildArgs(opts InvokeOpts) []string {
-	var args []string
-
-	args = append(args, "--dangerously-skip-permissions")
+	args := permissionArgs(opts)
 
 	if opts.Print {
 		args = append(args, "--print")
@@ -476,13 +460,25 @@ func buildArgs(opts InvokeOpts) []string {
 		args = append(args, "--max-turns", strconv.Itoa(opts.MaxTurns))
 	}
 
-	if len(opts.DisallowedTools) > 0 {
-		args = append(args, "--disallowedTools", strings.Join(opts.DisallowedTools, ","))
-	}
-
 	if opts.Prompt != "" && !opts.Print {
 		args = append(args, "--system-prompt", opts.Prompt)
 	}
 
 	return args
 }
+
+// permissionArgs returns the flags deciding which tools Claude may use.
+// Without an allow list permission checks are skipped, so only the disallowed
+// tools are blocked.
+func permissionArgs(opts InvokeOpts) []string {
+	var args []string
+	if len(opts.AllowedTools) > 0 {
+		args = append(args, "--allowedTools", strings.Join(opts.AllowedTools, ","))
+	} else {
+		args = append(args, "--dangerously-skip-permissions")
+	}
+	if len(opts.DisallowedTools) > 0 {
+		args = append(args, "--disallowedTools", strings.Join(opts.DisallowedTools, ","))
+	}
+	return args
+}
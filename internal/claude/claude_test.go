@@ -89,6 +89,23 @@ func TestBuildArgs_ContinueFalse(t *testing.T) {
 	}
 }
 
+func TestBuildArgs_AllowedTools_ChecksPermissions(t *testing.T) {
+	args := buildArgs(InvokeOpts{
+		Print:           true,
+		AllowedTools:    []string{"Read", "Bash(go test:*)"},
+		DisallowedTools: []string{"Bash(git push:*)"},
+	})
+	assertContains(t, args, "--allowedTools")
+	assertContains(t, args, "Read,Bash(go test:*)")
+	assertContains(t, args, "--disallowedTools")
+	assertContains(t, args, "Bash(git push:*)")
+	for _, a := range args {
+		if a == "--dangerously-skip-permissions" {
+			t.Error("permission checks must stay on with an allow list")
+		}
+	}
+}
+
 func assertContains(t *testing.T, args []string, want string) {
 	t.Helper()
 	for _, a := range args {
@@ -479,6 +496,22 @@ func TestStreamEvent_UnmarshalUsage_Present(t *testing.T) {
 	}
 }
 
+func TestStreamEvent_UnmarshalPermissionDenials(t *testing.T) {
+	raw := `{"type":"result","permission_denials":[{"tool_name":"Bash","tool_use_id":"t1","tool_input":{"command":"git push"}}]}`
+	var ev streamEvent
+	if err := json.Unmarshal([]byte(raw), &ev); err != nil {
+		t.Fatalf("unmarshal error: %v", err)
+	}
+
+	if len(ev.PermissionDenials) != 1 {
+		t.Fatalf("PermissionDenials = %+v, want 1", ev.PermissionDenials)
+	}
+	d := ev.PermissionDenials[0]
+	if d.ToolName != "Bash" || toolDetail(d.ToolName, d.ToolInput, "") != "git push" {
+		t.Errorf("denial = %+v", d)
+	}
+}
+
 func TestStreamEvent_UnmarshalUsage_Absent(t *testing.T) {
 	raw := `{"type":"result","result":"done","num_turns":3,"duration_ms":5000}`
 	var ev streamEvent
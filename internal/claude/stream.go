@@ -0,0 +1,294 @@
+package claude
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"unicode/utf8"
+
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/uesteibar/ralph/internal/events"
+	"github.com/uesteibar/ralph/internal/tracing"
+)
+
+// toolOutputLimit caps the tool output carried by a ToolResult event.
+const toolOutputLimit = 500
+
+// contentBlock is one block of a stream-json message: text, a tool call or
+// a tool result.
+type contentBlock struct {
+	Type  string         `json:"type"`
+	Text  string         `json:"text,omitempty"`
+	ID    string         `json:"id,omitempty"`
+	Name  string         `json:"name,omitempty"`
+	Input map[string]any `json:"input,omitempty"`
+
+	ToolUseID string          `json:"tool_use_id,omitempty"`
+	Content   json.RawMessage `json:"content,omitempty"`
+	IsError   bool            `json:"is_error,omitempty"`
+}
+
+// contentBlocks is a message's content. Messages may also carry plain
+// string content, which is read as a single text block.
+type contentBlocks []contentBlock
+
+func (c *contentBlocks) UnmarshalJSON(b []byte) error {
+	var text string
+	if err := json.Unmarshal(b, &text); err == nil {
+		*c = contentBlocks{{Type: "text", Text: text}}
+		return nil
+	}
+	var blocks []contentBlock
+	if err := json.Unmarshal(b, &blocks); err != nil {
+		return err
+	}
+	*c = blocks
+	return nil
+}
+
+// pendingTool is a tool call waiting for its result.
+type pendingTool struct {
+	name     string
+	detail   string
+	input    map[string]any
+	subagent string
+
+	// ctx carries span, which lasts until the result arrives.
+	ctx  context.Context
+	span trace.Span
+}
+
+// streamParser turns stream-json lines into events and collects what
+// runWithStreamJSON needs once the process exits.
+type streamParser struct {
+	// ctx carries the span of the invocation, the parent of tool spans.
+	ctx     context.Context
+	h       events.EventHandler
+	workDir string
+
+	// pending maps tool_use IDs to their calls; tasks maps the IDs of Task
+	// calls to their descriptions, to label the subagent's activity.
+	pending map[string]pendingTool
+	tasks   map[string]string
+
+	result        string
+	assistantText strings.Builder
+	nonJSONLines  []string
+	done          events.InvocationDone
+	session       events.SessionInit
+}
+
+func newStreamParser(ctx context.Context, h events.EventHandler, workDir string) *streamParser {
+	return &streamParser{
+		ctx:     ctx,
+		h:       h,
+		workDir: workDir,
+		pending: make(map[string]pendingTool),
+		tasks:   make(map[string]string),
+	}
+}
+
+// handleLine processes one line of stream-json output.
+func (p *streamParser) handleLine(line string) {
+	var ev streamEvent
+	if err := json.Unmarshal([]byte(line), &ev); err != nil {
+		// Capture non-JSON lines — Claude CLI may write error
+		// messages as plain text (e.g. usage limit warnings).
+		if trimmed := strings.TrimSpace(line); trimmed != "" {
+			p.nonJSONLines = append(p.nonJSONLines, trimmed)
+		}
+		return
+	}
+
+	switch ev.Type {
+	case "system":
+		if ev.Subtype == "init" {
+			p.session = events.SessionInit{SessionID: ev.SessionID, Model: ev.Model}
+			emitEvent(p.h, p.session)
+		}
+	case "assistant":
+		subagent := p.tasks[ev.ParentToolUseID]
+		for _, content := range ev.Message.Content {
+			if content.Type == "tool_use" {
+				detail := toolDetail(content.Name, content.Input, p.workDir)
+				call := pendingTool{name: content.Name, detail: detail, input: content.Input, subagent: subagent}
+				call.ctx, call.span = tracing.Start(p.toolParent(ev.ParentToolUseID), "claude.tool "+content.Name,
+					tracing.AttrToolName.String(content.Name),
+					tracing.AttrToolDetail.String(detail),
+					tracing.AttrSubagent.String(subagent),
+				)
+				p.pending[content.ID] = call
+				if content.Name == "Task" {
+					p.tasks[content.ID] = detail
+				}
+				emitEvent(p.h, events.ToolUse{
+					Name:     content.Name,
+					Detail:   detail,
+					Subagent: subagent,
+				})
+			} else if content.Type == "text" && content.Text != "" {
+				p.assistantText.WriteString(content.Text)
+				p.assistantText.WriteByte('\n')
+				emitEvent(p.h, events.AgentText{Text: content.Text})
+			}
+		}
+	case "user":
+		for _, content := range ev.Message.Content {
+			if content.Type == "tool_result" {
+				p.handleToolResult(content)
+			}
+		}
+	case "result":
+		p.result = ev.Result
+		p.done = events.InvocationDone{
+			NumTurns:            ev.NumTurns,
+			DurationMS:          ev.DurationMS,
+			InputTokens:         ev.Usage.InputTokens,
+			OutputTokens:        ev.Usage.OutputTokens,
+			CacheCreationTokens: ev.Usage.CacheCreationInputTokens,
+			CacheReadTokens:     ev.Usage.CacheReadInputTokens,
+			CostUSD:             ev.TotalCostUSD,
+		}
+		for _, d := range ev.PermissionDenials {
+			emitEvent(p.h, events.ToolDenied{
+				Name:   d.ToolName,
+				Detail: toolDetail(d.ToolName, d.ToolInput, p.workDir),
+			})
+		}
+	}
+}
+
+func (p *streamParser) handleToolResult(content contentBlock) {
+	call, ok := p.pending[content.ToolUseID]
+	if !ok {
+		return
+	}
+	delete(p.pending, content.ToolUseID)
+
+	var err error
+	if content.IsError {
+		err = errors.New(truncateOutput(resultText(content.Content)))
+	}
+	tracing.End(call.span, err)
+
+	emitEvent(p.h, events.ToolResult{
+		Name:     call.name,
+		Detail:   call.detail,
+		IsError:  content.IsError,
+		Output:   truncateOutput(resultText(content.Content)),
+		Subagent: call.subagent,
+	})
+	if !content.IsError {
+		if added, removed, ok := editDelta(call.name, call.input); ok {
+			emitEvent(p.h, events.FileEdited{Path: call.detail, Added: added, Removed: removed})
+		}
+	}
+}
+
+// toolParent returns the context of a tool call's span: that of the Task
+// call it was made for, or else the invocation's.
+func (p *streamParser) toolParent(parentToolUseID string) context.Context {
+	if call, ok := p.pending[parentToolUseID]; ok && parentToolUseID != "" {
+		return call.ctx
+	}
+	return p.ctx
+}
+
+// finish emits InvocationDone once the stream has ended, and ends the spans
+// of calls that never got a result.
+func (p *streamParser) finish() {
+	for _, call := range p.pending {
+		call.span.End()
+	}
+	if p.done.NumTurns > 0 {
+		emitEvent(p.h, p.done)
+	}
+}
+
+// resultText returns the text of a tool result, which is either a string or
+// a list of content blocks.
+func resultText(raw json.RawMessage) string {
+	if len(raw) == 0 {
+		return ""
+	}
+	var text string
+	if err := json.Unmarshal(raw, &text); err == nil {
+		return text
+	}
+	var blocks []contentBlock
+	if err := json.Unmarshal(raw, &blocks); err != nil {
+		return ""
+	}
+	var parts []string
+	for _, b := range blocks {
+		if b.Type == "text" {
+			parts = append(parts, b.Text)
+		}
+	}
+	return strings.Join(parts, "\n")
+}
+
+// truncateOutput cuts s to toolOutputLimit bytes on a rune boundary.
+func truncateOutput(s string) string {
+	s = strings.TrimSpace(s)
+	if len(s) <= toolOutputLimit {
+		return s
+	}
+	cut := toolOutputLimit
+	for cut > 0 && !utf8.RuneStart(s[cut]) {
+		cut--
+	}
+	return s[:cut] + "…"
+}
+
+// editDelta returns the lines added and removed by an Edit, MultiEdit or
+// Write call, from its input alone; see events.FileEdited for what that
+// misses. ok is false for other tools.
+func editDelta(name string, input map[string]any) (added, removed int, ok bool) {
+	switch name {
+	case "Edit":
+		oldText, _ := input["old_string"].(string)
+		newText, _ := input["new_string"].(string)
+		added, removed = lineDelta(oldText, newText)
+		return added, removed, true
+	case "MultiEdit":
+		edits, _ := input["edits"].([]any)
+		for _, e := range edits {
+			edit, _ := e.(map[string]any)
+			oldText, _ := edit["old_string"].(string)
+			newText, _ := edit["new_string"].(string)
+			a, r := lineDelta(oldText, newText)
+			added += a
+			removed += r
+		}
+		return added, removed, true
+	case "Write":
+		content, _ := input["content"].(string)
+		added, _ = lineDelta("", content)
+		return added, 0, true
+	}
+	return 0, 0, false
+}
+
+// lineDelta counts the lines that differ between oldText and newText once
+// the lines they share at the start and end are set aside.
+func lineDelta(oldText, newText string) (added, removed int) {
+	oldLines := splitLines(oldText)
+	newLines := splitLines(newText)
+	for len(oldLines) > 0 && len(newLines) > 0 && oldLines[0] == newLines[0] {
+		oldLines, newLines = oldLines[1:], newLines[1:]
+	}
+	for len(oldLines) > 0 && len(newLines) > 0 && oldLines[len(oldLines)-1] == newLines[len(newLines)-1] {
+		oldLines, newLines = oldLines[:len(oldLines)-1], newLines[:len(newLines)-1]
+	}
+	return len(newLines), len(oldLines)
+}
+
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+}
@@ -0,0 +1,155 @@
+package claude
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"github.com/uesteibar/ralph/internal/events"
+	"github.com/uesteibar/ralph/internal/tracing"
+)
+
+type recordingHandler struct {
+	events []events.Event
+}
+
+func (h *recordingHandler) Handle(e events.Event) {
+	h.events = append(h.events, e)
+}
+
+func TestStreamParser_EmitsSessionToolResultsAndEdits(t *testing.T) {
+	h := &recordingHandler{}
+	p := newStreamParser(context.Background(), h, "/work")
+
+	lines := []string{
+		`{"type":"system","subtype":"init","session_id":"sess-1","model":"claude-sonnet-4-5"}`,
+		`{"type":"assistant","message":{"content":[{"type":"tool_use","id":"t1","name":"Edit","input":{"file_path":"/work/main.go","old_string":"a\nb\nc","new_string":"a\nB\nB2\nc"}}]}}`,
+		`{"type":"user","message":{"content":[{"type":"tool_result","tool_use_id":"t1","content":"ok"}]}}`,
+		`{"type":"assistant","message":{"content":[{"type":"tool_use","id":"t2","name":"Bash","input":{"command":"go test ./..."}}]}}`,
+		`{"type":"user","message":{"content":[{"type":"tool_result","tool_use_id":"t2","is_error":true,"content":[{"type":"text","text":"FAIL main_test.go"}]}]}}`,
+		`{"type":"result","result":"done","num_turns":2,"duration_ms":900,"total_cost_usd":0.25,"usage":{"input_tokens":10,"output_tokens":20,"cache_creation_input_tokens":300,"cache_read_input_tokens":4000}}`,
+	}
+	for _, l := range lines {
+		p.handleLine(l)
+	}
+	p.finish()
+
+	want := []events.Event{
+		events.SessionInit{SessionID: "sess-1", Model: "claude-sonnet-4-5"},
+		events.ToolUse{Name: "Edit", Detail: "main.go"},
+		events.ToolResult{Name: "Edit", Detail: "main.go", Output: "ok"},
+		events.FileEdited{Path: "main.go", Added: 2, Removed: 1},
+		events.ToolUse{Name: "Bash", Detail: "go test ./..."},
+		events.ToolResult{Name: "Bash", Detail: "go test ./...", IsError: true, Output: "FAIL main_test.go"},
+		events.InvocationDone{NumTurns: 2, DurationMS: 900, InputTokens: 10, OutputTokens: 20, CacheCreationTokens: 300, CacheReadTokens: 4000, CostUSD: 0.25},
+	}
+	if len(h.events) != len(want) {
+		t.Fatalf("got %d events, want %d: %+v", len(h.events), len(want), h.events)
+	}
+	for i := range want {
+		if h.events[i] != want[i] {
+			t.Errorf("event %d = %+v, want %+v", i, h.events[i], want[i])
+		}
+	}
+	if p.result != "done" {
+		t.Errorf("result = %q, want done", p.result)
+	}
+}
+
+func TestStreamParser_LabelsSubagentActivity(t *testing.T) {
+	h := &recordingHandler{}
+	p := newStreamParser(context.Background(), h, "")
+
+	p.handleLine(`{"type":"assistant","message":{"content":[{"type":"tool_use","id":"task1","name":"Task","input":{"description":"Explore auth"}}]}}`)
+	p.handleLine(`{"type":"assistant","parent_tool_use_id":"task1","message":{"content":[{"type":"tool_use","id":"t2","name":"Glob","input":{"pattern":"**/*.go"}}]}}`)
+
+	sub, ok := h.events[1].(events.ToolUse)
+	if !ok || sub.Name != "Glob" || sub.Subagent != "Explore auth" {
+		t.Errorf("subagent tool use = %+v, want Glob labelled with its task", h.events[1])
+	}
+}
+
+func TestStreamParser_TracesToolCalls(t *testing.T) {
+	sr := tracetest.NewSpanRecorder()
+	prev := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(sr)))
+	t.Cleanup(func() { otel.SetTracerProvider(prev) })
+
+	ctx, invocation := tracing.Start(context.Background(), "claude.Invoke")
+	p := newStreamParser(ctx, nil, "")
+	p.handleLine(`{"type":"assistant","message":{"content":[{"type":"tool_use","id":"task1","name":"Task","input":{"description":"Explore auth"}}]}}`)
+	p.handleLine(`{"type":"assistant","parent_tool_use_id":"task1","message":{"content":[{"type":"tool_use","id":"t2","name":"Bash","input":{"command":"go test ./..."}}]}}`)
+	p.handleLine(`{"type":"user","message":{"content":[{"type":"tool_result","tool_use_id":"t2","is_error":true,"content":"FAIL"}]}}`)
+	p.handleLine(`{"type":"user","message":{"content":[{"type":"tool_result","tool_use_id":"task1","content":"found it"}]}}`)
+	p.finish()
+	invocation.End()
+
+	spans := make(map[string]sdktrace.ReadOnlySpan)
+	for _, s := range sr.Ended() {
+		spans[s.Name()] = s
+	}
+	task, bash := spans["claude.tool Task"], spans["claude.tool Bash"]
+	if task == nil || bash == nil {
+		t.Fatalf("expected Task and Bash tool spans, got %v", spans)
+	}
+	if task.Parent().SpanID() != invocation.SpanContext().SpanID() {
+		t.Error("expected the Task span to be a child of the invocation")
+	}
+	if bash.Parent().SpanID() != task.SpanContext().SpanID() {
+		t.Error("expected the subagent's Bash span to be a child of its Task span")
+	}
+	if bash.Status().Code != codes.Error || task.Status().Code == codes.Error {
+		t.Errorf("statuses = %v (Bash), %v (Task); want only Bash failed", bash.Status().Code, task.Status().Code)
+	}
+}
+
+func TestStreamParser_StringUserContentIsNotPlainText(t *testing.T) {
+	p := newStreamParser(context.Background(), nil, "")
+	p.handleLine(`{"type":"user","message":{"content":"You've hit your usage limit? no, this is the prompt"}}`)
+	if len(p.nonJSONLines) != 0 {
+		t.Errorf("nonJSONLines = %v, want the message parsed as JSON", p.nonJSONLines)
+	}
+}
+
+func TestTruncateOutput(t *testing.T) {
+	long := strings.Repeat("é", toolOutputLimit)
+	got := truncateOutput(long)
+	if !strings.HasSuffix(got, "…") || len(got) > toolOutputLimit+len("…") {
+		t.Errorf("truncateOutput length = %d", len(got))
+	}
+	if !strings.HasPrefix(got, "éé") || strings.ContainsRune(got, '�') {
+		t.Error("truncateOutput should cut on a rune boundary")
+	}
+}
+
+func TestEditDelta(t *testing.T) {
+	tests := []struct {
+		name         string
+		tool         string
+		input        map[string]any
+		added, remov int
+	}{
+		{"edit", "Edit", map[string]any{"old_string": "x := 1\n", "new_string": "x := 2\ny := 3\n"}, 2, 1},
+		{"write", "Write", map[string]any{"content": "a\nb\nc\n"}, 3, 0},
+		{"multi edit", "MultiEdit", map[string]any{"edits": []any{
+			map[string]any{"old_string": "a", "new_string": "b"},
+			map[string]any{"old_string": "c\nd", "new_string": ""},
+		}}, 1, 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			added, removed, ok := editDelta(tt.tool, tt.input)
+			if !ok || added != tt.added || removed != tt.remov {
+				t.Errorf("editDelta = +%d -%d (%v), want +%d -%d", added, removed, ok, tt.added, tt.remov)
+			}
+		})
+	}
+	if _, _, ok := editDelta("Read", nil); ok {
+		t.Error("Read is not an edit")
+	}
+}
@@ -0,0 +1,116 @@
+package commands
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/uesteibar/ralph/internal/shell"
+	"github.com/uesteibar/ralph/internal/workspace"
+)
+
+func workspacesArchive(args []string) error {
+	fs := flag.NewFlagSet("workspaces archive", flag.ExitOnError)
+	configPath := AddProjectConfigFlag(fs)
+	output := fs.String("output", "", "Path of the archive to write (default <name>.tar.gz)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	remaining := fs.Args()
+	if len(remaining) == 0 {
+		return fmt.Errorf("usage: ralph workspaces archive <name> [--output file] [--project-config path]")
+	}
+	name := remaining[0]
+
+	// Flags may also follow the name.
+	if err := fs.Parse(remaining[1:]); err != nil {
+		return err
+	}
+
+	cfg, err := ResolveConfig(*configPath)
+	if err != nil {
+		return fmt.Errorf("resolving config: %w", err)
+	}
+
+	if _, err := workspace.RegistryGet(cfg.Repo.Path, name); err != nil {
+		return fmt.Errorf("Workspace %q not found. Run ralph workspaces list to see available.", name)
+	}
+
+	ctx := context.Background()
+	repoRunner := &shell.Runner{Dir: cfg.Repo.Path}
+
+	// Only committed work travels in the bundle.
+	treeRunner := &shell.Runner{Dir: workspace.TreePath(cfg.Repo.Path, name)}
+	if status, err := treeRunner.Run(ctx, "git", "status", "--porcelain"); err == nil && strings.TrimSpace(status) != "" {
+		fmt.Fprintf(os.Stderr, "warning: workspace '%s' has uncommitted changes; they will not be archived\n", name)
+	}
+
+	path := *output
+	if path == "" {
+		path = name + ".tar.gz"
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("creating archive: %w", err)
+	}
+	if err := workspace.Archive(ctx, repoRunner, cfg.Repo.Path, name, f); err != nil {
+		f.Close()
+		os.Remove(path)
+		return fmt.Errorf("archiving workspace: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("writing archive: %w", err)
+	}
+
+	fmt.Fprintf(os.Stderr, "✓ Archived workspace '%s' to %s\n", name, path)
+	return nil
+}
+
+func workspacesRestore(args []string) error {
+	fs := flag.NewFlagSet("workspaces restore", flag.ExitOnError)
+	configPath := AddProjectConfigFlag(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	remaining := fs.Args()
+	if len(remaining) == 0 {
+		return fmt.Errorf("usage: ralph workspaces restore <file> [--project-config path]")
+	}
+	path := remaining[0]
+
+	if err := fs.Parse(remaining[1:]); err != nil {
+		return err
+	}
+
+	cfg, err := ResolveConfig(*configPath)
+	if err != nil {
+		return fmt.Errorf("resolving config: %w", err)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("opening archive: %w", err)
+	}
+	defer f.Close()
+
+	ctx := context.Background()
+	repoRunner := &shell.Runner{Dir: cfg.Repo.Path}
+
+	ws, err := workspace.Restore(ctx, repoRunner, cfg.Repo.Path, f, cfg.Repo.DefaultBase, createOptions(cfg))
+	if err != nil {
+		return fmt.Errorf("restoring workspace: %w", err)
+	}
+
+	fmt.Fprintf(os.Stderr, "✓ Restored workspace '%s' on branch %s\n", ws.Name, ws.Branch)
+	if ws.Parent != "" {
+		if _, err := workspace.RegistryGet(cfg.Repo.Path, ws.Parent); err != nil {
+			fmt.Fprintf(os.Stderr, "warning: parent workspace '%s' is not present; restore it too to keep the stack\n", ws.Parent)
+		}
+	}
+	fmt.Fprintf(os.Stderr, "  Switch to it: ralph workspaces switch %s\n", ws.Name)
+	return nil
+}
@@ -0,0 +1,66 @@
+package commands
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/uesteibar/ralph/internal/prd"
+	"github.com/uesteibar/ralph/internal/workspace"
+)
+
+func TestWorkspacesArchive_RemoveThenRestore(t *testing.T) {
+	dir := setupVariantSource(t)
+	out := filepath.Join(t.TempDir(), "a.tar.gz")
+
+	if _, err := captureStdout(t, func() error {
+		return workspacesDispatch([]string{"archive", "a", "--output", out}, strings.NewReader(""))
+	}); err != nil {
+		t.Fatalf("archive: %v", err)
+	}
+	if _, err := os.Stat(out); err != nil {
+		t.Fatalf("archive not written: %v", err)
+	}
+
+	if _, err := captureStdout(t, func() error {
+		return workspacesDispatch([]string{"remove", "a"}, strings.NewReader(""))
+	}); err != nil {
+		t.Fatalf("remove: %v", err)
+	}
+
+	if _, err := captureStdout(t, func() error {
+		return workspacesDispatch([]string{"restore", out}, strings.NewReader(""))
+	}); err != nil {
+		t.Fatalf("restore: %v", err)
+	}
+
+	ws, err := workspace.RegistryGet(dir, "a")
+	if err != nil {
+		t.Fatalf("restored workspace not registered: %v", err)
+	}
+	if ws.Branch != "ralph/a" {
+		t.Errorf("branch = %q, want ralph/a", ws.Branch)
+	}
+	if _, err := os.Stat(filepath.Join(workspace.TreePath(dir, "a"), "two.txt")); err != nil {
+		t.Errorf("committed work missing from restored tree: %v", err)
+	}
+	p, err := prd.Read(workspace.PRDPathForWorkspace(dir, "a"))
+	if err != nil {
+		t.Fatalf("PRD not restored: %v", err)
+	}
+	if !prd.AllPass(p) {
+		t.Error("restored PRD lost story state")
+	}
+}
+
+func TestWorkspacesArchive_UnknownWorkspace(t *testing.T) {
+	setupVariantSource(t)
+
+	_, err := captureStdout(t, func() error {
+		return workspacesDispatch([]string{"archive", "nope"}, strings.NewReader(""))
+	})
+	if err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Fatalf("expected not found error, got %v", err)
+	}
+}
@@ -60,5 +60,5 @@ func Attach(args []string) error {
 		return tailLogsPlainTextFn(wsPath, logsDir)
 	}
 
-	return tailLogsTUIFn(wsPath, logsDir, wc.Name, wc.PRDPath)
+	return tailLogsTUIFn(wsPath, logsDir, wc, storyBaseRef(context.Background(), cfg, wc))
 }
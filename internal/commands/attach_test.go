@@ -112,12 +112,12 @@ func TestAttach_RunningDaemon_DelegatesToTailLogs(t *testing.T) {
 	called := false
 	var gotWsPath, gotLogsDir, gotWsName, gotPrdPath string
 	origTailLogsTUIFn := tailLogsTUIFn
-	tailLogsTUIFn = func(wsPath, logsDir, workspaceName, prdPath string) error {
+	tailLogsTUIFn = func(wsPath, logsDir string, wc workspace.WorkContext, diffBase string) error {
 		called = true
 		gotWsPath = wsPath
 		gotLogsDir = logsDir
-		gotWsName = workspaceName
-		gotPrdPath = prdPath
+		gotWsName = wc.Name
+		gotPrdPath = wc.PRDPath
 		return nil
 	}
 	defer func() { tailLogsTUIFn = origTailLogsTUIFn }()
@@ -172,7 +172,7 @@ func TestAttach_NoTUI_DelegatesToTailLogsPlainText(t *testing.T) {
 
 	// Also mock tailLogsTUI to ensure it's NOT called.
 	origTailLogsTUIFn := tailLogsTUIFn
-	tailLogsTUIFn = func(wsPath, logsDir, workspaceName, prdPath string) error {
+	tailLogsTUIFn = func(wsPath, logsDir string, wc workspace.WorkContext, diffBase string) error {
 		t.Fatal("tailLogsTUI should not be called with --no-tui")
 		return nil
 	}
@@ -8,10 +8,12 @@ import (
 	"path/filepath"
 
 	"github.com/uesteibar/ralph/internal/claude"
+	"github.com/uesteibar/ralph/internal/config"
 	"github.com/uesteibar/ralph/internal/knowledge"
 	"github.com/uesteibar/ralph/internal/prd"
 	"github.com/uesteibar/ralph/internal/progress"
 	"github.com/uesteibar/ralph/internal/prompts"
+	"github.com/uesteibar/ralph/internal/sandbox"
 	"github.com/uesteibar/ralph/internal/shell"
 )
 
@@ -73,11 +75,19 @@ func Chat(args []string) error {
 		return fmt.Errorf("rendering chat prompt: %w", err)
 	}
 
+	policy, err := sandbox.New(cfg.Sandbox, config.PhaseChat, wc.WorkDir)
+	if err != nil {
+		return err
+	}
+
 	_, err = claude.Invoke(context.Background(), claude.InvokeOpts{
-		Prompt:      prompt,
-		Dir:         wc.WorkDir,
-		Interactive: true,
-		Continue:    *continueFlag,
+		Prompt:          prompt,
+		Dir:             wc.WorkDir,
+		Interactive:     true,
+		Continue:        *continueFlag,
+		AllowedTools:    cfg.ToolPolicy[config.PhaseChat].AllowRules(),
+		DisallowedTools: cfg.ToolPolicy[config.PhaseChat].DenyRules(),
+		Sandbox:         policy,
 	})
 	return err
 }
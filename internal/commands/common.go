@@ -11,6 +11,7 @@ import (
 
 	"github.com/charmbracelet/lipgloss"
 	"github.com/uesteibar/ralph/internal/config"
+	"github.com/uesteibar/ralph/internal/loop"
 	"github.com/uesteibar/ralph/internal/prd"
 	"github.com/uesteibar/ralph/internal/workspace"
 )
@@ -27,6 +28,16 @@ func AddWorkspaceFlag(fs *flag.FlagSet) *string {
 	return fs.String("workspace", "", "Workspace name")
 }
 
+// addScopeFlags adds the flags that restrict a run to part of the PRD.
+func addScopeFlags(fs *flag.FlagSet) *loop.Scope {
+	s := &loop.Scope{}
+	fs.StringVar(&s.Story, "story", "", "Work only on this story, then stop without QA")
+	fs.StringVar(&s.Until, "until", "", "Stop once this story and those before it pass, without QA")
+	fs.BoolVar(&s.SkipQA, "skip-qa", false, "Stop when all stories pass, without the QA phase")
+	fs.BoolVar(&s.QAOnly, "qa-only", false, "Run only the QA phase")
+	return s
+}
+
 // resolveWorkContextFromFlags resolves workspace context from the --workspace
 // flag value, RALPH_WORKSPACE env var, cwd, and repo path.
 func resolveWorkContextFromFlags(workspaceFlag string, repoPath string) (workspace.WorkContext, error) {
@@ -13,7 +13,9 @@ import (
 	"github.com/uesteibar/ralph/internal/events"
 	"github.com/uesteibar/ralph/internal/knowledge"
 	"github.com/uesteibar/ralph/internal/loop"
+	"github.com/uesteibar/ralph/internal/notify"
 	"github.com/uesteibar/ralph/internal/runstate"
+	"github.com/uesteibar/ralph/internal/tracing"
 	"github.com/uesteibar/ralph/internal/workspace"
 )
 
@@ -29,6 +31,7 @@ func Daemon(args []string) error {
 	configPath := AddProjectConfigFlag(fs)
 	maxIter := fs.Int("max-iterations", loop.DefaultMaxIterations, "Maximum loop iterations")
 	workspaceFlag := AddWorkspaceFlag(fs)
+	scope := addScopeFlags(fs)
 	if err := fs.Parse(args); err != nil {
 		return err
 	}
@@ -82,8 +85,38 @@ func Daemon(args []string) error {
 
 	// Set up FileHandler for JSONL logging.
 	logsDir := filepath.Join(wsPath, "logs")
-	handler := events.NewFileHandler(logsDir)
-	defer handler.Close()
+	fileHandler := events.NewFileHandler(logsDir)
+	fileHandler.SetRetention(logRetention(cfg.Logs))
+	defer fileHandler.Close()
+
+	// Wrap it with the notifier so configured sinks hear about run outcomes.
+	var handler events.EventHandler = fileHandler
+	var notifier *notify.Handler
+	if len(cfg.Notifications) > 0 {
+		n, err := notify.New(cfg.Notifications, cfg.Project, wc.Name, wc.WorkDir, fileHandler)
+		if err != nil {
+			fileHandler.Handle(events.LogMessage{Level: "warning", Message: fmt.Sprintf("notifications disabled: %v", err)})
+		} else {
+			notifier = n
+			handler = n
+			// Deferred after the file handler so queued notifications (and
+			// their delivery warnings) land before it closes.
+			defer n.Close()
+		}
+	}
+
+	// Export traces of the run when configured.
+	tracingCfg := cfg.Tracing
+	if tracingCfg.File != "" && !filepath.IsAbs(tracingCfg.File) {
+		tracingCfg.File = filepath.Join(cfg.Repo.Path, tracingCfg.File)
+	}
+	shutdownTracing, err := tracing.Setup(ctx, tracingCfg, "ralph")
+	if err != nil {
+		fileHandler.Handle(events.LogMessage{Level: "warning", Message: fmt.Sprintf("tracing disabled: %v", err)})
+	} else {
+		// Flush even when the run was cancelled.
+		defer shutdownTracing(context.WithoutCancel(ctx))
+	}
 
 	promptsDir := cfg.PromptsDir()
 
@@ -97,6 +130,12 @@ func Daemon(args []string) error {
 		QualityChecks: cfg.QualityChecks,
 		KnowledgePath: knowledge.Dir(wc.WorkDir),
 		EventHandler:  handler,
+		Workspace:     wc.Name,
+		Hooks:         cfg.Hooks,
+		Sandbox:       cfg.Sandbox,
+		ToolPolicy:    cfg.ToolPolicy,
+		Guardrails:    cfg.Guardrails,
+		Scope:         *scope,
 	})
 
 	// Write status file based on outcome.
@@ -111,6 +150,9 @@ func Daemon(args []string) error {
 		status.Error = loopErr.Error()
 	}
 	runstate.WriteStatus(wsPath, status)
+	if notifier != nil {
+		notifier.RunFinished(status)
+	}
 
 	return nil
 }
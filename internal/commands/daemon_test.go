@@ -80,6 +80,32 @@ func TestDaemon_WritesPIDAndCleansUp(t *testing.T) {
 	}
 }
 
+func TestDaemon_PassesScopeToLoop(t *testing.T) {
+	dir := realPath(t, t.TempDir())
+	initTestRepo(t, dir)
+	wsName := "test-scope"
+	setupWorkspace(t, dir, wsName, allPassingPRD(wsName))
+
+	oldWd, _ := os.Getwd()
+	defer os.Chdir(oldWd)
+	os.Chdir(dir)
+
+	var got loop.Scope
+	origRunLoop := daemonRunLoopFn
+	daemonRunLoopFn = func(ctx context.Context, cfg loop.Config) error {
+		got = cfg.Scope
+		return nil
+	}
+	defer func() { daemonRunLoopFn = origRunLoop }()
+
+	if err := Daemon([]string{"--workspace", wsName, "--until", "US-002"}); err != nil {
+		t.Fatalf("Daemon returned error: %v", err)
+	}
+	if got != (loop.Scope{Until: "US-002"}) {
+		t.Errorf("loop scope = %+v, want until US-002", got)
+	}
+}
+
 func TestDaemon_WritesSuccessStatus(t *testing.T) {
 	dir := realPath(t, t.TempDir())
 	initTestRepo(t, dir)
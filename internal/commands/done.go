@@ -13,7 +13,9 @@ import (
 
 	"github.com/uesteibar/ralph/internal/config"
 	"github.com/uesteibar/ralph/internal/gitops"
+	"github.com/uesteibar/ralph/internal/hooks"
 	"github.com/uesteibar/ralph/internal/prd"
+	"github.com/uesteibar/ralph/internal/sandbox"
 	"github.com/uesteibar/ralph/internal/shell"
 	"github.com/uesteibar/ralph/internal/workspace"
 )
@@ -21,22 +23,69 @@ import (
 // doneNowFn returns the current time. Overridable in tests.
 var doneNowFn = time.Now
 
-// Done squash-merges the feature branch into the base branch. In workspace
-// mode it auto-removes the workspace after merging. In base mode it keeps the
-// current behavior with an optional cleanup prompt.
+// doneOptions controls how `ralph done` lands the feature branch.
+type doneOptions struct {
+	Strategy string // config.DoneStrategy*
+	Check    bool   // run quality checks on the merged result
+	Push     bool   // push the base branch afterwards
+}
+
+// Done lands the feature branch on the base branch with the configured
+// strategy (squash by default). In workspace mode it auto-removes the
+// workspace after merging, or with --pr opens a pull request and keeps it. In
+// base mode it keeps the current behavior with an optional cleanup prompt.
 func Done(args []string) error {
 	fs := flag.NewFlagSet("done", flag.ExitOnError)
 	configPath := AddProjectConfigFlag(fs)
 	workspaceFlag := AddWorkspaceFlag(fs)
+	strategy := fs.String("strategy", "", "How to land the branch: squash, rebase or merge (default: done.strategy, else squash)")
+	check := fs.Bool("check", false, "Run the quality checks on the merged result and roll back if they fail (default: done.check)")
+	push := fs.Bool("push", false, "Push the base branch to origin after merging and roll back if the push fails (default: done.push)")
+	openPR := fs.Bool("pr", false, "Push the branch and open a GitHub pull request instead of merging locally (not with --strategy, --check or --push)")
 	if err := fs.Parse(args); err != nil {
 		return err
 	}
+	if *openPR {
+		// The merge flags only apply to a local merge; --pr merges nothing.
+		var conflicting error
+		fs.Visit(func(f *flag.Flag) {
+			switch f.Name {
+			case "strategy", "check", "push":
+				if conflicting == nil {
+					conflicting = fmt.Errorf("--pr cannot be combined with --%s", f.Name)
+				}
+			}
+		})
+		if conflicting != nil {
+			return conflicting
+		}
+	}
 
 	cfg, err := ResolveConfig(*configPath)
 	if err != nil {
 		return fmt.Errorf("loading config: %w", err)
 	}
 
+	opts := doneOptions{Strategy: cfg.Done.Strategy, Check: cfg.Done.Check, Push: cfg.Done.Push}
+	fs.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "strategy":
+			opts.Strategy = *strategy
+		case "check":
+			opts.Check = *check
+		case "push":
+			opts.Push = *push
+		}
+	})
+	switch opts.Strategy {
+	case "":
+		opts.Strategy = config.DoneStrategySquash
+	case config.DoneStrategySquash, config.DoneStrategyRebase, config.DoneStrategyMerge:
+	default:
+		return fmt.Errorf("unknown strategy %q (use %q, %q or %q)", opts.Strategy,
+			config.DoneStrategySquash, config.DoneStrategyRebase, config.DoneStrategyMerge)
+	}
+
 	ctx := context.Background()
 
 	wc, err := resolveWorkContextFromFlags(*workspaceFlag, cfg.Repo.Path)
@@ -46,14 +95,21 @@ func Done(args []string) error {
 
 	printWorkspaceHeader(wc, cfg.Repo.Path)
 
+	if *openPR {
+		if wc.Name == "base" {
+			return fmt.Errorf("--pr requires a workspace")
+		}
+		return donePR(ctx, cfg, wc)
+	}
+
 	if wc.Name == "base" {
-		return doneBase(ctx, cfg, os.Stdin)
+		return doneBase(ctx, cfg, opts, os.Stdin)
 	}
-	return doneWorkspace(ctx, cfg, wc, os.Stdin)
+	return doneWorkspace(ctx, cfg, wc, opts, os.Stdin)
 }
 
-// doneBase handles done from base mode: squash-merge + optional cleanup prompt.
-func doneBase(ctx context.Context, cfg *config.Config, stdin *os.File) error {
+// doneBase handles done from base mode: merge + optional cleanup prompt.
+func doneBase(ctx context.Context, cfg *config.Config, opts doneOptions, stdin *os.File) error {
 	r := &shell.Runner{}
 
 	fmt.Fprintln(os.Stderr, "checking worktree context...")
@@ -88,15 +144,23 @@ func doneBase(ctx context.Context, cfg *config.Config, stdin *os.File) error {
 		return fmt.Errorf("origin/%s is not an ancestor of HEAD — run `ralph rebase` first to incorporate the latest changes", baseBranch)
 	}
 
-	fmt.Fprintln(os.Stderr, "generating commit message...")
-	commitMsg, err := generateCommitMessage(cfg.StatePRDPath())
+	wtPath, err := os.Getwd()
 	if err != nil {
-		return fmt.Errorf("generating commit message: %w", err)
+		return fmt.Errorf("getting worktree path: %w", err)
+	}
+	if err := runBeforeDoneHooks(ctx, cfg, hooks.Payload{
+		Workspace: "base",
+		Branch:    featureBranch,
+		RepoPath:  cfg.Repo.Path,
+		WorkDir:   wtPath,
+		PRDPath:   cfg.StatePRDPath(),
+	}); err != nil {
+		return err
 	}
 
-	editedMsg, err := promptEditMessage(commitMsg, stdin)
+	commitMsg, err := doneCommitMessage(cfg.StatePRDPath(), opts, stdin)
 	if err != nil {
-		return fmt.Errorf("reading user input: %w", err)
+		return err
 	}
 
 	fmt.Fprintln(os.Stderr, "resolving main repo path...")
@@ -105,17 +169,11 @@ func doneBase(ctx context.Context, cfg *config.Config, stdin *os.File) error {
 		return fmt.Errorf("resolving main repo path: %w", err)
 	}
 
-	fmt.Fprintf(os.Stderr, "squash-merging %s into %s...\n", featureBranch, baseBranch)
-	if err := gitops.SquashMerge(ctx, r, repoPath, featureBranch, baseBranch, editedMsg); err != nil {
+	if err := landBranch(ctx, cfg, r, repoPath, featureBranch, baseBranch, commitMsg, opts); err != nil {
 		return err
 	}
-	fmt.Fprintln(os.Stderr, "squash merge completed")
 
 	if shouldCleanup(stdin) {
-		wtPath, err := os.Getwd()
-		if err != nil {
-			return fmt.Errorf("getting worktree path: %w", err)
-		}
 		fmt.Fprintf(os.Stderr, "removing worktree and deleting branch %s...\n", featureBranch)
 		if err := gitops.RemoveWorktree(ctx, r, repoPath, wtPath); err != nil {
 			return fmt.Errorf("removing worktree: %w", err)
@@ -132,13 +190,18 @@ func doneBase(ctx context.Context, cfg *config.Config, stdin *os.File) error {
 	return nil
 }
 
-// doneWorkspace handles done from workspace mode: squash-merge, archive PRD,
+// doneWorkspace handles done from workspace mode: merge, archive PRD,
 // auto-remove workspace, stdout base repo path.
-func doneWorkspace(ctx context.Context, cfg *config.Config, wc workspace.WorkContext, stdin *os.File) error {
+func doneWorkspace(ctx context.Context, cfg *config.Config, wc workspace.WorkContext, opts doneOptions, stdin *os.File) error {
 	r := &shell.Runner{Dir: wc.WorkDir}
 
 	baseBranch := cfg.Repo.DefaultBase
 
+	// A stacked workspace still depends on its parent's unmerged commits.
+	if ws, err := workspace.RegistryGet(cfg.Repo.Path, wc.Name); err == nil && ws.Parent != "" {
+		return fmt.Errorf("workspace %s is stacked on %s — run `ralph done` in %s first", wc.Name, ws.Parent, ws.Parent)
+	}
+
 	fmt.Fprintln(os.Stderr, "detecting current branch...")
 	featureBranch, err := gitops.CurrentBranch(ctx, r)
 	if err != nil {
@@ -160,26 +223,29 @@ func doneWorkspace(ctx context.Context, cfg *config.Config, wc workspace.WorkCon
 		return fmt.Errorf("origin/%s is not an ancestor of HEAD — run `ralph rebase` first to incorporate the latest changes", baseBranch)
 	}
 
-	fmt.Fprintln(os.Stderr, "generating commit message...")
-	commitMsg, err := generateCommitMessage(wc.PRDPath)
-	if err != nil {
-		return fmt.Errorf("generating commit message: %w", err)
+	if err := runBeforeDoneHooks(ctx, cfg, hooks.Payload{
+		Workspace: wc.Name,
+		Branch:    featureBranch,
+		RepoPath:  cfg.Repo.Path,
+		WorkDir:   wc.WorkDir,
+		PRDPath:   wc.PRDPath,
+	}); err != nil {
+		return err
 	}
 
-	editedMsg, err := promptEditMessage(commitMsg, stdin)
+	commitMsg, err := doneCommitMessage(wc.PRDPath, opts, stdin)
 	if err != nil {
-		return fmt.Errorf("reading user input: %w", err)
+		return err
 	}
 
 	repoPath := cfg.Repo.Path
 
-	fmt.Fprintf(os.Stderr, "squash-merging %s into %s...\n", featureBranch, baseBranch)
-	if err := gitops.SquashMerge(ctx, r, repoPath, featureBranch, baseBranch, editedMsg); err != nil {
+	if err := landBranch(ctx, cfg, r, repoPath, featureBranch, baseBranch, commitMsg, opts); err != nil {
 		return err
 	}
-	fmt.Fprintln(os.Stderr, "squash merge completed")
 
-	fmt.Fprintf(os.Stderr, "Squash-merged %s into %s\n", featureBranch, baseBranch)
+	// Move stacked children onto the base while this branch still exists.
+	retargetChildren(ctx, repoPath, wc.Name, featureBranch, baseBranch)
 
 	// Archive PRD from workspace level BEFORE removing the workspace.
 	fmt.Fprintln(os.Stderr, "archiving PRD...")
@@ -200,6 +266,125 @@ func doneWorkspace(ctx context.Context, cfg *config.Config, wc workspace.WorkCon
 	return nil
 }
 
+// doneCommitMessage generates the commit message for the squash or merge
+// commit and lets the user edit it. The rebase strategy keeps the existing
+// commits and needs none.
+func doneCommitMessage(prdPath string, opts doneOptions, stdin *os.File) (string, error) {
+	if opts.Strategy == config.DoneStrategyRebase {
+		return "", nil
+	}
+
+	fmt.Fprintln(os.Stderr, "generating commit message...")
+	commitMsg, err := generateCommitMessage(prdPath)
+	if err != nil {
+		return "", fmt.Errorf("generating commit message: %w", err)
+	}
+
+	editedMsg, err := promptEditMessage(commitMsg, stdin)
+	if err != nil {
+		return "", fmt.Errorf("reading user input: %w", err)
+	}
+	return editedMsg, nil
+}
+
+// landBranch merges featureBranch into baseBranch in the main repo with the
+// chosen strategy. With opts.Check the quality checks run on the merged
+// result, and with opts.Push the base branch is pushed to origin; if either
+// fails, baseBranch is moved back to where it was before the merge.
+func landBranch(ctx context.Context, cfg *config.Config, r *shell.Runner, repoPath, featureBranch, baseBranch, commitMsg string, opts doneOptions) error {
+	repoRunner := &shell.Runner{Dir: repoPath}
+
+	before, err := gitops.RevParse(ctx, repoRunner, baseBranch)
+	if err != nil {
+		return err
+	}
+
+	switch opts.Strategy {
+	case config.DoneStrategyRebase:
+		fmt.Fprintf(os.Stderr, "rebasing %s onto %s...\n", featureBranch, baseBranch)
+		err = gitops.RebaseMerge(ctx, repoPath, featureBranch, baseBranch)
+	case config.DoneStrategyMerge:
+		fmt.Fprintf(os.Stderr, "merging %s into %s...\n", featureBranch, baseBranch)
+		err = gitops.MergeCommit(ctx, repoPath, featureBranch, baseBranch, commitMsg)
+	default:
+		fmt.Fprintf(os.Stderr, "squash-merging %s into %s...\n", featureBranch, baseBranch)
+		err = gitops.SquashMerge(ctx, r, repoPath, featureBranch, baseBranch, commitMsg)
+	}
+	if err != nil {
+		return err
+	}
+
+	rollback := func(reason error) error {
+		fmt.Fprintf(os.Stderr, "rolling %s back to %s...\n", baseBranch, before[:12])
+		if err := gitops.ResetBranch(ctx, repoRunner, before); err != nil {
+			return fmt.Errorf("%w (rollback failed: %v)", reason, err)
+		}
+		return fmt.Errorf("%w — %s was rolled back, the workspace is kept", reason, baseBranch)
+	}
+
+	if opts.Check {
+		fmt.Fprintln(os.Stderr, "running quality checks on the merged result...")
+		checks, err := sandbox.Runner(cfg.Sandbox, config.PhaseChecks, repoPath)
+		if err != nil {
+			return rollback(err)
+		}
+		for _, check := range cfg.QualityChecks {
+			if _, err := checks.Run(ctx, "sh", "-c", check); err != nil {
+				return rollback(fmt.Errorf("quality check %q failed on the merged result: %w", check, err))
+			}
+		}
+	}
+
+	if opts.Push {
+		fmt.Fprintf(os.Stderr, "pushing %s...\n", baseBranch)
+		if err := gitops.PushBranch(ctx, repoRunner, baseBranch); err != nil {
+			return rollback(err)
+		}
+	}
+
+	fmt.Fprintf(os.Stderr, "Landed %s on %s (%s)\n", featureBranch, baseBranch, opts.Strategy)
+	return nil
+}
+
+// retargetChildren rebases the workspaces stacked on name onto baseBranch,
+// replaying only their own commits (the parent's have now landed on the
+// base). Failures are reported as warnings: a child that cannot be moved is
+// left as is for the user to fix with `ralph rebase`. The registry side is
+// handled when the parent is removed.
+func retargetChildren(ctx context.Context, repoPath, name, parentBranch, baseBranch string) {
+	children, err := workspace.Children(repoPath, name)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "warning: could not list stacked workspaces: %v\n", err)
+		return
+	}
+	for _, child := range children {
+		fmt.Fprintf(os.Stderr, "retargeting %s onto %s...\n", child.Name, baseBranch)
+		cr := &shell.Runner{Dir: workspace.TreePath(repoPath, child.Name)}
+		result, err := gitops.StartRebaseOnto(ctx, cr, baseBranch, parentBranch)
+		if err != nil || result.HasConflicts {
+			if result.HasConflicts {
+				_ = gitops.AbortRebase(ctx, cr)
+			}
+			fmt.Fprintf(os.Stderr, "warning: could not retarget %s onto %s; run `ralph rebase --workspace %s` to finish\n", child.Name, baseBranch, child.Name)
+			continue
+		}
+		fmt.Fprintf(os.Stderr, "Retargeted %s onto %s\n", child.Name, baseBranch)
+	}
+}
+
+// runBeforeDoneHooks runs the before_done hooks. A failing fail-closed hook
+// aborts done before anything is merged.
+func runBeforeDoneHooks(ctx context.Context, cfg *config.Config, p hooks.Payload) error {
+	if len(cfg.Hooks.BeforeDone) == 0 {
+		return nil
+	}
+	p.Event = hooks.BeforeDone
+	fmt.Fprintln(os.Stderr, "running before_done hooks...")
+	return hooks.Run(ctx, cfg.Hooks.BeforeDone, p, func(msg string) {
+		fmt.Fprintf(os.Stderr, "warning: %s\n", msg)
+	})
+}
+
 // archivePRDFromPath copies a PRD from sourcePath to the archive directory.
 func archivePRDFromPath(sourcePath string, cfg *config.Config) {
 	data, err := os.ReadFile(sourcePath)
@@ -0,0 +1,195 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/uesteibar/ralph/internal/autoralph/ai"
+	"github.com/uesteibar/ralph/internal/autoralph/credentials"
+	"github.com/uesteibar/ralph/internal/autoralph/github"
+	"github.com/uesteibar/ralph/internal/claude"
+	"github.com/uesteibar/ralph/internal/config"
+	"github.com/uesteibar/ralph/internal/gitops"
+	"github.com/uesteibar/ralph/internal/hooks"
+	"github.com/uesteibar/ralph/internal/prd"
+	"github.com/uesteibar/ralph/internal/sandbox"
+	"github.com/uesteibar/ralph/internal/shell"
+	"github.com/uesteibar/ralph/internal/workspace"
+)
+
+// maxTurnsPRDescription limits the agentic turns spent describing the PR.
+const maxTurnsPRDescription = 10
+
+// pullRequestOpener is the subset of the GitHub client used by done --pr.
+type pullRequestOpener interface {
+	CreatePullRequest(ctx context.Context, owner, repo, head, base, title, body string) (github.PR, error)
+	FindOpenPR(ctx context.Context, owner, repo, head, base string) (*github.PR, error)
+}
+
+// newPROpenerFn builds the GitHub client for done --pr. Overridable in tests.
+var newPROpenerFn = newPROpener
+
+// newPROpener authenticates with GITHUB_TOKEN or the default profile of the
+// AutoRalph credentials file. GITHUB_API_URL points it at GitHub Enterprise.
+func newPROpener() (pullRequestOpener, error) {
+	creds, err := credentials.ResolveGithub(credentials.DefaultPath(), "")
+	if err != nil {
+		return nil, fmt.Errorf("resolving GitHub credentials: %w", err)
+	}
+
+	var opts []github.Option
+	if url := os.Getenv("GITHUB_API_URL"); url != "" {
+		opts = append(opts, github.WithBaseURL(url+"/"))
+	}
+	if creds.HasGithubApp() {
+		opts = append(opts, github.WithAppAuth(github.AppCredentials{
+			ClientID:       creds.GithubAppClientID,
+			InstallationID: creds.GithubAppInstallationID,
+			PrivateKeyPath: creds.GithubAppPrivateKeyPath,
+		}))
+	}
+	return github.New(creds.GithubToken, opts...)
+}
+
+// donePR pushes the workspace branch and opens a pull request for it instead
+// of merging locally. The title and body are generated from the PRD with the
+// same prompt AutoRalph uses. The workspace is kept and marked as in review.
+func donePR(ctx context.Context, cfg *config.Config, wc workspace.WorkContext) error {
+	r := &shell.Runner{Dir: wc.WorkDir}
+
+	ws, err := workspace.RegistryGet(cfg.Repo.Path, wc.Name)
+	if err != nil {
+		return err
+	}
+
+	// A stacked workspace is reviewed against its parent's branch.
+	baseBranch := cfg.Repo.DefaultBase
+	if ws.Parent != "" {
+		parent, err := workspace.RegistryGet(cfg.Repo.Path, ws.Parent)
+		if err != nil {
+			return fmt.Errorf("loading parent workspace %s: %w", ws.Parent, err)
+		}
+		baseBranch = parent.Branch
+	}
+
+	fmt.Fprintln(os.Stderr, "detecting current branch...")
+	featureBranch, err := gitops.CurrentBranch(ctx, r)
+	if err != nil {
+		return fmt.Errorf("getting current branch: %w", err)
+	}
+	fmt.Fprintf(os.Stderr, "on branch %s\n", featureBranch)
+
+	remote, err := gitops.RemoteURL(ctx, r, "origin")
+	if err != nil {
+		return err
+	}
+	owner, repo, err := gitops.ParseGitHubRemote(remote)
+	if err != nil {
+		return err
+	}
+
+	if err := runBeforeDoneHooks(ctx, cfg, hooks.Payload{
+		Workspace: wc.Name,
+		Branch:    featureBranch,
+		RepoPath:  cfg.Repo.Path,
+		WorkDir:   wc.WorkDir,
+		PRDPath:   wc.PRDPath,
+	}); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(os.Stderr, "pushing %s...\n", featureBranch)
+	if err := gitops.PushBranch(ctx, r, featureBranch); err != nil {
+		return err
+	}
+
+	fmt.Fprintln(os.Stderr, "generating pull request description...")
+	title, body, err := describePullRequest(ctx, cfg, r, wc, baseBranch)
+	if err != nil {
+		return err
+	}
+
+	client, err := newPROpenerFn()
+	if err != nil {
+		return err
+	}
+
+	// Idempotent: reuse the open PR if done --pr already ran for this branch.
+	pr, err := client.FindOpenPR(ctx, owner, repo, featureBranch, baseBranch)
+	if err != nil {
+		return fmt.Errorf("checking for existing PR: %w", err)
+	}
+	if pr == nil {
+		created, err := client.CreatePullRequest(ctx, owner, repo, featureBranch, baseBranch, title, body)
+		if err != nil {
+			return fmt.Errorf("creating pull request: %w", err)
+		}
+		pr = &created
+		fmt.Fprintf(os.Stderr, "Opened pull request #%d: %s\n", pr.Number, pr.HTMLURL)
+	} else {
+		fmt.Fprintf(os.Stderr, "Pull request #%d is already open: %s\n", pr.Number, pr.HTMLURL)
+	}
+
+	if err := workspace.RegistryUpdate(cfg.Repo.Path, wc.Name, func(w *workspace.Workspace) {
+		w.Status = workspace.StatusInReview
+		w.PR = pr.Number
+	}); err != nil {
+		return fmt.Errorf("marking workspace as in review: %w", err)
+	}
+	fmt.Fprintf(os.Stderr, "Workspace '%s' is in review\n", wc.Name)
+
+	return nil
+}
+
+// describePullRequest renders the pr_description prompt from the workspace
+// PRD and diff stats, and returns the generated title and body.
+func describePullRequest(ctx context.Context, cfg *config.Config, r *shell.Runner, wc workspace.WorkContext, baseBranch string) (string, string, error) {
+	p, err := prd.Read(wc.PRDPath)
+	if err != nil {
+		return "", "", fmt.Errorf("reading PRD: %w", err)
+	}
+
+	diffStats, err := gitops.DiffStats(ctx, r, "origin/"+baseBranch)
+	if err != nil {
+		diffStats = "(diff stats unavailable)"
+	} else {
+		diffStats = ai.CapDiffStats(diffStats, 50)
+	}
+
+	var stories []ai.PRDescriptionStory
+	for _, s := range p.UserStories {
+		stories = append(stories, ai.PRDescriptionStory{ID: s.ID, Title: s.Title})
+	}
+
+	prompt, err := ai.RenderPRDescription(ai.PRDescriptionData{
+		PRDSummary: p.Description,
+		Stories:    stories,
+		DiffStats:  diffStats,
+	}, "")
+	if err != nil {
+		return "", "", fmt.Errorf("rendering PR prompt: %w", err)
+	}
+
+	policy, err := sandbox.New(cfg.Sandbox, config.PhasePR, wc.WorkDir)
+	if err != nil {
+		return "", "", err
+	}
+
+	output, err := invokeClaudeFn(ctx, claude.InvokeOpts{
+		Prompt:   prompt,
+		Dir:      wc.WorkDir,
+		Print:    true,
+		MaxTurns: maxTurnsPRDescription,
+		Sandbox:  policy,
+	})
+	if err != nil {
+		return "", "", fmt.Errorf("generating PR description: %w", err)
+	}
+
+	title, body := ai.ParsePRDescription(output)
+	if title == "" {
+		return "", "", fmt.Errorf("generated PR description is empty")
+	}
+	return title, body, nil
+}
@@ -0,0 +1,165 @@
+package commands
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/uesteibar/ralph/internal/autoralph/github"
+	"github.com/uesteibar/ralph/internal/claude"
+	"github.com/uesteibar/ralph/internal/config"
+	"github.com/uesteibar/ralph/internal/gitops"
+	"github.com/uesteibar/ralph/internal/prd"
+	"github.com/uesteibar/ralph/internal/shell"
+	"github.com/uesteibar/ralph/internal/workspace"
+)
+
+type fakePROpener struct {
+	existing *github.PR
+	created  []string // "head->base: title"
+}
+
+func (f *fakePROpener) CreatePullRequest(_ context.Context, owner, repo, head, base, title, body string) (github.PR, error) {
+	f.created = append(f.created, head+"->"+base+": "+title)
+	return github.PR{Number: 17, HTMLURL: "https://github.com/" + owner + "/" + repo + "/pull/17"}, nil
+}
+
+func (f *fakePROpener) FindOpenPR(_ context.Context, _, _, _, _ string) (*github.PR, error) {
+	return f.existing, nil
+}
+
+// setupDonePR prepares the stack from setupStack for done --pr: origin looks
+// like a GitHub remote but pushes go to the local bare repository, each
+// workspace has a PRD, and Claude and the GitHub client are stubbed.
+func setupDonePR(t *testing.T) (string, *fakePROpener) {
+	t.Helper()
+	dir := setupStack(t)
+	ctx := context.Background()
+	r := &shell.Runner{Dir: dir}
+
+	bare, err := gitops.RemoteURL(ctx, r, "origin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, c := range [][]string{
+		{"remote", "set-url", "origin", "git@github.com:acme/app.git"},
+		{"remote", "set-url", "--push", "origin", bare},
+	} {
+		if _, err := r.Run(ctx, "git", c...); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, name := range []string{"api", "ui"} {
+		p := &prd.PRD{
+			Project:     "app",
+			Description: "Build the " + name,
+			UserStories: []prd.Story{{ID: "US-001", Title: name + " story", Passes: true}},
+		}
+		data, _ := json.MarshalIndent(p, "", "  ")
+		if err := os.WriteFile(workspace.PRDPathForWorkspace(dir, name), data, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	oldInvoke := invokeClaudeFn
+	invokeClaudeFn = func(_ context.Context, opts claude.InvokeOpts) (string, error) {
+		if !strings.Contains(opts.Prompt, "US-001") {
+			t.Errorf("prompt does not list the PRD stories:\n%s", opts.Prompt)
+		}
+		return "feat: add the thing\n\n## Overall Approach\nDid it.", nil
+	}
+	fake := &fakePROpener{}
+	oldOpener := newPROpenerFn
+	newPROpenerFn = func() (pullRequestOpener, error) { return fake, nil }
+	t.Cleanup(func() {
+		invokeClaudeFn = oldInvoke
+		newPROpenerFn = oldOpener
+	})
+
+	return dir, fake
+}
+
+func TestDonePR_OpensPRAndMarksInReview(t *testing.T) {
+	dir, fake := setupDonePR(t)
+	ctx := context.Background()
+	cfg := &config.Config{Repo: config.RepoConfig{Path: dir, DefaultBase: "main"}}
+	wc, err := workspace.ResolveWorkContext("api", "", dir, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	out, err := captureStdout(t, func() error { return donePR(ctx, cfg, wc) })
+	if err != nil {
+		t.Fatalf("donePR: %v", err)
+	}
+	if out != "" {
+		t.Errorf("stdout = %q, want empty so the shell wrapper stays put", out)
+	}
+
+	if len(fake.created) != 1 || fake.created[0] != "ralph/api->main: feat: add the thing" {
+		t.Errorf("created PRs = %v", fake.created)
+	}
+
+	ws, err := workspace.RegistryGet(dir, "api")
+	if err != nil {
+		t.Fatalf("workspace should be kept: %v", err)
+	}
+	if ws.Status != workspace.StatusInReview || ws.PR != 17 {
+		t.Errorf("workspace = %+v, want in review with PR 17", ws)
+	}
+
+	local, _ := gitops.RevParse(ctx, &shell.Runner{Dir: dir}, "ralph/api")
+	remote, err := gitops.RevParse(ctx, &shell.Runner{Dir: dir}, "origin/ralph/api")
+	if err != nil || remote != local {
+		t.Errorf("origin/ralph/api = %s (%v), want pushed %s", remote, err, local)
+	}
+}
+
+func TestDonePR_StackedTargetsParentBranch(t *testing.T) {
+	dir, fake := setupDonePR(t)
+	cfg := &config.Config{Repo: config.RepoConfig{Path: dir, DefaultBase: "main"}}
+	wc, err := workspace.ResolveWorkContext("ui", "", dir, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := captureStdout(t, func() error { return donePR(context.Background(), cfg, wc) }); err != nil {
+		t.Fatalf("donePR: %v", err)
+	}
+	if len(fake.created) != 1 || !strings.HasPrefix(fake.created[0], "ralph/ui->ralph/api:") {
+		t.Errorf("created PRs = %v, want one against ralph/api", fake.created)
+	}
+}
+
+func TestDonePR_ReusesOpenPR(t *testing.T) {
+	dir, fake := setupDonePR(t)
+	fake.existing = &github.PR{Number: 5, HTMLURL: "https://github.com/acme/app/pull/5"}
+	cfg := &config.Config{Repo: config.RepoConfig{Path: dir, DefaultBase: "main"}}
+	wc, err := workspace.ResolveWorkContext("api", "", dir, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := captureStdout(t, func() error { return donePR(context.Background(), cfg, wc) }); err != nil {
+		t.Fatalf("donePR: %v", err)
+	}
+	if len(fake.created) != 0 {
+		t.Errorf("created PRs = %v, want none", fake.created)
+	}
+	ws, _ := workspace.RegistryGet(dir, "api")
+	if ws == nil || ws.PR != 5 {
+		t.Errorf("workspace = %+v, want PR 5", ws)
+	}
+}
+
+func TestDone_PRRejectsMergeFlags(t *testing.T) {
+	for _, flag := range []string{"--strategy=rebase", "--check", "--push"} {
+		err := Done([]string{"--pr", flag})
+		if err == nil || !strings.Contains(err.Error(), "--pr cannot be combined") {
+			t.Errorf("Done(--pr %s) error = %v, want it rejected", flag, err)
+		}
+	}
+}
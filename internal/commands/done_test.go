@@ -1,6 +1,7 @@
 package commands
 
 import (
+	"context"
 	"os"
 	"path/filepath"
 	"strings"
@@ -8,7 +9,11 @@ import (
 	"time"
 
 	"github.com/uesteibar/ralph/internal/config"
+	"github.com/uesteibar/ralph/internal/gitops"
+	"github.com/uesteibar/ralph/internal/hooks"
 	"github.com/uesteibar/ralph/internal/prd"
+	"github.com/uesteibar/ralph/internal/shell"
+	"github.com/uesteibar/ralph/internal/workspace"
 )
 
 func TestGenerateCommitMessage_IncludesDescriptionAndPassingStories(t *testing.T) {
@@ -211,3 +216,146 @@ func TestGenerateCommitMessage_ReadsFromGivenPath(t *testing.T) {
 		t.Errorf("expected passing story, got:\n%s", msg)
 	}
 }
+
+func TestRunBeforeDoneHooks_FailClosedAborts(t *testing.T) {
+	cfg := &config.Config{
+		Hooks: config.HooksConfig{
+			BeforeDone: []config.HookConfig{{Command: "exit 1", OnFailure: config.HookOnFailureAbort}},
+		},
+	}
+
+	err := runBeforeDoneHooks(context.Background(), cfg, hooks.Payload{WorkDir: t.TempDir()})
+	if err == nil {
+		t.Fatal("expected error from fail-closed before_done hook")
+	}
+	if !strings.Contains(err.Error(), "before_done hook") {
+		t.Errorf("error = %q, want before_done hook", err)
+	}
+}
+
+func TestRunBeforeDoneHooks_FailOpenContinues(t *testing.T) {
+	dir := t.TempDir()
+	cfg := &config.Config{
+		Hooks: config.HooksConfig{
+			BeforeDone: []config.HookConfig{
+				{Command: "exit 1"},
+				{Command: `echo "$RALPH_BRANCH" > branch.txt`},
+			},
+		},
+	}
+
+	err := runBeforeDoneHooks(context.Background(), cfg, hooks.Payload{WorkDir: dir, Branch: "ralph/feature"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "branch.txt"))
+	if err != nil {
+		t.Fatalf("expected second hook to run: %v", err)
+	}
+	if got := strings.TrimSpace(string(data)); got != "ralph/feature" {
+		t.Errorf("RALPH_BRANCH = %q, want %q", got, "ralph/feature")
+	}
+}
+
+func TestRetargetChildren_MovesChildOntoSquashedBase(t *testing.T) {
+	dir := setupStack(t)
+	ctx := context.Background()
+	repo := &shell.Runner{Dir: dir}
+
+	// Squash-merge api into main, as ralph done would.
+	if _, err := repo.Run(ctx, "git", "merge", "--squash", "ralph/api"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := repo.Run(ctx, "git", "commit", "-m", "squashed api"); err != nil {
+		t.Fatal(err)
+	}
+
+	retargetChildren(ctx, dir, "api", "ralph/api", "main")
+
+	out, err := repo.Run(ctx, "git", "log", "--format=%s", "ralph/ui")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := strings.Split(strings.TrimSpace(out), "\n")
+	want := []string{"ui work", "squashed api", "initial"}
+	if strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("ui history = %v, want %v", got, want)
+	}
+}
+
+func TestDoneWorkspace_RefusesStackedChild(t *testing.T) {
+	dir := setupStack(t)
+	cfg := &config.Config{Repo: config.RepoConfig{Path: dir, DefaultBase: "main"}}
+	wc, err := workspace.ResolveWorkContext("ui", "", dir, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = doneWorkspace(context.Background(), cfg, wc, doneOptions{}, os.Stdin)
+	if err == nil || !strings.Contains(err.Error(), "stacked on api") {
+		t.Fatalf("expected stacked error, got %v", err)
+	}
+}
+
+func TestLandBranch_CheckFailureRollsBack(t *testing.T) {
+	dir := setupStack(t)
+	ctx := context.Background()
+	repo := &shell.Runner{Dir: dir}
+	before, _ := gitops.RevParse(ctx, repo, "main")
+
+	cfg := &config.Config{Repo: config.RepoConfig{Path: dir, DefaultBase: "main"}, QualityChecks: []string{"test ! -f api.txt"}}
+	err := landBranch(ctx, cfg, repo, dir, "ralph/api", "main", "land api", doneOptions{Strategy: config.DoneStrategyMerge, Check: true})
+	if err == nil || !strings.Contains(err.Error(), "rolled back") {
+		t.Fatalf("expected rolled back error, got %v", err)
+	}
+
+	after, _ := gitops.RevParse(ctx, repo, "main")
+	if after != before {
+		t.Errorf("main = %s, want rolled back to %s", after, before)
+	}
+}
+
+func TestLandBranch_PushesBase(t *testing.T) {
+	dir := setupStack(t)
+	ctx := context.Background()
+	repo := &shell.Runner{Dir: dir}
+
+	cfg := &config.Config{Repo: config.RepoConfig{Path: dir, DefaultBase: "main"}, QualityChecks: []string{"test -f api.txt"}}
+	err := landBranch(ctx, cfg, repo, dir, "ralph/api", "main", "land api", doneOptions{Strategy: config.DoneStrategySquash, Check: true, Push: true})
+	if err != nil {
+		t.Fatalf("landBranch: %v", err)
+	}
+
+	local, _ := gitops.RevParse(ctx, repo, "main")
+	remote, _ := gitops.RevParse(ctx, repo, "origin/main")
+	if local != remote {
+		t.Errorf("origin/main = %s, want pushed %s", remote, local)
+	}
+}
+
+func TestLandBranch_PushFailureRollsBack(t *testing.T) {
+	dir := setupStack(t)
+	ctx := context.Background()
+	repo := &shell.Runner{Dir: dir}
+	before, _ := gitops.RevParse(ctx, repo, "main")
+
+	// Make origin reject every push.
+	originDir, err := repo.Run(ctx, "git", "remote", "get-url", "origin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	hook := filepath.Join(strings.TrimSpace(originDir), "hooks", "pre-receive")
+	if err := os.WriteFile(hook, []byte("#!/bin/sh\nexit 1\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := &config.Config{Repo: config.RepoConfig{Path: dir, DefaultBase: "main"}}
+	err = landBranch(ctx, cfg, repo, dir, "ralph/api", "main", "", doneOptions{Strategy: config.DoneStrategyRebase, Push: true})
+	if err == nil || !strings.Contains(err.Error(), "rolled back") {
+		t.Fatalf("expected rolled back error, got %v", err)
+	}
+	after, _ := gitops.RevParse(ctx, repo, "main")
+	if after != before {
+		t.Errorf("main = %s, want rolled back to %s", after, before)
+	}
+}
@@ -0,0 +1,81 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/uesteibar/ralph/internal/gitops"
+	"github.com/uesteibar/ralph/internal/secrets"
+	"github.com/uesteibar/ralph/internal/shell"
+)
+
+// Hook runs ralph's part of a git hook installed in workspace worktrees. The
+// hook scripts call it as "ralph _hook <name> [args...]" from the worktree
+// root; a non-nil error makes git abort the operation.
+func Hook(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("usage: ralph _hook <name> [args...]")
+	}
+	ctx := context.Background()
+	r := &shell.Runner{}
+
+	switch args[0] {
+	case "pre-commit":
+		return preCommitHook(ctx, r)
+	case "commit-msg":
+		if len(args) < 2 {
+			return fmt.Errorf("commit-msg: missing message file")
+		}
+		return commitMsgHook(ctx, r, args[1])
+	default:
+		// Hook scripts written by another ralph version may name hooks this
+		// binary does not know; they must not block git.
+		return nil
+	}
+}
+
+// preCommitHook blocks commits whose staged changes look like they contain
+// secrets.
+func preCommitHook(ctx context.Context, r *shell.Runner) error {
+	allow, err := secrets.LoadRepoAllowlist(ctx, r)
+	if err != nil {
+		return err
+	}
+	findings, err := secrets.ScanStaged(ctx, r, allow)
+	if err != nil {
+		return err
+	}
+	if len(findings) == 0 {
+		return nil
+	}
+
+	fmt.Fprintln(os.Stderr, "ralph: possible secrets in the staged changes:")
+	for _, f := range findings {
+		fmt.Fprintf(os.Stderr, "  %s\n", f)
+	}
+	fmt.Fprintf(os.Stderr, "Remove them, or add false positives to .ralph/%s.\n", secrets.AllowlistFile)
+	return fmt.Errorf("commit blocked: %d possible secret(s)", len(findings))
+}
+
+// commitMsgHook adds a Ralph-Story trailer naming the story the loop is
+// working on or, outside the loop, the story in a "feat(<id>): ..." subject.
+func commitMsgHook(ctx context.Context, r *shell.Runner, msgFile string) error {
+	storyID, err := gitops.CurrentStory(ctx, r)
+	if err != nil {
+		return err
+	}
+	if storyID == "" {
+		data, err := os.ReadFile(msgFile)
+		if err != nil {
+			return err
+		}
+		subject, _, _ := strings.Cut(string(data), "\n")
+		storyID = gitops.StoryIDFromSubject(subject)
+	}
+	if storyID == "" {
+		return nil
+	}
+	return gitops.AddStoryTrailer(ctx, r, msgFile, storyID)
+}
@@ -0,0 +1,75 @@
+package commands
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/uesteibar/ralph/internal/gitops"
+)
+
+func TestPreCommitHook_BlocksStagedSecrets(t *testing.T) {
+	dir := realPath(t, t.TempDir())
+	r := initTestRepo(t, dir)
+	ctx := context.Background()
+
+	key := "AKIA" + "IOSFODNN7EXAMPLE"
+	os.WriteFile(filepath.Join(dir, "config.go"), []byte(`const key = "`+key+`"`+"\n"), 0644)
+	if _, err := r.Run(ctx, "git", "add", "-A"); err != nil {
+		t.Fatal(err)
+	}
+
+	err := preCommitHook(ctx, r)
+	if err == nil || !strings.Contains(err.Error(), "commit blocked: 1 possible secret(s)") {
+		t.Fatalf("err = %v, want the commit blocked", err)
+	}
+
+	os.MkdirAll(filepath.Join(dir, ".ralph"), 0755)
+	os.WriteFile(filepath.Join(dir, ".ralph", "secrets-allowlist"), []byte("EXAMPLE$\n"), 0644)
+	if err := preCommitHook(ctx, r); err != nil {
+		t.Errorf("allowlisted key should pass, got %v", err)
+	}
+}
+
+func TestHook_UnknownHookIsNoop(t *testing.T) {
+	if err := Hook([]string{"post-checkout", "a", "b", "1"}); err != nil {
+		t.Errorf("Hook = %v, want nil", err)
+	}
+}
+
+func TestCommitMsgHook_AddsStoryTrailer(t *testing.T) {
+	dir := realPath(t, t.TempDir())
+	r := initTestRepo(t, dir)
+	ctx := context.Background()
+	msg := filepath.Join(t.TempDir(), "COMMIT_EDITMSG")
+
+	// Outside the loop the story comes from the subject.
+	os.WriteFile(msg, []byte("feat(US-001): Login form\n"), 0644)
+	if err := commitMsgHook(ctx, r, msg); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(msg); !strings.Contains(string(data), "\nRalph-Story: US-001\n") {
+		t.Errorf("message = %q, want the trailer", data)
+	}
+
+	// During the loop the current story wins, for any subject.
+	if err := gitops.SetCurrentStory(ctx, r, "US-002"); err != nil {
+		t.Fatal(err)
+	}
+	os.WriteFile(msg, []byte("fix: lint\n"), 0644)
+	if err := commitMsgHook(ctx, r, msg); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(msg); !strings.Contains(string(data), "Ralph-Story: US-002") {
+		t.Errorf("message = %q, want the current story's trailer", data)
+	}
+
+	gitops.SetCurrentStory(ctx, r, "")
+	os.WriteFile(msg, []byte("docs: readme\n"), 0644)
+	commitMsgHook(ctx, r, msg)
+	if data, _ := os.ReadFile(msg); strings.Contains(string(data), "Ralph-Story") {
+		t.Errorf("message = %q, want no trailer outside a story", data)
+	}
+}
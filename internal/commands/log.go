@@ -0,0 +1,123 @@
+package commands
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/uesteibar/ralph/internal/config"
+	"github.com/uesteibar/ralph/internal/gitops"
+	"github.com/uesteibar/ralph/internal/prd"
+	"github.com/uesteibar/ralph/internal/shell"
+	"github.com/uesteibar/ralph/internal/workspace"
+)
+
+// Log handles `ralph log`: it lists the PRD's stories with the commits that
+// implemented each one.
+func Log(args []string) error {
+	return logRun(args, os.Stdout)
+}
+
+func logRun(args []string, w io.Writer) error {
+	fs := flag.NewFlagSet("log", flag.ExitOnError)
+	configPath := AddProjectConfigFlag(fs)
+	workspaceFlag := AddWorkspaceFlag(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	cfg, err := ResolveConfig(*configPath)
+	if err != nil {
+		return fmt.Errorf("resolving config: %w", err)
+	}
+	wc, err := resolveWorkContextFromFlags(*workspaceFlag, cfg.Repo.Path)
+	if err != nil {
+		return fmt.Errorf("resolving workspace context: %w", err)
+	}
+
+	p, err := prd.Read(wc.PRDPath)
+	if err != nil {
+		return err
+	}
+	recorded, err := prd.ReadCommits(prd.CommitsPath(wc.PRDPath))
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	r := &shell.Runner{Dir: wc.WorkDir}
+	base := storyBaseRef(ctx, cfg, wc)
+
+	for _, s := range p.UserStories {
+		mark := failStyle.Render("✗")
+		if s.Passes {
+			mark = passStyle.Render("✓")
+		}
+		fmt.Fprintf(w, "%s %s %s\n", mark, labelStyle.Render(s.ID), s.Title)
+
+		ranges, ok := recorded[s.ID]
+		commits, err := resolveStoryCommits(ctx, r, ranges, ok, base, s.ID)
+		if err != nil {
+			return err
+		}
+		if len(commits) == 0 {
+			fmt.Fprintf(w, "  %s\n", hintStyle.Render("no commits"))
+		}
+		for _, c := range commits {
+			fmt.Fprintf(w, "  %s %s\n", valueStyle.Render(c.SHA[:min(len(c.SHA), 12)]), c.Subject)
+		}
+	}
+	return nil
+}
+
+// resolveStoryCommits returns the commits of storyID, oldest first. The
+// ranges the loop recorded are used while they are still on the branch.
+// When nothing was recorded (recorded is false), or a rebase rewrote the
+// recorded commits, they are looked up by their Ralph-Story trailer or loop
+// subject since base instead.
+func resolveStoryCommits(ctx context.Context, r *shell.Runner, ranges []prd.CommitRange, recorded bool, base, storyID string) ([]gitops.CommitSummary, error) {
+	onBranch := true
+	for _, cr := range ranges {
+		if ok, err := gitops.IsAncestor(ctx, r, cr.End, "HEAD"); err != nil || !ok {
+			onBranch = false
+			break
+		}
+	}
+
+	if recorded && onBranch {
+		var commits []gitops.CommitSummary
+		for _, cr := range ranges {
+			cs, err := gitops.CommitsInRange(ctx, r, cr.Start, cr.End)
+			if err != nil {
+				return nil, err
+			}
+			commits = append(commits, cs...)
+		}
+		return commits, nil
+	}
+	if base == "" {
+		return nil, nil
+	}
+	return gitops.StoryCommits(ctx, r, base, storyID)
+}
+
+// storyBaseRef returns the ref a workspace branched from: its parent's
+// branch when stacked, otherwise the default base, preferring origin's copy.
+// It returns "" in base mode, where there is no separate branch.
+func storyBaseRef(ctx context.Context, cfg *config.Config, wc workspace.WorkContext) string {
+	if wc.Name == "base" {
+		return ""
+	}
+	if ws, err := workspace.RegistryGet(cfg.Repo.Path, wc.Name); err == nil && ws.Parent != "" {
+		if parent, err := workspace.RegistryGet(cfg.Repo.Path, ws.Parent); err == nil {
+			return parent.Branch
+		}
+	}
+	r := &shell.Runner{Dir: wc.WorkDir}
+	if _, err := gitops.RevParse(ctx, r, "origin/"+cfg.Repo.DefaultBase); err == nil {
+		return "origin/" + cfg.Repo.DefaultBase
+	}
+	return cfg.Repo.DefaultBase
+}
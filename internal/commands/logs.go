@@ -0,0 +1,329 @@
+package commands
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/uesteibar/ralph/internal/events"
+	"github.com/uesteibar/ralph/internal/tui"
+	"github.com/uesteibar/ralph/internal/workspace"
+)
+
+const (
+	// qaStory is what --story matches for events of the QA phases.
+	qaStory = "QA"
+	// maxReplayGap caps the pause between two replayed events, so idle
+	// stretches such as usage-limit waits don't stall the replay.
+	maxReplayGap = 3 * time.Second
+	// untimedReplayGap is the pause before events logged without a time,
+	// whatever the speed.
+	untimedReplayGap = 100 * time.Millisecond
+)
+
+// Logs handles `ralph logs`: it searches the event logs of a workspace, or
+// plays them back in the TUI with `ralph logs replay`.
+func Logs(args []string) error {
+	if len(args) > 0 && args[0] == "replay" {
+		return logsReplay(args[1:])
+	}
+	return logsRun(args, os.Stdout)
+}
+
+// logFilter selects logged events by story, type, content and time.
+type logFilter struct {
+	story string
+	types map[string]bool
+	grep  *regexp.Regexp
+	since time.Time
+
+	// current is the story the events being read belong to.
+	current string
+}
+
+// addLogFilterFlags registers the flags that narrow down which events are
+// shown and returns a function that builds the filter once they are parsed.
+func addLogFilterFlags(fs *flag.FlagSet) func(now time.Time) (*logFilter, error) {
+	story := fs.String("story", "", "Only events of this story (QA for the QA phases)")
+	types := fs.String("type", "", "Only events of these comma-separated types, e.g. ToolUse,ToolResult")
+	grep := fs.String("grep", "", "Only events whose JSON matches this regular expression")
+	since := fs.String("since", "", "Only events logged since a duration ago (e.g. 2h, 3d) or a date/RFC 3339 time")
+
+	return func(now time.Time) (*logFilter, error) {
+		f := &logFilter{story: *story}
+		if *types != "" {
+			f.types = make(map[string]bool)
+			for _, t := range strings.Split(*types, ",") {
+				f.types[normalizeEventType(t)] = true
+			}
+		}
+		if *grep != "" {
+			re, err := regexp.Compile(*grep)
+			if err != nil {
+				return nil, fmt.Errorf("invalid --grep pattern: %w", err)
+			}
+			f.grep = re
+		}
+		if *since != "" {
+			t, err := parseSince(*since, now)
+			if err != nil {
+				return nil, err
+			}
+			f.since = t
+		}
+		return f, nil
+	}
+}
+
+// normalizeEventType lets --type accept both Go names (ToolUse) and log
+// names (tool_use).
+func normalizeEventType(s string) string {
+	return strings.ToLower(strings.ReplaceAll(strings.TrimSpace(s), "_", ""))
+}
+
+// parseSince parses --since: a duration before now (90m, 2h, 3d), a date
+// (2006-01-02) or an RFC 3339 time.
+func parseSince(s string, now time.Time) (time.Time, error) {
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		if n, err := strconv.Atoi(days); err == nil {
+			return now.Add(-time.Duration(n) * 24 * time.Hour), nil
+		}
+	}
+	if d, err := time.ParseDuration(s); err == nil {
+		return now.Add(-d), nil
+	}
+	if t, err := time.ParseInLocation("2006-01-02", s, time.Local); err == nil {
+		return t, nil
+	}
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("invalid --since %q: use a duration (2h, 3d), a date (2006-01-02) or an RFC 3339 time", s)
+}
+
+// match reports whether rec passes the filter. Records must be passed in
+// log order: story and QA phase events set the story of those that follow.
+// Events logged without a time never match --since.
+func (f *logFilter) match(rec events.Record) bool {
+	switch e := rec.Event.(type) {
+	case events.StoryStarted:
+		f.current = e.StoryID
+	case events.QAPhaseStarted:
+		f.current = qaStory
+	}
+
+	if f.story != "" && !strings.EqualFold(f.current, f.story) {
+		return false
+	}
+	if !f.since.IsZero() && rec.Time.Before(f.since) {
+		return false
+	}
+	if f.types != nil {
+		name, err := events.TypeName(rec.Event)
+		if err != nil || !f.types[normalizeEventType(name)] {
+			return false
+		}
+	}
+	if f.grep != nil {
+		data, err := events.MarshalEvent(rec.Event)
+		if err != nil || !f.grep.Match(data) {
+			return false
+		}
+	}
+	return true
+}
+
+// logPrinter writes matching records as styled text or, with asJSON, as
+// JSONL in the log format.
+type logPrinter struct {
+	w       io.Writer
+	asJSON  bool
+	handler *events.PlainTextHandler
+}
+
+func newLogPrinter(w io.Writer, asJSON bool) *logPrinter {
+	return &logPrinter{w: w, asJSON: asJSON, handler: &events.PlainTextHandler{W: w}}
+}
+
+func (p *logPrinter) print(rec events.Record) {
+	if !p.asJSON {
+		p.handler.Handle(rec.Event)
+		return
+	}
+	var data []byte
+	var err error
+	if rec.Time.IsZero() {
+		data, err = events.MarshalEvent(rec.Event)
+	} else {
+		data, err = events.MarshalEventAt(rec.Event, rec.Time)
+	}
+	if err != nil {
+		return
+	}
+	fmt.Fprintf(p.w, "%s\n", data)
+}
+
+func logsRun(args []string, w io.Writer) error {
+	fs := flag.NewFlagSet("logs", flag.ExitOnError)
+	configPath := AddProjectConfigFlag(fs)
+	workspaceFlag := AddWorkspaceFlag(fs)
+	buildFilter := addLogFilterFlags(fs)
+	asJSON := fs.Bool("json", false, "Print matching events as JSONL")
+	follow := fs.Bool("follow", false, "Keep printing events as they are logged")
+	fs.BoolVar(follow, "f", false, "Shorthand for --follow")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	filter, err := buildFilter(time.Now())
+	if err != nil {
+		return err
+	}
+
+	logsDir, err := resolveLogsDir(*configPath, *workspaceFlag)
+	if err != nil {
+		return err
+	}
+
+	printer := newLogPrinter(w, *asJSON)
+
+	if *follow {
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+		defer stop()
+		followLogs(ctx, logsDir, filter, printer)
+		return nil
+	}
+
+	if _, err := os.Stat(logsDir); os.IsNotExist(err) {
+		return fmt.Errorf("no logs found at %s", logsDir)
+	}
+	return events.ReadRecords(logsDir, func(rec events.Record) bool {
+		if filter.match(rec) {
+			printer.print(rec)
+		}
+		return true
+	})
+}
+
+// followLogs prints the matching events already logged, then those logged
+// from now on, until ctx is cancelled.
+func followLogs(ctx context.Context, logsDir string, filter *logFilter, printer *logPrinter) {
+	reader := events.NewRecordReader(logsDir)
+	go reader.Run(ctx)
+	for rec := range reader.Records() {
+		if filter.match(rec) {
+			printer.print(rec)
+		}
+	}
+}
+
+// resolveLogsDir returns the logs directory of the workspace named by the
+// --workspace flag, or of the current one.
+func resolveLogsDir(configPath, workspaceFlag string) (string, error) {
+	cfg, err := ResolveConfig(configPath)
+	if err != nil {
+		return "", fmt.Errorf("resolving config: %w", err)
+	}
+	wc, err := resolveWorkContextFromFlags(workspaceFlag, cfg.Repo.Path)
+	if err != nil {
+		return "", fmt.Errorf("resolving workspace context: %w", err)
+	}
+	return filepath.Join(workspace.WorkspacePath(cfg.Repo.Path, wc.Name), "logs"), nil
+}
+
+func logsReplay(args []string) error {
+	fs := flag.NewFlagSet("logs replay", flag.ExitOnError)
+	configPath := AddProjectConfigFlag(fs)
+	workspaceFlag := AddWorkspaceFlag(fs)
+	buildFilter := addLogFilterFlags(fs)
+	speed := fs.Float64("speed", 1, "Playback speed multiplier, e.g. 10 for ten times faster")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *speed <= 0 {
+		return fmt.Errorf("--speed must be greater than 0")
+	}
+
+	filter, err := buildFilter(time.Now())
+	if err != nil {
+		return err
+	}
+
+	cfg, err := ResolveConfig(*configPath)
+	if err != nil {
+		return fmt.Errorf("resolving config: %w", err)
+	}
+	wc, err := resolveWorkContextFromFlags(*workspaceFlag, cfg.Repo.Path)
+	if err != nil {
+		return fmt.Errorf("resolving workspace context: %w", err)
+	}
+	logsDir := filepath.Join(workspace.WorkspacePath(cfg.Repo.Path, wc.Name), "logs")
+
+	var recs []events.Record
+	if err := events.ReadRecords(logsDir, func(rec events.Record) bool {
+		if filter.match(rec) {
+			recs = append(recs, rec)
+		}
+		return true
+	}); err != nil {
+		return err
+	}
+	if len(recs) == 0 {
+		return fmt.Errorf("no logged events to replay for workspace %s", wc.Name)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	model := tui.NewModel(wc.Name, wc.PRDPath)
+	// There is no daemon behind a replay: stopping just ends the playback.
+	model.SetStopDaemonFn(cancel)
+	p := tea.NewProgram(model, tea.WithAltScreen())
+
+	go replayRecords(ctx, recs, *speed, tui.NewHandler(p))
+
+	if _, err := p.Run(); err != nil {
+		return fmt.Errorf("running TUI: %w", err)
+	}
+	return nil
+}
+
+// replayRecords hands recs to h, spaced out as they were logged divided by
+// speed, until they run out or ctx is cancelled.
+func replayRecords(ctx context.Context, recs []events.Record, speed float64, h events.EventHandler) {
+	var prev time.Time
+	for _, rec := range recs {
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(replayGap(prev, rec.Time, speed)):
+		}
+		h.Handle(rec.Event)
+		if !rec.Time.IsZero() {
+			prev = rec.Time
+		}
+	}
+}
+
+// replayGap returns how long to wait before replaying an event logged at t
+// when the previous one was logged at prev.
+func replayGap(prev, t time.Time, speed float64) time.Duration {
+	switch {
+	case t.IsZero():
+		return untimedReplayGap
+	case prev.IsZero():
+		// The first timed event plays right away.
+		return 0
+	}
+	gap := time.Duration(float64(max(t.Sub(prev), 0)) / speed)
+	return min(gap, maxReplayGap)
+}
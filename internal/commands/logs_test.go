@@ -0,0 +1,132 @@
+package commands
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/uesteibar/ralph/internal/events"
+	"github.com/uesteibar/ralph/internal/workspace"
+)
+
+// writeLoggedRun logs a short run of workspace a: two stories and a QA
+// phase, an hour apart from each other.
+func writeLoggedRun(t *testing.T, dir string, start time.Time) {
+	t.Helper()
+	logsDir := filepath.Join(workspace.WorkspacePath(dir, "a"), "logs")
+	os.MkdirAll(logsDir, 0755)
+	f, err := os.Create(filepath.Join(logsDir, "startup-20260206T120000Z.jsonl"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	at := start
+	for _, e := range []events.Event{
+		events.StoryStarted{StoryID: "US-001", Title: "First"},
+		events.ToolUse{Name: "Bash", Detail: "go test ./..."},
+		events.StoryStarted{StoryID: "US-002", Title: "Second"},
+		events.ToolUse{Name: "Read", Detail: "main.go"},
+		events.ToolUse{Name: "Bash", Detail: "make lint"},
+		events.QAPhaseStarted{Phase: "fix"},
+		events.ToolResult{Name: "Bash", Detail: "go test ./...", IsError: true, Output: "FAIL"},
+	} {
+		data, err := events.MarshalEventAt(e, at)
+		if err != nil {
+			t.Fatal(err)
+		}
+		f.Write(append(data, '\n'))
+		at = at.Add(time.Hour)
+	}
+}
+
+func TestLogs_FiltersByStoryAndType(t *testing.T) {
+	dir := setupVariantSource(t)
+	writeLoggedRun(t, dir, time.Now().Add(-24*time.Hour))
+
+	var out bytes.Buffer
+	if err := logsRun([]string{"--workspace", "a", "--story", "US-002", "--type", "ToolUse", "--json"}, &out); err != nil {
+		t.Fatalf("logs: %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 events, got %d:\n%s", len(lines), out.String())
+	}
+	if !strings.Contains(lines[0], `"main.go"`) || !strings.Contains(lines[1], `"make lint"`) {
+		t.Errorf("unexpected events:\n%s", out.String())
+	}
+	if !strings.Contains(lines[0], `"time":`) {
+		t.Errorf("expected the JSON output to keep timestamps: %s", lines[0])
+	}
+}
+
+func TestLogs_GrepAndQAStory(t *testing.T) {
+	dir := setupVariantSource(t)
+	writeLoggedRun(t, dir, time.Now().Add(-24*time.Hour))
+
+	var out bytes.Buffer
+	if err := logsRun([]string{"--workspace", "a", "--story", "qa", "--grep", "FAIL"}, &out); err != nil {
+		t.Fatalf("logs: %v", err)
+	}
+	if !strings.Contains(out.String(), "failed:") || strings.Contains(out.String(), "main.go") {
+		t.Errorf("output = %q", out.String())
+	}
+}
+
+func TestLogs_Since(t *testing.T) {
+	dir := setupVariantSource(t)
+	writeLoggedRun(t, dir, time.Now().Add(-7*time.Hour))
+
+	var out bytes.Buffer
+	if err := logsRun([]string{"--workspace", "a", "--since", "90m", "--json"}, &out); err != nil {
+		t.Fatalf("logs: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 1 || !strings.Contains(lines[0], "tool_result") {
+		t.Errorf("expected only the last event, got:\n%s", out.String())
+	}
+}
+
+func TestParseSince(t *testing.T) {
+	now := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+	tests := map[string]time.Time{
+		"2h":                   now.Add(-2 * time.Hour),
+		"3d":                   now.Add(-72 * time.Hour),
+		"2026-03-01T08:00:00Z": time.Date(2026, 3, 1, 8, 0, 0, 0, time.UTC),
+	}
+	for in, want := range tests {
+		got, err := parseSince(in, now)
+		if err != nil || !got.Equal(want) {
+			t.Errorf("parseSince(%q) = %v, %v; want %v", in, got, err, want)
+		}
+	}
+	if _, err := parseSince("yesterday", now); err == nil {
+		t.Error("expected an error for an unparseable --since")
+	}
+}
+
+func TestReplayGap(t *testing.T) {
+	t0 := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name      string
+		prev, cur time.Time
+		speed     float64
+		want      time.Duration
+	}{
+		{"first event", time.Time{}, t0, 1, 0},
+		{"scaled by speed", t0, t0.Add(2 * time.Second), 4, 500 * time.Millisecond},
+		{"capped", t0, t0.Add(time.Hour), 1, maxReplayGap},
+		{"untimed", t0, time.Time{}, 10, untimedReplayGap},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := replayGap(tt.prev, tt.cur, tt.speed); got != tt.want {
+				t.Errorf("replayGap = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,144 @@
+package commands
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/uesteibar/ralph/internal/config"
+	"github.com/uesteibar/ralph/internal/events"
+	"github.com/uesteibar/ralph/internal/runstate"
+	"github.com/uesteibar/ralph/internal/workspace"
+)
+
+// logRetention turns the logs: config into the policy applied to a
+// workspace's logs directory.
+func logRetention(c config.LogsConfig) events.Retention {
+	const mb = 1 << 20
+	return events.Retention{
+		MaxFileSize:  int64(c.MaxFileSizeMB) * mb,
+		MaxTotalSize: int64(c.MaxTotalSizeMB) * mb,
+		MaxAge:       time.Duration(c.MaxAgeDays) * 24 * time.Hour,
+		Compress:     true,
+	}
+}
+
+func workspacesGC(args []string) error {
+	fs := flag.NewFlagSet("workspaces gc", flag.ExitOnError)
+	configPath := AddProjectConfigFlag(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	// Workspace names may be mixed with flags.
+	var names []string
+	for remaining := fs.Args(); len(remaining) > 0; remaining = fs.Args() {
+		names = append(names, remaining[0])
+		if err := fs.Parse(remaining[1:]); err != nil {
+			return err
+		}
+	}
+
+	cfg, err := ResolveConfig(*configPath)
+	if err != nil {
+		return fmt.Errorf("resolving config: %w", err)
+	}
+
+	return gcLogs(cfg, names, time.Now(), os.Stderr)
+}
+
+// gcLogs applies the logs: policy to the named workspaces, or to every
+// workspace, base and the logs of ralph check in base when names is empty.
+func gcLogs(cfg *config.Config, names []string, now time.Time, w io.Writer) error {
+	type target struct {
+		name    string
+		logsDir string
+		running bool
+	}
+	var targets []target
+
+	if len(names) == 0 {
+		targets = append(targets, target{name: "base checks", logsDir: filepath.Join(cfg.Repo.Path, ".ralph", "logs")})
+		entries, err := workspace.RegistryList(cfg.Repo.Path)
+		if err != nil {
+			return fmt.Errorf("reading workspace registry: %w", err)
+		}
+		// The base loop keeps its state and logs like a workspace does.
+		names = append(names, "base")
+		for _, e := range entries {
+			names = append(names, e.Name)
+		}
+	} else {
+		for _, name := range names {
+			if _, err := workspace.RegistryGet(cfg.Repo.Path, name); err != nil {
+				return fmt.Errorf("Workspace %q not found. Run ralph workspaces list to see available.", name)
+			}
+		}
+	}
+	for _, name := range names {
+		wsPath := workspace.WorkspacePath(cfg.Repo.Path, name)
+		targets = append(targets, target{
+			name:    name,
+			logsDir: filepath.Join(wsPath, "logs"),
+			running: runstate.IsRunning(wsPath),
+		})
+	}
+
+	retention := logRetention(cfg.Logs)
+	cleaned := 0
+	for _, t := range targets {
+		// A running loop is still writing its newest event log.
+		active := ""
+		if t.running {
+			active = newestLog(t.logsDir)
+		}
+		res, err := events.PruneLogs(t.logsDir, retention, active, now)
+		if err != nil {
+			return fmt.Errorf("cleaning up logs of '%s': %w", t.name, err)
+		}
+		if res.Compressed == 0 && res.Removed == 0 {
+			continue
+		}
+		cleaned++
+		fmt.Fprintf(w, "✓ %s: compressed %d, removed %d, freed %s\n", t.name, res.Compressed, res.Removed, formatSize(res.Freed))
+	}
+	if cleaned == 0 {
+		fmt.Fprintln(w, "No logs to clean up.")
+	}
+	return nil
+}
+
+// newestLog returns the most recently modified event log in logsDir.
+func newestLog(logsDir string) string {
+	var newest string
+	var newestTime time.Time
+	for _, path := range events.LogFiles(logsDir) {
+		info, err := os.Stat(path)
+		if err != nil {
+			continue
+		}
+		if newest == "" || info.ModTime().After(newestTime) {
+			newest, newestTime = path, info.ModTime()
+		}
+	}
+	return newest
+}
+
+// formatSize formats a byte count for humans, e.g. 3.2 MB.
+func formatSize(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	value := float64(n) / unit
+	for _, suffix := range []string{"KB", "MB", "GB"} {
+		if value < unit {
+			return fmt.Sprintf("%.1f %s", value, suffix)
+		}
+		value /= unit
+	}
+	return fmt.Sprintf("%.1f TB", value)
+}
@@ -0,0 +1,120 @@
+package commands
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/uesteibar/ralph/internal/events"
+	"github.com/uesteibar/ralph/internal/runstate"
+	"github.com/uesteibar/ralph/internal/workspace"
+)
+
+func TestGCLogs_CompressesAndExpires(t *testing.T) {
+	dir := setupVariantSource(t)
+	cfg, err := ResolveConfig("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg.Logs.MaxAgeDays = 7
+
+	now := time.Now()
+	logsDir := filepath.Join(workspace.WorkspacePath(dir, "a"), "logs")
+	os.MkdirAll(logsDir, 0755)
+	write := func(name string, age time.Duration) string {
+		path := filepath.Join(logsDir, name)
+		data, _ := events.MarshalEvent(events.IterationStart{Iteration: 1, MaxIterations: 5})
+		os.WriteFile(path, append(data, '\n'), 0644)
+		os.Chtimes(path, now.Add(-age), now.Add(-age))
+		return path
+	}
+	expired := write("US-001-20260101T000000Z.jsonl", 10*24*time.Hour)
+	recent := write("US-002-20260110T000000Z.jsonl", time.Hour)
+
+	var out bytes.Buffer
+	if err := gcLogs(cfg, nil, now, &out); err != nil {
+		t.Fatalf("gcLogs: %v", err)
+	}
+
+	if _, err := os.Stat(expired + ".gz"); !os.IsNotExist(err) {
+		t.Error("expected the expired log to be removed")
+	}
+	if _, err := os.Stat(recent + ".gz"); err != nil {
+		t.Errorf("expected the recent log to be compressed: %v", err)
+	}
+	if !strings.Contains(out.String(), "✓ a: compressed 2, removed 1") {
+		t.Errorf("output = %q", out.String())
+	}
+
+	out.Reset()
+	if err := gcLogs(cfg, []string{"a"}, now, &out); err != nil {
+		t.Fatalf("second gcLogs: %v", err)
+	}
+	if !strings.Contains(out.String(), "No logs to clean up.") {
+		t.Errorf("output = %q", out.String())
+	}
+}
+
+func TestGCLogs_Base_KeepsRunningLoopLog(t *testing.T) {
+	dir := setupVariantSource(t)
+	cfg, err := ResolveConfig("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg.Logs.MaxAgeDays = 7
+
+	now := time.Now()
+	basePath := workspace.WorkspacePath(dir, "base")
+	logsDir := filepath.Join(basePath, "logs")
+	os.MkdirAll(logsDir, 0755)
+	write := func(name string, age time.Duration) string {
+		path := filepath.Join(logsDir, name)
+		data, _ := events.MarshalEvent(events.IterationStart{Iteration: 1, MaxIterations: 5})
+		os.WriteFile(path, append(data, '\n'), 0644)
+		os.Chtimes(path, now.Add(-age), now.Add(-age))
+		return path
+	}
+	old := write("US-001-20260101T000000Z.jsonl", time.Hour)
+	active := write("US-002-20260110T000000Z.jsonl", time.Minute)
+	// This test process stands in for the running base loop.
+	if err := runstate.WritePID(basePath); err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	if err := gcLogs(cfg, nil, now, &out); err != nil {
+		t.Fatalf("gcLogs: %v", err)
+	}
+
+	if _, err := os.Stat(old + ".gz"); err != nil {
+		t.Errorf("expected the finished base log to be compressed: %v", err)
+	}
+	if _, err := os.Stat(active); err != nil {
+		t.Errorf("expected the running loop's log to be left alone: %v", err)
+	}
+	if !strings.Contains(out.String(), "✓ base: compressed 1") {
+		t.Errorf("output = %q", out.String())
+	}
+}
+
+func TestGCLogs_UnknownWorkspace(t *testing.T) {
+	setupVariantSource(t)
+	cfg, err := ResolveConfig("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := gcLogs(cfg, []string{"nope"}, time.Now(), &bytes.Buffer{}); err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Fatalf("expected not found error, got %v", err)
+	}
+}
+
+func TestFormatSize(t *testing.T) {
+	for n, want := range map[int64]string{512: "512 B", 2048: "2.0 KB", 5 << 20: "5.0 MB"} {
+		if got := formatSize(n); got != want {
+			t.Errorf("formatSize(%d) = %q, want %q", n, got, want)
+		}
+	}
+}
@@ -8,20 +8,25 @@ import (
 	"strings"
 
 	"github.com/uesteibar/ralph/internal/claude"
+	"github.com/uesteibar/ralph/internal/config"
 	"github.com/uesteibar/ralph/internal/gitops"
 	"github.com/uesteibar/ralph/internal/prd"
 	"github.com/uesteibar/ralph/internal/prompts"
+	"github.com/uesteibar/ralph/internal/sandbox"
 	"github.com/uesteibar/ralph/internal/shell"
 	"github.com/uesteibar/ralph/internal/workspace"
 )
 
 // Rebase rebases the current workspace branch onto the latest changes from a
-// target branch (defaulting to the configured default_base). If conflicts
+// target branch (defaulting to the parent workspace's branch for stacked
+// workspaces, and to the configured default_base otherwise). With --stack it
+// rebases every workspace in the current stack, parents first. If conflicts
 // occur, Claude is invoked interactively to resolve them.
 func Rebase(args []string) error {
 	fs := flag.NewFlagSet("rebase", flag.ExitOnError)
 	configPath := AddProjectConfigFlag(fs)
 	workspaceFlag := AddWorkspaceFlag(fs)
+	stack := fs.Bool("stack", false, "Rebase every workspace in the current stack, parents first")
 	if err := fs.Parse(args); err != nil {
 		return err
 	}
@@ -43,8 +48,28 @@ func Rebase(args []string) error {
 	}
 
 	ctx := context.Background()
+
+	if *stack {
+		if fs.NArg() > 0 {
+			return fmt.Errorf("--stack does not take a target branch")
+		}
+		return rebaseStack(ctx, cfg, wc.Name)
+	}
+
 	r := &shell.Runner{Dir: wc.WorkDir}
 
+	// A stacked workspace follows its parent's local branch by default.
+	if fs.NArg() == 0 {
+		if ws, err := workspace.RegistryGet(cfg.Repo.Path, wc.Name); err == nil && ws.Parent != "" {
+			parent, err := workspace.RegistryGet(cfg.Repo.Path, ws.Parent)
+			if err != nil {
+				return fmt.Errorf("parent workspace: %w", err)
+			}
+			fmt.Fprintf(os.Stderr, "rebasing onto %s (workspace %s)\n", parent.Branch, parent.Name)
+			return rebaseOnto(ctx, r, wc, parent.Branch, "", cfg)
+		}
+	}
+
 	targetBranch := cfg.Repo.DefaultBase
 	if fs.NArg() > 0 {
 		targetBranch = fs.Arg(0)
@@ -56,7 +81,69 @@ func Rebase(args []string) error {
 	}
 
 	fmt.Fprintf(os.Stderr, "rebasing onto origin/%s\n", targetBranch)
-	result, err := gitops.StartRebase(ctx, r, "origin/"+targetBranch)
+	return rebaseOnto(ctx, r, wc, "origin/"+targetBranch, "", cfg)
+}
+
+// rebaseStack rebases every workspace in the stack containing name. The root
+// goes onto origin/<default_base>; each child is then moved onto its freshly
+// rebased parent, replaying only the commits after the parent's old tip.
+func rebaseStack(ctx context.Context, cfg *config.Config, name string) error {
+	members, err := workspace.Stack(cfg.Repo.Path, name)
+	if err != nil {
+		return fmt.Errorf("resolving stack: %w", err)
+	}
+
+	baseBranch := cfg.Repo.DefaultBase
+	repoRunner := &shell.Runner{Dir: cfg.Repo.Path}
+	fmt.Fprintf(os.Stderr, "fetching origin/%s\n", baseBranch)
+	if err := gitops.FetchBranch(ctx, repoRunner, baseBranch); err != nil {
+		return err
+	}
+
+	byName := make(map[string]workspace.Workspace, len(members))
+	oldTips := make(map[string]string, len(members))
+	for _, ws := range members {
+		byName[ws.Name] = ws
+		tip, err := gitops.RevParse(ctx, repoRunner, ws.Branch)
+		if err != nil {
+			return fmt.Errorf("workspace %s: %w", ws.Name, err)
+		}
+		oldTips[ws.Name] = tip
+	}
+
+	for _, ws := range members {
+		wc, err := resolveWorkContextFromFlags(ws.Name, cfg.Repo.Path)
+		if err != nil {
+			return fmt.Errorf("resolving workspace %s: %w", ws.Name, err)
+		}
+		r := &shell.Runner{Dir: wc.WorkDir}
+
+		onto, upstream := "origin/"+baseBranch, ""
+		if parent, ok := byName[ws.Parent]; ok {
+			onto, upstream = parent.Branch, oldTips[parent.Name]
+		}
+
+		fmt.Fprintf(os.Stderr, "rebasing %s onto %s\n", ws.Name, onto)
+		if err := rebaseOnto(ctx, r, wc, onto, upstream, cfg); err != nil {
+			return fmt.Errorf("rebasing workspace %s: %w", ws.Name, err)
+		}
+	}
+
+	fmt.Fprintf(os.Stderr, "rebased %d workspace(s) in the stack\n", len(members))
+	return nil
+}
+
+// rebaseOnto rebases the branch checked out in r onto the given ref. When
+// upstream is set, only commits after it are replayed (git rebase --onto).
+// Conflicts are handed to Claude until the rebase completes.
+func rebaseOnto(ctx context.Context, r *shell.Runner, wc workspace.WorkContext, onto, upstream string, cfg *config.Config) error {
+	var result gitops.RebaseResult
+	var err error
+	if upstream == "" {
+		result, err = gitops.StartRebase(ctx, r, onto)
+	} else {
+		result, err = gitops.StartRebaseOnto(ctx, r, onto, upstream)
+	}
 	if err != nil {
 		return err
 	}
@@ -66,12 +153,8 @@ func Rebase(args []string) error {
 		return nil
 	}
 
-	promptsDir := cfg.PromptsDir()
-
-	qualityChecks := cfg.QualityChecks
-
 	for result.HasConflicts {
-		if err := resolveConflicts(ctx, r, wc, targetBranch, promptsDir, qualityChecks); err != nil {
+		if err := resolveConflicts(ctx, r, wc, onto, cfg); err != nil {
 			return err
 		}
 
@@ -94,7 +177,7 @@ func Rebase(args []string) error {
 	return nil
 }
 
-func resolveConflicts(ctx context.Context, r *shell.Runner, wc workspace.WorkContext, targetBranch, promptsDir string, qualityChecks []string) error {
+func resolveConflicts(ctx context.Context, r *shell.Runner, wc workspace.WorkContext, targetRef string, cfg *config.Config) error {
 	conflictFiles, err := gitops.ConflictFiles(ctx, r)
 	if err != nil {
 		return fmt.Errorf("listing conflict files: %w", err)
@@ -102,16 +185,23 @@ func resolveConflicts(ctx context.Context, r *shell.Runner, wc workspace.WorkCon
 
 	fmt.Fprintf(os.Stderr, "conflicts detected in %d file(s): %s\n", len(conflictFiles), strings.Join(conflictFiles, ", "))
 
-	prompt, err := buildConflictPrompt(ctx, r, wc, targetBranch, conflictFiles, promptsDir, qualityChecks)
+	prompt, err := buildConflictPrompt(ctx, r, wc, targetRef, conflictFiles, cfg.PromptsDir(), cfg.QualityChecks)
 	if err != nil {
 		return fmt.Errorf("building conflict prompt: %w", err)
 	}
 
+	policy, err := sandbox.New(cfg.Sandbox, config.PhaseRebase, r.Dir)
+	if err != nil {
+		return err
+	}
+
 	fmt.Fprintln(os.Stderr, "invoking Claude to resolve conflicts...")
 	_, err = claude.Invoke(ctx, claude.InvokeOpts{
 		Prompt:   prompt,
+		Dir:      r.Dir,
 		Print:    true,
 		MaxTurns: 20,
+		Sandbox:  policy,
 	})
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Claude session ended with error: %v\n", err)
@@ -120,7 +210,7 @@ func resolveConflicts(ctx context.Context, r *shell.Runner, wc workspace.WorkCon
 	return nil
 }
 
-func buildConflictPrompt(ctx context.Context, r *shell.Runner, wc workspace.WorkContext, targetBranch string, conflictFiles []string, promptsDir string, qualityChecks []string) (string, error) {
+func buildConflictPrompt(ctx context.Context, r *shell.Runner, wc workspace.WorkContext, targetRef string, conflictFiles []string, promptsDir string, qualityChecks []string) (string, error) {
 	data := prompts.RebaseConflictData{
 		ConflictFiles: strings.Join(conflictFiles, "\n"),
 		QualityChecks: qualityChecks,
@@ -139,13 +229,13 @@ func buildConflictPrompt(ctx context.Context, r *shell.Runner, wc workspace.Work
 	}
 
 	currentBranch, _ := gitops.CurrentBranch(ctx, r)
-	mergeBase, err := r.Run(ctx, "git", "merge-base", currentBranch, "origin/"+targetBranch)
+	mergeBase, err := r.Run(ctx, "git", "merge-base", currentBranch, targetRef)
 	if err == nil {
 		mb := strings.TrimSpace(mergeBase)
 		featureDiff, _ := r.Run(ctx, "git", "diff", mb+"..."+currentBranch)
 		data.FeatureDiff = featureDiff
 
-		baseDiff, _ := r.Run(ctx, "git", "diff", mb+"...origin/"+targetBranch)
+		baseDiff, _ := r.Run(ctx, "git", "diff", mb+"..."+targetRef)
 		data.BaseDiff = baseDiff
 	}
 
@@ -1,12 +1,14 @@
 package commands
 
 import (
+	"context"
 	"os"
 	"path/filepath"
 	"strings"
 	"testing"
 
 	"github.com/uesteibar/ralph/internal/prd"
+	"github.com/uesteibar/ralph/internal/shell"
 	"github.com/uesteibar/ralph/internal/workspace"
 )
 
@@ -144,3 +146,118 @@ func TestRebase_PRDContext_LoadedFromWorkspacePath(t *testing.T) {
 		t.Errorf("expected PRD description, got %q", p.Description)
 	}
 }
+
+// commitFile writes file in dir and commits only that file.
+func commitFile(t *testing.T, dir, file, msg string) {
+	t.Helper()
+	ctx := context.Background()
+	r := &shell.Runner{Dir: dir}
+	if err := os.WriteFile(filepath.Join(dir, file), []byte(msg+"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.Run(ctx, "git", "add", file); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.Run(ctx, "git", "commit", "-m", msg); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// setupStack creates a repo with a bare origin and two stacked workspaces,
+// api and ui (on api), each with one commit.
+func setupStack(t *testing.T) string {
+	t.Helper()
+	dir := realPath(t, t.TempDir())
+	r := initTestRepo(t, dir)
+	ctx := context.Background()
+
+	originDir := t.TempDir()
+	if _, err := (&shell.Runner{Dir: originDir}).Run(ctx, "git", "init", "--bare"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.Run(ctx, "git", "remote", "add", "origin", originDir); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.Run(ctx, "git", "push", "origin", "main"); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv("RALPH_SHELL_INIT", "1")
+	oldDir, _ := os.Getwd()
+	os.Chdir(dir)
+	t.Cleanup(func() { os.Chdir(oldDir) })
+
+	if _, err := captureStdout(t, func() error {
+		return workspacesDispatch([]string{"new", "api"}, strings.NewReader(""))
+	}); err != nil {
+		t.Fatalf("creating api: %v", err)
+	}
+	commitFile(t, workspace.TreePath(dir, "api"), "api.txt", "api work")
+
+	if _, err := captureStdout(t, func() error {
+		return workspacesDispatch([]string{"new", "ui", "--on", "api"}, strings.NewReader(""))
+	}); err != nil {
+		t.Fatalf("creating ui: %v", err)
+	}
+	commitFile(t, workspace.TreePath(dir, "ui"), "ui.txt", "ui work")
+
+	return dir
+}
+
+func TestWorkspacesNew_On_BranchesFromParent(t *testing.T) {
+	dir := setupStack(t)
+
+	ws, err := workspace.RegistryGet(dir, "ui")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ws.Parent != "api" {
+		t.Errorf("ui parent = %q, want api", ws.Parent)
+	}
+	// The parent's unpushed commit is part of the child's history.
+	if _, err := os.Stat(filepath.Join(workspace.TreePath(dir, "ui"), "api.txt")); err != nil {
+		t.Errorf("expected api.txt in ui worktree: %v", err)
+	}
+}
+
+func TestWorkspacesNew_On_UnknownParent(t *testing.T) {
+	dir := realPath(t, t.TempDir())
+	initTestRepo(t, dir)
+	t.Setenv("RALPH_SHELL_INIT", "1")
+	oldDir, _ := os.Getwd()
+	os.Chdir(dir)
+	defer os.Chdir(oldDir)
+
+	_, err := captureStdout(t, func() error {
+		return workspacesDispatch([]string{"new", "ui", "--on", "nope"}, strings.NewReader(""))
+	})
+	if err == nil || !strings.Contains(err.Error(), "parent workspace") {
+		t.Fatalf("expected parent workspace error, got %v", err)
+	}
+}
+
+func TestRebase_Stack_RebasesParentsThenChildren(t *testing.T) {
+	dir := setupStack(t)
+	ctx := context.Background()
+
+	// Advance origin/main.
+	commitFile(t, dir, "main.txt", "main work")
+	if _, err := (&shell.Runner{Dir: dir}).Run(ctx, "git", "push", "origin", "main"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := Rebase([]string{"--stack", "--workspace", "ui"}); err != nil {
+		t.Fatalf("Rebase --stack: %v", err)
+	}
+
+	ui := &shell.Runner{Dir: workspace.TreePath(dir, "ui")}
+	out, err := ui.Run(ctx, "git", "log", "--format=%s", "ralph/ui")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := strings.Split(strings.TrimSpace(out), "\n")
+	want := []string{"ui work", "api work", "main work", "initial"}
+	if strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("ui history = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,96 @@
+package commands
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/uesteibar/ralph/internal/gitops"
+	"github.com/uesteibar/ralph/internal/prd"
+	"github.com/uesteibar/ralph/internal/report"
+	"github.com/uesteibar/ralph/internal/shell"
+	"github.com/uesteibar/ralph/internal/workspace"
+)
+
+// Report handles `ralph report`: it writes a Markdown or HTML account of a
+// workspace's run to a single file.
+func Report(args []string) error {
+	return reportRun(args, os.Stdout)
+}
+
+func reportRun(args []string, stdout io.Writer) error {
+	fs := flag.NewFlagSet("report", flag.ExitOnError)
+	configPath := AddProjectConfigFlag(fs)
+	workspaceFlag := AddWorkspaceFlag(fs)
+	format := fs.String("format", "md", "Report format: md or html")
+	output := fs.String("output", "", "File to write (default: ralph-report-<workspace>.<format>; - for stdout)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *format != "md" && *format != "html" {
+		return fmt.Errorf("invalid --format %q: use md or html", *format)
+	}
+
+	cfg, err := ResolveConfig(*configPath)
+	if err != nil {
+		return fmt.Errorf("resolving config: %w", err)
+	}
+	wc, err := resolveWorkContextFromFlags(*workspaceFlag, cfg.Repo.Path)
+	if err != nil {
+		return fmt.Errorf("resolving workspace context: %w", err)
+	}
+
+	ctx := context.Background()
+	r := &shell.Runner{Dir: wc.WorkDir}
+	recorded, err := prd.ReadCommits(prd.CommitsPath(wc.PRDPath))
+	if err != nil {
+		return err
+	}
+	base := storyBaseRef(ctx, cfg, wc)
+
+	rep, err := report.Collect(ctx, report.Source{
+		Workspace:    wc.Name,
+		PRDPath:      wc.PRDPath,
+		ProgressPath: wc.ProgressPath,
+		LogsDir:      filepath.Join(workspace.WorkspacePath(cfg.Repo.Path, wc.Name), "logs"),
+		WorkDir:      wc.WorkDir,
+		Base:         base,
+		StoryCommits: func(ctx context.Context, storyID string) ([]gitops.CommitSummary, error) {
+			ranges, ok := recorded[storyID]
+			return resolveStoryCommits(ctx, r, ranges, ok, base, storyID)
+		},
+	}, time.Now())
+	if err != nil {
+		return err
+	}
+
+	write := report.WriteMarkdown
+	if *format == "html" {
+		write = report.WriteHTML
+	}
+
+	if *output == "-" {
+		return write(stdout, rep)
+	}
+	path := *output
+	if path == "" {
+		path = fmt.Sprintf("ralph-report-%s.%s", wc.Name, *format)
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("creating report: %w", err)
+	}
+	if err := write(f, rep); err != nil {
+		f.Close()
+		return fmt.Errorf("writing report: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("writing report: %w", err)
+	}
+	fmt.Fprintf(os.Stderr, "✓ Wrote %s\n", path)
+	return nil
+}
@@ -0,0 +1,52 @@
+package commands
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestReport_Markdown(t *testing.T) {
+	dir := setupVariantSource(t)
+	writeLoggedRun(t, dir, time.Now().Add(-24*time.Hour))
+
+	var out bytes.Buffer
+	if err := reportRun([]string{"--workspace", "a", "--output", "-"}, &out); err != nil {
+		t.Fatalf("report: %v", err)
+	}
+	for _, want := range []string{
+		"# Ralph run report: a",
+		"### ✓ US-001: First",
+		"feat(US-001): first (1 files, +1 −0)",
+		"- ✓ `make lint`",
+		"## QA",
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("missing %q in:\n%s", want, out.String())
+		}
+	}
+}
+
+func TestReport_WritesHTMLFile(t *testing.T) {
+	dir := setupVariantSource(t)
+
+	if err := reportRun([]string{"--workspace", "a", "--format", "html"}, &bytes.Buffer{}); err != nil {
+		t.Fatalf("report: %v", err)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "ralph-report-a.html"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), "<!DOCTYPE html>") {
+		t.Errorf("expected an HTML page, got:\n%s", data)
+	}
+}
+
+func TestReport_InvalidFormat(t *testing.T) {
+	if err := reportRun([]string{"--format", "pdf"}, &bytes.Buffer{}); err == nil || !strings.Contains(err.Error(), "invalid --format") {
+		t.Fatalf("expected an invalid format error, got %v", err)
+	}
+}
@@ -0,0 +1,107 @@
+package commands
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/uesteibar/ralph/internal/gitops"
+	"github.com/uesteibar/ralph/internal/prd"
+	"github.com/uesteibar/ralph/internal/runstate"
+	"github.com/uesteibar/ralph/internal/shell"
+	"github.com/uesteibar/ralph/internal/workspace"
+)
+
+// RevertStory handles `ralph revert-story <ID>`: it reverts the commits of a
+// story in one new commit and marks the story as not passing, so the next
+// `ralph run` implements it again.
+func RevertStory(args []string) error {
+	fs := flag.NewFlagSet("revert-story", flag.ExitOnError)
+	configPath := AddProjectConfigFlag(fs)
+	workspaceFlag := AddWorkspaceFlag(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	remaining := fs.Args()
+	if len(remaining) == 0 {
+		return fmt.Errorf("usage: ralph revert-story <story-id> [--workspace name] [--project-config path]")
+	}
+	storyID := remaining[0]
+
+	// Flags may also follow the story ID.
+	if err := fs.Parse(remaining[1:]); err != nil {
+		return err
+	}
+
+	cfg, err := ResolveConfig(*configPath)
+	if err != nil {
+		return fmt.Errorf("resolving config: %w", err)
+	}
+	wc, err := resolveWorkContextFromFlags(*workspaceFlag, cfg.Repo.Path)
+	if err != nil {
+		return fmt.Errorf("resolving workspace context: %w", err)
+	}
+
+	if wc.Name != "base" && runstate.IsRunning(workspace.WorkspacePath(cfg.Repo.Path, wc.Name)) {
+		return fmt.Errorf("the loop is running in %s; stop it with ralph stop first", wc.Name)
+	}
+
+	p, err := prd.Read(wc.PRDPath)
+	if err != nil {
+		return err
+	}
+	found := false
+	for _, s := range p.UserStories {
+		found = found || s.ID == storyID
+	}
+	if !found {
+		return fmt.Errorf("story %s not found in %s", storyID, wc.PRDPath)
+	}
+
+	commitsPath := prd.CommitsPath(wc.PRDPath)
+	recorded, err := prd.ReadCommits(commitsPath)
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	r := &shell.Runner{Dir: wc.WorkDir}
+	ranges, ok := recorded[storyID]
+	commits, err := resolveStoryCommits(ctx, r, ranges, ok, storyBaseRef(ctx, cfg, wc), storyID)
+	if err != nil {
+		return err
+	}
+	if len(commits) == 0 {
+		return fmt.Errorf("no commits found for story %s", storyID)
+	}
+
+	fmt.Fprintf(os.Stderr, "reverting %d commit(s) of %s...\n", len(commits), storyID)
+	msg := fmt.Sprintf("revert(%s): undo the story's commits\n\nReverted with ralph revert-story.", storyID)
+	if err := gitops.RevertCommits(ctx, r, commits, msg); err != nil {
+		return err
+	}
+
+	// The story's ranges are dropped but its key is kept, so the reverted
+	// commits are not picked up again by their trailers.
+	if err := prd.UpdateCommits(commitsPath, func(c map[string][]prd.CommitRange) {
+		c[storyID] = []prd.CommitRange{}
+	}); err != nil {
+		return err
+	}
+
+	if err := prd.Update(wc.PRDPath, func(p *prd.PRD) error {
+		for i := range p.UserStories {
+			if p.UserStories[i].ID == storyID {
+				p.UserStories[i].Passes = false
+			}
+		}
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(os.Stderr, "Reverted %s; the next ralph run will implement it again\n", storyID)
+	return nil
+}
@@ -0,0 +1,119 @@
+package commands
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/uesteibar/ralph/internal/gitops"
+	"github.com/uesteibar/ralph/internal/prd"
+	"github.com/uesteibar/ralph/internal/shell"
+)
+
+// setupStoryHistory commits one file per story in base mode and records the
+// ranges the loop would have recorded. It returns the PRD path.
+func setupStoryHistory(t *testing.T, dir string, r *shell.Runner) string {
+	t.Helper()
+	ctx := context.Background()
+	prdPath := filepath.Join(dir, ".ralph", "state", "prd.json")
+	writePRD(t, prdPath, &prd.PRD{
+		Project: "test",
+		UserStories: []prd.Story{
+			{ID: "US-001", Title: "Login form", Passes: true},
+			{ID: "US-002", Title: "Logout", Passes: true},
+		},
+	})
+
+	for _, s := range []struct{ id, file string }{{"US-001", "login.go"}, {"US-002", "logout.go"}} {
+		start, _ := gitops.RevParse(ctx, r, "HEAD")
+		os.WriteFile(filepath.Join(dir, s.file), []byte("package app\n"), 0644)
+		if _, err := r.Run(ctx, "git", "add", s.file); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := r.Run(ctx, "git", "commit", "-m", "feat("+s.id+"): "+s.file); err != nil {
+			t.Fatal(err)
+		}
+		end, _ := gitops.RevParse(ctx, r, "HEAD")
+		if err := prd.RecordCommits(prd.CommitsPath(prdPath), s.id, prd.CommitRange{Start: start, End: end}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return prdPath
+}
+
+func TestLog_ListsStoryCommits(t *testing.T) {
+	dir := realPath(t, t.TempDir())
+	r := initTestRepo(t, dir)
+	setupStoryHistory(t, dir, r)
+
+	oldDir, _ := os.Getwd()
+	os.Chdir(dir)
+	defer os.Chdir(oldDir)
+	t.Setenv("RALPH_WORKSPACE", "")
+
+	var buf bytes.Buffer
+	if err := logRun(nil, &buf); err != nil {
+		t.Fatalf("logRun: %v", err)
+	}
+	out := string(stripANSI(buf.Bytes()))
+	first := strings.Index(out, "US-001")
+	second := strings.Index(out, "US-002")
+	if first < 0 || second < first {
+		t.Fatalf("output lists stories out of order:\n%s", out)
+	}
+	if !strings.Contains(out[first:second], "feat(US-001): login.go") || strings.Contains(out[first:second], "logout.go") {
+		t.Errorf("US-001 should list only its commit:\n%s", out)
+	}
+}
+
+func TestRevertStory_RevertsCommitsAndResetsStory(t *testing.T) {
+	dir := realPath(t, t.TempDir())
+	r := initTestRepo(t, dir)
+	prdPath := setupStoryHistory(t, dir, r)
+
+	oldDir, _ := os.Getwd()
+	os.Chdir(dir)
+	defer os.Chdir(oldDir)
+	t.Setenv("RALPH_WORKSPACE", "")
+
+	if err := RevertStory([]string{"US-001"}); err != nil {
+		t.Fatalf("RevertStory: %v", err)
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, "login.go")); !os.IsNotExist(err) {
+		t.Error("login.go should be reverted")
+	}
+	if _, err := os.Stat(filepath.Join(dir, "logout.go")); err != nil {
+		t.Errorf("US-002 should be untouched: %v", err)
+	}
+	subject, _ := r.Run(context.Background(), "git", "log", "-1", "--format=%s")
+	if !strings.HasPrefix(subject, "revert(US-001):") {
+		t.Errorf("HEAD subject = %q, want the revert commit", subject)
+	}
+
+	p, _ := prd.Read(prdPath)
+	if p.UserStories[0].Passes || !p.UserStories[1].Passes {
+		t.Errorf("stories = %+v, want only US-001 reset", p.UserStories)
+	}
+	if err := RevertStory([]string{"US-001"}); err == nil || !strings.Contains(err.Error(), "no commits found") {
+		t.Errorf("second revert = %v, want no commits left to revert", err)
+	}
+}
+
+func TestRevertStory_UnknownStory(t *testing.T) {
+	dir := realPath(t, t.TempDir())
+	r := initTestRepo(t, dir)
+	setupStoryHistory(t, dir, r)
+
+	oldDir, _ := os.Getwd()
+	os.Chdir(dir)
+	defer os.Chdir(oldDir)
+	t.Setenv("RALPH_WORKSPACE", "")
+
+	if err := RevertStory([]string{"US-999"}); err == nil || !strings.Contains(err.Error(), "story US-999 not found") {
+		t.Errorf("err = %v, want story not found", err)
+	}
+}
@@ -2,17 +2,15 @@ package commands
 
 import (
 	"bufio"
+	"bytes"
 	"context"
 	"errors"
 	"flag"
 	"fmt"
-	"io"
 	"os"
 	"os/exec"
 	"os/signal"
 	"path/filepath"
-	"sort"
-	"strings"
 	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
@@ -28,14 +26,15 @@ import (
 )
 
 // spawnDaemonFn spawns the ralph _daemon process. Package-level var for testability.
-var spawnDaemonFn = func(workspaceName string, maxIter int) (*exec.Cmd, error) {
+var spawnDaemonFn = func(workspaceName string, maxIter int, scope loop.Scope) (*exec.Cmd, error) {
 	exe, err := os.Executable()
 	if err != nil {
 		return nil, fmt.Errorf("finding executable path: %w", err)
 	}
-	cmd := exec.Command(exe, "_daemon",
+	args := []string{"_daemon",
 		"--workspace", workspaceName,
-		"--max-iterations", fmt.Sprintf("%d", maxIter))
+		"--max-iterations", fmt.Sprintf("%d", maxIter)}
+	cmd := exec.Command(exe, append(args, scope.Args()...)...)
 	cmd.SysProcAttr = spawnSysProcAttr()
 	cmd.Stdout = nil
 	cmd.Stderr = nil
@@ -67,6 +66,7 @@ func Run(args []string) error {
 	verbose := fs.Bool("verbose", false, "Enable verbose debug logging")
 	workspaceFlag := AddWorkspaceFlag(fs)
 	noTUI := fs.Bool("no-tui", false, "Disable TUI and use plain-text output")
+	scope := addScopeFlags(fs)
 	if err := fs.Parse(args); err != nil {
 		return err
 	}
@@ -94,6 +94,8 @@ func Run(args []string) error {
 			branch = cfg.Repo.DefaultBase
 		}
 		fmt.Fprintf(os.Stderr, "Running in base. Changes commit to %s. Consider: ralph workspaces new <name>\n", branch)
+	} else if ws, err := workspace.ReadWorkspaceJSON(cfg.Repo.Path, wc.Name); err == nil && ws.HooksError != "" {
+		fmt.Fprintf(os.Stderr, "warning: commits in this workspace are not scanned for secrets; its git hooks were not installed: %s\n", ws.HooksError)
 	}
 
 	// Verify PRD exists
@@ -107,8 +109,16 @@ func Run(args []string) error {
 		return fmt.Errorf("reading PRD: %w", err)
 	}
 
-	if prd.AllPass(currentPRD) && prd.AllIntegrationTestsPass(currentPRD) {
+	if err := scope.Validate(currentPRD); err != nil {
+		return err
+	}
+
+	if scope.Done(currentPRD) {
 		doneStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("2")).Bold(true)
+		if !scope.IsZero() {
+			fmt.Fprintf(os.Stderr, "\n%s Everything in scope (%s) passes — nothing to do.\n", doneStyle.Render("✓"), scope)
+			return nil
+		}
 		fmt.Fprintf(os.Stderr, "\n%s All stories and integration tests pass — nothing to do.\n\n", doneStyle.Render("✓"))
 		fmt.Fprintf(os.Stderr, "Run `ralph done` to squash and merge your changes back to base.\n")
 		return nil
@@ -120,7 +130,7 @@ func Run(args []string) error {
 	alreadyRunning := runstate.IsRunning(wsPath)
 	if !alreadyRunning {
 		fmt.Fprintf(os.Stderr, "workspace=%s workDir=%s prdPath=%s\n", wc.Name, wc.WorkDir, wc.PRDPath)
-		_, err := spawnDaemonFn(wc.Name, *maxIter)
+		_, err := spawnDaemonFn(wc.Name, *maxIter, *scope)
 		if err != nil {
 			return fmt.Errorf("spawning daemon: %w", err)
 		}
@@ -129,6 +139,9 @@ func Run(args []string) error {
 		}
 	} else {
 		fmt.Fprintf(os.Stderr, "daemon already running for workspace %s\n", wc.Name)
+		if !scope.IsZero() {
+			fmt.Fprintf(os.Stderr, "warning: attaching to the running loop; the %s scope was not applied\n", scope)
+		}
 	}
 
 	logsDir := filepath.Join(wsPath, "logs")
@@ -137,7 +150,7 @@ func Run(args []string) error {
 		return tailLogsPlainText(ctx, wsPath, logsDir)
 	}
 
-	return tailLogsTUI(wsPath, logsDir, wc.Name, wc.PRDPath)
+	return tailLogsTUI(wsPath, logsDir, wc, storyBaseRef(ctx, cfg, wc))
 }
 
 // tailLogsPlainText tails JSONL log files and streams events to stderr via PlainTextHandler.
@@ -186,8 +199,11 @@ func tailLogsPlainText(ctx context.Context, wsPath, logsDir string) error {
 // tailLogsTUI opens a BubbleTea TUI that reads events from JSONL log files.
 // Historical events are replayed on startup; live events appear in real-time.
 // d=detach (quit TUI, daemon continues), q=stop (SIGTERM to daemon, then quit).
-func tailLogsTUI(wsPath, logsDir, workspaceName, prdPath string) error {
-	model := tui.NewModel(workspaceName, prdPath)
+// The diff pane shows the workspace's changes since it branched from diffBase.
+func tailLogsTUI(wsPath, logsDir string, wc workspace.WorkContext, diffBase string) error {
+	model := tui.NewModel(wc.Name, wc.PRDPath)
+	model.SetDiffSource(wc.WorkDir, diffBase)
+	model.EnablePRDEditing()
 	model.SetStopDaemonFn(func() {
 		stopDaemon(wsPath)
 		// Wait briefly for daemon to exit
@@ -227,7 +243,7 @@ func tailLogsTUI(wsPath, logsDir, workspaceName, prdPath string) error {
 				return
 			case <-time.After(500 * time.Millisecond):
 				if !runstate.IsRunning(wsPath) {
-					// Give LogReader one final poll cycle to drain remaining events.
+					// Give LogReader time to pick up the last writes (a poll cycle without notifications).
 					time.Sleep(300 * time.Millisecond)
 					cancel()
 					return
@@ -244,43 +260,45 @@ func tailLogsTUI(wsPath, logsDir, workspaceName, prdPath string) error {
 }
 
 // readNewLogEntries reads new JSONL lines from all log files in logsDir.
+// Offsets are keyed by events.LogName so a log compressed after it was
+// partly read picks up where it left off; -1 marks a compressed log as done.
 func readNewLogEntries(logsDir string, offsets map[string]int64, handler events.EventHandler) {
-	files, err := filepath.Glob(filepath.Join(logsDir, "*.jsonl"))
-	if err != nil || len(files) == 0 {
-		return
-	}
-	sort.Strings(files)
+	for _, path := range events.LogFiles(logsDir) {
+		name := events.LogName(path)
+		offset := offsets[name]
+		if offset < 0 {
+			continue
+		}
 
-	for _, path := range files {
-		offset := offsets[path]
-		f, err := os.Open(path)
+		f, err := events.OpenLogAt(path, offset)
 		if err != nil {
 			continue
 		}
 
-		if offset > 0 {
-			if _, err := f.Seek(offset, io.SeekStart); err != nil {
-				f.Close()
-				continue
+		// Only complete lines are consumed; a partial one is read once it ends.
+		br := bufio.NewReader(f)
+		for {
+			line, err := br.ReadBytes('\n')
+			if err != nil {
+				break
 			}
-		}
-
-		scanner := bufio.NewScanner(f)
-		for scanner.Scan() {
-			line := strings.TrimSpace(scanner.Text())
-			if line == "" {
+			offset += int64(len(line))
+			trimmed := bytes.TrimSpace(line)
+			if len(trimmed) == 0 {
 				continue
 			}
-			evt, err := events.UnmarshalEvent([]byte(line))
+			evt, err := events.UnmarshalEvent(trimmed)
 			if err != nil {
 				continue
 			}
 			handler.Handle(evt)
 		}
-
-		newOffset, _ := f.Seek(0, io.SeekCurrent)
-		offsets[path] = newOffset
 		f.Close()
+
+		if path != name {
+			offset = -1
+		}
+		offsets[name] = offset
 	}
 }
 
@@ -11,6 +11,7 @@ import (
 	"time"
 
 	"github.com/uesteibar/ralph/internal/events"
+	"github.com/uesteibar/ralph/internal/loop"
 	"github.com/uesteibar/ralph/internal/runstate"
 	"github.com/uesteibar/ralph/internal/workspace"
 )
@@ -180,7 +181,7 @@ func TestRun_InBase_WarningPrinted(t *testing.T) {
 
 	// Mock spawn+wait so Run doesn't try to start a real daemon.
 	origSpawn := spawnDaemonFn
-	spawnDaemonFn = func(name string, maxIter int) (*exec.Cmd, error) {
+	spawnDaemonFn = func(name string, maxIter int, scope loop.Scope) (*exec.Cmd, error) {
 		return nil, nil
 	}
 	defer func() { spawnDaemonFn = origSpawn }()
@@ -416,7 +417,7 @@ func TestRun_SpawnsDaemon_WhenNotRunning(t *testing.T) {
 	var spawnedName string
 	var spawnedMaxIter int
 	origSpawn := spawnDaemonFn
-	spawnDaemonFn = func(name string, maxIter int) (*exec.Cmd, error) {
+	spawnDaemonFn = func(name string, maxIter int, scope loop.Scope) (*exec.Cmd, error) {
 		spawnedName = name
 		spawnedMaxIter = maxIter
 		return nil, nil
@@ -450,6 +451,51 @@ func TestRun_SpawnsDaemon_WhenNotRunning(t *testing.T) {
 	}
 }
 
+func TestRun_PassesScopeToDaemon(t *testing.T) {
+	dir := realPath(t, t.TempDir())
+	initTestRepo(t, dir)
+	wsName := "scoped"
+	setupWorkspace(t, dir, wsName, notPassingPRD(wsName))
+
+	oldWd, _ := os.Getwd()
+	defer os.Chdir(oldWd)
+	os.Chdir(dir)
+
+	var spawnedScope loop.Scope
+	origSpawn := spawnDaemonFn
+	spawnDaemonFn = func(name string, maxIter int, scope loop.Scope) (*exec.Cmd, error) {
+		spawnedScope = scope
+		return nil, nil
+	}
+	defer func() { spawnDaemonFn = origSpawn }()
+
+	origWait := waitForPIDFileFn
+	waitForPIDFileFn = func(path string, timeout time.Duration) error { return nil }
+	defer func() { waitForPIDFileFn = origWait }()
+
+	oldStderr := os.Stderr
+	_, wPipe, _ := os.Pipe()
+	os.Stderr = wPipe
+	defer func() { os.Stderr = oldStderr }()
+
+	if err := Run([]string{"--workspace", wsName, "--no-tui", "--story", "US-404"}); err == nil || !strings.Contains(err.Error(), "story US-404 not found") {
+		t.Errorf("unknown story: err = %v, want not found", err)
+	}
+	if err := Run([]string{"--workspace", wsName, "--no-tui", "--story", "US-001", "--skip-qa"}); err == nil || !strings.Contains(err.Error(), "cannot be combined") {
+		t.Errorf("combined flags: err = %v, want an error", err)
+	}
+
+	Run([]string{"--workspace", wsName, "--no-tui", "--story", "US-001"})
+	wPipe.Close()
+
+	if spawnedScope != (loop.Scope{Story: "US-001"}) {
+		t.Errorf("spawned scope = %+v, want story US-001", spawnedScope)
+	}
+	if got := strings.Join(spawnedScope.Args(), " "); got != "--story US-001" {
+		t.Errorf("daemon args = %q, want --story US-001", got)
+	}
+}
+
 func TestRun_SkipsSpawn_WhenDaemonAlreadyRunning(t *testing.T) {
 	dir := realPath(t, t.TempDir())
 	initTestRepo(t, dir)
@@ -469,7 +515,7 @@ func TestRun_SkipsSpawn_WhenDaemonAlreadyRunning(t *testing.T) {
 
 	spawnCalled := false
 	origSpawn := spawnDaemonFn
-	spawnDaemonFn = func(name string, maxIter int) (*exec.Cmd, error) {
+	spawnDaemonFn = func(name string, maxIter int, scope loop.Scope) (*exec.Cmd, error) {
 		spawnCalled = true
 		return nil, nil
 	}
@@ -516,7 +562,7 @@ func TestRun_ErrorWhenDaemonFailsToStart(t *testing.T) {
 	os.Chdir(dir)
 
 	origSpawn := spawnDaemonFn
-	spawnDaemonFn = func(name string, maxIter int) (*exec.Cmd, error) {
+	spawnDaemonFn = func(name string, maxIter int, scope loop.Scope) (*exec.Cmd, error) {
 		return nil, nil
 	}
 	defer func() { spawnDaemonFn = origSpawn }()
@@ -557,7 +603,7 @@ func TestRun_TailsLogs_AfterDaemonStops(t *testing.T) {
 	wsPath := workspace.WorkspacePath(dir, wsName)
 
 	origSpawn := spawnDaemonFn
-	spawnDaemonFn = func(name string, maxIter int) (*exec.Cmd, error) {
+	spawnDaemonFn = func(name string, maxIter int, scope loop.Scope) (*exec.Cmd, error) {
 		return nil, nil
 	}
 	defer func() { spawnDaemonFn = origSpawn }()
@@ -647,6 +693,37 @@ func TestReadNewLogEntries_ReadsJSONL(t *testing.T) {
 	}
 }
 
+func TestReadNewLogEntries_ContinuesIntoCompressedLog(t *testing.T) {
+	logsDir := t.TempDir()
+	logFile := filepath.Join(logsDir, "US-001-20260206T120000Z.jsonl")
+	evt1, _ := events.MarshalEvent(events.IterationStart{Iteration: 1, MaxIterations: 5})
+	if err := os.WriteFile(logFile, append(evt1, '\n'), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var handled []events.Event
+	handler := &captureHandler{events: &handled}
+	offsets := make(map[string]int64)
+	readNewLogEntries(logsDir, offsets, handler)
+
+	evt2, _ := events.MarshalEvent(events.StoryStarted{StoryID: "US-001", Title: "Last"})
+	f, _ := os.OpenFile(logFile, os.O_APPEND|os.O_WRONLY, 0644)
+	f.Write(append(evt2, '\n'))
+	f.Close()
+	if err := events.CompressLog(logFile); err != nil {
+		t.Fatal(err)
+	}
+
+	readNewLogEntries(logsDir, offsets, handler)
+	readNewLogEntries(logsDir, offsets, handler)
+	if len(handled) != 2 {
+		t.Fatalf("expected each event once, got %d", len(handled))
+	}
+	if ss, ok := handled[1].(events.StoryStarted); !ok || ss.Title != "Last" {
+		t.Errorf("expected StoryStarted Last, got %T", handled[1])
+	}
+}
+
 func TestReadNewLogEntries_SkipsCorruptLines(t *testing.T) {
 	logsDir := filepath.Join(t.TempDir(), "logs")
 	if err := os.MkdirAll(logsDir, 0755); err != nil {
@@ -49,6 +49,18 @@ func shellFunction() string {
                         fi
                     fi
                     ;;
+                fork)
+                    __output=$(command ralph "$@")
+                    __exit=$?
+                    if [ $__exit -ne 0 ]; then
+                        return $__exit
+                    fi
+                    __path=$(echo "$__output" | tail -n 1)
+                    if [ -n "$__path" ] && [ -d "$__path" ]; then
+                        cd "$__path" || return 1
+                        export RALPH_WORKSPACE="$4"
+                    fi
+                    ;;
                 switch)
                     __output=$(command ralph "$@")
                     __exit=$?
@@ -38,7 +38,7 @@ func Tui(args []string) error {
 	})
 	model.SetMakeResumeFn(func(index int, wsName, wsPath string) tea.Cmd {
 		return func() tea.Msg {
-			_, err := spawnDaemonFn(wsName, loop.DefaultMaxIterations)
+			_, err := spawnDaemonFn(wsName, loop.DefaultMaxIterations, loop.Scope{})
 			if err != nil {
 				return tui.MakeMultiDaemonResumedMsg(index, err)
 			}
@@ -0,0 +1,378 @@
+package commands
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"maps"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/uesteibar/ralph/internal/config"
+	"github.com/uesteibar/ralph/internal/events"
+	"github.com/uesteibar/ralph/internal/gitops"
+	"github.com/uesteibar/ralph/internal/prd"
+	"github.com/uesteibar/ralph/internal/runstate"
+	"github.com/uesteibar/ralph/internal/sandbox"
+	"github.com/uesteibar/ralph/internal/shell"
+	"github.com/uesteibar/ralph/internal/workspace"
+)
+
+// workspacesFork creates a variant of a workspace: a new workspace whose
+// branch starts at the source's HEAD (or at a story's commit with --at) and
+// which gets a copy of the source's PRD and progress log.
+func workspacesFork(args []string) error {
+	fs := flag.NewFlagSet("workspaces fork", flag.ExitOnError)
+	configPath := AddProjectConfigFlag(fs)
+	at := fs.String("at", "", "Branch from the commit of this story instead of the source's HEAD")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	remaining := fs.Args()
+	if len(remaining) < 2 {
+		return fmt.Errorf("usage: ralph workspaces fork <name> <variant> [--at story-id] [--project-config path]")
+	}
+	name, variant := remaining[0], remaining[1]
+
+	// Flags may also follow the names.
+	if err := fs.Parse(remaining[2:]); err != nil {
+		return err
+	}
+
+	if err := workspace.ValidateName(variant); err != nil {
+		return err
+	}
+
+	if os.Getenv("RALPH_SHELL_INIT") == "" {
+		return fmt.Errorf("Shell integration required. Add to your shell config:\n\n  eval \"$(ralph shell-init)\"\n\nThen restart your shell.")
+	}
+
+	cfg, err := ResolveConfig(*configPath)
+	if err != nil {
+		return fmt.Errorf("resolving config: %w", err)
+	}
+
+	source, err := workspace.RegistryGet(cfg.Repo.Path, name)
+	if err != nil {
+		return fmt.Errorf("source workspace: %w", err)
+	}
+	if existing, err := workspace.RegistryGet(cfg.Repo.Path, variant); err == nil && existing != nil {
+		return fmt.Errorf("Workspace %q already exists. Switch to it: ralph workspaces switch %s", variant, variant)
+	}
+
+	ctx := context.Background()
+	repoRunner := &shell.Runner{Dir: cfg.Repo.Path}
+
+	sourceHead, err := gitops.RevParse(ctx, repoRunner, source.Branch)
+	if err != nil {
+		return err
+	}
+	forkPoint := sourceHead
+	if *at != "" {
+		forkPoint, err = gitops.StoryCommit(ctx, repoRunner, source.Branch, *at)
+		if err != nil {
+			return err
+		}
+	}
+
+	branch, err := workspace.DeriveBranch(cfg.Repo.BranchPrefix, variant, cfg.Repo.BranchPattern)
+	if err != nil {
+		return err
+	}
+	if gitops.BranchExistsLocally(ctx, repoRunner, branch) {
+		return fmt.Errorf("branch %s already exists; pick another variant name", branch)
+	}
+
+	ws := workspace.Workspace{
+		Name:      variant,
+		Branch:    branch,
+		CreatedAt: time.Now(),
+		Parent:    source.Parent,
+		ForkOf:    source.Name,
+	}
+	if err := workspace.CreateWorkspace(ctx, cfg.Repo.Path, ws, forkPoint, createOptions(cfg)); err != nil {
+		return fmt.Errorf("creating workspace: %w", err)
+	}
+
+	// Stories committed after the fork point are not part of the variant.
+	var undone []string
+	if forkPoint != sourceHead {
+		undone, err = gitops.StoriesInRange(ctx, repoRunner, forkPoint, sourceHead)
+		if err != nil {
+			return err
+		}
+	}
+	if err := copyVariantState(cfg.Repo.Path, source.Name, variant, branch, undone); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(os.Stderr, "✓ Forked workspace '%s' into '%s' (branch: %s, from %s)\n", name, variant, branch, forkPoint[:min(7, len(forkPoint))])
+	if len(undone) > 0 {
+		fmt.Fprintf(os.Stderr, "  reset stories committed after the fork point: %s\n", strings.Join(undone, ", "))
+	}
+
+	// stdout: absolute path to tree/ for shell function to cd into.
+	fmt.Println(workspace.TreePath(cfg.Repo.Path, variant))
+	return nil
+}
+
+// copyVariantState copies the PRD, story commits and progress log from the
+// source workspace into the variant. Stories in undone are marked as not
+// passing and their commits dropped, and integration tests are reset when any
+// story was, since QA has to run again.
+func copyVariantState(repoPath, source, variant, branch string, undone []string) error {
+	sourcePRD := workspace.PRDPathForWorkspace(repoPath, source)
+	p, err := prd.Read(sourcePRD)
+	if err == nil {
+		p.BranchName = branch
+		for i := range p.UserStories {
+			for _, id := range undone {
+				if p.UserStories[i].ID == id {
+					p.UserStories[i].Passes = false
+				}
+			}
+		}
+		if len(undone) > 0 {
+			for i := range p.IntegrationTests {
+				p.IntegrationTests[i].Passes = false
+				p.IntegrationTests[i].Failure = ""
+			}
+		}
+		if err := prd.Write(workspace.PRDPathForWorkspace(repoPath, variant), p); err != nil {
+			return fmt.Errorf("copying PRD: %w", err)
+		}
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("reading source PRD: %w", err)
+	}
+
+	commits, err := prd.ReadCommits(prd.CommitsPath(sourcePRD))
+	if err != nil {
+		return err
+	}
+	for _, id := range undone {
+		delete(commits, id)
+	}
+	if len(commits) > 0 {
+		variantCommits := prd.CommitsPath(workspace.PRDPathForWorkspace(repoPath, variant))
+		if err := prd.UpdateCommits(variantCommits, func(c map[string][]prd.CommitRange) {
+			maps.Copy(c, commits)
+		}); err != nil {
+			return fmt.Errorf("copying story commits: %w", err)
+		}
+	}
+
+	progress, err := os.ReadFile(workspace.ProgressPathForWorkspace(repoPath, source))
+	if err == nil {
+		if err := os.WriteFile(workspace.ProgressPathForWorkspace(repoPath, variant), progress, 0644); err != nil {
+			return fmt.Errorf("copying progress: %w", err)
+		}
+	}
+	return nil
+}
+
+// variantSummary is one column of `ralph workspaces compare`.
+type variantSummary struct {
+	Name         string
+	Branch       string
+	PRD          *prd.PRD
+	Status       *runstate.Status
+	Diff         string
+	InputTokens  int
+	OutputTokens int
+	Checks       string
+}
+
+// workspacesCompare prints two workspaces side by side: diff stats, story and
+// integration test progress, the last run result and token spend. With
+// --checks it also runs the quality checks in each tree.
+func workspacesCompare(args []string, w io.Writer) error {
+	fs := flag.NewFlagSet("workspaces compare", flag.ExitOnError)
+	configPath := AddProjectConfigFlag(fs)
+	runChecks := fs.Bool("checks", false, "Run the quality checks in both workspaces")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	remaining := fs.Args()
+	if len(remaining) < 2 {
+		return fmt.Errorf("usage: ralph workspaces compare <a> <b> [--checks] [--project-config path]")
+	}
+	if err := fs.Parse(remaining[2:]); err != nil {
+		return err
+	}
+
+	cfg, err := ResolveConfig(*configPath)
+	if err != nil {
+		return fmt.Errorf("resolving config: %w", err)
+	}
+
+	ctx := context.Background()
+	var summaries []variantSummary
+	for _, name := range remaining[:2] {
+		ws, err := workspace.RegistryGet(cfg.Repo.Path, name)
+		if err != nil {
+			return err
+		}
+		s := variantSummary{Name: ws.Name, Branch: ws.Branch}
+		wsPath := workspace.WorkspacePath(cfg.Repo.Path, ws.Name)
+
+		if p, err := prd.Read(workspace.PRDPathForWorkspace(cfg.Repo.Path, ws.Name)); err == nil {
+			s.PRD = p
+		}
+		if st, err := runstate.ReadStatus(wsPath); err == nil {
+			s.Status = st
+		}
+		s.InputTokens, s.OutputTokens = tokenUsage(filepath.Join(wsPath, "logs"))
+
+		r := &shell.Runner{Dir: workspace.TreePath(cfg.Repo.Path, ws.Name)}
+		s.Diff, err = gitops.DiffShortStat(ctx, r, diffBase(ctx, r, cfg.Repo.Path, cfg.Repo.DefaultBase, ws), "HEAD")
+		if err != nil {
+			s.Diff = "unavailable"
+		}
+
+		if *runChecks {
+			cr, err := sandbox.Runner(cfg.Sandbox, config.PhaseChecks, r.Dir)
+			if err != nil {
+				return err
+			}
+			s.Checks = runQualityChecks(ctx, cr, cfg.QualityChecks)
+		}
+		summaries = append(summaries, s)
+	}
+
+	printComparison(w, summaries[0], summaries[1], *runChecks)
+	return nil
+}
+
+// diffBase returns the ref a workspace's changes are measured against: its
+// parent's branch when stacked, otherwise origin/<base> (or <base> when
+// there is no origin).
+func diffBase(ctx context.Context, r *shell.Runner, repoPath, base string, ws *workspace.Workspace) string {
+	if ws.Parent != "" {
+		if parent, err := workspace.RegistryGet(repoPath, ws.Parent); err == nil {
+			return parent.Branch
+		}
+	}
+	if _, err := gitops.RevParse(ctx, r, "origin/"+base); err == nil {
+		return "origin/" + base
+	}
+	return base
+}
+
+// tokenUsage sums the token counts of every invocation logged in logsDir,
+// counting cached input tokens as ralph report does.
+func tokenUsage(logsDir string) (in, out int) {
+	for _, path := range events.LogFiles(logsDir) {
+		f, err := events.OpenLog(path)
+		if err != nil {
+			continue
+		}
+		scanner := bufio.NewScanner(f)
+		scanner.Buffer(make([]byte, 0, 64*1024), 4*1024*1024)
+		for scanner.Scan() {
+			ev, err := events.UnmarshalEvent(scanner.Bytes())
+			if err != nil {
+				continue
+			}
+			if done, ok := ev.(events.InvocationDone); ok {
+				in += done.TotalInputTokens()
+				out += done.OutputTokens
+			}
+		}
+		f.Close()
+	}
+	return in, out
+}
+
+// runQualityChecks runs each check via sh -c and reports the first failure.
+func runQualityChecks(ctx context.Context, r *shell.Runner, checks []string) string {
+	if len(checks) == 0 {
+		return "none configured"
+	}
+	for _, check := range checks {
+		if _, err := r.Run(ctx, "sh", "-c", check); err != nil {
+			return "FAIL: " + check
+		}
+	}
+	return fmt.Sprintf("pass (%d)", len(checks))
+}
+
+func printComparison(w io.Writer, a, b variantSummary, withChecks bool) {
+	tw := tabwriter.NewWriter(w, 0, 0, 3, ' ', 0)
+	row := func(label string, fn func(variantSummary) string) {
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", label, fn(a), fn(b))
+	}
+
+	row("", func(s variantSummary) string { return s.Name })
+	row("Branch", func(s variantSummary) string { return s.Branch })
+	row("Diff", func(s variantSummary) string {
+		if s.Diff == "" {
+			return "no changes"
+		}
+		return s.Diff
+	})
+	row("Stories", func(s variantSummary) string {
+		if s.PRD == nil {
+			return "no prd"
+		}
+		passing, total := storyProgress(s.PRD)
+		return fmt.Sprintf("%d/%d passing", passing, total)
+	})
+	row("Tests", func(s variantSummary) string {
+		if s.PRD == nil || len(s.PRD.IntegrationTests) == 0 {
+			return "-"
+		}
+		passing, total := integrationTestProgress(s.PRD)
+		return fmt.Sprintf("%d/%d passing", passing, total)
+	})
+	row("Last run", func(s variantSummary) string {
+		if s.Status == nil {
+			return "never"
+		}
+		return string(s.Status.Result)
+	})
+	row("Tokens", func(s variantSummary) string {
+		return fmt.Sprintf("%d in / %d out", s.InputTokens, s.OutputTokens)
+	})
+	if withChecks {
+		row("Checks", func(s variantSummary) string { return s.Checks })
+	}
+
+	// Per-story breakdown, in the order stories appear in either PRD.
+	var ids []string
+	titles := map[string]string{}
+	passes := map[string]map[string]bool{}
+	for _, s := range []variantSummary{a, b} {
+		passes[s.Name] = map[string]bool{}
+		if s.PRD == nil {
+			continue
+		}
+		for _, st := range s.PRD.UserStories {
+			if _, ok := titles[st.ID]; !ok {
+				ids = append(ids, st.ID)
+				titles[st.ID] = st.Title
+			}
+			passes[s.Name][st.ID] = st.Passes
+		}
+	}
+	if len(ids) > 0 {
+		fmt.Fprintln(tw, "\t\t")
+		for _, id := range ids {
+			row(id+" "+titles[id], func(s variantSummary) string {
+				if passes[s.Name][id] {
+					return "✓"
+				}
+				return "✗"
+			})
+		}
+	}
+	tw.Flush()
+}
@@ -0,0 +1,185 @@
+package commands
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/uesteibar/ralph/internal/events"
+	"github.com/uesteibar/ralph/internal/gitops"
+	"github.com/uesteibar/ralph/internal/prd"
+	"github.com/uesteibar/ralph/internal/shell"
+	"github.com/uesteibar/ralph/internal/workspace"
+)
+
+// setupVariantSource creates workspace "a" with two story commits and a PRD
+// where both stories pass.
+func setupVariantSource(t *testing.T) string {
+	t.Helper()
+	dir := realPath(t, t.TempDir())
+	initTestRepo(t, dir)
+
+	t.Setenv("RALPH_SHELL_INIT", "1")
+	oldDir, _ := os.Getwd()
+	os.Chdir(dir)
+	t.Cleanup(func() { os.Chdir(oldDir) })
+
+	if _, err := captureStdout(t, func() error {
+		return workspacesDispatch([]string{"new", "a"}, strings.NewReader(""))
+	}); err != nil {
+		t.Fatalf("creating a: %v", err)
+	}
+	tree := workspace.TreePath(dir, "a")
+	commitFile(t, tree, "one.txt", "feat(US-001): first")
+	commitFile(t, tree, "two.txt", "feat(US-002): second")
+
+	p := &prd.PRD{
+		Project:    "test-project",
+		BranchName: "ralph/a",
+		UserStories: []prd.Story{
+			{ID: "US-001", Title: "First", Priority: 1, Passes: true},
+			{ID: "US-002", Title: "Second", Priority: 2, Passes: true},
+		},
+		IntegrationTests: []prd.IntegrationTest{{ID: "IT-001", Passes: true}},
+	}
+	if err := prd.Write(workspace.PRDPathForWorkspace(dir, "a"), p); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(workspace.ProgressPathForWorkspace(dir, "a"), []byte("did things\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	commits := prd.CommitsPath(workspace.PRDPathForWorkspace(dir, "a"))
+	prd.RecordCommits(commits, "US-001", prd.CommitRange{Start: "s1", End: "e1"})
+	prd.RecordCommits(commits, "US-002", prd.CommitRange{Start: "s2", End: "e2"})
+	return dir
+}
+
+func TestWorkspacesFork_CopiesStateFromHead(t *testing.T) {
+	dir := setupVariantSource(t)
+	ctx := context.Background()
+
+	stdout, err := captureStdout(t, func() error {
+		return workspacesDispatch([]string{"fork", "a", "b"}, strings.NewReader(""))
+	})
+	if err != nil {
+		t.Fatalf("fork: %v", err)
+	}
+	if strings.TrimSpace(stdout) != workspace.TreePath(dir, "b") {
+		t.Errorf("stdout = %q, want tree path of b", stdout)
+	}
+
+	ws, err := workspace.RegistryGet(dir, "b")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ws.ForkOf != "a" || ws.Branch != "ralph/b" {
+		t.Errorf("registry entry = %+v, want fork of a on ralph/b", ws)
+	}
+
+	repo := &shell.Runner{Dir: dir}
+	aHead, _ := gitops.RevParse(ctx, repo, "ralph/a")
+	bHead, _ := gitops.RevParse(ctx, repo, "ralph/b")
+	if aHead != bHead {
+		t.Errorf("ralph/b = %s, want source HEAD %s", bHead, aHead)
+	}
+
+	p, err := prd.Read(workspace.PRDPathForWorkspace(dir, "b"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.BranchName != "ralph/b" || !prd.AllPass(p) {
+		t.Errorf("forked PRD = %+v, want branch ralph/b with all stories passing", p)
+	}
+	progress, _ := os.ReadFile(workspace.ProgressPathForWorkspace(dir, "b"))
+	if string(progress) != "did things\n" {
+		t.Errorf("progress = %q, want copy of source", progress)
+	}
+}
+
+func TestWorkspacesFork_AtStoryResetsLaterStories(t *testing.T) {
+	dir := setupVariantSource(t)
+	ctx := context.Background()
+
+	if _, err := captureStdout(t, func() error {
+		return workspacesDispatch([]string{"fork", "a", "b", "--at", "US-001"}, strings.NewReader(""))
+	}); err != nil {
+		t.Fatalf("fork: %v", err)
+	}
+
+	out, err := (&shell.Runner{Dir: dir}).Run(ctx, "git", "log", "-1", "--format=%s", "ralph/b")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.TrimSpace(out) != "feat(US-001): first" {
+		t.Errorf("ralph/b tip = %q, want US-001 commit", strings.TrimSpace(out))
+	}
+
+	p, err := prd.Read(workspace.PRDPathForWorkspace(dir, "b"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !p.UserStories[0].Passes || p.UserStories[1].Passes {
+		t.Errorf("stories = %+v, want only US-001 passing", p.UserStories)
+	}
+	if p.IntegrationTests[0].Passes {
+		t.Error("integration tests should be reset when stories are reset")
+	}
+
+	commits, err := prd.ReadCommits(prd.CommitsPath(workspace.PRDPathForWorkspace(dir, "b")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(commits["US-001"]) != 1 || commits["US-002"] != nil {
+		t.Errorf("story commits = %v, want only US-001's range", commits)
+	}
+}
+
+func TestWorkspacesFork_UnknownStory(t *testing.T) {
+	setupVariantSource(t)
+
+	_, err := captureStdout(t, func() error {
+		return workspacesDispatch([]string{"fork", "a", "b", "--at", "US-404"}, strings.NewReader(""))
+	})
+	if err == nil || !strings.Contains(err.Error(), "no commit for story US-404") {
+		t.Fatalf("expected missing story error, got %v", err)
+	}
+}
+
+func TestWorkspacesCompare_ShowsBothVariants(t *testing.T) {
+	dir := setupVariantSource(t)
+
+	if _, err := captureStdout(t, func() error {
+		return workspacesDispatch([]string{"fork", "a", "b", "--at", "US-001"}, strings.NewReader(""))
+	}); err != nil {
+		t.Fatalf("fork: %v", err)
+	}
+
+	// Log some token spend for a.
+	logsDir := filepath.Join(workspace.WorkspacePath(dir, "a"), "logs")
+	h := events.NewFileHandler(logsDir)
+	h.Handle(events.StoryStarted{StoryID: "US-001"})
+	h.Handle(events.InvocationDone{InputTokens: 1200, OutputTokens: 300})
+	h.Handle(events.InvocationDone{InputTokens: 300, CacheCreationTokens: 200, CacheReadTokens: 300, OutputTokens: 200})
+	h.Close()
+
+	var buf bytes.Buffer
+	if err := workspacesCompare([]string{"a", "b"}, &buf); err != nil {
+		t.Fatalf("compare: %v", err)
+	}
+	out := buf.String()
+
+	for _, want := range []string{
+		"ralph/a", "ralph/b",
+		"2/2 passing", "1/2 passing",
+		"2000 in / 500 out", "0 in / 0 out",
+		"US-002 Second",
+		"2 files changed", "1 file changed",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+}
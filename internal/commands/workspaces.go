@@ -11,9 +11,11 @@ import (
 	"time"
 
 	"github.com/uesteibar/ralph/internal/claude"
+	"github.com/uesteibar/ralph/internal/config"
 	"github.com/uesteibar/ralph/internal/gitops"
 	"github.com/uesteibar/ralph/internal/prd"
 	"github.com/uesteibar/ralph/internal/prompts"
+	"github.com/uesteibar/ralph/internal/sandbox"
 	"github.com/uesteibar/ralph/internal/shell"
 	"github.com/uesteibar/ralph/internal/workspace"
 )
@@ -43,14 +45,27 @@ func workspacesDispatch(args []string, in io.Reader) error {
 		return workspacesRemove(rest)
 	case "prune":
 		return workspacesPrune(rest, in)
+	case "fork":
+		return workspacesFork(rest)
+	case "compare":
+		return workspacesCompare(rest, os.Stdout)
+	case "archive":
+		return workspacesArchive(rest)
+	case "restore":
+		return workspacesRestore(rest)
+	case "gc":
+		return workspacesGC(rest)
 	default:
-		return fmt.Errorf("unknown workspaces subcommand: %s (use 'new', 'list', 'switch', 'remove', or 'prune')", subcmd)
+		return fmt.Errorf("unknown workspaces subcommand: %s (use 'new', 'list', 'switch', 'remove', 'prune', 'fork', 'compare', 'archive', 'restore', or 'gc')", subcmd)
 	}
 }
 
 func workspacesNew(args []string, in io.Reader) error {
 	fs := flag.NewFlagSet("workspaces new", flag.ExitOnError)
 	configPath := AddProjectConfigFlag(fs)
+	fromBranch := fs.String("from-branch", "", "Adopt an existing local or origin/ branch instead of creating one")
+	fromPR := fs.Int("from-pr", 0, "Adopt the head branch of a pull request on origin")
+	on := fs.String("on", "", "Stack the workspace on top of an existing workspace")
 	if err := fs.Parse(args); err != nil {
 		return err
 	}
@@ -58,10 +73,18 @@ func workspacesNew(args []string, in io.Reader) error {
 	// Require workspace name as positional arg.
 	remaining := fs.Args()
 	if len(remaining) == 0 {
-		return fmt.Errorf("usage: ralph workspaces new <name> [--project-config path]")
+		return fmt.Errorf("usage: ralph workspaces new <name> [--on parent] [--from-branch branch | --from-pr number] [--project-config path]")
 	}
 	name := remaining[0]
 
+	// Flags may also follow the name.
+	if err := fs.Parse(remaining[1:]); err != nil {
+		return err
+	}
+	if *fromBranch != "" && *fromPR != 0 {
+		return fmt.Errorf("--from-branch and --from-pr are mutually exclusive")
+	}
+
 	// Validate workspace name.
 	if err := workspace.ValidateName(name); err != nil {
 		return err
@@ -88,44 +111,55 @@ func workspacesNew(args []string, in io.Reader) error {
 		return fmt.Errorf("Workspace %q already exists. Switch to it: ralph workspaces switch %s", name, name)
 	}
 
-	// Derive branch name.
-	branch, err := workspace.DeriveBranch(cfg.Repo.BranchPrefix, name, cfg.Repo.BranchPattern)
-	if err != nil {
-		return err
-	}
-
 	ctx := context.Background()
 	repoRunner := &shell.Runner{Dir: cfg.Repo.Path}
 
-	// Check if branch already exists.
-	existsLocally := gitops.BranchExistsLocally(ctx, repoRunner, branch)
-	if existsLocally {
-		choice, err := promptBranchChoice(branch, in)
-		if err != nil {
-			return err
-		}
-		if choice == "fresh" {
-			// Delete the existing branch so CreateWorkspace creates a new one.
-			_ = gitops.DeleteBranch(ctx, repoRunner, branch)
-		}
-		// "resume" — CreateWorkspace will detect the existing branch and use it.
-	}
-
-	// Create workspace.
 	ws := workspace.Workspace{
 		Name:      name,
-		Branch:    branch,
 		CreatedAt: time.Now(),
 	}
 
-	if err := workspace.CreateWorkspace(ctx, repoRunner, cfg.Repo.Path, ws, cfg.Repo.DefaultBase, cfg.CopyToWorktree); err != nil {
+	// Stacked workspaces branch off their parent instead of the default base.
+	base := cfg.Repo.DefaultBase
+	if *on != "" {
+		parent, err := workspace.RegistryGet(cfg.Repo.Path, *on)
+		if err != nil {
+			return fmt.Errorf("parent workspace: %w", err)
+		}
+		ws.Parent = parent.Name
+		base = parent.Branch
+	}
+
+	switch {
+	case *fromBranch != "":
+		ws.Adopted = true
+		ws.Branch, err = adoptBranch(ctx, repoRunner, *fromBranch)
+	case *fromPR != 0:
+		ws.Adopted = true
+		ws.PR = *fromPR
+		ws.Branch, err = adoptPR(ctx, repoRunner, cfg.Repo.BranchPrefix, name, cfg.Repo.BranchPattern, *fromPR)
+	default:
+		ws.Branch, err = freshBranch(ctx, repoRunner, cfg.Repo.BranchPrefix, name, cfg.Repo.BranchPattern, in)
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := workspace.CreateWorkspace(ctx, cfg.Repo.Path, ws, base, createOptions(cfg)); err != nil {
 		return fmt.Errorf("creating workspace: %w", err)
 	}
 
 	treePath := workspace.TreePath(cfg.Repo.Path, name)
 
 	// stderr: human-readable confirmation.
-	fmt.Fprintf(os.Stderr, "✓ Created workspace '%s' (branch: %s)\n", name, branch)
+	if ws.Adopted {
+		fmt.Fprintf(os.Stderr, "✓ Created workspace '%s' on existing branch %s\n", name, ws.Branch)
+	} else {
+		fmt.Fprintf(os.Stderr, "✓ Created workspace '%s' (branch: %s)\n", name, ws.Branch)
+	}
+	if ws.Parent != "" {
+		fmt.Fprintf(os.Stderr, "  stacked on '%s'\n", ws.Parent)
+	}
 
 	// stdout: absolute path to tree/ for shell function to cd into.
 	fmt.Println(treePath)
@@ -133,6 +167,77 @@ func workspacesNew(args []string, in io.Reader) error {
 	return nil
 }
 
+// createOptions returns the project settings applied to new workspaces.
+func createOptions(cfg *config.Config) workspace.CreateOptions {
+	return workspace.CreateOptions{
+		CopyPatterns: cfg.CopyToWorktree,
+		Hooks:        cfg.Hooks.WorkspaceCreated,
+		ToolPolicy:   cfg.ToolPolicy[config.PhaseStory],
+	}
+}
+
+// freshBranch derives the workspace branch name. If the branch already
+// exists locally the user picks between starting fresh and resuming it.
+func freshBranch(ctx context.Context, repoRunner *shell.Runner, prefix, name, pattern string, in io.Reader) (string, error) {
+	branch, err := workspace.DeriveBranch(prefix, name, pattern)
+	if err != nil {
+		return "", err
+	}
+
+	if gitops.BranchExistsLocally(ctx, repoRunner, branch) {
+		choice, err := promptBranchChoice(branch, in)
+		if err != nil {
+			return "", err
+		}
+		if choice == "fresh" {
+			// Delete the existing branch so CreateWorkspace creates a new one.
+			_ = gitops.DeleteBranch(ctx, repoRunner, branch)
+		}
+		// "resume" — CreateWorkspace will detect the existing branch and use it.
+	}
+	return branch, nil
+}
+
+// adoptBranch resolves a --from-branch value to a branch CreateWorkspace can
+// check out. An "origin/" prefix is stripped; branches missing locally are
+// fetched from origin.
+func adoptBranch(ctx context.Context, repoRunner *shell.Runner, branch string) (string, error) {
+	branch = strings.TrimPrefix(branch, "origin/")
+	if gitops.BranchExistsLocally(ctx, repoRunner, branch) {
+		return branch, nil
+	}
+	if err := gitops.FetchBranch(ctx, repoRunner, branch); err != nil {
+		return "", fmt.Errorf("branch %q not found locally or on origin: %w", branch, err)
+	}
+	return branch, nil
+}
+
+// adoptPR resolves a --from-pr number to a branch. When the pull request's
+// head branch lives on origin it is adopted directly so pushes update the
+// PR. Otherwise (e.g. a fork) the head is fetched into the derived branch.
+func adoptPR(ctx context.Context, repoRunner *shell.Runner, prefix, name, pattern string, number int) (string, error) {
+	head, err := gitops.PRHeadBranch(ctx, repoRunner, number)
+	if err != nil {
+		return "", err
+	}
+	if head != "" {
+		return adoptBranch(ctx, repoRunner, head)
+	}
+
+	branch, err := workspace.DeriveBranch(prefix, name, pattern)
+	if err != nil {
+		return "", err
+	}
+	if gitops.BranchExistsLocally(ctx, repoRunner, branch) {
+		return "", fmt.Errorf("branch %s already exists; pick another workspace name", branch)
+	}
+	if err := gitops.FetchPRHead(ctx, repoRunner, number, branch); err != nil {
+		return "", err
+	}
+	fmt.Fprintf(os.Stderr, "warning: pull request #%d is not on an origin branch; its head was copied to %s, so pushes will not update the PR\n", number, branch)
+	return branch, nil
+}
+
 func promptBranchChoice(branch string, in io.Reader) (string, error) {
 	fmt.Fprintf(os.Stderr, "Branch %s already exists. Start fresh or resume? (fresh/resume) ", branch)
 	scanner := bufio.NewScanner(in)
@@ -190,6 +295,15 @@ func workspacesList(args []string) error {
 			prefix = "* "
 			suffix = " [current]"
 		}
+		if e.Parent != "" {
+			suffix += " (on " + e.Parent + ")"
+		}
+		if e.ForkOf != "" {
+			suffix += " (fork of " + e.ForkOf + ")"
+		}
+		if e.Status == workspace.StatusInReview {
+			suffix += fmt.Sprintf(" (in review #%d)", e.PR)
+		}
 		if e.Missing {
 			suffix += " [missing]"
 		}
@@ -295,9 +409,10 @@ func workspacesPrune(args []string, in io.Reader) error {
 		return fmt.Errorf("reading workspace registry: %w", err)
 	}
 
+	// Workspaces in review are finished but their pull request is still open.
 	var doneNames []string
 	for _, e := range entries {
-		if e.Missing || isDoneWorkspace(cfg.Repo.Path, e.Name) {
+		if e.Missing || (e.Status != workspace.StatusInReview && isDoneWorkspace(cfg.Repo.Path, e.Name)) {
 			doneNames = append(doneNames, e.Name)
 		}
 	}
@@ -355,12 +470,12 @@ func workspacesPrune(args []string, in io.Reader) error {
 	return nil
 }
 
-// PRD handles the `ralph prd` subcommand. This is an internal command used by
-// the shell function to create a PRD after workspace creation. It is not
-// documented in `ralph help`.
+// PRD handles the `ralph prd` subcommand. `prd new` is an internal command
+// used by the shell function to create a PRD after workspace creation;
+// `prd repair` restores a corrupted PRD from its backups.
 func PRD(args []string) error {
 	if len(args) == 0 {
-		return fmt.Errorf("usage: ralph prd new")
+		return fmt.Errorf("usage: ralph prd new|repair")
 	}
 
 	subcmd := args[0]
@@ -369,8 +484,10 @@ func PRD(args []string) error {
 	switch subcmd {
 	case "new":
 		return prdNew(rest)
+	case "repair":
+		return prdRepair(rest)
 	default:
-		return fmt.Errorf("unknown prd subcommand: %s (use 'new')", subcmd)
+		return fmt.Errorf("unknown prd subcommand: %s (use 'new' or 'repair')", subcmd)
 	}
 }
 
@@ -394,30 +511,77 @@ func prdNew(args []string) error {
 
 	printWorkspaceHeader(wc, cfg.Repo.Path)
 
+	ctx := context.Background()
+	prompt, err := prompts.RenderPRDNew(prdNewData(ctx, cfg, wc), cfg.PromptsDir())
+	if err != nil {
+		return fmt.Errorf("rendering PRD prompt: %w", err)
+	}
+
+	policy, err := sandbox.New(cfg.Sandbox, config.PhaseChat, wc.WorkDir)
+	if err != nil {
+		return err
+	}
+
+	_, err = claude.Invoke(ctx, claude.InvokeOpts{
+		Prompt:          prompt,
+		Dir:             wc.WorkDir,
+		Interactive:     true,
+		AllowedTools:    cfg.ToolPolicy[config.PhaseChat].AllowRules(),
+		DisallowedTools: cfg.ToolPolicy[config.PhaseChat].DenyRules(),
+		Sandbox:         policy,
+	})
+	return err
+}
+
+// prdNewData builds the PRD prompt data. In a workspace it carries the
+// branch and the ref the branch started from, so an adopted branch's work
+// is read against its parent when stacked.
+func prdNewData(ctx context.Context, cfg *config.Config, wc workspace.WorkContext) prompts.PRDNewData {
 	data := prompts.PRDNewData{
 		ProjectName: cfg.Project,
 		PRDPath:     wc.PRDPath,
 	}
-
-	// If in a workspace, look up the branch name from registry.
 	if wc.Name != "base" {
-		ws, lookupErr := workspace.RegistryGet(cfg.Repo.Path, wc.Name)
-		if lookupErr == nil {
+		if ws, err := workspace.RegistryGet(cfg.Repo.Path, wc.Name); err == nil {
 			data.WorkspaceBranch = ws.Branch
+			data.Adopted = ws.Adopted
+			data.PRNumber = ws.PR
+			data.BaseBranch = storyBaseRef(ctx, cfg, wc)
 		}
 	}
+	return data
+}
 
-	prompt, err := prompts.RenderPRDNew(data, cfg.PromptsDir())
+func prdRepair(args []string) error {
+	fs := flag.NewFlagSet("prd repair", flag.ExitOnError)
+	configPath := AddProjectConfigFlag(fs)
+	workspaceFlag := AddWorkspaceFlag(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	cfg, err := ResolveConfig(*configPath)
 	if err != nil {
-		return fmt.Errorf("rendering PRD prompt: %w", err)
+		return fmt.Errorf("resolving config: %w", err)
 	}
 
-	_, err = claude.Invoke(context.Background(), claude.InvokeOpts{
-		Prompt:      prompt,
-		Dir:         wc.WorkDir,
-		Interactive: true,
-	})
-	return err
+	wc, err := resolveWorkContextFromFlags(*workspaceFlag, cfg.Repo.Path)
+	if err != nil {
+		return fmt.Errorf("resolving workspace context: %w", err)
+	}
+
+	printWorkspaceHeader(wc, cfg.Repo.Path)
+
+	restored, err := prd.Repair(wc.PRDPath)
+	if err != nil {
+		return err
+	}
+	if restored == "" {
+		fmt.Fprintf(os.Stderr, "✓ PRD is valid, nothing to repair\n")
+		return nil
+	}
+	fmt.Fprintf(os.Stderr, "✓ Restored PRD from %s\n", restored)
+	return nil
 }
 
 func workspacesRemove(args []string) error {
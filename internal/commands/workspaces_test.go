@@ -11,6 +11,7 @@ import (
 
 	"encoding/json"
 
+	"github.com/uesteibar/ralph/internal/config"
 	"github.com/uesteibar/ralph/internal/prd"
 	"github.com/uesteibar/ralph/internal/shell"
 	"github.com/uesteibar/ralph/internal/workspace"
@@ -776,6 +777,130 @@ func TestNewAlias_BehavesIdenticallyToWorkspacesNew(t *testing.T) {
 	}
 }
 
+// addOriginWithPR gives the repo in dir a bare origin that holds a
+// "human/feature" branch and a pull request ref (#12) pointing at it.
+func addOriginWithPR(t *testing.T, r *shell.Runner, dir string) {
+	t.Helper()
+	ctx := context.Background()
+	originDir := t.TempDir()
+	origin := &shell.Runner{Dir: originDir}
+	if _, err := origin.Run(ctx, "git", "init", "--bare"); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := r.Run(ctx, "git", "checkout", "-b", "human/feature"); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "started.txt"), []byte("human work"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	for _, c := range [][]string{
+		{"git", "add", "-A"},
+		{"git", "commit", "-m", "start feature"},
+		{"git", "remote", "add", "origin", originDir},
+		{"git", "push", "origin", "main", "human/feature"},
+		{"git", "checkout", "main"},
+		{"git", "branch", "-D", "human/feature"},
+	} {
+		if _, err := r.Run(ctx, c[0], c[1:]...); err != nil {
+			t.Fatalf("%v: %v", c, err)
+		}
+	}
+	if _, err := origin.Run(ctx, "git", "update-ref", "refs/pull/12/head", "human/feature"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestWorkspacesNew_FromBranch_AdoptsOriginBranch(t *testing.T) {
+	dir := realPath(t, t.TempDir())
+	r := initTestRepo(t, dir)
+	addOriginWithPR(t, r, dir)
+
+	t.Setenv("RALPH_SHELL_INIT", "1")
+	oldDir, _ := os.Getwd()
+	os.Chdir(dir)
+	defer os.Chdir(oldDir)
+
+	_, err := captureStdout(t, func() error {
+		return workspacesDispatch([]string{"new", "adopted", "--from-branch", "origin/human/feature"}, strings.NewReader(""))
+	})
+	if err != nil {
+		t.Fatalf("workspacesNew error: %v", err)
+	}
+
+	ws, err := workspace.RegistryGet(dir, "adopted")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ws.Branch != "human/feature" || !ws.Adopted {
+		t.Errorf("registry entry = %+v, want adopted human/feature", ws)
+	}
+	if _, err := os.Stat(filepath.Join(workspace.TreePath(dir, "adopted"), "started.txt")); err != nil {
+		t.Errorf("expected branch contents in worktree: %v", err)
+	}
+}
+
+func TestWorkspacesNew_FromPR_AdoptsHeadBranch(t *testing.T) {
+	dir := realPath(t, t.TempDir())
+	r := initTestRepo(t, dir)
+	addOriginWithPR(t, r, dir)
+
+	t.Setenv("RALPH_SHELL_INIT", "1")
+	oldDir, _ := os.Getwd()
+	os.Chdir(dir)
+	defer os.Chdir(oldDir)
+
+	_, err := captureStdout(t, func() error {
+		return workspacesDispatch([]string{"new", "review-fix", "--from-pr", "12"}, strings.NewReader(""))
+	})
+	if err != nil {
+		t.Fatalf("workspacesNew error: %v", err)
+	}
+
+	ws, err := workspace.RegistryGet(dir, "review-fix")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ws.Branch != "human/feature" || ws.PR != 12 || !ws.Adopted {
+		t.Errorf("registry entry = %+v, want human/feature from PR 12", ws)
+	}
+}
+
+func TestWorkspacesNew_FromBranchAndPR_Error(t *testing.T) {
+	dir := realPath(t, t.TempDir())
+	initTestRepo(t, dir)
+
+	t.Setenv("RALPH_SHELL_INIT", "1")
+	oldDir, _ := os.Getwd()
+	os.Chdir(dir)
+	defer os.Chdir(oldDir)
+
+	err := workspacesDispatch([]string{"new", "both", "--from-branch", "x", "--from-pr", "1"}, strings.NewReader(""))
+	if err == nil || !strings.Contains(err.Error(), "mutually exclusive") {
+		t.Fatalf("expected mutually exclusive error, got %v", err)
+	}
+}
+
+func TestWorkspacesNew_FromBranch_UnknownBranch(t *testing.T) {
+	dir := realPath(t, t.TempDir())
+	initTestRepo(t, dir)
+
+	t.Setenv("RALPH_SHELL_INIT", "1")
+	oldDir, _ := os.Getwd()
+	os.Chdir(dir)
+	defer os.Chdir(oldDir)
+
+	_, err := captureStdout(t, func() error {
+		return workspacesDispatch([]string{"new", "ghost", "--from-branch", "nope"}, strings.NewReader(""))
+	})
+	if err == nil || !strings.Contains(err.Error(), "not found locally or on origin") {
+		t.Fatalf("expected not found error, got %v", err)
+	}
+	if _, err := workspace.RegistryGet(dir, "ghost"); err == nil {
+		t.Error("workspace should not be registered")
+	}
+}
+
 func TestWorkspacesRemove_Nonexistent_Error(t *testing.T) {
 	dir := realPath(t, t.TempDir())
 	initTestRepo(t, dir)
@@ -1050,6 +1175,58 @@ func TestWorkspacesPrune_NoDoneWorkspaces(t *testing.T) {
 	}
 }
 
+func TestWorkspacesPrune_KeepsInReviewWorkspaces(t *testing.T) {
+	dir := realPath(t, t.TempDir())
+	initTestRepo(t, dir)
+
+	t.Setenv("RALPH_SHELL_INIT", "1")
+	t.Setenv("RALPH_WORKSPACE", "")
+	oldDir, _ := os.Getwd()
+	os.Chdir(dir)
+	defer os.Chdir(oldDir)
+
+	_, err := captureStdout(t, func() error {
+		return workspacesDispatch([]string{"new", "review-ws"}, strings.NewReader(""))
+	})
+	if err != nil {
+		t.Fatalf("creating workspace: %v", err)
+	}
+
+	// All stories pass, but the pull request is still open.
+	donePRD := &prd.PRD{
+		Project:     "test",
+		UserStories: []prd.Story{{ID: "US-001", Passes: true}},
+	}
+	data, _ := json.MarshalIndent(donePRD, "", "  ")
+	os.WriteFile(workspace.PRDPathForWorkspace(dir, "review-ws"), data, 0644)
+	if err := workspace.RegistryUpdate(dir, "review-ws", func(w *workspace.Workspace) {
+		w.Status = workspace.StatusInReview
+		w.PR = 7
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = captureStdout(t, func() error {
+		return workspacesDispatch([]string{"prune"}, strings.NewReader(""))
+	})
+	if err != nil {
+		t.Fatalf("prune error: %v", err)
+	}
+	if _, err := workspace.RegistryGet(dir, "review-ws"); err != nil {
+		t.Errorf("in-review workspace should not be pruned: %v", err)
+	}
+
+	out, err := captureStdout(t, func() error {
+		return workspacesDispatch([]string{"list"}, strings.NewReader(""))
+	})
+	if err != nil {
+		t.Fatalf("list error: %v", err)
+	}
+	if !strings.Contains(out, "review-ws (in review #7)") {
+		t.Errorf("list output = %q, want in-review marker", out)
+	}
+}
+
 func TestWorkspacesPrune_AbortOnNo(t *testing.T) {
 	dir := realPath(t, t.TempDir())
 	initTestRepo(t, dir)
@@ -1182,3 +1359,31 @@ func TestWorkspacesPrune_ConfirmWithYes(t *testing.T) {
 		t.Errorf("expected 0 workspaces after prune, got %d", len(entries))
 	}
 }
+
+func TestPrdNewData_StackedWorkspaceUsesParentBranch(t *testing.T) {
+	dir := realPath(t, t.TempDir())
+	initTestRepo(t, dir)
+	cfg := &config.Config{Project: "test", Repo: config.RepoConfig{Path: dir, DefaultBase: "main"}}
+
+	for _, ws := range []workspace.Workspace{
+		{Name: "auth", Branch: "ralph/auth"},
+		{Name: "login", Branch: "feature/login", Adopted: true, Parent: "auth"},
+		{Name: "signup", Branch: "ralph/signup"},
+	} {
+		if err := workspace.WriteWorkspaceJSON(dir, ws.Name, ws); err != nil {
+			t.Fatal(err)
+		}
+		if err := workspace.RegistryCreate(dir, ws); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ctx := context.Background()
+	login := prdNewData(ctx, cfg, workspace.WorkContext{Name: "login", WorkDir: dir})
+	if login.BaseBranch != "ralph/auth" || login.WorkspaceBranch != "feature/login" || !login.Adopted {
+		t.Errorf("login data = %+v, want base ralph/auth", login)
+	}
+	if signup := prdNewData(ctx, cfg, workspace.WorkContext{Name: "signup", WorkDir: dir}); signup.BaseBranch != "main" {
+		t.Errorf("signup base = %q, want main", signup.BaseBranch)
+	}
+}
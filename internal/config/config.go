@@ -2,19 +2,34 @@ package config
 
 import (
 	"fmt"
+	"maps"
+	"net/url"
 	"os"
 	"path/filepath"
 	"regexp"
+	"slices"
+	"strings"
+	"time"
 
+	"github.com/bmatcuk/doublestar/v4"
 	"gopkg.in/yaml.v3"
 )
 
 type Config struct {
-	Project        string      `yaml:"project"`
-	Repo           RepoConfig  `yaml:"repo"`
-	Paths          PathsConfig `yaml:"paths"`
-	QualityChecks  []string    `yaml:"quality_checks"`
-	CopyToWorktree []string    `yaml:"copy_to_worktree,omitempty"`
+	Project        string               `yaml:"project"`
+	Repo           RepoConfig           `yaml:"repo"`
+	Paths          PathsConfig          `yaml:"paths"`
+	QualityChecks  []string             `yaml:"quality_checks"`
+	CopyToWorktree []string             `yaml:"copy_to_worktree,omitempty"`
+	Hooks          HooksConfig          `yaml:"hooks,omitempty"`
+	Notifications  []NotificationConfig `yaml:"notifications,omitempty"`
+	Done           DoneConfig           `yaml:"done,omitempty"`
+	Sandbox        SandboxConfig        `yaml:"sandbox,omitempty"`
+	// ToolPolicy restricts the tools Claude may use, keyed by phase.
+	ToolPolicy map[string]ToolPolicy `yaml:"tool_policy,omitempty"`
+	Guardrails GuardrailsConfig      `yaml:"guardrails,omitempty"`
+	Logs       LogsConfig            `yaml:"logs,omitempty"`
+	Tracing    TracingConfig         `yaml:"tracing,omitempty"`
 }
 
 type RepoConfig struct {
@@ -29,6 +44,248 @@ type PathsConfig struct {
 	SkillsDir string `yaml:"skills_dir"`
 }
 
+// HooksConfig lists shell commands to run at points of the workspace and
+// loop lifecycle. Each list runs in order.
+type HooksConfig struct {
+	WorkspaceCreated []HookConfig `yaml:"workspace_created,omitempty"`
+	BeforeStory      []HookConfig `yaml:"before_story,omitempty"`
+	AfterStory       []HookConfig `yaml:"after_story,omitempty"`
+	QAStarted        []HookConfig `yaml:"qa_started,omitempty"`
+	RunFinished      []HookConfig `yaml:"run_finished,omitempty"`
+	BeforeDone       []HookConfig `yaml:"before_done,omitempty"`
+}
+
+// Hook failure policies.
+const (
+	HookOnFailureContinue = "continue" // fail-open: warn and carry on (default)
+	HookOnFailureAbort    = "abort"    // fail-closed: stop the calling operation
+)
+
+// HookConfig is a single lifecycle hook.
+type HookConfig struct {
+	Command   string        `yaml:"command"`
+	Timeout   time.Duration `yaml:"timeout,omitempty"`
+	OnFailure string        `yaml:"on_failure,omitempty"`
+}
+
+// FailClosed reports whether a failure of this hook should abort the
+// operation that triggered it.
+func (h HookConfig) FailClosed() bool {
+	return h.OnFailure == HookOnFailureAbort
+}
+
+// namedHooks pairs a hook list with its YAML key.
+type namedHooks struct {
+	name  string
+	hooks []HookConfig
+}
+
+// all returns every hook list with its YAML key, in declaration order.
+func (h HooksConfig) all() []namedHooks {
+	return []namedHooks{
+		{"workspace_created", h.WorkspaceCreated},
+		{"before_story", h.BeforeStory},
+		{"after_story", h.AfterStory},
+		{"qa_started", h.QAStarted},
+		{"run_finished", h.RunFinished},
+		{"before_done", h.BeforeDone},
+	}
+}
+
+// Strategies `ralph done` can land a branch with.
+const (
+	DoneStrategySquash = "squash" // one commit on the base (default)
+	DoneStrategyRebase = "rebase" // replay every commit, tagged with its story
+	DoneStrategyMerge  = "merge"  // a merge commit
+)
+
+// DoneConfig sets the defaults of `ralph done`; its flags override them.
+type DoneConfig struct {
+	Strategy string `yaml:"strategy,omitempty"`
+	// Check runs the quality checks on the merged result and rolls the merge
+	// back if they fail.
+	Check bool `yaml:"check,omitempty"`
+	// Push pushes the base branch to origin after merging and rolls the merge
+	// back if the push fails.
+	Push bool `yaml:"push,omitempty"`
+}
+
+// Sandbox tools.
+const (
+	SandboxBwrap   = "bwrap"   // bubblewrap (default)
+	SandboxUnshare = "unshare" // util-linux unshare, for hosts without bubblewrap
+)
+
+// Sandbox phases name what a sandboxed process is doing, so network access
+// can be granted per phase.
+const (
+	PhaseStory     = "story"      // implementing a story in the loop
+	PhaseQA        = "qa"         // QA verification and fixes in the loop
+	PhaseChat      = "chat"       // interactive sessions (ralph chat, PRD creation)
+	PhaseRebase    = "rebase"     // resolving rebase conflicts
+	PhasePR        = "pr"         // writing pull request descriptions
+	PhaseChecks    = "checks"     // quality checks run by ralph itself
+	PhaseRefine    = "refine"     // AutoRalph issue refinement
+	PhasePlan      = "plan"       // AutoRalph PRD generation
+	PhaseFeedback  = "feedback"   // AutoRalph review feedback
+	PhaseFixChecks = "fix_checks" // AutoRalph CI fixes
+)
+
+// SandboxPhases lists every phase that can be given its own network policy.
+var SandboxPhases = []string{
+	PhaseStory, PhaseQA, PhaseChat, PhaseRebase, PhasePR,
+	PhaseChecks, PhaseRefine, PhasePlan, PhaseFeedback, PhaseFixChecks,
+}
+
+// Network policies for SandboxConfig.Network.
+const (
+	NetworkAllow = "allow"
+	NetworkDeny  = "deny"
+)
+
+// SandboxConfig confines Claude and the commands ralph runs with Linux
+// namespaces: the workspace is writable, the rest of the filesystem is
+// read-only.
+type SandboxConfig struct {
+	Enabled bool   `yaml:"enabled,omitempty"`
+	Tool    string `yaml:"tool,omitempty"`
+	// Writable lists extra absolute paths (~ expands to the home directory)
+	// the sandboxed process may write to.
+	Writable []string `yaml:"writable,omitempty"`
+	// Network maps a phase, or "default", to allow or deny. Network is
+	// allowed when neither is set.
+	Network map[string]string `yaml:"network,omitempty"`
+}
+
+// NetworkAllowed reports whether processes of the given phase keep network
+// access.
+func (s SandboxConfig) NetworkAllowed(phase string) bool {
+	if v, ok := s.Network[phase]; ok {
+		return v != NetworkDeny
+	}
+	return s.Network["default"] != NetworkDeny
+}
+
+// ToolPolicyPhases lists the phases a tool_policy can be set for.
+var ToolPolicyPhases = []string{PhaseStory, PhaseQA, PhaseChat, PhaseRefine}
+
+// ToolPolicy restricts the tools Claude may use in a phase. Setting
+// AllowedTools or Bash.Allow turns the phase into an allow list: anything not
+// listed is denied. Deny entries always win.
+type ToolPolicy struct {
+	AllowedTools []string   `yaml:"allowed_tools,omitempty"`
+	DeniedTools  []string   `yaml:"denied_tools,omitempty"`
+	Bash         BashPolicy `yaml:"bash,omitempty"`
+}
+
+// BashPolicy lists Bash command prefixes, e.g. "go test" or "git push".
+type BashPolicy struct {
+	Allow []string `yaml:"allow,omitempty"`
+	Deny  []string `yaml:"deny,omitempty"`
+}
+
+// AllowRules returns the policy's allow list as Claude permission rules, or
+// nil when the phase is unrestricted.
+func (p ToolPolicy) AllowRules() []string {
+	return append(slices.Clone(p.AllowedTools), bashRules(p.Bash.Allow)...)
+}
+
+// DenyRules returns the policy's deny list as Claude permission rules.
+func (p ToolPolicy) DenyRules() []string {
+	return append(slices.Clone(p.DeniedTools), bashRules(p.Bash.Deny)...)
+}
+
+func bashRules(prefixes []string) []string {
+	var rules []string
+	for _, prefix := range prefixes {
+		rules = append(rules, fmt.Sprintf("Bash(%s:*)", prefix))
+	}
+	return rules
+}
+
+// GuardrailsConfig limits what a single story attempt may change. The loop
+// reverts attempts that break these limits.
+type GuardrailsConfig struct {
+	// Protected lists path globs (e.g. "migrations/**") the agent must not
+	// change. Changes to them are reverted.
+	Protected []string `yaml:"protected,omitempty"`
+	// MaxChangedLines caps the added plus deleted lines of an attempt; 0 means
+	// no limit. Larger attempts are reverted entirely.
+	MaxChangedLines int `yaml:"max_changed_lines,omitempty"`
+	// MaxChangedFiles caps the files an attempt changes; 0 means no limit.
+	// Larger attempts are reverted entirely.
+	MaxChangedFiles int `yaml:"max_changed_files,omitempty"`
+}
+
+// Enabled reports whether any guardrail is set.
+func (g GuardrailsConfig) Enabled() bool {
+	return len(g.Protected) > 0 || g.MaxChangedLines > 0 || g.MaxChangedFiles > 0
+}
+
+// LogsConfig limits how much each workspace's logs directory may hold. Zero
+// values mean no limit. Closed event logs are always compressed.
+type LogsConfig struct {
+	// MaxFileSizeMB starts a new event log once the current one reaches
+	// this size.
+	MaxFileSizeMB int `yaml:"max_file_size_mb,omitempty"`
+	// MaxTotalSizeMB deletes a workspace's oldest log files once its logs
+	// directory holds more than this.
+	MaxTotalSizeMB int `yaml:"max_total_size_mb,omitempty"`
+	// MaxAgeDays deletes log files last written longer ago than this.
+	MaxAgeDays int `yaml:"max_age_days,omitempty"`
+}
+
+// TracingConfig exports OpenTelemetry traces of the loop. Both exporters
+// may be set; with neither, tracing is off unless the standard
+// OTEL_EXPORTER_OTLP_ENDPOINT or RALPH_TRACE_FILE variables are set.
+type TracingConfig struct {
+	// Endpoint is the URL of an OTLP/HTTP collector, e.g.
+	// http://localhost:4318.
+	Endpoint string `yaml:"endpoint,omitempty"`
+	// File appends spans as JSON to this file, relative to the repo root.
+	File string `yaml:"file,omitempty"`
+}
+
+// Notification sink types.
+const (
+	NotifyWebhook = "webhook"
+	NotifyDesktop = "desktop"
+	NotifyCommand = "command"
+)
+
+// Notification event names used in NotificationConfig.Events.
+const (
+	NotifyRunSuccess     = "run_success"
+	NotifyRunFailed      = "run_failed"
+	NotifyRunCancelled   = "run_cancelled"
+	NotifyUsageLimitWait = "usage_limit_wait"
+	NotifyStoryBlocked   = "story_blocked"
+)
+
+// NotificationEvents lists every event a notification sink can subscribe to.
+var NotificationEvents = []string{
+	NotifyRunSuccess,
+	NotifyRunFailed,
+	NotifyRunCancelled,
+	NotifyUsageLimitWait,
+	NotifyStoryBlocked,
+}
+
+// NotificationConfig configures a sink that is told about run outcomes.
+// Events filters which notifications the sink receives; empty means all.
+type NotificationConfig struct {
+	Type   string   `yaml:"type"`
+	Events []string `yaml:"events,omitempty"`
+
+	// URL is the endpoint for webhook sinks.
+	URL string `yaml:"url,omitempty"`
+	// SecretEnv names the environment variable holding the webhook HMAC key.
+	SecretEnv string `yaml:"secret_env,omitempty"`
+
+	// Command is the shell command for command sinks.
+	Command string `yaml:"command,omitempty"`
+}
+
 // StatePRDPath returns the path to the current PRD staging file.
 func (c *Config) StatePRDPath() string {
 	return filepath.Join(c.Repo.Path, ".ralph", "state", "prd.json")
@@ -158,6 +415,133 @@ func (c *Config) Validate() []string {
 		}
 	}
 
+	for _, nh := range c.Hooks.all() {
+		name := nh.name
+		for i, h := range nh.hooks {
+			if h.Command == "" {
+				issues = append(issues, fmt.Sprintf("hooks.%s[%d]: missing command", name, i))
+			}
+			if h.Timeout < 0 {
+				issues = append(issues, fmt.Sprintf("hooks.%s[%d]: timeout must not be negative", name, i))
+			}
+			switch h.OnFailure {
+			case "", HookOnFailureContinue, HookOnFailureAbort:
+			default:
+				issues = append(issues, fmt.Sprintf("hooks.%s[%d]: on_failure must be %q or %q, got %q",
+					name, i, HookOnFailureContinue, HookOnFailureAbort, h.OnFailure))
+			}
+		}
+	}
+
+	for i, n := range c.Notifications {
+		switch n.Type {
+		case NotifyWebhook:
+			if n.URL == "" {
+				issues = append(issues, fmt.Sprintf("notifications[%d]: webhook requires url", i))
+			}
+		case NotifyCommand:
+			if n.Command == "" {
+				issues = append(issues, fmt.Sprintf("notifications[%d]: command requires command", i))
+			}
+		case NotifyDesktop:
+		default:
+			issues = append(issues, fmt.Sprintf("notifications[%d]: unknown type %q (use %q, %q or %q)",
+				i, n.Type, NotifyWebhook, NotifyDesktop, NotifyCommand))
+		}
+		for _, ev := range n.Events {
+			if !slices.Contains(NotificationEvents, ev) {
+				issues = append(issues, fmt.Sprintf("notifications[%d]: unknown event %q", i, ev))
+			}
+		}
+	}
+
+	switch c.Done.Strategy {
+	case "", DoneStrategySquash, DoneStrategyRebase, DoneStrategyMerge:
+	default:
+		issues = append(issues, fmt.Sprintf("done.strategy must be %q, %q or %q, got %q",
+			DoneStrategySquash, DoneStrategyRebase, DoneStrategyMerge, c.Done.Strategy))
+	}
+	if c.Done.Check && len(c.QualityChecks) == 0 {
+		issues = append(issues, "warning: done.check is set but no quality_checks are defined")
+	}
+
+	switch c.Sandbox.Tool {
+	case "", SandboxBwrap, SandboxUnshare:
+	default:
+		issues = append(issues, fmt.Sprintf("sandbox.tool must be %q or %q, got %q",
+			SandboxBwrap, SandboxUnshare, c.Sandbox.Tool))
+	}
+	for _, w := range c.Sandbox.Writable {
+		if !filepath.IsAbs(w) && w != "~" && !strings.HasPrefix(w, "~/") {
+			issues = append(issues, fmt.Sprintf("sandbox.writable: %q must be an absolute path", w))
+		}
+	}
+	for _, phase := range slices.Sorted(maps.Keys(c.Sandbox.Network)) {
+		policy := c.Sandbox.Network[phase]
+		if phase != "default" && !slices.Contains(SandboxPhases, phase) {
+			issues = append(issues, fmt.Sprintf("sandbox.network: unknown phase %q", phase))
+		}
+		switch policy {
+		case NetworkAllow:
+		case NetworkDeny:
+			// Claude itself runs inside the sandbox and must reach its API.
+			if c.Sandbox.Enabled && phase != PhaseChecks {
+				issues = append(issues, fmt.Sprintf("warning: sandbox.network.%s is deny — Claude cannot reach its API without network, so only the checks phase works offline", phase))
+			}
+		default:
+			issues = append(issues, fmt.Sprintf("sandbox.network.%s must be %q or %q, got %q",
+				phase, NetworkAllow, NetworkDeny, policy))
+		}
+	}
+
+	for _, phase := range slices.Sorted(maps.Keys(c.ToolPolicy)) {
+		if !slices.Contains(ToolPolicyPhases, phase) {
+			issues = append(issues, fmt.Sprintf("tool_policy: unknown phase %q (use %s)",
+				phase, strings.Join(ToolPolicyPhases, ", ")))
+			continue
+		}
+		p := c.ToolPolicy[phase]
+		for _, prefix := range slices.Concat(p.Bash.Allow, p.Bash.Deny) {
+			if strings.TrimSpace(prefix) == "" || strings.ContainsAny(prefix, "()") {
+				issues = append(issues, fmt.Sprintf("tool_policy.%s.bash: invalid command prefix %q", phase, prefix))
+			}
+		}
+		if len(p.Bash.Allow) > 0 && slices.Contains(p.AllowedTools, "Bash") {
+			issues = append(issues, fmt.Sprintf("warning: tool_policy.%s allows all of Bash, so bash.allow has no effect", phase))
+		}
+	}
+
+	for _, pattern := range c.Guardrails.Protected {
+		if !doublestar.ValidatePattern(pattern) {
+			issues = append(issues, fmt.Sprintf("guardrails.protected: %q is not a valid glob", pattern))
+		}
+	}
+	if c.Guardrails.MaxChangedLines < 0 {
+		issues = append(issues, "guardrails.max_changed_lines must not be negative")
+	}
+	if c.Guardrails.MaxChangedFiles < 0 {
+		issues = append(issues, "guardrails.max_changed_files must not be negative")
+	}
+
+	if c.Logs.MaxFileSizeMB < 0 {
+		issues = append(issues, "logs.max_file_size_mb must not be negative")
+	}
+	if c.Logs.MaxTotalSizeMB < 0 {
+		issues = append(issues, "logs.max_total_size_mb must not be negative")
+	}
+	if c.Logs.MaxAgeDays < 0 {
+		issues = append(issues, "logs.max_age_days must not be negative")
+	}
+	if c.Logs.MaxFileSizeMB > 0 && c.Logs.MaxTotalSizeMB > 0 && c.Logs.MaxFileSizeMB > c.Logs.MaxTotalSizeMB {
+		issues = append(issues, "warning: logs.max_file_size_mb is larger than logs.max_total_size_mb")
+	}
+
+	if c.Tracing.Endpoint != "" {
+		if u, err := url.Parse(c.Tracing.Endpoint); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			issues = append(issues, fmt.Sprintf("tracing.endpoint must be an http or https URL, got %q", c.Tracing.Endpoint))
+		}
+	}
+
 	if len(c.QualityChecks) == 0 {
 		issues = append(issues, "warning: no quality_checks defined — the loop will commit without verification")
 	}
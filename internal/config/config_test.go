@@ -3,7 +3,9 @@ package config
 import (
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestLoad_ExampleConfig_ParsesAllFields(t *testing.T) {
@@ -228,6 +230,277 @@ func TestLoad_BranchPrefix_CustomValue(t *testing.T) {
 	}
 }
 
+func TestLoad_Hooks_ParsesField(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "ralph.yaml")
+	content := `project: Test
+repo:
+  default_base: main
+hooks:
+  workspace_created:
+    - command: "./scripts/seed-db.sh"
+      timeout: 2m
+      on_failure: abort
+  after_story:
+    - command: "notify-chat"
+`
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+
+	if len(cfg.Hooks.WorkspaceCreated) != 1 {
+		t.Fatalf("WorkspaceCreated length = %d, want 1", len(cfg.Hooks.WorkspaceCreated))
+	}
+	h := cfg.Hooks.WorkspaceCreated[0]
+	if h.Command != "./scripts/seed-db.sh" {
+		t.Errorf("Command = %q, want %q", h.Command, "./scripts/seed-db.sh")
+	}
+	if h.Timeout != 2*time.Minute {
+		t.Errorf("Timeout = %v, want 2m", h.Timeout)
+	}
+	if !h.FailClosed() {
+		t.Error("expected FailClosed() = true for on_failure: abort")
+	}
+	if len(cfg.Hooks.AfterStory) != 1 || cfg.Hooks.AfterStory[0].FailClosed() {
+		t.Errorf("AfterStory = %+v, want one fail-open hook", cfg.Hooks.AfterStory)
+	}
+	if len(cfg.Hooks.BeforeDone) != 0 {
+		t.Errorf("BeforeDone length = %d, want 0", len(cfg.Hooks.BeforeDone))
+	}
+}
+
+func TestValidate_Hooks_ReportsIssues(t *testing.T) {
+	cfg := &Config{
+		Project:       "P",
+		Repo:          RepoConfig{DefaultBase: "main"},
+		QualityChecks: []string{"true"},
+		Hooks: HooksConfig{
+			BeforeStory: []HookConfig{{Command: ""}},
+			BeforeDone:  []HookConfig{{Command: "true", OnFailure: "explode"}},
+		},
+	}
+
+	issues := cfg.Validate()
+	if len(issues) != 2 {
+		t.Fatalf("expected 2 issues, got %d: %v", len(issues), issues)
+	}
+	if !contains(issues[0], "hooks.before_story[0]: missing command") {
+		t.Errorf("issues[0] = %q, want missing command", issues[0])
+	}
+	if !contains(issues[1], "hooks.before_done[0]: on_failure") {
+		t.Errorf("issues[1] = %q, want on_failure issue", issues[1])
+	}
+}
+
+func TestValidate_Notifications_ReportsIssues(t *testing.T) {
+	cfg := &Config{
+		Project:       "P",
+		Repo:          RepoConfig{DefaultBase: "main"},
+		QualityChecks: []string{"true"},
+		Notifications: []NotificationConfig{
+			{Type: NotifyWebhook},
+			{Type: NotifyDesktop, Events: []string{NotifyRunFailed, "lunch_time"}},
+			{Type: "pager"},
+		},
+	}
+
+	issues := cfg.Validate()
+	if len(issues) != 3 {
+		t.Fatalf("expected 3 issues, got %d: %v", len(issues), issues)
+	}
+	if !contains(issues[0], "notifications[0]: webhook requires url") {
+		t.Errorf("issues[0] = %q, want missing url", issues[0])
+	}
+	if !contains(issues[1], `notifications[1]: unknown event "lunch_time"`) {
+		t.Errorf("issues[1] = %q, want unknown event", issues[1])
+	}
+	if !contains(issues[2], `notifications[2]: unknown type "pager"`) {
+		t.Errorf("issues[2] = %q, want unknown type", issues[2])
+	}
+}
+
+func TestValidate_DoneStrategy(t *testing.T) {
+	cfg := &Config{
+		Project:       "P",
+		Repo:          RepoConfig{DefaultBase: "main"},
+		QualityChecks: []string{"true"},
+		Done:          DoneConfig{Strategy: "octopus"},
+	}
+	issues := cfg.Validate()
+	if len(issues) != 1 || !contains(issues[0], `done.strategy must be "squash", "rebase" or "merge", got "octopus"`) {
+		t.Errorf("issues = %v, want invalid strategy", issues)
+	}
+
+	cfg.Done.Strategy = DoneStrategyRebase
+	if issues := cfg.Validate(); len(issues) != 0 {
+		t.Errorf("issues = %v, want none", issues)
+	}
+}
+
+func TestValidate_Sandbox(t *testing.T) {
+	cfg := &Config{
+		Project:       "P",
+		Repo:          RepoConfig{DefaultBase: "main"},
+		QualityChecks: []string{"true"},
+		Sandbox: SandboxConfig{
+			Enabled:  true,
+			Tool:     "docker",
+			Writable: []string{"cache"},
+			Network:  map[string]string{"checks": "deny", "build": "allow", "story": "maybe"},
+		},
+	}
+	issues := cfg.Validate()
+	for _, want := range []string{
+		`sandbox.tool must be "bwrap" or "unshare", got "docker"`,
+		`sandbox.writable: "cache" must be an absolute path`,
+		`sandbox.network: unknown phase "build"`,
+		`sandbox.network.story must be "allow" or "deny", got "maybe"`,
+	} {
+		found := false
+		for _, issue := range issues {
+			found = found || contains(issue, want)
+		}
+		if !found {
+			t.Errorf("issues = %v, want %q", issues, want)
+		}
+	}
+	if len(issues) != 4 {
+		t.Errorf("issues = %v, want 4", issues)
+	}
+
+	cfg.Sandbox = SandboxConfig{Enabled: true, Writable: []string{"~/.cache"}, Network: map[string]string{"story": "deny"}}
+	issues = cfg.Validate()
+	if len(issues) != 1 || !contains(issues[0], "warning: sandbox.network.story is deny") {
+		t.Errorf("issues = %v, want a warning for an offline agent phase", issues)
+	}
+}
+
+func TestSandboxConfig_NetworkAllowed(t *testing.T) {
+	var s SandboxConfig
+	if !s.NetworkAllowed(PhaseStory) {
+		t.Error("network should be allowed by default")
+	}
+
+	s.Network = map[string]string{"default": NetworkDeny, PhaseStory: NetworkAllow}
+	if !s.NetworkAllowed(PhaseStory) {
+		t.Error("phase setting should override the default")
+	}
+	if s.NetworkAllowed(PhaseChecks) {
+		t.Error("unlisted phases should use the default")
+	}
+}
+
+func TestValidate_ToolPolicy(t *testing.T) {
+	cfg := &Config{
+		Project:       "P",
+		Repo:          RepoConfig{DefaultBase: "main"},
+		QualityChecks: []string{"true"},
+		ToolPolicy: map[string]ToolPolicy{
+			"build": {},
+			"story": {AllowedTools: []string{"Bash"}, Bash: BashPolicy{Allow: []string{"go test"}, Deny: []string{"rm (x)"}}},
+		},
+	}
+	issues := cfg.Validate()
+	for _, want := range []string{
+		`tool_policy: unknown phase "build"`,
+		`tool_policy.story.bash: invalid command prefix "rm (x)"`,
+		`warning: tool_policy.story allows all of Bash`,
+	} {
+		found := false
+		for _, issue := range issues {
+			found = found || contains(issue, want)
+		}
+		if !found {
+			t.Errorf("issues = %v, want %q", issues, want)
+		}
+	}
+	if len(issues) != 3 {
+		t.Errorf("issues = %v, want 3", issues)
+	}
+}
+
+func TestValidate_Guardrails(t *testing.T) {
+	cfg := &Config{
+		Project:       "P",
+		Repo:          RepoConfig{DefaultBase: "main"},
+		QualityChecks: []string{"true"},
+		Guardrails: GuardrailsConfig{
+			Protected:       []string{"migrations/**", "vendor/[a"},
+			MaxChangedLines: -1,
+		},
+	}
+	issues := cfg.Validate()
+	want := []string{
+		`guardrails.protected: "vendor/[a" is not a valid glob`,
+		"guardrails.max_changed_lines must not be negative",
+	}
+	if strings.Join(issues, "\n") != strings.Join(want, "\n") {
+		t.Errorf("issues = %v, want %v", issues, want)
+	}
+	if !cfg.Guardrails.Enabled() || (GuardrailsConfig{}).Enabled() {
+		t.Error("Enabled should report whether any guardrail is set")
+	}
+}
+
+func TestValidate_Logs(t *testing.T) {
+	cfg := &Config{
+		Project:       "P",
+		Repo:          RepoConfig{DefaultBase: "main"},
+		QualityChecks: []string{"true"},
+		Logs:          LogsConfig{MaxFileSizeMB: 50, MaxTotalSizeMB: 20, MaxAgeDays: -1},
+	}
+	issues := cfg.Validate()
+	want := []string{
+		"logs.max_age_days must not be negative",
+		"warning: logs.max_file_size_mb is larger than logs.max_total_size_mb",
+	}
+	if strings.Join(issues, "\n") != strings.Join(want, "\n") {
+		t.Errorf("issues = %v, want %v", issues, want)
+	}
+}
+
+func TestValidate_TracingEndpoint(t *testing.T) {
+	for endpoint, valid := range map[string]bool{
+		"http://localhost:4318":         true,
+		"https://otel.example.com/otlp": true,
+		"localhost:4318":                false,
+	} {
+		cfg := &Config{
+			Project:       "P",
+			Repo:          RepoConfig{DefaultBase: "main"},
+			QualityChecks: []string{"true"},
+			Tracing:       TracingConfig{Endpoint: endpoint},
+		}
+		if issues := cfg.Validate(); (len(issues) == 0) != valid {
+			t.Errorf("Validate(%q) = %v, want valid=%v", endpoint, issues, valid)
+		}
+	}
+}
+
+func TestToolPolicy_Rules(t *testing.T) {
+	var unrestricted ToolPolicy
+	if unrestricted.AllowRules() != nil || unrestricted.DenyRules() != nil {
+		t.Error("an empty policy should produce no rules")
+	}
+
+	p := ToolPolicy{
+		AllowedTools: []string{"Read", "Edit"},
+		DeniedTools:  []string{"WebFetch"},
+		Bash:         BashPolicy{Allow: []string{"go test"}, Deny: []string{"git push"}},
+	}
+	if got := strings.Join(p.AllowRules(), ","); got != "Read,Edit,Bash(go test:*)" {
+		t.Errorf("AllowRules = %s", got)
+	}
+	if got := strings.Join(p.DenyRules(), ","); got != "WebFetch,Bash(git push:*)" {
+		t.Errorf("DenyRules = %s", got)
+	}
+}
+
 func TestDiscover_SkipsConfigInsideWorkspaceTree(t *testing.T) {
 	// Simulate the real workspace structure:
 	// <repo>/.ralph/ralph.yaml            ← real config (should be found)
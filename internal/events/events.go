@@ -17,10 +17,55 @@ type ToolUse struct {
 	Name    string `json:"name"`
 	Detail  string `json:"detail"`
 	WorkDir string `json:"workDir"`
+	// Subagent is the description of the Task a subagent made the call in.
+	// It is empty for calls made by the main agent.
+	Subagent string `json:"subagent,omitempty"`
 }
 
 func (ToolUse) eventTag() {}
 
+// ToolResult is emitted when a tool call returns, successfully or not.
+type ToolResult struct {
+	Name    string `json:"name"`
+	Detail  string `json:"detail"`
+	IsError bool   `json:"isError"`
+	// Output is the start of the tool output, truncated to a few hundred bytes.
+	Output   string `json:"output"`
+	Subagent string `json:"subagent,omitempty"`
+}
+
+func (ToolResult) eventTag() {}
+
+// SessionInit is emitted when a Claude session starts.
+type SessionInit struct {
+	SessionID string `json:"sessionId"`
+	Model     string `json:"model"`
+}
+
+func (SessionInit) eventTag() {}
+
+// FileEdited is emitted when an Edit, MultiEdit or Write call succeeds.
+// Added and Removed count changed lines, not counting those the edit kept.
+// They are estimated from the tool input alone: a Write counts every line of
+// the new content as added and none as removed, and an Edit with replace_all
+// counts a single replacement.
+type FileEdited struct {
+	Path    string `json:"path"`
+	Added   int    `json:"added"`
+	Removed int    `json:"removed"`
+}
+
+func (FileEdited) eventTag() {}
+
+// ToolDenied is emitted when the tool policy of the phase denied a tool call
+// Claude attempted.
+type ToolDenied struct {
+	Name   string `json:"name"`
+	Detail string `json:"detail"`
+}
+
+func (ToolDenied) eventTag() {}
+
 // AgentText is emitted when Claude produces text output.
 type AgentText struct {
 	Text string `json:"text"`
@@ -34,14 +79,40 @@ type InvocationDone struct {
 	DurationMS   int `json:"durationMs"`
 	InputTokens  int `json:"inputTokens"`
 	OutputTokens int `json:"outputTokens"`
+	// CacheCreationTokens and CacheReadTokens are input tokens written to
+	// and served from the prompt cache, on top of InputTokens.
+	CacheCreationTokens int     `json:"cacheCreationTokens,omitempty"`
+	CacheReadTokens     int     `json:"cacheReadTokens,omitempty"`
+	CostUSD             float64 `json:"costUsd,omitempty"`
 }
 
 func (InvocationDone) eventTag() {}
 
+// TotalInputTokens returns all input tokens of the invocation, cached or not.
+func (e InvocationDone) TotalInputTokens() int {
+	return e.InputTokens + e.CacheCreationTokens + e.CacheReadTokens
+}
+
+// CommitCreated is emitted for each commit a Claude invocation landed.
+// StoryID is empty for commits made during the QA phase.
+type CommitCreated struct {
+	SHA          string `json:"sha"`
+	Subject      string `json:"subject"`
+	StoryID      string `json:"storyId,omitempty"`
+	FilesChanged int    `json:"filesChanged"`
+	Insertions   int    `json:"insertions"`
+	Deletions    int    `json:"deletions"`
+}
+
+func (CommitCreated) eventTag() {}
+
 // IterationStart is emitted at the beginning of each loop iteration.
 type IterationStart struct {
 	Iteration     int `json:"iteration"`
 	MaxIterations int `json:"maxIterations"`
+	// Scope describes a run restricted to part of the PRD, e.g. "story
+	// US-003". It is empty for a full run.
+	Scope string `json:"scope,omitempty"`
 }
 
 func (IterationStart) eventTag() {}
@@ -91,6 +91,60 @@ func TestPlainTextHandler_InvocationDone(t *testing.T) {
 	}
 }
 
+func TestPlainTextHandler_InvocationDone_WithCost(t *testing.T) {
+	var buf bytes.Buffer
+	h := &PlainTextHandler{W: &buf}
+
+	h.Handle(InvocationDone{NumTurns: 5, DurationMS: 12000, CostUSD: 0.4213})
+
+	if output := stripANSI(buf.String()); !strings.Contains(output, "(5 turns, 12s, $0.42)") {
+		t.Errorf("expected the cost, got %q", output)
+	}
+}
+
+func TestPlainTextHandler_ToolResult_OnlyErrors(t *testing.T) {
+	var buf bytes.Buffer
+	h := &PlainTextHandler{W: &buf}
+
+	h.Handle(ToolResult{Name: "Read", Output: "package main"})
+	if buf.Len() != 0 {
+		t.Errorf("successful results should print nothing, got %q", buf.String())
+	}
+
+	h.Handle(ToolResult{Name: "Bash", IsError: true, Output: "exit status 1\nmore"})
+	output := stripANSI(buf.String())
+	if !strings.Contains(output, "✗ Bash failed: exit status 1") || strings.Contains(output, "more") {
+		t.Errorf("expected the first output line of the failure, got %q", output)
+	}
+}
+
+func TestPlainTextHandler_FileEditedAndSubagent(t *testing.T) {
+	var buf bytes.Buffer
+	h := &PlainTextHandler{W: &buf}
+
+	h.Handle(FileEdited{Path: "main.go", Added: 3, Removed: 1})
+	h.Handle(ToolUse{Name: "Glob", Detail: "*.go", Subagent: "Explore"})
+
+	output := stripANSI(buf.String())
+	if !strings.Contains(output, "✎ main.go +3 -1") {
+		t.Errorf("expected the edit delta, got %q", output)
+	}
+	if !strings.Contains(output, "    ↳ Glob *.go") {
+		t.Errorf("expected a nested subagent call, got %q", output)
+	}
+}
+
+func TestPlainTextHandler_CommitCreated(t *testing.T) {
+	var buf bytes.Buffer
+	h := &PlainTextHandler{W: &buf}
+
+	h.Handle(CommitCreated{SHA: "abc1234def", Subject: "feat(US-002): Logout", StoryID: "US-002", FilesChanged: 3, Insertions: 10, Deletions: 2})
+
+	if output := stripANSI(buf.String()); !strings.Contains(output, "● commit abc1234 feat(US-002): Logout (3 files, +10 -2)") {
+		t.Errorf("unexpected output %q", output)
+	}
+}
+
 func TestPlainTextHandler_IterationStart(t *testing.T) {
 	var buf bytes.Buffer
 	h := &PlainTextHandler{W: &buf}
@@ -185,4 +239,8 @@ func TestEventTypes_ImplementEvent(t *testing.T) {
 	var _ Event = QAPhaseStarted{}
 	var _ Event = UsageLimitWait{}
 	var _ Event = LogMessage{}
+	var _ Event = ToolResult{}
+	var _ Event = SessionInit{}
+	var _ Event = FileEdited{}
+	var _ Event = CommitCreated{}
 }
@@ -11,10 +11,21 @@ import (
 // under a workspace's logs/ directory. A new log file is created when
 // StoryStarted or QAPhaseStarted events are received. Events before the
 // first such event go to a startup-<timestamp>.jsonl file.
+//
+// With a Retention set, a file that grows past MaxFileSize continues in
+// <name>.<n>.jsonl, closed files are compressed, and the directory is pruned
+// each time a file is closed.
 type FileHandler struct {
-	logsDir string
-	nowFn   func() time.Time
-	file    *os.File
+	logsDir   string
+	nowFn     func() time.Time
+	file      *os.File
+	retention Retention
+
+	// path, base and part name the current file; written counts its bytes.
+	path    string
+	base    string
+	part    int
+	written int64
 }
 
 // NewFileHandler creates a FileHandler that writes JSONL log files to logsDir.
@@ -26,45 +37,83 @@ func newFileHandler(logsDir string, nowFn func() time.Time) *FileHandler {
 	return &FileHandler{logsDir: logsDir, nowFn: nowFn}
 }
 
+// SetRetention sets the policy applied to the logs directory.
+func (h *FileHandler) SetRetention(r Retention) {
+	h.retention = r
+}
+
 func (h *FileHandler) Handle(event Event) {
 	switch e := event.(type) {
 	case StoryStarted:
-		h.rotateFile(fmt.Sprintf("%s-%s.jsonl", e.StoryID, h.timestamp()))
+		h.rotateFile(fmt.Sprintf("%s-%s", e.StoryID, h.timestamp()))
 	case QAPhaseStarted:
-		h.rotateFile(fmt.Sprintf("QA-%s-%s.jsonl", e.Phase, h.timestamp()))
+		h.rotateFile(fmt.Sprintf("QA-%s-%s", e.Phase, h.timestamp()))
+	default:
+		if h.file != nil && h.retention.MaxFileSize > 0 && h.written >= h.retention.MaxFileSize {
+			h.part++
+			h.openFile(fmt.Sprintf("%s.%d.jsonl", h.base, h.part))
+		}
 	}
 
 	h.ensureFile()
 	h.writeLine(event)
 }
 
-// Close closes the current log file.
+// Close closes the current log file and applies the retention policy.
 func (h *FileHandler) Close() {
-	if h.file != nil {
-		h.file.Close()
-		h.file = nil
+	closed := h.closeFile()
+	if closed != "" {
+		h.tidy("")
+	}
+}
+
+// closeFile closes the current log file and returns its path.
+func (h *FileHandler) closeFile() string {
+	if h.file == nil {
+		return ""
 	}
+	h.file.Close()
+	h.file = nil
+	path := h.path
+	h.path = ""
+	return path
+}
+
+// rotateFile starts a new log file named after base.
+func (h *FileHandler) rotateFile(base string) {
+	h.base = base
+	h.part = 0
+	h.openFile(base + ".jsonl")
 }
 
-func (h *FileHandler) rotateFile(name string) {
-	h.Close()
+// openFile closes the current log file and continues in name.
+func (h *FileHandler) openFile(name string) {
+	closed := h.closeFile()
 	f, err := h.createFile(name)
-	if err != nil {
+	if err == nil {
+		h.file = f
+		h.path = f.Name()
+		h.written = 0
+	}
+	if closed != "" {
+		h.tidy(h.path)
+	}
+}
+
+// tidy compresses closed logs and prunes the directory, leaving the file at
+// active alone. Errors are ignored: logging must not stop the loop.
+func (h *FileHandler) tidy(active string) {
+	if h.retention == (Retention{}) {
 		return
 	}
-	h.file = f
+	PruneLogs(h.logsDir, h.retention, active, h.nowFn())
 }
 
 func (h *FileHandler) ensureFile() {
 	if h.file != nil {
 		return
 	}
-	name := fmt.Sprintf("startup-%s.jsonl", h.timestamp())
-	f, err := h.createFile(name)
-	if err != nil {
-		return
-	}
-	h.file = f
+	h.rotateFile(fmt.Sprintf("startup-%s", h.timestamp()))
 }
 
 func (h *FileHandler) createFile(name string) (*os.File, error) {
@@ -78,12 +127,12 @@ func (h *FileHandler) writeLine(event Event) {
 	if h.file == nil {
 		return
 	}
-	data, err := MarshalEvent(event)
+	data, err := MarshalEventAt(event, h.nowFn())
 	if err != nil {
 		return
 	}
-	h.file.Write(data)
-	h.file.Write([]byte("\n"))
+	n, _ := h.file.Write(append(data, '\n'))
+	h.written += int64(n)
 }
 
 func (h *FileHandler) timestamp() string {
@@ -1,6 +1,7 @@
 package events
 
 import (
+	"context"
 	"os"
 	"path/filepath"
 	"strings"
@@ -256,3 +257,42 @@ func readLines(t *testing.T, path string) []string {
 	}
 	return strings.Split(text, "\n")
 }
+
+func TestFileHandler_RetentionSplitsAndCompresses(t *testing.T) {
+	dir := t.TempDir()
+	ts := time.Date(2026, 2, 6, 10, 0, 0, 0, time.UTC)
+	h := newFileHandler(dir, func() time.Time { return ts })
+	h.SetRetention(Retention{MaxFileSize: 100, Compress: true})
+
+	h.Handle(StoryStarted{StoryID: "US-001", Title: "Build auth"})
+	for i := 0; i < 5; i++ {
+		h.Handle(ToolUse{Name: "Read", Detail: "internal/auth/session.go"})
+	}
+
+	// Only the file being written stays uncompressed.
+	if files := listJSONLFiles(t, dir); len(files) != 1 {
+		t.Fatalf("expected 1 open file, got %v", files)
+	}
+	h.Close()
+	if files := listJSONLFiles(t, dir); len(files) != 0 {
+		t.Fatalf("expected every file compressed after Close, got %v", files)
+	}
+
+	compressed, _ := filepath.Glob(filepath.Join(dir, "*.jsonl.gz"))
+	if len(compressed) < 2 {
+		t.Fatalf("expected the story log split in several files, got %v", compressed)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "US-001-20260206T100000Z.1.jsonl.gz")); err != nil {
+		t.Errorf("expected a second part of the story log: %v", err)
+	}
+
+	lr := NewLogReader(dir)
+	lr.SetBackfill(0)
+	ctx, cancel := context.WithCancel(context.Background())
+	go lr.Run(ctx)
+	time.Sleep(300 * time.Millisecond)
+	cancel()
+	if events := collectEvents(lr.Events(), time.Second); len(events) != 6 {
+		t.Errorf("read back %d events, want 6", len(events))
+	}
+}
@@ -3,11 +3,17 @@ package events
 import (
 	"encoding/json"
 	"fmt"
+	"time"
 )
 
 // Type discriminator values for JSON serialization.
 const (
 	typeToolUse         = "tool_use"
+	typeToolDenied      = "tool_denied"
+	typeToolResult      = "tool_result"
+	typeSessionInit     = "session_init"
+	typeFileEdited      = "file_edited"
+	typeCommitCreated   = "commit_created"
 	typeAgentText       = "agent_text"
 	typeInvocationDone  = "invocation_done"
 	typeIterationStart  = "iteration_start"
@@ -21,15 +27,61 @@ const (
 // envelope wraps an event with a type discriminator for JSON serialization.
 type envelope struct {
 	Type string          `json:"type"`
+	Time *time.Time      `json:"time,omitempty"`
 	Data json.RawMessage `json:"data,omitempty"`
 }
 
+// Record is an event read back from a log together with the time it was
+// logged. Time is zero for events logged before timestamps were recorded.
+type Record struct {
+	Time  time.Time
+	Event Event
+}
+
 // MarshalEvent serializes an Event to JSON with a "type" discriminator field.
 func MarshalEvent(e Event) ([]byte, error) {
+	return marshalEnvelope(e, nil)
+}
+
+// MarshalEventAt serializes an Event like MarshalEvent, stamped with the time
+// it happened.
+func MarshalEventAt(e Event, t time.Time) ([]byte, error) {
+	t = t.UTC()
+	return marshalEnvelope(e, &t)
+}
+
+func marshalEnvelope(e Event, t *time.Time) ([]byte, error) {
+	typeName, err := TypeName(e)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := json.Marshal(e)
+	if err != nil {
+		return nil, err
+	}
+
+	env := envelope{Type: typeName, Time: t, Data: data}
+	return json.Marshal(env)
+}
+
+// TypeName returns the "type" discriminator an event is serialized with,
+// e.g. "tool_use".
+func TypeName(e Event) (string, error) {
 	var typeName string
 	switch e.(type) {
 	case ToolUse:
 		typeName = typeToolUse
+	case ToolDenied:
+		typeName = typeToolDenied
+	case ToolResult:
+		typeName = typeToolResult
+	case SessionInit:
+		typeName = typeSessionInit
+	case FileEdited:
+		typeName = typeFileEdited
+	case CommitCreated:
+		typeName = typeCommitCreated
 	case AgentText:
 		typeName = typeAgentText
 	case InvocationDone:
@@ -47,25 +99,39 @@ func MarshalEvent(e Event) ([]byte, error) {
 	case PRDRefresh:
 		typeName = typePRDRefresh
 	default:
-		return nil, fmt.Errorf("unknown event type: %T", e)
+		return "", fmt.Errorf("unknown event type: %T", e)
 	}
+	return typeName, nil
+}
 
-	data, err := json.Marshal(e)
+// UnmarshalEvent deserializes an Event from JSON using the "type" discriminator field.
+func UnmarshalEvent(b []byte) (Event, error) {
+	rec, err := UnmarshalRecord(b)
 	if err != nil {
 		return nil, err
 	}
-
-	env := envelope{Type: typeName, Data: data}
-	return json.Marshal(env)
+	return rec.Event, nil
 }
 
-// UnmarshalEvent deserializes an Event from JSON using the "type" discriminator field.
-func UnmarshalEvent(b []byte) (Event, error) {
+// UnmarshalRecord deserializes an Event and the time it was logged.
+func UnmarshalRecord(b []byte) (Record, error) {
 	var env envelope
 	if err := json.Unmarshal(b, &env); err != nil {
-		return nil, err
+		return Record{}, err
 	}
 
+	e, err := decodeEnvelope(env)
+	if err != nil {
+		return Record{}, err
+	}
+	rec := Record{Event: e}
+	if env.Time != nil {
+		rec.Time = *env.Time
+	}
+	return rec, nil
+}
+
+func decodeEnvelope(env envelope) (Event, error) {
 	if env.Type == "" {
 		return nil, fmt.Errorf("missing event type field")
 	}
@@ -77,6 +143,36 @@ func UnmarshalEvent(b []byte) (Event, error) {
 			return nil, err
 		}
 		return e, nil
+	case typeToolDenied:
+		var e ToolDenied
+		if err := json.Unmarshal(env.Data, &e); err != nil {
+			return nil, err
+		}
+		return e, nil
+	case typeToolResult:
+		var e ToolResult
+		if err := json.Unmarshal(env.Data, &e); err != nil {
+			return nil, err
+		}
+		return e, nil
+	case typeSessionInit:
+		var e SessionInit
+		if err := json.Unmarshal(env.Data, &e); err != nil {
+			return nil, err
+		}
+		return e, nil
+	case typeFileEdited:
+		var e FileEdited
+		if err := json.Unmarshal(env.Data, &e); err != nil {
+			return nil, err
+		}
+		return e, nil
+	case typeCommitCreated:
+		var e CommitCreated
+		if err := json.Unmarshal(env.Data, &e); err != nil {
+			return nil, err
+		}
+		return e, nil
 	case typeAgentText:
 		var e AgentText
 		if err := json.Unmarshal(env.Data, &e); err != nil {
@@ -23,6 +23,43 @@ func TestMarshalUnmarshal_RoundTrip(t *testing.T) {
 				}
 			},
 		},
+		{
+			name:  "ToolResult",
+			event: ToolResult{Name: "Bash", Detail: "go test ./...", IsError: true, Output: "FAIL", Subagent: "Run tests"},
+			check: func(t *testing.T, got Event) {
+				e := got.(ToolResult)
+				if e != (ToolResult{Name: "Bash", Detail: "go test ./...", IsError: true, Output: "FAIL", Subagent: "Run tests"}) {
+					t.Errorf("ToolResult mismatch: %+v", e)
+				}
+			},
+		},
+		{
+			name:  "SessionInit",
+			event: SessionInit{SessionID: "abc", Model: "claude-sonnet-4-5"},
+			check: func(t *testing.T, got Event) {
+				if e := got.(SessionInit); e.SessionID != "abc" || e.Model != "claude-sonnet-4-5" {
+					t.Errorf("SessionInit mismatch: %+v", e)
+				}
+			},
+		},
+		{
+			name:  "FileEdited",
+			event: FileEdited{Path: "main.go", Added: 3, Removed: 1},
+			check: func(t *testing.T, got Event) {
+				if e := got.(FileEdited); e != (FileEdited{Path: "main.go", Added: 3, Removed: 1}) {
+					t.Errorf("FileEdited mismatch: %+v", e)
+				}
+			},
+		},
+		{
+			name:  "CommitCreated",
+			event: CommitCreated{SHA: "abc123", Subject: "feat(US-002): Logout", StoryID: "US-002", FilesChanged: 3, Insertions: 10, Deletions: 2},
+			check: func(t *testing.T, got Event) {
+				if e := got.(CommitCreated); e != (CommitCreated{SHA: "abc123", Subject: "feat(US-002): Logout", StoryID: "US-002", FilesChanged: 3, Insertions: 10, Deletions: 2}) {
+					t.Errorf("CommitCreated mismatch: %+v", e)
+				}
+			},
+		},
 		{
 			name:  "AgentText",
 			event: AgentText{Text: "Hello\nWorld"},
@@ -35,9 +72,12 @@ func TestMarshalUnmarshal_RoundTrip(t *testing.T) {
 		},
 		{
 			name:  "InvocationDone",
-			event: InvocationDone{NumTurns: 5, DurationMS: 12000, InputTokens: 1200, OutputTokens: 800},
+			event: InvocationDone{NumTurns: 5, DurationMS: 12000, InputTokens: 1200, OutputTokens: 800, CacheCreationTokens: 300, CacheReadTokens: 9000, CostUSD: 0.42},
 			check: func(t *testing.T, got Event) {
 				e := got.(InvocationDone)
+				if e.CacheCreationTokens != 300 || e.CacheReadTokens != 9000 || e.CostUSD != 0.42 {
+					t.Errorf("InvocationDone cache/cost mismatch: %+v", e)
+				}
 				if e.NumTurns != 5 || e.DurationMS != 12000 {
 					t.Errorf("InvocationDone mismatch: %+v", e)
 				}
@@ -99,6 +139,16 @@ func TestMarshalUnmarshal_RoundTrip(t *testing.T) {
 				}
 			},
 		},
+		{
+			name:  "ToolDenied",
+			event: ToolDenied{Name: "Bash", Detail: "git push"},
+			check: func(t *testing.T, got Event) {
+				e := got.(ToolDenied)
+				if e.Name != "Bash" || e.Detail != "git push" {
+					t.Errorf("ToolDenied mismatch: %+v", e)
+				}
+			},
+		},
 		{
 			name:  "PRDRefresh",
 			event: PRDRefresh{},
@@ -176,3 +226,40 @@ func searchString(s, substr string) bool {
 	}
 	return false
 }
+
+func TestMarshalEventAt_RecordsTime(t *testing.T) {
+	at := time.Date(2026, 2, 5, 15, 30, 0, 0, time.UTC)
+	data, err := MarshalEventAt(StoryStarted{StoryID: "US-001", Title: "Login"}, at)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rec, err := UnmarshalRecord(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !rec.Time.Equal(at) {
+		t.Errorf("Time = %v, want %v", rec.Time, at)
+	}
+	if e, ok := rec.Event.(StoryStarted); !ok || e.StoryID != "US-001" {
+		t.Errorf("Event = %+v", rec.Event)
+	}
+}
+
+func TestUnmarshalRecord_WithoutTime(t *testing.T) {
+	data, err := MarshalEvent(AgentText{Text: "hi"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rec, err := UnmarshalRecord(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !rec.Time.IsZero() {
+		t.Errorf("Time = %v, want zero", rec.Time)
+	}
+	if _, ok := rec.Event.(AgentText); !ok {
+		t.Errorf("Event = %T, want AgentText", rec.Event)
+	}
+}
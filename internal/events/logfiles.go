@@ -0,0 +1,262 @@
+package events
+
+import (
+	"bufio"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	logExt           = ".jsonl"
+	compressedLogExt = ".jsonl.gz"
+)
+
+// Retention limits how much a logs directory may hold. Zero values mean no
+// limit.
+type Retention struct {
+	// MaxFileSize starts a new log file once the current one reaches this
+	// many bytes.
+	MaxFileSize int64
+	// MaxTotalSize deletes the oldest files once the directory holds more
+	// than this many bytes.
+	MaxTotalSize int64
+	// MaxAge deletes files last modified longer ago than this.
+	MaxAge time.Duration
+	// Compress gzips event logs once they are closed.
+	Compress bool
+}
+
+// pruning reports whether the policy deletes files.
+func (r Retention) pruning() bool {
+	return r.MaxTotalSize > 0 || r.MaxAge > 0
+}
+
+// LogFiles returns the event logs in dir, compressed or not, sorted by name.
+// A log caught mid-compression, present in both forms, is listed once, as
+// its uncompressed file.
+func LogFiles(dir string) []string {
+	plain, _ := filepath.Glob(filepath.Join(dir, "*"+logExt))
+	compressed, _ := filepath.Glob(filepath.Join(dir, "*"+compressedLogExt))
+
+	files := plain
+	seen := make(map[string]bool, len(plain))
+	for _, p := range plain {
+		seen[p] = true
+	}
+	for _, c := range compressed {
+		if !seen[LogName(c)] {
+			files = append(files, c)
+		}
+	}
+	sort.Strings(files)
+	return files
+}
+
+// LogName returns the uncompressed path of an event log, which identifies it
+// before and after compression.
+func LogName(path string) string {
+	return strings.TrimSuffix(path, ".gz")
+}
+
+// isCompressed reports whether path is a compressed event log.
+func isCompressed(path string) bool {
+	return strings.HasSuffix(path, compressedLogExt)
+}
+
+// OpenLog opens an event log for reading, decompressing .jsonl.gz files.
+func OpenLog(path string) (io.ReadCloser, error) {
+	return OpenLogAt(path, 0)
+}
+
+// OpenLogAt opens an event log for reading from offset, counted in
+// uncompressed bytes.
+func OpenLogAt(path string, offset int64) (io.ReadCloser, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	if !isCompressed(path) {
+		if offset > 0 {
+			if _, err := f.Seek(offset, io.SeekStart); err != nil {
+				f.Close()
+				return nil, err
+			}
+		}
+		return f, nil
+	}
+
+	zr, err := gzip.NewReader(f)
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("reading %s: %w", path, err)
+	}
+	rc := &gzipFile{Reader: zr, f: f}
+	if offset > 0 {
+		if _, err := io.CopyN(io.Discard, zr, offset); err != nil {
+			rc.Close()
+			return nil, fmt.Errorf("reading %s: %w", path, err)
+		}
+	}
+	return rc, nil
+}
+
+// gzipFile closes both the gzip stream and the file under it.
+type gzipFile struct {
+	*gzip.Reader
+	f *os.File
+}
+
+func (g *gzipFile) Close() error {
+	g.Reader.Close()
+	return g.f.Close()
+}
+
+// CompressLog gzips a closed event log to <path>.gz and removes the original.
+// The compressed file keeps the original's modification time, which orders
+// logs when they are read back.
+func CompressLog(path string) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	src, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	// Write under a name no listing matches, then move it into place.
+	dst := path + ".gz"
+	tmp := filepath.Join(filepath.Dir(path), "."+filepath.Base(dst)+".tmp")
+	out, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	zw := gzip.NewWriter(out)
+	_, err = io.Copy(zw, bufio.NewReader(src))
+	if cerr := zw.Close(); err == nil {
+		err = cerr
+	}
+	if cerr := out.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("compressing %s: %w", path, err)
+	}
+
+	if err := os.Chtimes(tmp, info.ModTime(), info.ModTime()); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	if err := os.Rename(tmp, dst); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return os.Remove(path)
+}
+
+// PruneResult summarizes what PruneLogs did.
+type PruneResult struct {
+	Compressed int
+	Removed    int
+	// Freed is the number of bytes the directory shrank by.
+	Freed int64
+}
+
+// PruneLogs applies r to every file in logsDir: event logs and the output
+// of `ralph check`. It compresses closed event logs when r.Compress is set,
+// then deletes files past r.MaxAge, then the oldest files until the
+// directory fits r.MaxTotalSize. The file at active, which is still being
+// written, is left alone. A missing directory is not an error.
+func PruneLogs(logsDir string, r Retention, active string, now time.Time) (PruneResult, error) {
+	var res PruneResult
+
+	files, err := listFiles(logsDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return res, nil
+		}
+		return res, err
+	}
+
+	if r.Compress {
+		for _, f := range files {
+			if f.path == active || !strings.HasSuffix(f.path, logExt) {
+				continue
+			}
+			if err := CompressLog(f.path); err != nil {
+				return res, err
+			}
+			res.Compressed++
+		}
+		if res.Compressed > 0 {
+			before := totalSize(files)
+			if files, err = listFiles(logsDir); err != nil {
+				return res, err
+			}
+			res.Freed += before - totalSize(files)
+		}
+	}
+
+	if !r.pruning() {
+		return res, nil
+	}
+
+	// Oldest first.
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].modTime.Before(files[j].modTime)
+	})
+	total := totalSize(files)
+	for _, f := range files {
+		if f.path == active {
+			continue
+		}
+		expired := r.MaxAge > 0 && now.Sub(f.modTime) > r.MaxAge
+		overSize := r.MaxTotalSize > 0 && total > r.MaxTotalSize
+		if !expired && !overSize {
+			continue
+		}
+		if err := os.Remove(f.path); err != nil {
+			return res, err
+		}
+		res.Removed++
+		res.Freed += f.size
+		total -= f.size
+	}
+	return res, nil
+}
+
+// listFiles returns the regular, non-hidden files in dir.
+func listFiles(dir string) ([]logFile, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var files []logFile
+	for _, e := range entries {
+		if !e.Type().IsRegular() || strings.HasPrefix(e.Name(), ".") {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			continue
+		}
+		files = append(files, logFile{path: filepath.Join(dir, e.Name()), size: info.Size(), modTime: info.ModTime()})
+	}
+	return files, nil
+}
+
+func totalSize(files []logFile) int64 {
+	var n int64
+	for _, f := range files {
+		n += f.size
+	}
+	return n
+}
@@ -0,0 +1,113 @@
+package events
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCompressLog_KeepsContentAndModTime(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "US-001-20260206T120000Z.jsonl")
+	writeEvent(t, path, StoryStarted{StoryID: "US-001", Title: "Auth"})
+	past := time.Now().Add(-time.Hour).Truncate(time.Second)
+	os.Chtimes(path, past, past)
+
+	if err := CompressLog(path); err != nil {
+		t.Fatalf("CompressLog: %v", err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Error("expected the uncompressed log to be removed")
+	}
+	info, err := os.Stat(path + ".gz")
+	if err != nil {
+		t.Fatalf("compressed log missing: %v", err)
+	}
+	if !info.ModTime().Equal(past) {
+		t.Errorf("mod time = %v, want %v", info.ModTime(), past)
+	}
+
+	f, err := OpenLogAt(path+".gz", 5)
+	if err != nil {
+		t.Fatalf("OpenLogAt: %v", err)
+	}
+	defer f.Close()
+	data, _ := io.ReadAll(f)
+	if !strings.HasPrefix(string(data), `e":"story_started"`) {
+		t.Errorf("content from offset 5 = %q", data)
+	}
+}
+
+func TestLogFiles_ListsEachLogOnce(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"a.jsonl", "b.jsonl.gz", "c.jsonl", "c.jsonl.gz", "check-go_test.log"} {
+		os.WriteFile(filepath.Join(dir, name), nil, 0644)
+	}
+
+	var got []string
+	for _, p := range LogFiles(dir) {
+		got = append(got, filepath.Base(p))
+	}
+	if strings.Join(got, ",") != "a.jsonl,b.jsonl.gz,c.jsonl" {
+		t.Errorf("LogFiles = %v", got)
+	}
+}
+
+func TestPruneLogs(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	dir := t.TempDir()
+	write := func(name string, size int, age time.Duration) string {
+		path := filepath.Join(dir, name)
+		os.WriteFile(path, []byte(strings.Repeat("x", size)), 0644)
+		os.Chtimes(path, now.Add(-age), now.Add(-age))
+		return path
+	}
+	write("expired.jsonl.gz", 10, 30*24*time.Hour)
+	write("old-check.log", 100, 3*time.Hour)
+	write("newer.jsonl.gz", 100, 2*time.Hour)
+	active := write("active.jsonl", 100, time.Minute)
+
+	res, err := PruneLogs(dir, Retention{MaxTotalSize: 250, MaxAge: 7 * 24 * time.Hour}, active, now)
+	if err != nil {
+		t.Fatalf("PruneLogs: %v", err)
+	}
+	if res.Removed != 2 || res.Freed != 110 {
+		t.Errorf("result = %+v, want 2 removed and 110 bytes freed", res)
+	}
+	for _, name := range []string{"newer.jsonl.gz", "active.jsonl"} {
+		if _, err := os.Stat(filepath.Join(dir, name)); err != nil {
+			t.Errorf("%s should be kept: %v", name, err)
+		}
+	}
+}
+
+func TestPruneLogs_CompressesAllButActive(t *testing.T) {
+	dir := t.TempDir()
+	closed := filepath.Join(dir, "closed.jsonl")
+	active := filepath.Join(dir, "active.jsonl")
+	writeEvent(t, closed, IterationStart{Iteration: 1, MaxIterations: 5})
+	writeEvent(t, active, IterationStart{Iteration: 2, MaxIterations: 5})
+
+	res, err := PruneLogs(dir, Retention{Compress: true}, active, time.Now())
+	if err != nil {
+		t.Fatalf("PruneLogs: %v", err)
+	}
+	if res.Compressed != 1 {
+		t.Errorf("compressed %d, want 1", res.Compressed)
+	}
+	if _, err := os.Stat(closed + ".gz"); err != nil {
+		t.Errorf("closed log not compressed: %v", err)
+	}
+	if _, err := os.Stat(active); err != nil {
+		t.Errorf("active log should stay uncompressed: %v", err)
+	}
+}
+
+func TestPruneLogs_MissingDir(t *testing.T) {
+	if _, err := PruneLogs(filepath.Join(t.TempDir(), "logs"), Retention{MaxAge: time.Hour}, "", time.Now()); err != nil {
+		t.Errorf("PruneLogs on a missing dir: %v", err)
+	}
+}
@@ -2,124 +2,400 @@ package events
 
 import (
 	"bufio"
+	"bytes"
 	"context"
+	"errors"
+	"fmt"
 	"io"
 	"os"
-	"path/filepath"
 	"sort"
-	"strings"
 	"time"
 )
 
+// DefaultBackfill is how many past events a LogReader delivers when it
+// starts. Older history is skipped.
+const DefaultBackfill = 1000
+
+const (
+	// pollInterval is how often log files are rescanned when the directory
+	// can't be watched.
+	pollInterval = 200 * time.Millisecond
+	// watchedRescanInterval is how often they are rescanned while watched,
+	// in case a notification is missed.
+	watchedRescanInterval = 2 * time.Second
+	// tailChunkSize is how much of a file is read at a time when looking
+	// for its last lines.
+	tailChunkSize = 64 * 1024
+)
+
+// watcher signals changes to the files in a directory.
+type watcher interface {
+	Changes() <-chan struct{}
+	Close() error
+}
+
+// newWatcher watches dir for changes. It returns an error where the platform
+// has no filesystem notifications, in which case the reader polls.
+var newWatcher = newDirWatcher
+
 // LogReader reads JSONL log files from a directory and delivers parsed events
 // via a channel. It supports tailing: detecting new files and new lines
 // appended to existing files.
 type LogReader struct {
 	logsDir string
 	ch      chan Event
-	offsets map[string]int64
+	// records replaces ch for readers made with NewRecordReader.
+	records chan Record
+	// offsets maps each log, by LogName, to the uncompressed bytes read so
+	// far; compressed lists the logs read in full once compressed.
+	offsets    map[string]int64
+	compressed map[string]bool
+	backfill   int
 }
 
 // NewLogReader creates a LogReader that reads from the given logs directory.
+// It delivers the last DefaultBackfill events before tailing.
 func NewLogReader(logsDir string) *LogReader {
 	return &LogReader{
-		logsDir: logsDir,
-		ch:      make(chan Event, 64),
-		offsets: make(map[string]int64),
+		logsDir:    logsDir,
+		ch:         make(chan Event, 64),
+		offsets:    make(map[string]int64),
+		compressed: make(map[string]bool),
+		backfill:   DefaultBackfill,
 	}
 }
 
+// NewRecordReader creates a LogReader that delivers every logged event with
+// its timestamp on Records, from the oldest on, instead of on Events.
+func NewRecordReader(logsDir string) *LogReader {
+	return &LogReader{
+		logsDir:    logsDir,
+		records:    make(chan Record, 64),
+		offsets:    make(map[string]int64),
+		compressed: make(map[string]bool),
+	}
+}
+
+// SetBackfill sets how many past events Run delivers before tailing. n <= 0
+// delivers the whole history. It must be called before Run.
+func (r *LogReader) SetBackfill(n int) {
+	r.backfill = n
+}
+
 // Events returns the channel on which parsed events are delivered.
 func (r *LogReader) Events() <-chan Event {
 	return r.ch
 }
 
-// Run reads existing log files and then polls for new content until ctx is
-// cancelled. It closes the events channel when it returns.
+// Records returns the channel on which a reader made with NewRecordReader
+// delivers events.
+func (r *LogReader) Records() <-chan Record {
+	return r.records
+}
+
+// Run delivers the most recent events from existing log files, then tails
+// the directory until ctx is cancelled. It waits on filesystem notifications
+// where available and polls otherwise, or while the directory doesn't exist.
+// It closes the events channel when it returns.
 func (r *LogReader) Run(ctx context.Context) {
-	defer close(r.ch)
+	defer func() {
+		if r.records != nil {
+			close(r.records)
+		} else {
+			close(r.ch)
+		}
+	}()
+
+	var w watcher
+	defer func() {
+		if w != nil {
+			w.Close()
+		}
+	}()
+
+	r.readBackfill(ctx)
 
 	for {
+		if w == nil {
+			if nw, err := newWatcher(r.logsDir); err == nil {
+				w = nw
+			}
+		}
+
+		// Read after the watch is in place so nothing written in between is missed.
 		r.readNewEntries(ctx)
 
+		interval := pollInterval
+		var changes <-chan struct{}
+		if w != nil {
+			interval = watchedRescanInterval
+			changes = w.Changes()
+		}
+
 		select {
 		case <-ctx.Done():
 			return
-		case <-time.After(200 * time.Millisecond):
+		case <-changes:
+		case <-time.After(interval):
 		}
 	}
 }
 
-// readNewEntries scans all .jsonl files for new lines since the last read.
-func (r *LogReader) readNewEntries(ctx context.Context) {
-	files, err := filepath.Glob(filepath.Join(r.logsDir, "*.jsonl"))
-	if err != nil || len(files) == 0 {
+// logFile is a log file and its size when the directory was scanned.
+type logFile struct {
+	path    string
+	size    int64
+	modTime time.Time
+}
+
+func (r *LogReader) logFiles() []logFile {
+	return sortedLogFiles(r.logsDir)
+}
+
+// sortedLogFiles lists the event logs in dir by modification time (oldest
+// first), falling back to alphabetical order for equal times.
+func sortedLogFiles(dir string) []logFile {
+	paths := LogFiles(dir)
+	files := make([]logFile, 0, len(paths))
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			continue
+		}
+		files = append(files, logFile{path: path, size: info.Size(), modTime: info.ModTime()})
+	}
+	sort.Slice(files, func(i, j int) bool {
+		if files[i].modTime.Equal(files[j].modTime) {
+			return files[i].path < files[j].path
+		}
+		return files[i].modTime.Before(files[j].modTime)
+	})
+	return files
+}
+
+// readBackfill delivers the last r.backfill lines across all files and moves
+// every offset to the end of its file's last complete line. With no limit it
+// leaves the offsets at zero for readNewEntries to read everything.
+func (r *LogReader) readBackfill(ctx context.Context) {
+	if r.backfill <= 0 {
 		return
 	}
-	sortByModTime(files)
+	files := r.logFiles()
 
-	for _, path := range files {
-		offset := r.offsets[path]
-		f, err := os.Open(path)
+	tails := make([][][]byte, len(files))
+	remaining := r.backfill
+	for i := len(files) - 1; i >= 0; i-- {
+		path := files[i].path
+		var end int64
+		var lines [][]byte
+		var err error
+		if isCompressed(path) {
+			end, lines, err = lastCompressedLines(path, remaining)
+			r.compressed[LogName(path)] = true
+		} else {
+			end, lines, err = lastLines(path, remaining)
+		}
 		if err != nil {
 			continue
 		}
+		r.offsets[LogName(path)] = end
+		tails[i] = lines
+		remaining -= len(lines)
+	}
 
-		if offset > 0 {
-			if _, err := f.Seek(offset, io.SeekStart); err != nil {
-				f.Close()
-				continue
+	for _, lines := range tails {
+		for _, line := range lines {
+			if !r.deliver(ctx, line) {
+				return
 			}
 		}
+	}
+}
 
-		scanner := bufio.NewScanner(f)
-		for scanner.Scan() {
-			line := strings.TrimSpace(scanner.Text())
-			if line == "" {
+// readNewEntries reads the lines appended to each file since the last read.
+// Only complete lines are consumed; a line still being written is read once
+// its newline lands.
+func (r *LogReader) readNewEntries(ctx context.Context) {
+	for _, file := range r.logFiles() {
+		name := LogName(file.path)
+		offset := r.offsets[name]
+		if isCompressed(file.path) {
+			// A compressed log is complete: whatever is left of it is read once.
+			if r.compressed[name] {
 				continue
 			}
-			evt, err := UnmarshalEvent([]byte(line))
-			if err != nil {
+			r.compressed[name] = true
+		} else {
+			if file.size < offset {
+				// Truncated or replaced: start over.
+				offset = 0
+			}
+			if file.size == offset {
 				continue
 			}
+		}
 
-			select {
-			case r.ch <- evt:
-			case <-ctx.Done():
-				f.Close()
-				return
-			}
+		newOffset, ok := r.readFrom(ctx, file.path, offset)
+		r.offsets[name] = newOffset
+		if !ok {
+			return
 		}
+	}
+}
 
-		newOffset, _ := f.Seek(0, io.SeekCurrent)
-		r.offsets[path] = newOffset
-		f.Close()
+// readFrom delivers the complete lines of path from offset on and returns the
+// offset after the last one. ok is false when ctx was cancelled.
+func (r *LogReader) readFrom(ctx context.Context, path string, offset int64) (int64, bool) {
+	f, err := OpenLogAt(path, offset)
+	if err != nil {
+		return offset, true
+	}
+	defer f.Close()
+
+	br := bufio.NewReader(f)
+	for {
+		line, err := br.ReadBytes('\n')
+		if err != nil {
+			// A partial line (or none) is left for the next read.
+			return offset, true
+		}
+		offset += int64(len(line))
+		if !r.deliver(ctx, line) {
+			return offset, false
+		}
 	}
 }
 
-// sortByModTime sorts file paths by modification time (oldest first).
-// Falls back to alphabetical order for files that cannot be stat'd.
-func sortByModTime(files []string) {
-	type fileInfo struct {
-		path    string
-		modTime time.Time
+// deliver parses line and sends the event, skipping blank and corrupt lines.
+// It returns false when ctx was cancelled.
+func (r *LogReader) deliver(ctx context.Context, line []byte) bool {
+	line = bytes.TrimSpace(line)
+	if len(line) == 0 {
+		return true
 	}
-	infos := make([]fileInfo, len(files))
-	for i, path := range files {
-		info, err := os.Stat(path)
+	rec, err := UnmarshalRecord(line)
+	if err != nil {
+		return true
+	}
+	if r.records != nil {
+		select {
+		case r.records <- rec:
+			return true
+		case <-ctx.Done():
+			return false
+		}
+	}
+	select {
+	case r.ch <- rec.Event:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// ReadRecords calls fn for every event logged in logsDir, oldest first, until
+// fn returns false. Blank and corrupt lines are skipped.
+func ReadRecords(logsDir string, fn func(Record) bool) error {
+	for _, file := range sortedLogFiles(logsDir) {
+		f, err := OpenLog(file.path)
 		if err != nil {
-			infos[i] = fileInfo{path: path}
-		} else {
-			infos[i] = fileInfo{path: path, modTime: info.ModTime()}
+			return err
+		}
+		br := bufio.NewReader(f)
+		for {
+			line, err := br.ReadBytes('\n')
+			if line = bytes.TrimSpace(line); len(line) > 0 {
+				if rec, uerr := UnmarshalRecord(line); uerr == nil && !fn(rec) {
+					f.Close()
+					return nil
+				}
+			}
+			if err != nil {
+				if !errors.Is(err, io.EOF) {
+					f.Close()
+					return fmt.Errorf("reading %s: %w", file.path, err)
+				}
+				break
+			}
 		}
+		f.Close()
 	}
-	sort.Slice(infos, func(i, j int) bool {
-		if infos[i].modTime.Equal(infos[j].modTime) {
-			return infos[i].path < infos[j].path
+	return nil
+}
+
+// lastLines returns up to n of the last complete lines of path, reading it
+// backwards, along with the offset just past the last complete line.
+func lastLines(path string, n int) (end int64, lines [][]byte, err error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return 0, nil, err
+	}
+
+	// Read chunks from the end until there are more than n newlines: the
+	// last one ends the final complete line, and the text before the first
+	// one may be cut off.
+	var tail []byte
+	pos := info.Size()
+	for pos > 0 && bytes.Count(tail, []byte{'\n'}) <= n {
+		step := min(int64(tailChunkSize), pos)
+		pos -= step
+		chunk := make([]byte, step)
+		if _, err := f.ReadAt(chunk, pos); err != nil && !errors.Is(err, io.EOF) {
+			return 0, nil, err
 		}
-		return infos[i].modTime.Before(infos[j].modTime)
-	})
-	for i, info := range infos {
-		files[i] = info.path
+		tail = append(chunk, tail...)
+	}
+
+	last := bytes.LastIndexByte(tail, '\n')
+	if last < 0 {
+		return 0, nil, nil
+	}
+	end = pos + int64(last) + 1
+	if n == 0 {
+		return end, nil, nil
+	}
+
+	lines = bytes.Split(tail[:last], []byte{'\n'})
+	if pos > 0 {
+		lines = lines[1:]
+	}
+	if len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+	return end, lines, nil
+}
+
+// lastCompressedLines is lastLines for a compressed log, which can only be
+// read from the start.
+func lastCompressedLines(path string, n int) (end int64, lines [][]byte, err error) {
+	f, err := OpenLog(path)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer f.Close()
+
+	br := bufio.NewReader(f)
+	for {
+		line, err := br.ReadBytes('\n')
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return end, lines, nil
+			}
+			return 0, nil, err
+		}
+		end += int64(len(line))
+		if n == 0 {
+			continue
+		}
+		if len(lines) == n {
+			lines = lines[1:]
+		}
+		lines = append(lines, bytes.TrimSuffix(line, []byte{'\n'}))
 	}
 }
@@ -0,0 +1,103 @@
+package events
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeLog writes n events to a new log file in dir.
+func writeLog(b *testing.B, dir, name string, n int) string {
+	b.Helper()
+	path := filepath.Join(dir, name)
+	f, err := os.Create(path)
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer f.Close()
+	w := bufio.NewWriter(f)
+	for i := 0; i < n; i++ {
+		data, err := MarshalEvent(ToolUse{Name: "Read", Detail: fmt.Sprintf("internal/pkg/file_%d.go", i)})
+		if err != nil {
+			b.Fatal(err)
+		}
+		w.Write(data)
+		w.WriteByte('\n')
+	}
+	if err := w.Flush(); err != nil {
+		b.Fatal(err)
+	}
+	return path
+}
+
+// drain reads n events from ch.
+func drain(b *testing.B, ch <-chan Event, n int) {
+	b.Helper()
+	for i := 0; i < n; i++ {
+		if _, ok := <-ch; !ok {
+			b.Fatalf("channel closed after %d of %d events", i, n)
+		}
+	}
+}
+
+// BenchmarkLogReader_StartLargeLog measures how long a reader takes to
+// deliver its backfill from a workspace with a long history.
+func BenchmarkLogReader_StartLargeLog(b *testing.B) {
+	dir := b.TempDir()
+	for i := 0; i < 10; i++ {
+		writeLog(b, dir, fmt.Sprintf("US-%03d.jsonl", i), 20000)
+	}
+
+	for b.Loop() {
+		ctx, cancel := context.WithCancel(context.Background())
+		lr := NewLogReader(dir)
+		go lr.Run(ctx)
+		drain(b, lr.Events(), DefaultBackfill)
+		cancel()
+		for range lr.Events() {
+		}
+	}
+}
+
+// BenchmarkLogReader_ManyWorkspaces measures the delay between an event
+// being appended and delivered while a reader per workspace is tailing.
+func BenchmarkLogReader_ManyWorkspaces(b *testing.B) {
+	const workspaces = 20
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	files := make([]string, workspaces)
+	readers := make([]*LogReader, workspaces)
+	for i := range readers {
+		dir := b.TempDir()
+		for j := 0; j < 5; j++ {
+			files[i] = writeLog(b, dir, fmt.Sprintf("US-%03d.jsonl", j), 2000)
+		}
+		readers[i] = NewLogReader(dir)
+		go readers[i].Run(ctx)
+		drain(b, readers[i].Events(), DefaultBackfill)
+	}
+
+	data, err := MarshalEvent(ToolUse{Name: "Bash", Detail: "go test ./..."})
+	if err != nil {
+		b.Fatal(err)
+	}
+	data = append(data, '\n')
+
+	i := 0
+	for b.Loop() {
+		ws := i % workspaces
+		f, err := os.OpenFile(files[ws], os.O_APPEND|os.O_WRONLY, 0o644)
+		if err != nil {
+			b.Fatal(err)
+		}
+		f.Write(data)
+		f.Close()
+		drain(b, readers[ws].Events(), 1)
+		i++
+	}
+}
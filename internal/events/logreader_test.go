@@ -2,6 +2,7 @@ package events
 
 import (
 	"context"
+	"errors"
 	"os"
 	"path/filepath"
 	"testing"
@@ -314,3 +315,232 @@ func TestLogReader_HandlesMissingLogsDir(t *testing.T) {
 		t.Errorf("expected 0 events from missing dir, got %d", len(events))
 	}
 }
+
+func TestLogReader_BackfillsOnlyLastEvents(t *testing.T) {
+	logsDir := t.TempDir()
+	older := filepath.Join(logsDir, "startup-20260206T120000Z.jsonl")
+	newer := filepath.Join(logsDir, "US-001-20260206T120100Z.jsonl")
+	for i := 1; i <= 3; i++ {
+		writeEvent(t, older, IterationStart{Iteration: i, MaxIterations: 9})
+	}
+	for i := 4; i <= 5; i++ {
+		writeEvent(t, newer, IterationStart{Iteration: i, MaxIterations: 9})
+	}
+	past := time.Now().Add(-time.Minute)
+	os.Chtimes(older, past, past)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	lr := NewLogReader(logsDir)
+	lr.SetBackfill(3)
+	ch := lr.Events()
+	go lr.Run(ctx)
+
+	time.Sleep(300 * time.Millisecond)
+	writeEvent(t, older, IterationStart{Iteration: 6, MaxIterations: 9})
+	time.Sleep(300 * time.Millisecond)
+	cancel()
+
+	var got []int
+	for _, e := range collectEvents(ch, time.Second) {
+		got = append(got, e.(IterationStart).Iteration)
+	}
+	want := []int{3, 4, 5, 6}
+	if len(got) != len(want) {
+		t.Fatalf("iterations = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("iterations = %v, want %v", got, want)
+		}
+	}
+}
+
+func TestLogReader_WaitsForCompleteLines(t *testing.T) {
+	logsDir := t.TempDir()
+	logFile := filepath.Join(logsDir, "test.jsonl")
+	data, err := MarshalEvent(StoryStarted{StoryID: "US-001", Title: "Split"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(logFile, data[:10], 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	lr := NewLogReader(logsDir)
+	ch := lr.Events()
+	go lr.Run(ctx)
+
+	time.Sleep(300 * time.Millisecond)
+	f, err := os.OpenFile(logFile, os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Write(data[10:])
+	f.Write([]byte("\n"))
+	f.Close()
+	time.Sleep(300 * time.Millisecond)
+	cancel()
+
+	events := collectEvents(ch, time.Second)
+	if len(events) != 1 {
+		t.Fatalf("expected the line once it was complete, got %d events", len(events))
+	}
+	if ss, ok := events[0].(StoryStarted); !ok || ss.Title != "Split" {
+		t.Errorf("expected StoryStarted Split, got %T %v", events[0], events[0])
+	}
+}
+
+func TestLogReader_PollsWithoutWatcher(t *testing.T) {
+	orig := newWatcher
+	newWatcher = func(string) (watcher, error) { return nil, errors.New("unsupported") }
+	t.Cleanup(func() { newWatcher = orig })
+
+	logsDir := t.TempDir()
+	logFile := filepath.Join(logsDir, "test.jsonl")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	lr := NewLogReader(logsDir)
+	ch := lr.Events()
+	go lr.Run(ctx)
+
+	writeEvent(t, logFile, IterationStart{Iteration: 1, MaxIterations: 5})
+	time.Sleep(500 * time.Millisecond)
+	cancel()
+
+	if events := collectEvents(ch, time.Second); len(events) != 1 {
+		t.Fatalf("expected 1 event from polling, got %d", len(events))
+	}
+}
+
+func TestLogReader_PicksUpDirCreatedLater(t *testing.T) {
+	logsDir := filepath.Join(t.TempDir(), "logs")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	lr := NewLogReader(logsDir)
+	ch := lr.Events()
+	go lr.Run(ctx)
+
+	time.Sleep(100 * time.Millisecond)
+	if err := os.MkdirAll(logsDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	writeEvent(t, filepath.Join(logsDir, "test.jsonl"), IterationStart{Iteration: 1, MaxIterations: 5})
+	time.Sleep(500 * time.Millisecond)
+	cancel()
+
+	if events := collectEvents(ch, time.Second); len(events) != 1 {
+		t.Fatalf("expected 1 event once the dir exists, got %d", len(events))
+	}
+}
+
+func TestLogReader_FollowsLogIntoCompression(t *testing.T) {
+	logsDir := t.TempDir()
+	logFile := filepath.Join(logsDir, "US-001-20260206T120000Z.jsonl")
+	writeEvent(t, logFile, IterationStart{Iteration: 1, MaxIterations: 5})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	lr := NewLogReader(logsDir)
+	ch := lr.Events()
+	go lr.Run(ctx)
+
+	time.Sleep(300 * time.Millisecond)
+	// The last line lands just before the file is closed and compressed.
+	writeEvent(t, logFile, StoryStarted{StoryID: "US-001", Title: "Last"})
+	if err := CompressLog(logFile); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(500 * time.Millisecond)
+	cancel()
+
+	events := collectEvents(ch, time.Second)
+	if len(events) != 2 {
+		t.Fatalf("expected each event once, got %d", len(events))
+	}
+	if ss, ok := events[1].(StoryStarted); !ok || ss.Title != "Last" {
+		t.Errorf("expected StoryStarted Last, got %T %v", events[1], events[1])
+	}
+}
+
+func TestReadRecords_ReadsAllFilesInOrder(t *testing.T) {
+	logsDir := t.TempDir()
+	first := filepath.Join(logsDir, "US-001-20260206T120000Z.jsonl")
+	second := filepath.Join(logsDir, "US-002-20260206T130000Z.jsonl")
+	writeEvent(t, first, StoryStarted{StoryID: "US-001"})
+	writeEvent(t, first, AgentText{Text: "one"})
+	writeEvent(t, second, StoryStarted{StoryID: "US-002"})
+	os.Chtimes(first, time.Now().Add(-time.Hour), time.Now().Add(-time.Hour))
+	if err := CompressLog(first); err != nil {
+		t.Fatal(err)
+	}
+
+	var got []Event
+	err := ReadRecords(logsDir, func(rec Record) bool {
+		got = append(got, rec.Event)
+		return true
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 3 {
+		t.Fatalf("expected 3 events, got %d: %v", len(got), got)
+	}
+	if ss, ok := got[2].(StoryStarted); !ok || ss.StoryID != "US-002" {
+		t.Errorf("expected StoryStarted US-002 last, got %v", got[2])
+	}
+}
+
+func TestReadRecords_StopsWhenFnReturnsFalse(t *testing.T) {
+	logsDir := t.TempDir()
+	logFile := filepath.Join(logsDir, "startup-20260206T120000Z.jsonl")
+	writeEvent(t, logFile, AgentText{Text: "one"})
+	writeEvent(t, logFile, AgentText{Text: "two"})
+
+	n := 0
+	ReadRecords(logsDir, func(Record) bool {
+		n++
+		return false
+	})
+	if n != 1 {
+		t.Errorf("fn called %d times, want 1", n)
+	}
+}
+
+func TestRecordReader_DeliversWholeHistoryThenTails(t *testing.T) {
+	logsDir := t.TempDir()
+	logFile := filepath.Join(logsDir, "startup-20260206T120000Z.jsonl")
+	for i := range 3 {
+		writeEvent(t, logFile, IterationStart{Iteration: i + 1})
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	lr := NewRecordReader(logsDir)
+	go lr.Run(ctx)
+
+	time.Sleep(300 * time.Millisecond)
+	writeEvent(t, logFile, AgentText{Text: "live"})
+	time.Sleep(500 * time.Millisecond)
+	cancel()
+
+	var got []Record
+	for rec := range lr.Records() {
+		got = append(got, rec)
+	}
+	if len(got) != 4 {
+		t.Fatalf("expected 4 records, got %d", len(got))
+	}
+	if _, ok := got[3].Event.(AgentText); !ok {
+		t.Errorf("expected AgentText last, got %T", got[3].Event)
+	}
+}
@@ -32,6 +32,16 @@ func (h *PlainTextHandler) Handle(event Event) {
 	switch e := event.(type) {
 	case ToolUse:
 		h.handleToolUse(e)
+	case ToolDenied:
+		h.handleToolDenied(e)
+	case ToolResult:
+		h.handleToolResult(e)
+	case SessionInit:
+		h.handleSessionInit(e)
+	case FileEdited:
+		h.handleFileEdited(e)
+	case CommitCreated:
+		h.handleCommitCreated(e)
 	case AgentText:
 		h.handleAgentText(e)
 	case InvocationDone:
@@ -51,6 +61,10 @@ func (h *PlainTextHandler) Handle(event Event) {
 
 func (h *PlainTextHandler) handleToolUse(e ToolUse) {
 	arrow := arrowStyle.Render("→")
+	if e.Subagent != "" {
+		// Subagent calls are nested under the Task that started them.
+		arrow = "  " + arrowStyle.Render("↳")
+	}
 	tool := toolStyle.Render(e.Name)
 	if e.Detail != "" {
 		path := pathStyle.Render(e.Detail)
@@ -60,6 +74,44 @@ func (h *PlainTextHandler) handleToolUse(e ToolUse) {
 	}
 }
 
+func (h *PlainTextHandler) handleToolDenied(e ToolDenied) {
+	cross := waitStyle.Render("✗")
+	tool := toolStyle.Render(e.Name)
+	msg := waitStyle.Render("denied by tool policy")
+	if e.Detail != "" {
+		fmt.Fprintf(h.W, "  %s %s %s %s\n", cross, tool, pathStyle.Render(e.Detail), msg)
+	} else {
+		fmt.Fprintf(h.W, "  %s %s %s\n", cross, tool, msg)
+	}
+}
+
+// handleToolResult prints failed tool calls; successful ones are already
+// covered by the ToolUse line.
+func (h *PlainTextHandler) handleToolResult(e ToolResult) {
+	if !e.IsError {
+		return
+	}
+	cross := waitStyle.Render("✗")
+	tool := toolStyle.Render(e.Name)
+	output, _, _ := strings.Cut(e.Output, "\n")
+	fmt.Fprintf(h.W, "  %s %s %s %s\n", cross, tool, waitStyle.Render("failed:"), pathStyle.Render(output))
+}
+
+func (h *PlainTextHandler) handleSessionInit(e SessionInit) {
+	fmt.Fprintf(h.W, "  %s\n", dimStyle.Render(fmt.Sprintf("session %s (%s)", e.SessionID, e.Model)))
+}
+
+func (h *PlainTextHandler) handleFileEdited(e FileEdited) {
+	delta := successStyle.Render(fmt.Sprintf("+%d", e.Added)) + " " + waitStyle.Render(fmt.Sprintf("-%d", e.Removed))
+	fmt.Fprintf(h.W, "  %s %s %s\n", arrowStyle.Render("✎"), pathStyle.Render(e.Path), delta)
+}
+
+func (h *PlainTextHandler) handleCommitCreated(e CommitCreated) {
+	sha := toolStyle.Render(e.SHA[:min(len(e.SHA), 7)])
+	stats := dimStyle.Render(fmt.Sprintf("(%d files, +%d -%d)", e.FilesChanged, e.Insertions, e.Deletions))
+	fmt.Fprintf(h.W, "  %s commit %s %s %s\n", successStyle.Render("●"), sha, e.Subject, stats)
+}
+
 func (h *PlainTextHandler) handleAgentText(e AgentText) {
 	lines := strings.Split(strings.TrimSpace(e.Text), "\n")
 	fmt.Fprintln(h.W)
@@ -73,11 +125,19 @@ func (h *PlainTextHandler) handleAgentText(e AgentText) {
 func (h *PlainTextHandler) handleInvocationDone(e InvocationDone) {
 	durationSec := e.DurationMS / 1000
 	check := successStyle.Render("✓")
-	info := dimStyle.Render(fmt.Sprintf("(%d turns, %ds)", e.NumTurns, durationSec))
+	info := fmt.Sprintf("(%d turns, %ds)", e.NumTurns, durationSec)
+	if e.CostUSD > 0 {
+		info = fmt.Sprintf("(%d turns, %ds, $%.2f)", e.NumTurns, durationSec, e.CostUSD)
+	}
+	info = dimStyle.Render(info)
 	fmt.Fprintf(h.W, "  %s Done %s\n", check, info)
 }
 
 func (h *PlainTextHandler) handleIterationStart(e IterationStart) {
+	if e.Scope != "" {
+		fmt.Fprintf(h.W, "iteration %d/%d (%s)\n", e.Iteration, e.MaxIterations, e.Scope)
+		return
+	}
 	fmt.Fprintf(h.W, "iteration %d/%d\n", e.Iteration, e.MaxIterations)
 }
 
@@ -0,0 +1,63 @@
+//go:build linux
+
+package events
+
+import (
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+// inotifyWatcher signals changes to a directory using inotify.
+type inotifyWatcher struct {
+	f       *os.File
+	changes chan struct{}
+}
+
+func newDirWatcher(dir string) (watcher, error) {
+	fd, err := unix.InotifyInit1(unix.IN_CLOEXEC | unix.IN_NONBLOCK)
+	if err != nil {
+		return nil, err
+	}
+	mask := uint32(unix.IN_CREATE | unix.IN_MODIFY | unix.IN_MOVED_TO | unix.IN_CLOSE_WRITE)
+	if _, err := unix.InotifyAddWatch(fd, dir, mask); err != nil {
+		unix.Close(fd)
+		return nil, err
+	}
+
+	// A non-blocking descriptor goes through the runtime poller, so Close
+	// unblocks the pending Read.
+	w := &inotifyWatcher{
+		f:       os.NewFile(uintptr(fd), "inotify"),
+		changes: make(chan struct{}, 1),
+	}
+	go w.run()
+	return w, nil
+}
+
+// run turns inotify events into change signals. Signals are coalesced: the
+// reader rescans the whole directory on each one.
+func (w *inotifyWatcher) run() {
+	buf := make([]byte, 16*(unix.SizeofInotifyEvent+unix.NAME_MAX+1))
+	for {
+		n, err := w.f.Read(buf)
+		if err != nil {
+			return
+		}
+		if n == 0 {
+			continue
+		}
+		select {
+		case w.changes <- struct{}{}:
+		default:
+		}
+	}
+}
+
+func (w *inotifyWatcher) Changes() <-chan struct{} {
+	return w.changes
+}
+
+func (w *inotifyWatcher) Close() error {
+	return w.f.Close()
+}
@@ -0,0 +1,35 @@
+//go:build linux
+
+package events
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestLogReader_WakesOnNotification(t *testing.T) {
+	logsDir := t.TempDir()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	lr := NewLogReader(logsDir)
+	ch := lr.Events()
+	go lr.Run(ctx)
+
+	// Let Run settle into waiting on the watcher before writing.
+	time.Sleep(100 * time.Millisecond)
+	start := time.Now()
+	writeEvent(t, filepath.Join(logsDir, "test.jsonl"), IterationStart{Iteration: 1, MaxIterations: 5})
+
+	select {
+	case <-ch:
+	case <-time.After(watchedRescanInterval):
+		t.Fatal("event not delivered before the safety rescan")
+	}
+	if elapsed := time.Since(start); elapsed >= watchedRescanInterval/2 {
+		t.Errorf("event took %s, want it delivered on notification", elapsed)
+	}
+}
@@ -0,0 +1,9 @@
+//go:build !linux
+
+package events
+
+import "errors"
+
+func newDirWatcher(dir string) (watcher, error) {
+	return nil, errors.New("filesystem notifications not supported on this platform")
+}
@@ -0,0 +1,87 @@
+package fsutil
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"testing"
+)
+
+func TestWriteFileAtomic_ReplacesContent(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	if err := os.WriteFile(path, []byte("old"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := WriteFileAtomic(path, []byte("new"), 0600); err != nil {
+		t.Fatalf("WriteFileAtomic: %v", err)
+	}
+
+	data, _ := os.ReadFile(path)
+	if string(data) != "new" {
+		t.Errorf("content = %q, want new", data)
+	}
+	info, _ := os.Stat(path)
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("mode = %v, want 0600", info.Mode().Perm())
+	}
+
+	// No temp files are left behind.
+	entries, _ := os.ReadDir(filepath.Dir(path))
+	if len(entries) != 1 {
+		t.Errorf("expected only the target file, got %d entries", len(entries))
+	}
+}
+
+func TestWithLock_SerialisesReadModifyWrite(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "counter")
+	if err := os.WriteFile(path, []byte("0"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// Each goroutine opens its own lock file descriptor, as separate
+	// processes would.
+	var wg sync.WaitGroup
+	for range 20 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := WithLock(path, func() error {
+				data, err := os.ReadFile(path)
+				if err != nil {
+					return err
+				}
+				n, _ := strconv.Atoi(string(data))
+				return WriteFileAtomic(path, []byte(strconv.Itoa(n+1)), 0644)
+			})
+			if err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	data, _ := os.ReadFile(path)
+	if string(data) != "20" {
+		t.Errorf("counter = %s, want 20 (lost updates)", data)
+	}
+}
+
+func TestHash_MissingFileIsEmpty(t *testing.T) {
+	h, err := Hash(filepath.Join(t.TempDir(), "missing"))
+	if err != nil || h != "" {
+		t.Errorf("Hash(missing) = %q, %v; want empty, nil", h, err)
+	}
+}
+
+func TestHash_ChangesWithContent(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "f")
+	os.WriteFile(path, []byte("a"), 0644)
+	h1, _ := Hash(path)
+	os.WriteFile(path, []byte("b"), 0644)
+	h2, _ := Hash(path)
+	if h1 == h2 || h1 != HashBytes([]byte("a")) {
+		t.Errorf("hashes = %s, %s", h1, h2)
+	}
+}
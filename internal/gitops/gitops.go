@@ -1,12 +1,17 @@
 package gitops
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io/fs"
 	"os"
 	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
 	"strings"
 
 	"github.com/bmatcuk/doublestar/v4"
@@ -128,10 +133,60 @@ func CopyDotRalph(repoPath, worktreePath string) error {
 	})
 }
 
+// ClaudePermissions are Claude permission rules, such as "Edit" or
+// "Bash(git push:*)".
+type ClaudePermissions struct {
+	Allow []string
+	Deny  []string
+}
+
 // CopyDotClaude copies the .claude directory from the repo root into the
 // worktree, enabling Claude settings and skills to be available in the
-// isolated environment.
-func CopyDotClaude(repoPath, worktreePath string) error {
+// isolated environment. Non-empty perms are merged into the worktree's
+// ClaudeLocalSettings, which is created if the repo has none; callers keep it
+// out of commits with ExcludeFromGit.
+func CopyDotClaude(repoPath, worktreePath string, perms ClaudePermissions) error {
+	if err := copyDotClaudeDir(repoPath, worktreePath); err != nil {
+		return err
+	}
+	if len(perms.Allow) == 0 && len(perms.Deny) == 0 {
+		return nil
+	}
+	return mergeClaudePermissions(filepath.Join(worktreePath, ClaudeLocalSettings), perms)
+}
+
+// ClaudeLocalSettings is Claude's settings file for one checkout, relative to
+// its root. Unlike .claude/settings.json it is not meant to be committed.
+const ClaudeLocalSettings = ".claude/settings.local.json"
+
+// ExcludeFromGit adds path, relative to the repository root, to the
+// repository's info/exclude so git status and git add -A ignore it.
+func ExcludeFromGit(ctx context.Context, r *shell.Runner, path string) error {
+	out, err := r.Run(ctx, "git", "rev-parse", "--path-format=absolute", "--git-path", "info/exclude")
+	if err != nil {
+		return fmt.Errorf("locating info/exclude: %w", err)
+	}
+	exclude := strings.TrimSpace(out)
+	pattern := "/" + filepath.ToSlash(path)
+
+	data, err := os.ReadFile(exclude)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if slices.Contains(strings.Split(string(data), "\n"), pattern) {
+		return nil
+	}
+	if len(data) > 0 && !bytes.HasSuffix(data, []byte("\n")) {
+		data = append(data, '\n')
+	}
+	data = append(data, pattern+"\n"...)
+	if err := os.MkdirAll(filepath.Dir(exclude), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(exclude, data, 0644)
+}
+
+func copyDotClaudeDir(repoPath, worktreePath string) error {
 	src := filepath.Join(repoPath, ".claude")
 	dst := filepath.Join(worktreePath, ".claude")
 
@@ -170,6 +225,47 @@ func CopyDotClaude(repoPath, worktreePath string) error {
 	})
 }
 
+// mergeClaudePermissions adds the rules to the permissions of the settings
+// file at path, keeping every other setting and the rules already there.
+func mergeClaudePermissions(path string, perms ClaudePermissions) error {
+	settings := map[string]any{}
+	data, err := os.ReadFile(path)
+	switch {
+	case err == nil:
+		if err := json.Unmarshal(data, &settings); err != nil {
+			return fmt.Errorf("parsing %s: %w", path, err)
+		}
+	case !os.IsNotExist(err):
+		return err
+	}
+
+	permissions, _ := settings["permissions"].(map[string]any)
+	if permissions == nil {
+		permissions = map[string]any{}
+	}
+	for key, rules := range map[string][]string{"allow": perms.Allow, "deny": perms.Deny} {
+		existing, _ := permissions[key].([]any)
+		for _, rule := range rules {
+			if !slices.Contains(existing, any(rule)) {
+				existing = append(existing, rule)
+			}
+		}
+		if len(existing) > 0 {
+			permissions[key] = existing
+		}
+	}
+	settings["permissions"] = permissions
+
+	out, err := json.MarshalIndent(settings, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(out, '\n'), 0644)
+}
+
 // Commit stages all changes and creates a commit.
 func Commit(ctx context.Context, r *shell.Runner, message string) error {
 	if _, err := r.Run(ctx, "git", "add", "-A"); err != nil {
@@ -210,6 +306,34 @@ func CurrentBranch(ctx context.Context, r *shell.Runner) (string, error) {
 	return strings.TrimSpace(out), nil
 }
 
+// RevParse resolves ref to a commit SHA.
+func RevParse(ctx context.Context, r *shell.Runner, ref string) (string, error) {
+	out, err := r.Run(ctx, "git", "rev-parse", "--verify", ref+"^{commit}")
+	if err != nil {
+		return "", fmt.Errorf("resolving %s: %w", ref, err)
+	}
+	return strings.TrimSpace(out), nil
+}
+
+// StoryCommit returns the most recent commit reachable from ref that belongs
+// to storyID, by its Ralph-Story trailer or, lacking any trailer, its loop
+// commit subject ("feat(<storyID>): ...").
+func StoryCommit(ctx context.Context, r *shell.Runner, ref, storyID string) (string, error) {
+	id := regexp.QuoteMeta(storyID)
+	out, err := r.Run(ctx, "git", "log", storyLogFormat, "--extended-regexp",
+		"--grep=^"+regexp.QuoteMeta("feat(")+id+regexp.QuoteMeta("):"),
+		"--grep=^"+StoryTrailer+":[[:space:]]*"+id+"[[:space:]]*$", ref)
+	if err != nil {
+		return "", fmt.Errorf("searching commits for %s: %w", storyID, err)
+	}
+	for _, c := range parseStoryLog(out) {
+		if slices.Contains(c.stories, storyID) {
+			return c.SHA, nil
+		}
+	}
+	return "", fmt.Errorf("no commit for story %s on %s", storyID, ref)
+}
+
 // IsAncestor returns true when ancestor is an ancestor of descendant.
 func IsAncestor(ctx context.Context, r *shell.Runner, ancestor, descendant string) (bool, error) {
 	_, err := r.Run(ctx, "git", "merge-base", "--is-ancestor", ancestor, descendant)
@@ -232,6 +356,42 @@ func PushBranch(ctx context.Context, r *shell.Runner, branch string) error {
 	return nil
 }
 
+// RemoteURL returns the fetch URL of the named remote.
+func RemoteURL(ctx context.Context, r *shell.Runner, remote string) (string, error) {
+	out, err := r.Run(ctx, "git", "remote", "get-url", remote)
+	if err != nil {
+		return "", fmt.Errorf("getting URL of remote %s: %w", remote, err)
+	}
+	return strings.TrimSpace(out), nil
+}
+
+// ParseGitHubRemote extracts the owner and repository name from a GitHub
+// remote URL in scp-like (git@github.com:owner/repo.git), https:// or ssh://
+// form.
+func ParseGitHubRemote(url string) (owner, repo string, err error) {
+	path := url
+	switch {
+	case strings.Contains(url, "://"):
+		path = url[strings.Index(url, "://")+3:]
+		i := strings.Index(path, "/")
+		if i < 0 {
+			return "", "", fmt.Errorf("unrecognised remote URL %q", url)
+		}
+		path = path[i+1:]
+	case strings.Contains(url, ":"):
+		path = url[strings.Index(url, ":")+1:]
+	default:
+		return "", "", fmt.Errorf("unrecognised remote URL %q", url)
+	}
+
+	path = strings.TrimSuffix(strings.TrimSuffix(path, "/"), ".git")
+	parts := strings.Split(path, "/")
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", "", fmt.Errorf("remote URL %q does not point to a GitHub repository", url)
+	}
+	return parts[0], parts[1], nil
+}
+
 // ForcePushBranch force-pushes a local branch to origin using --force-with-lease
 // for safety (fails if the remote has unexpected commits).
 func ForcePushBranch(ctx context.Context, r *shell.Runner, branch string) error {
@@ -251,6 +411,220 @@ func DiffStats(ctx context.Context, r *shell.Runner, base string) (string, error
 	return strings.TrimSpace(out), nil
 }
 
+// DiffShortStat returns the --shortstat summary of ref compared to base
+// (e.g. "3 files changed, 40 insertions(+), 2 deletions(-)").
+func DiffShortStat(ctx context.Context, r *shell.Runner, base, ref string) (string, error) {
+	out, err := r.Run(ctx, "git", "diff", "--shortstat", base+"..."+ref)
+	if err != nil {
+		return "", fmt.Errorf("getting diff stats of %s against %s: %w", ref, base, err)
+	}
+	return strings.TrimSpace(out), nil
+}
+
+// FileChange is a file changed since some commit, with its line counts.
+// Binary files count no lines.
+type FileChange struct {
+	Path    string
+	Added   int
+	Deleted int
+}
+
+// ChangesSince lists the files that differ between ref and the working tree,
+// whether the changes are committed or not. Untracked files count as added.
+// Renames are reported as a deletion and an addition.
+func ChangesSince(ctx context.Context, r *shell.Runner, ref string) ([]FileChange, error) {
+	out, err := r.Run(ctx, "git", "diff", "--numstat", "--no-renames", "-z", ref)
+	if err != nil {
+		return nil, fmt.Errorf("diffing against %s: %w", ref, err)
+	}
+	changes := parseNumstat(out)
+
+	untracked, err := UntrackedFiles(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+	for _, path := range untracked {
+		data, err := os.ReadFile(filepath.Join(r.Dir, path))
+		if err != nil {
+			continue
+		}
+		lines := bytes.Count(data, []byte("\n"))
+		if len(data) > 0 && data[len(data)-1] != '\n' {
+			lines++
+		}
+		changes = append(changes, FileChange{Path: path, Added: lines})
+	}
+	return changes, nil
+}
+
+// MergeBase returns the best common ancestor of a and b.
+func MergeBase(ctx context.Context, r *shell.Runner, a, b string) (string, error) {
+	out, err := r.Run(ctx, "git", "merge-base", a, b)
+	if err != nil {
+		return "", fmt.Errorf("finding the merge base of %s and %s: %w", a, b, err)
+	}
+	return strings.TrimSpace(out), nil
+}
+
+// FileDiff returns the unified diff of path between ref and the working
+// tree. An untracked file diffs as entirely added.
+func FileDiff(ctx context.Context, r *shell.Runner, ref, path string) (string, error) {
+	out, err := r.Run(ctx, "git", "diff", "--no-renames", ref, "--", path)
+	if err != nil {
+		return "", fmt.Errorf("diffing %s against %s: %w", path, ref, err)
+	}
+	if out != "" {
+		return out, nil
+	}
+	// git diff --no-index exits 1 when the files differ.
+	out, err = r.Run(ctx, "git", "diff", "--no-index", "--", os.DevNull, path)
+	var exitErr *shell.ExitError
+	if err != nil && (!errors.As(err, &exitErr) || exitErr.Code != 1) {
+		return "", fmt.Errorf("diffing untracked %s: %w", path, err)
+	}
+	return out, nil
+}
+
+// CommitChanges lists the files a commit changed compared to its first
+// parent. A merge commit reports no files.
+func CommitChanges(ctx context.Context, r *shell.Runner, sha string) ([]FileChange, error) {
+	out, err := r.Run(ctx, "git", "diff-tree", "--root", "--no-commit-id", "-r", "--numstat", "--no-renames", "-z", sha)
+	if err != nil {
+		return nil, fmt.Errorf("listing the changes of %s: %w", sha, err)
+	}
+	return parseNumstat(out), nil
+}
+
+// parseNumstat parses the output of git's --numstat -z.
+func parseNumstat(out string) []FileChange {
+	var changes []FileChange
+	for entry := range strings.SplitSeq(out, "\x00") {
+		added, rest, ok := strings.Cut(entry, "\t")
+		if !ok {
+			continue
+		}
+		deleted, path, ok := strings.Cut(rest, "\t")
+		if !ok {
+			continue
+		}
+		// Binary files report "-" for both counts.
+		a, _ := strconv.Atoi(added)
+		d, _ := strconv.Atoi(deleted)
+		changes = append(changes, FileChange{Path: path, Added: a, Deleted: d})
+	}
+	return changes
+}
+
+// UntrackedFiles lists the files git does not track and does not ignore.
+func UntrackedFiles(ctx context.Context, r *shell.Runner) ([]string, error) {
+	out, err := r.Run(ctx, "git", "ls-files", "--others", "--exclude-standard", "-z")
+	if err != nil {
+		return nil, fmt.Errorf("listing untracked files: %w", err)
+	}
+	var files []string
+	for path := range strings.SplitSeq(out, "\x00") {
+		if path != "" {
+			files = append(files, path)
+		}
+	}
+	return files, nil
+}
+
+// RestorePaths puts paths back to their content at ref in the working tree
+// and the index. Paths that did not exist at ref are deleted.
+func RestorePaths(ctx context.Context, r *shell.Runner, ref string, paths []string) error {
+	for _, path := range paths {
+		if _, err := r.Run(ctx, "git", "cat-file", "-e", ref+":"+path); err == nil {
+			if _, err := r.Run(ctx, "git", "checkout", ref, "--", path); err != nil {
+				return fmt.Errorf("restoring %s: %w", path, err)
+			}
+			continue
+		}
+		if _, err := r.Run(ctx, "git", "rm", "-q", "--cached", "--ignore-unmatch", "--", path); err != nil {
+			return fmt.Errorf("removing %s: %w", path, err)
+		}
+		if err := os.Remove(filepath.Join(r.Dir, path)); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("removing %s: %w", path, err)
+		}
+	}
+	return nil
+}
+
+// CommitPaths commits the current state of paths, and only those, if it
+// differs from HEAD. It reports whether a commit was made.
+func CommitPaths(ctx context.Context, r *shell.Runner, message string, paths []string) (bool, error) {
+	args := append([]string{"status", "--porcelain", "--"}, paths...)
+	out, err := r.Run(ctx, "git", args...)
+	if err != nil {
+		return false, fmt.Errorf("checking status: %w", err)
+	}
+	if strings.TrimSpace(out) == "" {
+		return false, nil
+	}
+	args = append([]string{"commit", "-q", "-m", message, "--only", "--"}, paths...)
+	if _, err := r.Run(ctx, "git", args...); err != nil {
+		return false, fmt.Errorf("git commit: %w", err)
+	}
+	return true, nil
+}
+
+// RestoreWorktree puts paths back to their content at ref in the working
+// tree only, leaving the index alone. Paths that did not exist at ref are
+// deleted.
+func RestoreWorktree(ctx context.Context, r *shell.Runner, ref string, paths []string) error {
+	for _, path := range paths {
+		if _, err := r.Run(ctx, "git", "cat-file", "-e", ref+":"+path); err == nil {
+			if _, err := r.Run(ctx, "git", "restore", "--source", ref, "--worktree", "--", path); err != nil {
+				return fmt.Errorf("restoring %s: %w", path, err)
+			}
+			continue
+		}
+		if err := os.Remove(filepath.Join(r.Dir, path)); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("removing %s: %w", path, err)
+		}
+	}
+	return nil
+}
+
+// SnapshotChanges records the uncommitted changes to tracked files, staged
+// or not, as a stash commit without touching the working tree or the stash
+// list. It returns the commit, or "" when there are no such changes.
+func SnapshotChanges(ctx context.Context, r *shell.Runner) (string, error) {
+	out, err := r.Run(ctx, "git", "stash", "create")
+	if err != nil {
+		return "", fmt.Errorf("snapshotting uncommitted changes: %w", err)
+	}
+	return strings.TrimSpace(out), nil
+}
+
+// DiscardChanges resets the checked-out branch and working tree to ref and
+// deletes untracked files, except those listed in keep. A snapshot from
+// SnapshotChanges, if not empty, is then applied again, so uncommitted
+// changes made before it was taken survive.
+func DiscardChanges(ctx context.Context, r *shell.Runner, ref, snapshot string, keep []string) error {
+	if _, err := r.Run(ctx, "git", "reset", "-q", "--hard", ref); err != nil {
+		return fmt.Errorf("resetting to %s: %w", ref, err)
+	}
+	untracked, err := UntrackedFiles(ctx, r)
+	if err != nil {
+		return err
+	}
+	for _, path := range untracked {
+		if slices.Contains(keep, path) {
+			continue
+		}
+		if err := os.Remove(filepath.Join(r.Dir, path)); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("removing %s: %w", path, err)
+		}
+	}
+	if snapshot != "" {
+		if _, err := r.Run(ctx, "git", "stash", "apply", "-q", "--index", snapshot); err != nil {
+			return fmt.Errorf("restoring uncommitted changes from %s: %w", snapshot, err)
+		}
+	}
+	return nil
+}
+
 // FetchBranch fetches origin/<branch>.
 func FetchBranch(ctx context.Context, r *shell.Runner, branch string) error {
 	_, err := r.Run(ctx, "git", "fetch", "origin", branch)
@@ -260,6 +634,65 @@ func FetchBranch(ctx context.Context, r *shell.Runner, branch string) error {
 	return nil
 }
 
+// PRHeadBranch returns the origin branch whose tip matches the head of pull
+// request number. It returns "" when no origin branch matches, as is the
+// case for pull requests opened from forks.
+func PRHeadBranch(ctx context.Context, r *shell.Runner, number int) (string, error) {
+	prRef := fmt.Sprintf("refs/pull/%d/head", number)
+	out, err := r.Run(ctx, "git", "ls-remote", "origin", prRef, "refs/heads/*")
+	if err != nil {
+		return "", fmt.Errorf("listing origin refs: %w", err)
+	}
+
+	var prSHA string
+	heads := map[string][]string{}
+	for _, line := range strings.Split(strings.TrimSpace(out), "\n") {
+		sha, ref, ok := strings.Cut(line, "\t")
+		if !ok {
+			continue
+		}
+		if ref == prRef {
+			prSHA = sha
+		} else if branch, ok := strings.CutPrefix(ref, "refs/heads/"); ok {
+			heads[sha] = append(heads[sha], branch)
+		}
+	}
+	if prSHA == "" {
+		return "", fmt.Errorf("pull request #%d not found on origin", number)
+	}
+	if branches := heads[prSHA]; len(branches) == 1 {
+		return branches[0], nil
+	}
+	return "", nil
+}
+
+// FetchPRHead fetches the head of pull request number into the local branch.
+func FetchPRHead(ctx context.Context, r *shell.Runner, number int, branch string) error {
+	refspec := fmt.Sprintf("refs/pull/%d/head:refs/heads/%s", number, branch)
+	if _, err := r.Run(ctx, "git", "fetch", "origin", refspec); err != nil {
+		return fmt.Errorf("fetching pull request #%d: %w", number, err)
+	}
+	return nil
+}
+
+// CreateBundle writes a git bundle containing the full history of branch to
+// path.
+func CreateBundle(ctx context.Context, r *shell.Runner, path, branch string) error {
+	if _, err := r.Run(ctx, "git", "bundle", "create", path, "refs/heads/"+branch); err != nil {
+		return fmt.Errorf("bundling %s: %w", branch, err)
+	}
+	return nil
+}
+
+// FetchBundle fetches branch from the bundle at path without updating any
+// local ref and returns the fetched commit.
+func FetchBundle(ctx context.Context, r *shell.Runner, path, branch string) (string, error) {
+	if _, err := r.Run(ctx, "git", "fetch", "--no-tags", path, "refs/heads/"+branch); err != nil {
+		return "", fmt.Errorf("fetching %s from bundle: %w", branch, err)
+	}
+	return RevParse(ctx, r, "FETCH_HEAD")
+}
+
 // PullFFOnly pulls the given branch from origin using fast-forward only.
 func PullFFOnly(ctx context.Context, r *shell.Runner, branch string) error {
 	_, err := r.Run(ctx, "git", "pull", "--ff-only", "origin", branch)
@@ -278,7 +711,18 @@ type RebaseResult struct {
 // StartRebase runs git rebase onto the given ref and returns whether conflicts
 // occurred.
 func StartRebase(ctx context.Context, r *shell.Runner, onto string) (RebaseResult, error) {
-	_, err := r.Run(ctx, "git", "rebase", onto)
+	return startRebase(ctx, r, onto)
+}
+
+// StartRebaseOnto replays only the commits after upstream onto newBase
+// (git rebase --onto). It is used when upstream was itself rewritten, so its
+// old commits are not replayed a second time.
+func StartRebaseOnto(ctx context.Context, r *shell.Runner, newBase, upstream string) (RebaseResult, error) {
+	return startRebase(ctx, r, "--onto", newBase, upstream)
+}
+
+func startRebase(ctx context.Context, r *shell.Runner, args ...string) (RebaseResult, error) {
+	_, err := r.Run(ctx, "git", append([]string{"rebase"}, args...)...)
 	if err != nil {
 		var exitErr *shell.ExitError
 		if errors.As(err, &exitErr) {
@@ -376,6 +820,251 @@ func SquashMerge(ctx context.Context, r *shell.Runner, repoPath, featureBranch,
 	return nil
 }
 
+// StoryTrailer is the commit trailer that records which PRD story a commit
+// implements.
+const StoryTrailer = "Ralph-Story"
+
+// StoryIDFromSubject returns the story ID of a loop commit subject
+// ("feat(<storyID>): ..."), or "" for any other subject.
+func StoryIDFromSubject(subject string) string {
+	rest, ok := strings.CutPrefix(subject, "feat(")
+	if !ok {
+		return ""
+	}
+	id, _, ok := strings.Cut(rest, "):")
+	if !ok || id == "" {
+		return ""
+	}
+	return id
+}
+
+// currentStoryFile, in a worktree's git dir, names the story the loop is
+// working on. The commit-msg hook reads it to add the Ralph-Story trailer.
+const currentStoryFile = "ralph-story"
+
+// gitPath returns the absolute path of name inside the git dir of the
+// worktree r runs in.
+func gitPath(ctx context.Context, r *shell.Runner, name string) (string, error) {
+	out, err := r.Run(ctx, "git", "rev-parse", "--path-format=absolute", "--git-path", name)
+	if err != nil {
+		return "", fmt.Errorf("locating %s in the git dir: %w", name, err)
+	}
+	return strings.TrimSpace(out), nil
+}
+
+// SetCurrentStory records storyID as the story being worked on in the
+// worktree r runs in. An empty storyID clears it.
+func SetCurrentStory(ctx context.Context, r *shell.Runner, storyID string) error {
+	path, err := gitPath(ctx, r, currentStoryFile)
+	if err != nil {
+		return err
+	}
+	if storyID == "" {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return nil
+	}
+	return os.WriteFile(path, []byte(storyID+"\n"), 0644)
+}
+
+// CurrentStory returns the story set with SetCurrentStory, or "" when none
+// is being worked on.
+func CurrentStory(ctx context.Context, r *shell.Runner) (string, error) {
+	path, err := gitPath(ctx, r, currentStoryFile)
+	if err != nil {
+		return "", err
+	}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+// AddStoryTrailer adds a Ralph-Story trailer for storyID to the commit
+// message in msgFile, replacing one that is already there.
+func AddStoryTrailer(ctx context.Context, r *shell.Runner, msgFile, storyID string) error {
+	if _, err := r.Run(ctx, "git", "interpret-trailers", "--in-place", "--if-exists", "replace",
+		"--trailer", StoryTrailer+": "+storyID, msgFile); err != nil {
+		return fmt.Errorf("adding %s trailer: %w", StoryTrailer, err)
+	}
+	return nil
+}
+
+// CommitSummary is a commit's SHA and subject line.
+type CommitSummary struct {
+	SHA     string
+	Subject string
+}
+
+// CommitsInRange lists the commits reachable from end but not from start,
+// oldest first.
+func CommitsInRange(ctx context.Context, r *shell.Runner, start, end string) ([]CommitSummary, error) {
+	out, err := r.Run(ctx, "git", "log", "--reverse", "--format=%H%x00%s", start+".."+end)
+	if err != nil {
+		return nil, fmt.Errorf("listing commits %s..%s: %w", start, end, err)
+	}
+	var commits []CommitSummary
+	for _, line := range strings.Split(strings.TrimSpace(out), "\n") {
+		sha, subject, ok := strings.Cut(line, "\x00")
+		if ok {
+			commits = append(commits, CommitSummary{SHA: sha, Subject: subject})
+		}
+	}
+	return commits, nil
+}
+
+// storyLogFormat makes git log print each commit's SHA, subject and
+// Ralph-Story trailer values, for parseStoryLog.
+const storyLogFormat = "--format=%H%x00%s%x00%(trailers:key=" + StoryTrailer + ",valueonly,separator=%x2C)%x1e"
+
+// storyCommit is a commit and the stories it belongs to.
+type storyCommit struct {
+	CommitSummary
+	stories []string
+}
+
+// parseStoryLog reads git log output in storyLogFormat. A commit belongs to
+// the stories of its Ralph-Story trailers or, lacking any trailer, to the
+// story of its loop commit subject.
+func parseStoryLog(out string) []storyCommit {
+	var commits []storyCommit
+	for _, record := range strings.Split(out, "\x1e") {
+		fields := strings.SplitN(strings.TrimSpace(record), "\x00", 3)
+		if len(fields) != 3 {
+			continue
+		}
+		c := storyCommit{CommitSummary: CommitSummary{SHA: fields[0], Subject: fields[1]}}
+		if trailers := strings.TrimSpace(fields[2]); trailers != "" {
+			c.stories = strings.Split(trailers, ",")
+		} else if id := StoryIDFromSubject(c.Subject); id != "" {
+			c.stories = []string{id}
+		}
+		commits = append(commits, c)
+	}
+	return commits
+}
+
+// StoryCommits lists the commits in base..HEAD that belong to storyID,
+// oldest first: those with its Ralph-Story trailer and, lacking any
+// trailer, those with its loop commit subject ("feat(<storyID>): ...").
+func StoryCommits(ctx context.Context, r *shell.Runner, base, storyID string) ([]CommitSummary, error) {
+	out, err := r.Run(ctx, "git", "log", "--reverse", storyLogFormat, base+"..HEAD")
+	if err != nil {
+		return nil, fmt.Errorf("listing commits since %s: %w", base, err)
+	}
+	var commits []CommitSummary
+	for _, c := range parseStoryLog(out) {
+		if slices.Contains(c.stories, storyID) {
+			commits = append(commits, c.CommitSummary)
+		}
+	}
+	return commits, nil
+}
+
+// StoriesInRange returns the IDs of the stories that commits reachable from
+// end but not from start belong to, in the order they were first committed.
+func StoriesInRange(ctx context.Context, r *shell.Runner, start, end string) ([]string, error) {
+	out, err := r.Run(ctx, "git", "log", "--reverse", storyLogFormat, start+".."+end)
+	if err != nil {
+		return nil, fmt.Errorf("listing commits %s..%s: %w", start, end, err)
+	}
+	var ids []string
+	for _, c := range parseStoryLog(out) {
+		for _, id := range c.stories {
+			if !slices.Contains(ids, id) {
+				ids = append(ids, id)
+			}
+		}
+	}
+	return ids, nil
+}
+
+// RevertCommits reverts commits in a single new commit with message. On a
+// conflict the revert is aborted and the branch is left as it was.
+func RevertCommits(ctx context.Context, r *shell.Runner, commits []CommitSummary, message string) error {
+	args := []string{"revert", "--no-commit"}
+	for i := len(commits) - 1; i >= 0; i-- {
+		args = append(args, commits[i].SHA)
+	}
+	if _, err := r.Run(ctx, "git", args...); err != nil {
+		_, _ = r.Run(ctx, "git", "revert", "--abort")
+		return fmt.Errorf("reverting: %w", err)
+	}
+	if _, err := r.Run(ctx, "git", "commit", "-m", message); err != nil {
+		_, _ = r.Run(ctx, "git", "revert", "--abort")
+		return fmt.Errorf("committing the revert: %w", err)
+	}
+	return nil
+}
+
+// RebaseMerge checks out baseBranch in the main repo and replays the
+// non-merge commits of featureBranch on top of it one by one, adding a
+// Ralph-Story trailer to each commit made by the loop. On a conflict the
+// cherry-pick is aborted and baseBranch is left where it was.
+func RebaseMerge(ctx context.Context, repoPath, featureBranch, baseBranch string) error {
+	repoRunner := &shell.Runner{Dir: repoPath}
+
+	if _, err := repoRunner.Run(ctx, "git", "checkout", baseBranch); err != nil {
+		return fmt.Errorf("checking out %s: %w", baseBranch, err)
+	}
+	before, err := RevParse(ctx, repoRunner, "HEAD")
+	if err != nil {
+		return err
+	}
+	out, err := repoRunner.Run(ctx, "git", "log", "--reverse", "--no-merges", "--format=%H %s", baseBranch+".."+featureBranch)
+	if err != nil {
+		return fmt.Errorf("listing commits of %s: %w", featureBranch, err)
+	}
+
+	for _, line := range strings.Split(strings.TrimSpace(out), "\n") {
+		sha, subject, _ := strings.Cut(line, " ")
+		if sha == "" {
+			continue
+		}
+		if _, err := repoRunner.Run(ctx, "git", "cherry-pick", sha); err != nil {
+			_, _ = repoRunner.Run(ctx, "git", "cherry-pick", "--abort")
+			_ = ResetBranch(ctx, repoRunner, before)
+			return fmt.Errorf("replaying %s onto %s: %w", sha[:min(len(sha), 12)], baseBranch, err)
+		}
+		if id := StoryIDFromSubject(subject); id != "" {
+			if _, err := repoRunner.Run(ctx, "git", "commit", "--amend", "--no-edit", "--trailer", StoryTrailer+": "+id); err != nil {
+				_ = ResetBranch(ctx, repoRunner, before)
+				return fmt.Errorf("adding %s trailer: %w", StoryTrailer, err)
+			}
+		}
+	}
+	return nil
+}
+
+// MergeCommit checks out baseBranch in the main repo and merges featureBranch
+// with a merge commit (--no-ff). On a conflict the merge is aborted.
+func MergeCommit(ctx context.Context, repoPath, featureBranch, baseBranch, commitMsg string) error {
+	repoRunner := &shell.Runner{Dir: repoPath}
+
+	if _, err := repoRunner.Run(ctx, "git", "checkout", baseBranch); err != nil {
+		return fmt.Errorf("checking out %s: %w", baseBranch, err)
+	}
+	if _, err := repoRunner.Run(ctx, "git", "merge", "--no-ff", "-m", commitMsg, featureBranch); err != nil {
+		_, _ = repoRunner.Run(ctx, "git", "merge", "--abort")
+		return fmt.Errorf("merging %s: %w", featureBranch, err)
+	}
+	return nil
+}
+
+// ResetBranch moves the checked-out branch back to ref with git reset
+// --keep, which refuses rather than discarding unrelated local changes.
+func ResetBranch(ctx context.Context, r *shell.Runner, ref string) error {
+	if _, err := r.Run(ctx, "git", "reset", "--keep", ref); err != nil {
+		return fmt.Errorf("resetting to %s: %w", ref, err)
+	}
+	return nil
+}
+
 // MainRepoPath returns the root of the main repository, even when called from
 // inside a worktree. It uses git's common dir to find the shared .git directory,
 // then returns its parent.
@@ -2,6 +2,8 @@ package gitops
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
@@ -236,6 +238,83 @@ func TestFetchBranch(t *testing.T) {
 	}
 }
 
+// cloneWithPR clones a remote that has a "feature" branch and a pull
+// request ref pointing at it, plus a fork-only pull request ref.
+func cloneWithPR(t *testing.T) *shell.Runner {
+	t.Helper()
+	ctx := context.Background()
+	remoteDir := t.TempDir()
+	remote := initRepo(t, remoteDir)
+
+	if _, err := remote.Run(ctx, "git", "checkout", "-b", "feature"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := remote.Run(ctx, "git", "commit", "--allow-empty", "-m", "feature work"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := remote.Run(ctx, "git", "update-ref", "refs/pull/7/head", "feature"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := remote.Run(ctx, "git", "commit", "--allow-empty", "-m", "fork work"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := remote.Run(ctx, "git", "update-ref", "refs/pull/8/head", "HEAD"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := remote.Run(ctx, "git", "reset", "--hard", "HEAD~1"); err != nil {
+		t.Fatal(err)
+	}
+
+	cloneDir := filepath.Join(t.TempDir(), "clone")
+	parent := &shell.Runner{Dir: filepath.Dir(cloneDir)}
+	if _, err := parent.Run(ctx, "git", "clone", remoteDir, cloneDir); err != nil {
+		t.Fatalf("cloning: %v", err)
+	}
+	return &shell.Runner{Dir: cloneDir}
+}
+
+func TestPRHeadBranch(t *testing.T) {
+	r := cloneWithPR(t)
+	ctx := context.Background()
+
+	branch, err := PRHeadBranch(ctx, r, 7)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if branch != "feature" {
+		t.Errorf("branch = %q, want %q", branch, "feature")
+	}
+
+	// PR 8 has no matching branch on origin (fork).
+	branch, err = PRHeadBranch(ctx, r, 8)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if branch != "" {
+		t.Errorf("branch = %q, want empty for fork PR", branch)
+	}
+
+	if _, err := PRHeadBranch(ctx, r, 99); err == nil || !strings.Contains(err.Error(), "#99 not found") {
+		t.Errorf("expected not found error, got %v", err)
+	}
+}
+
+func TestFetchPRHead(t *testing.T) {
+	r := cloneWithPR(t)
+	ctx := context.Background()
+
+	if err := FetchPRHead(ctx, r, 8, "ralph/pr-8"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	out, err := r.Run(ctx, "git", "log", "-1", "--format=%s", "ralph/pr-8")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.TrimSpace(out) != "fork work" {
+		t.Errorf("ralph/pr-8 tip = %q, want %q", strings.TrimSpace(out), "fork work")
+	}
+}
+
 func TestStartRebase_NoConflicts(t *testing.T) {
 	// Create a repo with two branches that don't conflict.
 	dir := t.TempDir()
@@ -289,6 +368,73 @@ func TestStartRebase_NoConflicts(t *testing.T) {
 	}
 }
 
+func TestStartRebaseOnto_ReplaysOnlyCommitsAfterUpstream(t *testing.T) {
+	dir := t.TempDir()
+	r := initRepo(t, dir)
+	ctx := context.Background()
+
+	commit := func(file, msg string) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(dir, file), []byte(msg), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := r.Run(ctx, "git", "add", "-A"); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := r.Run(ctx, "git", "commit", "-m", msg); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	base, err := CurrentBranch(ctx, r)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// parent: one commit; child: stacked on parent with one commit.
+	if _, err := r.Run(ctx, "git", "checkout", "-b", "parent"); err != nil {
+		t.Fatal(err)
+	}
+	commit("parent.txt", "parent work")
+	oldParent, err := RevParse(ctx, r, "parent")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.Run(ctx, "git", "checkout", "-b", "child"); err != nil {
+		t.Fatal(err)
+	}
+	commit("child.txt", "child work")
+
+	// Rewrite parent (amend), as a rebase of the parent would.
+	if _, err := r.Run(ctx, "git", "checkout", "parent"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.Run(ctx, "git", "commit", "--amend", "-m", "parent work (rewritten)"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.Run(ctx, "git", "checkout", "child"); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := StartRebaseOnto(ctx, r, "parent", oldParent)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !result.Success {
+		t.Fatalf("expected success, got %+v", result)
+	}
+
+	out, err := r.Run(ctx, "git", "log", "--format=%s", base+"..child")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := strings.Split(strings.TrimSpace(out), "\n")
+	want := []string{"child work", "parent work (rewritten)"}
+	if strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("child history = %v, want %v", got, want)
+	}
+}
+
 func TestStartRebase_WithConflicts(t *testing.T) {
 	dir := t.TempDir()
 	r := initRepo(t, dir)
@@ -740,7 +886,7 @@ func TestCopyDotClaude_CopiesDirectory(t *testing.T) {
 	}
 
 	// Copy .claude to worktree.
-	if err := CopyDotClaude(repoDir, worktreeDir); err != nil {
+	if err := CopyDotClaude(repoDir, worktreeDir, ClaudePermissions{}); err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
 
@@ -769,7 +915,7 @@ func TestCopyDotClaude_NoErrorWhenMissing(t *testing.T) {
 	worktreeDir := t.TempDir()
 
 	// No .claude directory in repo — should not error.
-	if err := CopyDotClaude(repoDir, worktreeDir); err != nil {
+	if err := CopyDotClaude(repoDir, worktreeDir, ClaudePermissions{}); err != nil {
 		t.Fatalf("unexpected error when .claude does not exist: %v", err)
 	}
 
@@ -780,6 +926,91 @@ func TestCopyDotClaude_NoErrorWhenMissing(t *testing.T) {
 	}
 }
 
+func TestCopyDotClaude_MergesPermissions(t *testing.T) {
+	repoDir := t.TempDir()
+	worktreeDir := t.TempDir()
+
+	claudeDir := filepath.Join(repoDir, ".claude")
+	if err := os.MkdirAll(claudeDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	settings := `{"model": "opus", "permissions": {"deny": ["WebFetch"]}}`
+	if err := os.WriteFile(filepath.Join(claudeDir, "settings.local.json"), []byte(settings), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	perms := ClaudePermissions{Allow: []string{"Read", "Bash(go test:*)"}, Deny: []string{"WebFetch", "Bash(git push:*)"}}
+	if err := CopyDotClaude(repoDir, worktreeDir, perms); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(worktreeDir, ClaudeLocalSettings))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got struct {
+		Model       string
+		Permissions struct{ Allow, Deny []string }
+	}
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatalf("settings.local.json is not valid JSON: %v\n%s", err, data)
+	}
+	if got.Model != "opus" {
+		t.Errorf("model = %q, want the repo setting kept", got.Model)
+	}
+	if strings.Join(got.Permissions.Allow, ",") != "Read,Bash(go test:*)" {
+		t.Errorf("allow = %v", got.Permissions.Allow)
+	}
+	if strings.Join(got.Permissions.Deny, ",") != "WebFetch,Bash(git push:*)" {
+		t.Errorf("deny = %v, want the repo rules followed by the new ones", got.Permissions.Deny)
+	}
+
+	// The repo's settings are untouched.
+	if data, _ := os.ReadFile(filepath.Join(claudeDir, "settings.local.json")); string(data) != settings {
+		t.Errorf("repo settings changed: %s", data)
+	}
+}
+
+func TestCopyDotClaude_PermissionsWithoutRepoSettings(t *testing.T) {
+	worktreeDir := t.TempDir()
+
+	if err := CopyDotClaude(t.TempDir(), worktreeDir, ClaudePermissions{Deny: []string{"Bash(rm:*)"}}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	data, err := os.ReadFile(filepath.Join(worktreeDir, ClaudeLocalSettings))
+	if err != nil {
+		t.Fatalf("expected settings.local.json to be created: %v", err)
+	}
+	if !strings.Contains(string(data), `"Bash(rm:*)"`) {
+		t.Errorf("settings.local.json = %s, want the deny rule", data)
+	}
+	if _, err := os.Stat(filepath.Join(worktreeDir, ".claude", "settings.json")); !os.IsNotExist(err) {
+		t.Error("expected the committed settings.json not to be written")
+	}
+}
+
+func TestExcludeFromGit(t *testing.T) {
+	dir := t.TempDir()
+	r := initRepo(t, dir)
+	ctx := context.Background()
+	os.MkdirAll(filepath.Join(dir, ".claude"), 0755)
+	os.WriteFile(filepath.Join(dir, ClaudeLocalSettings), []byte("{}"), 0644)
+
+	for range 2 {
+		if err := ExcludeFromGit(ctx, r, ClaudeLocalSettings); err != nil {
+			t.Fatalf("ExcludeFromGit: %v", err)
+		}
+	}
+
+	if out, _ := r.Run(ctx, "git", "status", "--porcelain"); strings.TrimSpace(out) != "" {
+		t.Errorf("status = %q, want the local settings ignored", out)
+	}
+	data, _ := os.ReadFile(filepath.Join(dir, ".git", "info", "exclude"))
+	if n := strings.Count(string(data), "/"+ClaudeLocalSettings+"\n"); n != 1 {
+		t.Errorf("exclude lists the settings %d times, want once:\n%s", n, data)
+	}
+}
+
 func TestPullFFOnly_Success(t *testing.T) {
 	// Create a "remote" repo and a clone, then push a new commit to the
 	// remote and verify PullFFOnly brings the clone up to date.
@@ -1291,3 +1522,439 @@ func TestCopyGlobPatterns_PreservesRelativePath(t *testing.T) {
 		t.Fatalf("expected content to match, got: %s", data)
 	}
 }
+
+// featureWithCommits creates branch feature off the default branch with the
+// given commit subjects, each adding a file, then returns to the default
+// branch.
+func featureWithCommits(t *testing.T, dir string, r *shell.Runner, subjects ...string) string {
+	t.Helper()
+	ctx := context.Background()
+	defaultBranch, _ := CurrentBranch(ctx, r)
+	if _, err := r.Run(ctx, "git", "checkout", "-b", "feature"); err != nil {
+		t.Fatal(err)
+	}
+	for i, subject := range subjects {
+		if err := os.WriteFile(filepath.Join(dir, fmt.Sprintf("f%d.txt", i)), []byte(subject), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := r.Run(ctx, "git", "add", "-A"); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := r.Run(ctx, "git", "commit", "-m", subject); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := r.Run(ctx, "git", "checkout", defaultBranch); err != nil {
+		t.Fatal(err)
+	}
+	return defaultBranch
+}
+
+func TestStoryIDFromSubject(t *testing.T) {
+	tests := map[string]string{
+		"feat(US-001): add login": "US-001",
+		"feat(IT-2): fix":         "IT-2",
+		"feat: no scope":          "",
+		"fix(US-001): not a loop": "",
+		"feat(): empty":           "",
+	}
+	for subject, want := range tests {
+		if got := StoryIDFromSubject(subject); got != want {
+			t.Errorf("StoryIDFromSubject(%q) = %q, want %q", subject, got, want)
+		}
+	}
+}
+
+func TestRebaseMerge_KeepsCommitsWithStoryTrailers(t *testing.T) {
+	dir := t.TempDir()
+	r := initRepo(t, dir)
+	ctx := context.Background()
+	defaultBranch := featureWithCommits(t, dir, r, "feat(US-001): first", "chore: tidy", "feat(US-002): second")
+
+	if err := RebaseMerge(ctx, dir, "feature", defaultBranch); err != nil {
+		t.Fatalf("RebaseMerge: %v", err)
+	}
+
+	out, err := r.Run(ctx, "git", "log", "--reverse", "--format=%s|%(trailers:key=Ralph-Story,valueonly,separator=)", "-3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := strings.Split(strings.TrimSpace(out), "\n")
+	want := []string{"feat(US-001): first|US-001", "chore: tidy|", "feat(US-002): second|US-002"}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("log = %q, want %q", got, want)
+	}
+}
+
+func TestStoryCommits_TrailerOverridesSubject(t *testing.T) {
+	dir := t.TempDir()
+	r := initRepo(t, dir)
+	ctx := context.Background()
+	defaultBranch := featureWithCommits(t, dir, r,
+		"feat(US-001): first",
+		"chore: tidy\n\nRalph-Story: US-001",
+		"feat(US-001): mislabelled\n\nRalph-Story: US-002",
+		"docs: unrelated",
+	)
+	r.Run(ctx, "git", "checkout", "feature")
+
+	commits, err := StoryCommits(ctx, r, defaultBranch, "US-001")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var subjects []string
+	for _, c := range commits {
+		subjects = append(subjects, c.Subject)
+	}
+	if want := "feat(US-001): first|chore: tidy"; strings.Join(subjects, "|") != want {
+		t.Errorf("subjects = %q, want %q", subjects, want)
+	}
+}
+
+func TestStoryCommit_MatchesIDLiterally(t *testing.T) {
+	dir := t.TempDir()
+	r := initRepo(t, dir)
+	ctx := context.Background()
+	featureWithCommits(t, dir, r, "feat(US.1): dotted", "feat(USx1): lookalike")
+
+	sha, err := StoryCommit(ctx, r, "feature", "US.1")
+	if err != nil {
+		t.Fatalf("StoryCommit: %v", err)
+	}
+	subject, _ := r.Run(ctx, "git", "log", "-1", "--format=%s", sha)
+	if got := strings.TrimSpace(subject); got != "feat(US.1): dotted" {
+		t.Errorf("StoryCommit found %q, want the US.1 commit", got)
+	}
+}
+
+func TestStoryCommit_FindsTrailerCommits(t *testing.T) {
+	dir := t.TempDir()
+	r := initRepo(t, dir)
+	ctx := context.Background()
+	featureWithCommits(t, dir, r,
+		"feat(US-001): first",
+		"refactor: split handler\n\nRalph-Story: US-001",
+		"feat(US-001): mislabelled\n\nRalph-Story: US-002",
+	)
+
+	sha, err := StoryCommit(ctx, r, "feature", "US-001")
+	if err != nil {
+		t.Fatalf("StoryCommit: %v", err)
+	}
+	subject, _ := r.Run(ctx, "git", "log", "-1", "--format=%s", sha)
+	if got := strings.TrimSpace(subject); got != "refactor: split handler" {
+		t.Errorf("StoryCommit found %q, want the latest US-001 trailer commit", got)
+	}
+}
+
+func TestStoriesInRange(t *testing.T) {
+	dir := t.TempDir()
+	r := initRepo(t, dir)
+	ctx := context.Background()
+	defaultBranch := featureWithCommits(t, dir, r,
+		"feat(US-001): first",
+		"refactor: split handler\n\nRalph-Story: US-002",
+		"docs: unrelated",
+		"feat(US-001): again",
+	)
+
+	ids, err := StoriesInRange(ctx, r, defaultBranch, "feature")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(ids, ","); got != "US-001,US-002" {
+		t.Errorf("StoriesInRange = %q, want US-001,US-002", got)
+	}
+}
+
+func TestCurrentStory_SetAndClear(t *testing.T) {
+	dir := t.TempDir()
+	r := initRepo(t, dir)
+	ctx := context.Background()
+
+	if err := SetCurrentStory(ctx, r, "US-003"); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := CurrentStory(ctx, r); got != "US-003" {
+		t.Errorf("CurrentStory = %q, want US-003", got)
+	}
+	if err := SetCurrentStory(ctx, r, ""); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := CurrentStory(ctx, r); got != "" || err != nil {
+		t.Errorf("CurrentStory after clearing = %q, %v", got, err)
+	}
+}
+
+func TestRebaseMerge_ConflictLeavesBaseUntouched(t *testing.T) {
+	dir := t.TempDir()
+	r := initRepo(t, dir)
+	ctx := context.Background()
+	defaultBranch := featureWithCommits(t, dir, r, "feat(US-001): first")
+
+	// A conflicting change on the base.
+	if err := os.WriteFile(filepath.Join(dir, "f0.txt"), []byte("other"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	r.Run(ctx, "git", "add", "-A")
+	r.Run(ctx, "git", "commit", "-m", "conflicting")
+	before, _ := RevParse(ctx, r, "HEAD")
+
+	if err := RebaseMerge(ctx, dir, "feature", defaultBranch); err == nil {
+		t.Fatal("expected conflict error")
+	}
+	after, _ := RevParse(ctx, r, "HEAD")
+	if after != before {
+		t.Errorf("base moved to %s, want %s", after, before)
+	}
+	if out, _ := r.Run(ctx, "git", "status", "--porcelain"); strings.TrimSpace(out) != "" {
+		t.Errorf("worktree not clean after conflict: %s", out)
+	}
+}
+
+func TestMergeCommit_CreatesMergeCommit(t *testing.T) {
+	dir := t.TempDir()
+	r := initRepo(t, dir)
+	ctx := context.Background()
+	defaultBranch := featureWithCommits(t, dir, r, "feat(US-001): first")
+
+	if err := MergeCommit(ctx, dir, "feature", defaultBranch, "Merge login"); err != nil {
+		t.Fatalf("MergeCommit: %v", err)
+	}
+
+	out, err := r.Run(ctx, "git", "log", "-1", "--format=%s %P")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fields := strings.Fields(out)
+	if len(fields) != 4 || fields[0]+" "+fields[1] != "Merge login" {
+		t.Errorf("HEAD = %q, want merge commit with two parents", out)
+	}
+}
+
+func TestParseGitHubRemote(t *testing.T) {
+	tests := []struct {
+		url         string
+		owner, repo string
+	}{
+		{"git@github.com:uesteibar/ralph.git", "uesteibar", "ralph"},
+		{"git@github.com:uesteibar/ralph", "uesteibar", "ralph"},
+		{"https://github.com/uesteibar/ralph.git", "uesteibar", "ralph"},
+		{"https://github.com/uesteibar/ralph", "uesteibar", "ralph"},
+		{"https://token@github.com/uesteibar/ralph/", "uesteibar", "ralph"},
+		{"ssh://git@github.com/uesteibar/ralph.git", "uesteibar", "ralph"},
+	}
+	for _, tt := range tests {
+		owner, repo, err := ParseGitHubRemote(tt.url)
+		if err != nil {
+			t.Errorf("ParseGitHubRemote(%q): %v", tt.url, err)
+			continue
+		}
+		if owner != tt.owner || repo != tt.repo {
+			t.Errorf("ParseGitHubRemote(%q) = %s/%s, want %s/%s", tt.url, owner, repo, tt.owner, tt.repo)
+		}
+	}
+}
+
+func TestParseGitHubRemote_Invalid(t *testing.T) {
+	for _, url := range []string{"/tmp/origin.git", "https://github.com/uesteibar", "git@github.com:a/b/c.git"} {
+		if _, _, err := ParseGitHubRemote(url); err == nil {
+			t.Errorf("ParseGitHubRemote(%q): expected error", url)
+		}
+	}
+}
+
+func TestCommitChanges(t *testing.T) {
+	dir := t.TempDir()
+	r := initRepo(t, dir)
+	ctx := context.Background()
+
+	os.WriteFile(filepath.Join(dir, "a.go"), []byte("1\n2\n3\n"), 0644)
+	os.WriteFile(filepath.Join(dir, "README.md"), []byte("# changed\n"), 0644)
+	if err := Commit(ctx, r, "add a"); err != nil {
+		t.Fatal(err)
+	}
+	head, _ := RevParse(ctx, r, "HEAD")
+
+	changes, err := CommitChanges(ctx, r, head)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []FileChange{{Path: "README.md", Added: 1, Deleted: 1}, {Path: "a.go", Added: 3}}
+	if fmt.Sprint(changes) != fmt.Sprint(want) {
+		t.Errorf("changes = %+v, want %+v", changes, want)
+	}
+}
+
+func TestChangesSince_CountsCommittedUncommittedAndUntracked(t *testing.T) {
+	dir := t.TempDir()
+	r := initRepo(t, dir)
+	ctx := context.Background()
+	start, _ := RevParse(ctx, r, "HEAD")
+
+	os.WriteFile(filepath.Join(dir, "a.go"), []byte("1\n2\n3\n"), 0644)
+	if err := Commit(ctx, r, "add a"); err != nil {
+		t.Fatal(err)
+	}
+	os.WriteFile(filepath.Join(dir, "README.md"), []byte("# changed\n"), 0644)
+	os.WriteFile(filepath.Join(dir, "new.txt"), []byte("x\ny"), 0644)
+
+	changes, err := ChangesSince(ctx, r, start)
+	if err != nil {
+		t.Fatalf("ChangesSince: %v", err)
+	}
+	got := map[string]FileChange{}
+	for _, c := range changes {
+		got[c.Path] = c
+	}
+	if len(got) != 3 {
+		t.Fatalf("changes = %+v, want 3 files", changes)
+	}
+	if c := got["a.go"]; c.Added != 3 || c.Deleted != 0 {
+		t.Errorf("a.go = %+v, want 3 added", c)
+	}
+	if c := got["README.md"]; c.Added != 1 || c.Deleted != 1 {
+		t.Errorf("README.md = %+v, want 1 added and 1 deleted", c)
+	}
+	if c := got["new.txt"]; c.Added != 2 {
+		t.Errorf("new.txt = %+v, want 2 added", c)
+	}
+}
+
+func TestFileDiff_TrackedAndUntracked(t *testing.T) {
+	dir := t.TempDir()
+	r := initRepo(t, dir)
+	ctx := context.Background()
+	start, _ := RevParse(ctx, r, "HEAD")
+
+	os.WriteFile(filepath.Join(dir, "README.md"), []byte("# changed\n"), 0644)
+	if err := Commit(ctx, r, "change readme"); err != nil {
+		t.Fatal(err)
+	}
+	os.WriteFile(filepath.Join(dir, "new.txt"), []byte("fresh\n"), 0644)
+
+	base, err := MergeBase(ctx, r, start, "HEAD")
+	if err != nil || base != start {
+		t.Fatalf("MergeBase = %q, %v; want %q", base, err, start)
+	}
+
+	diff, err := FileDiff(ctx, r, base, "README.md")
+	if err != nil {
+		t.Fatalf("FileDiff: %v", err)
+	}
+	if !strings.Contains(diff, "-# test") || !strings.Contains(diff, "+# changed") {
+		t.Errorf("README.md diff = %q", diff)
+	}
+
+	diff, err = FileDiff(ctx, r, base, "new.txt")
+	if err != nil {
+		t.Fatalf("FileDiff untracked: %v", err)
+	}
+	if !strings.Contains(diff, "+fresh") {
+		t.Errorf("new.txt diff = %q", diff)
+	}
+}
+
+func TestRestorePaths_AndCommitPaths(t *testing.T) {
+	dir := t.TempDir()
+	r := initRepo(t, dir)
+	ctx := context.Background()
+	start, _ := RevParse(ctx, r, "HEAD")
+
+	os.WriteFile(filepath.Join(dir, "README.md"), []byte("# changed\n"), 0644)
+	os.WriteFile(filepath.Join(dir, "added.txt"), []byte("x\n"), 0644)
+	os.WriteFile(filepath.Join(dir, "keep.txt"), []byte("keep\n"), 0644)
+	if err := Commit(ctx, r, "agent work"); err != nil {
+		t.Fatal(err)
+	}
+
+	paths := []string{"README.md", "added.txt"}
+	if err := RestorePaths(ctx, r, start, paths); err != nil {
+		t.Fatalf("RestorePaths: %v", err)
+	}
+	committed, err := CommitPaths(ctx, r, "revert", paths)
+	if err != nil || !committed {
+		t.Fatalf("CommitPaths = %v, %v; want a commit", committed, err)
+	}
+
+	if data, _ := os.ReadFile(filepath.Join(dir, "README.md")); string(data) != "# test\n" {
+		t.Errorf("README.md = %q, want the original content", data)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "added.txt")); !os.IsNotExist(err) {
+		t.Error("added.txt should be deleted")
+	}
+	out, _ := r.Run(ctx, "git", "status", "--porcelain")
+	if strings.TrimSpace(out) != "" {
+		t.Errorf("work tree not clean after the revert commit:\n%s", out)
+	}
+	out, _ = r.Run(ctx, "git", "show", "--name-only", "--format=", "HEAD")
+	if strings.Contains(out, "keep.txt") {
+		t.Error("the revert commit should only touch the restored paths")
+	}
+
+	// Nothing left to commit the second time.
+	if committed, err := CommitPaths(ctx, r, "revert", paths); err != nil || committed {
+		t.Errorf("CommitPaths = %v, %v; want no commit", committed, err)
+	}
+}
+
+func TestDiscardChanges_KeepsListedUntrackedFiles(t *testing.T) {
+	dir := t.TempDir()
+	r := initRepo(t, dir)
+	ctx := context.Background()
+	os.WriteFile(filepath.Join(dir, "local.env"), []byte("x\n"), 0644)
+	start, _ := RevParse(ctx, r, "HEAD")
+
+	os.WriteFile(filepath.Join(dir, "a.go"), []byte("a\n"), 0644)
+	if _, err := r.Run(ctx, "git", "add", "a.go"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.Run(ctx, "git", "commit", "-m", "agent"); err != nil {
+		t.Fatal(err)
+	}
+	os.WriteFile(filepath.Join(dir, "scratch.txt"), []byte("x\n"), 0644)
+
+	if err := DiscardChanges(ctx, r, start, "", []string{"local.env"}); err != nil {
+		t.Fatalf("DiscardChanges: %v", err)
+	}
+	if head, _ := RevParse(ctx, r, "HEAD"); head != start {
+		t.Errorf("HEAD = %s, want %s", head, start)
+	}
+	for name, want := range map[string]bool{"a.go": false, "scratch.txt": false, "local.env": true} {
+		_, err := os.Stat(filepath.Join(dir, name))
+		if (err == nil) != want {
+			t.Errorf("%s exists = %v, want %v", name, err == nil, want)
+		}
+	}
+}
+
+func TestDiscardChanges_RestoresSnapshot(t *testing.T) {
+	dir := t.TempDir()
+	r := initRepo(t, dir)
+	ctx := context.Background()
+	start, _ := RevParse(ctx, r, "HEAD")
+
+	if snapshot, err := SnapshotChanges(ctx, r); err != nil || snapshot != "" {
+		t.Fatalf("SnapshotChanges on a clean tree = %q, %v; want none", snapshot, err)
+	}
+	os.WriteFile(filepath.Join(dir, "README.md"), []byte("# human\n"), 0644)
+	snapshot, err := SnapshotChanges(ctx, r)
+	if err != nil || snapshot == "" {
+		t.Fatalf("SnapshotChanges = %q, %v", snapshot, err)
+	}
+
+	os.WriteFile(filepath.Join(dir, "README.md"), []byte("# agent\n"), 0644)
+	if _, err := r.Run(ctx, "git", "commit", "-qam", "agent"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := DiscardChanges(ctx, r, start, snapshot, nil); err != nil {
+		t.Fatalf("DiscardChanges: %v", err)
+	}
+	if head, _ := RevParse(ctx, r, "HEAD"); head != start {
+		t.Errorf("HEAD = %s, want %s", head, start)
+	}
+	if data, _ := os.ReadFile(filepath.Join(dir, "README.md")); string(data) != "# human\n" {
+		t.Errorf("README.md = %q, want the uncommitted change restored", data)
+	}
+}
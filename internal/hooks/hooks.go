@@ -0,0 +1,148 @@
+package hooks
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/uesteibar/ralph/internal/config"
+	"github.com/uesteibar/ralph/internal/shell"
+)
+
+// DefaultTimeout bounds a hook that does not configure its own timeout.
+const DefaultTimeout = 60 * time.Second
+
+// waitDelay is how long to wait for a killed hook's output pipes to close.
+const waitDelay = 2 * time.Second
+
+// Hook event names, matching the keys under hooks: in ralph.yaml.
+const (
+	WorkspaceCreated = "workspace_created"
+	BeforeStory      = "before_story"
+	AfterStory       = "after_story"
+	QAStarted        = "qa_started"
+	RunFinished      = "run_finished"
+	BeforeDone       = "before_done"
+)
+
+// Payload is the context handed to a hook. It is written as JSON to the
+// hook's stdin and exported as RALPH_* environment variables.
+type Payload struct {
+	Event       string `json:"event"`
+	Workspace   string `json:"workspace,omitempty"`
+	Branch      string `json:"branch,omitempty"`
+	RepoPath    string `json:"repoPath,omitempty"`
+	WorkDir     string `json:"workDir,omitempty"`
+	PRDPath     string `json:"prdPath,omitempty"`
+	StoryID     string `json:"storyId,omitempty"`
+	StoryTitle  string `json:"storyTitle,omitempty"`
+	StoryPasses bool   `json:"storyPasses,omitempty"`
+	QAPhase     string `json:"qaPhase,omitempty"`
+	Result      string `json:"result,omitempty"`
+	Error       string `json:"error,omitempty"`
+}
+
+// env returns the payload as environment variables. Empty fields are omitted
+// so hooks can test for presence with ${VAR:-}.
+func (p Payload) env() []string {
+	vars := []struct{ key, value string }{
+		{"RALPH_HOOK_EVENT", p.Event},
+		{"RALPH_WORKSPACE", p.Workspace},
+		{"RALPH_BRANCH", p.Branch},
+		{"RALPH_REPO_PATH", p.RepoPath},
+		{"RALPH_WORK_DIR", p.WorkDir},
+		{"RALPH_PRD_PATH", p.PRDPath},
+		{"RALPH_STORY_ID", p.StoryID},
+		{"RALPH_STORY_TITLE", p.StoryTitle},
+		{"RALPH_QA_PHASE", p.QAPhase},
+		{"RALPH_RESULT", p.Result},
+		{"RALPH_ERROR", p.Error},
+	}
+	var env []string
+	for _, v := range vars {
+		if v.value != "" {
+			env = append(env, v.key+"="+v.value)
+		}
+	}
+	if p.Event == AfterStory {
+		env = append(env, "RALPH_STORY_PASSES="+strconv.FormatBool(p.StoryPasses))
+	}
+	return env
+}
+
+// Run executes hooks in order via sh -c, in the payload's WorkDir (or RepoPath
+// when WorkDir is empty). A failing fail-open hook is reported through warn
+// and the remaining hooks still run. The first failing fail-closed hook stops
+// execution and its error is returned.
+func Run(ctx context.Context, hooks []config.HookConfig, p Payload, warn func(string)) error {
+	if len(hooks) == 0 {
+		return nil
+	}
+
+	stdin, err := json.Marshal(p)
+	if err != nil {
+		return fmt.Errorf("marshaling %s hook payload: %w", p.Event, err)
+	}
+
+	dir := p.WorkDir
+	if dir == "" {
+		dir = p.RepoPath
+	}
+
+	for _, h := range hooks {
+		err := runOne(ctx, h, dir, p.env(), string(stdin))
+		if err == nil {
+			continue
+		}
+		if h.FailClosed() {
+			return fmt.Errorf("%s hook %q: %w", p.Event, h.Command, err)
+		}
+		if warn != nil {
+			warn(fmt.Sprintf("%s hook %q failed: %v", p.Event, h.Command, err))
+		}
+	}
+	return nil
+}
+
+func runOne(ctx context.Context, h config.HookConfig, dir string, env []string, stdin string) error {
+	timeout := h.Timeout
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	hookCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(hookCtx, "sh", "-c", h.Command)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), env...)
+	cmd.Stdin = strings.NewReader(stdin)
+	// Background children of the hook may keep stdout/stderr open after sh
+	// is killed; don't let them hold up the caller past the timeout.
+	cmd.WaitDelay = waitDelay
+
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	err := cmd.Run()
+	if err == nil {
+		return nil
+	}
+	if errors.Is(hookCtx.Err(), context.DeadlineExceeded) {
+		return fmt.Errorf("timed out after %s", timeout)
+	}
+	if exitErr, ok := err.(*exec.ExitError); ok {
+		return &shell.ExitError{
+			Code:   exitErr.ExitCode(),
+			Stderr: strings.TrimSpace(stderr.String()),
+			Cmd:    h.Command,
+		}
+	}
+	return err
+}
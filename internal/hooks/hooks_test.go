@@ -0,0 +1,118 @@
+package hooks
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/uesteibar/ralph/internal/config"
+)
+
+func TestRun_NoHooks_ReturnsNil(t *testing.T) {
+	if err := Run(context.Background(), nil, Payload{Event: BeforeStory}, nil); err != nil {
+		t.Fatalf("Run returned error: %v", err)
+	}
+}
+
+func TestRun_PassesEnvAndStdin(t *testing.T) {
+	dir := t.TempDir()
+	hooks := []config.HookConfig{{
+		Command: `echo "$RALPH_HOOK_EVENT $RALPH_STORY_ID $RALPH_STORY_PASSES" > env.txt; cat > stdin.json`,
+	}}
+
+	err := Run(context.Background(), hooks, Payload{
+		Event:       AfterStory,
+		Workspace:   "login",
+		WorkDir:     dir,
+		StoryID:     "US-001",
+		StoryPasses: true,
+	}, nil)
+	if err != nil {
+		t.Fatalf("Run returned error: %v", err)
+	}
+
+	env, err := os.ReadFile(filepath.Join(dir, "env.txt"))
+	if err != nil {
+		t.Fatalf("reading env.txt: %v", err)
+	}
+	if got := strings.TrimSpace(string(env)); got != "after_story US-001 true" {
+		t.Errorf("env = %q, want %q", got, "after_story US-001 true")
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, "stdin.json"))
+	if err != nil {
+		t.Fatalf("reading stdin.json: %v", err)
+	}
+	var p Payload
+	if err := json.Unmarshal(data, &p); err != nil {
+		t.Fatalf("parsing stdin payload: %v", err)
+	}
+	if p.Workspace != "login" || p.StoryID != "US-001" || !p.StoryPasses {
+		t.Errorf("payload = %+v, want workspace=login storyId=US-001 passes=true", p)
+	}
+}
+
+func TestRun_FailOpen_WarnsAndContinues(t *testing.T) {
+	dir := t.TempDir()
+	hooks := []config.HookConfig{
+		{Command: "exit 3"},
+		{Command: "touch second-ran"},
+	}
+
+	var warnings []string
+	err := Run(context.Background(), hooks, Payload{Event: BeforeStory, WorkDir: dir}, func(msg string) {
+		warnings = append(warnings, msg)
+	})
+	if err != nil {
+		t.Fatalf("Run returned error for fail-open hook: %v", err)
+	}
+	if len(warnings) != 1 || !strings.Contains(warnings[0], "before_story hook") {
+		t.Errorf("warnings = %v, want one before_story warning", warnings)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "second-ran")); err != nil {
+		t.Error("expected second hook to run after fail-open failure")
+	}
+}
+
+func TestRun_FailClosed_StopsAndReturnsError(t *testing.T) {
+	dir := t.TempDir()
+	hooks := []config.HookConfig{
+		{Command: "echo nope >&2; exit 1", OnFailure: config.HookOnFailureAbort},
+		{Command: "touch second-ran"},
+	}
+
+	err := Run(context.Background(), hooks, Payload{Event: BeforeDone, WorkDir: dir}, nil)
+	if err == nil {
+		t.Fatal("expected error from fail-closed hook")
+	}
+	if !strings.Contains(err.Error(), "before_done hook") || !strings.Contains(err.Error(), "nope") {
+		t.Errorf("error = %q, want hook name and stderr", err)
+	}
+	if _, statErr := os.Stat(filepath.Join(dir, "second-ran")); statErr == nil {
+		t.Error("expected remaining hooks to be skipped after fail-closed failure")
+	}
+}
+
+func TestRun_Timeout(t *testing.T) {
+	hooks := []config.HookConfig{{
+		Command:   "sleep 5",
+		Timeout:   50 * time.Millisecond,
+		OnFailure: config.HookOnFailureAbort,
+	}}
+
+	start := time.Now()
+	err := Run(context.Background(), hooks, Payload{Event: QAStarted, WorkDir: t.TempDir()}, nil)
+	if err == nil {
+		t.Fatal("expected timeout error")
+	}
+	if !strings.Contains(err.Error(), "timed out") {
+		t.Errorf("error = %q, want timed out", err)
+	}
+	if time.Since(start) > 3*time.Second {
+		t.Error("hook was not killed at its timeout")
+	}
+}
@@ -0,0 +1,175 @@
+package loop
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/bmatcuk/doublestar/v4"
+	"github.com/uesteibar/ralph/internal/config"
+	"github.com/uesteibar/ralph/internal/gitops"
+	"github.com/uesteibar/ralph/internal/prd"
+	"github.com/uesteibar/ralph/internal/shell"
+)
+
+// guard records where a story attempt started, so the guardrails can inspect
+// and revert what the attempt changed.
+type guard struct {
+	runner    *shell.Runner
+	sha       string
+	snapshot  string   // uncommitted changes to tracked files before the attempt, if any
+	untracked []string // untracked before the attempt; not the agent's doing
+}
+
+// startGuard snapshots the work tree before a story attempt. It returns nil
+// when no guardrails are configured or the snapshot fails.
+func startGuard(ctx context.Context, cfg Config) *guard {
+	if !cfg.Guardrails.Enabled() {
+		return nil
+	}
+	r := &shell.Runner{Dir: cfg.WorkDir}
+	sha, err := gitops.RevParse(ctx, r, "HEAD")
+	if err != nil {
+		emitWarn(cfg.EventHandler, "guardrails disabled for this story: %v", err)
+		return nil
+	}
+	snapshot, err := gitops.SnapshotChanges(ctx, r)
+	if err != nil {
+		emitWarn(cfg.EventHandler, "guardrails disabled for this story: %v", err)
+		return nil
+	}
+	untracked, err := gitops.UntrackedFiles(ctx, r)
+	if err != nil {
+		emitWarn(cfg.EventHandler, "guardrails disabled for this story: %v", err)
+		return nil
+	}
+	return &guard{runner: r, sha: sha, snapshot: snapshot, untracked: untracked}
+}
+
+// base is the commit holding the work tree as the attempt found it.
+func (g *guard) base() string {
+	if g.snapshot != "" {
+		return g.snapshot
+	}
+	return g.sha
+}
+
+// enforce checks the attempt at story against the guardrails. An attempt
+// over the size limits is discarded entirely; otherwise changes to protected
+// paths are reverted. Either way the story is marked as not passing, the
+// reason goes into its notes, and a warning is emitted.
+func (g *guard) enforce(ctx context.Context, cfg Config, storyID string) {
+	if g == nil {
+		return
+	}
+
+	changes, err := gitops.ChangesSince(ctx, g.runner, g.base())
+	if err != nil {
+		emitWarn(cfg.EventHandler, "guardrails: inspecting changes of %s: %v", storyID, err)
+		return
+	}
+
+	ignored := slices.Clone(g.untracked)
+	// In base mode the PRD and progress log may live inside the work tree.
+	for _, p := range []string{cfg.PRDPath, cfg.ProgressPath} {
+		if rel, err := filepath.Rel(cfg.WorkDir, p); err == nil && !strings.HasPrefix(rel, "..") {
+			ignored = append(ignored, filepath.ToSlash(rel))
+		}
+	}
+
+	var lines, files int
+	var protected []string
+	for _, c := range changes {
+		if slices.Contains(ignored, c.Path) {
+			continue
+		}
+		files++
+		lines += c.Added + c.Deleted
+		if isProtected(cfg.Guardrails.Protected, c.Path) {
+			protected = append(protected, c.Path)
+		}
+	}
+
+	var reason string
+	gr := cfg.Guardrails
+	switch {
+	case gr.MaxChangedLines > 0 && lines > gr.MaxChangedLines,
+		gr.MaxChangedFiles > 0 && files > gr.MaxChangedFiles:
+		reason = fmt.Sprintf("the attempt was too large and was reverted (%s); split the work into smaller steps",
+			sizeReport(lines, files, gr))
+		if err := gitops.DiscardChanges(ctx, g.runner, g.sha, g.snapshot, g.untracked); err != nil {
+			emitWarn(cfg.EventHandler, "guardrails: reverting %s: %v", storyID, err)
+			return
+		}
+	case len(protected) > 0:
+		reason = fmt.Sprintf("changes to protected paths were reverted: %s; do not modify them", strings.Join(protected, ", "))
+		if err := gitops.RestorePaths(ctx, g.runner, g.sha, protected); err != nil {
+			emitWarn(cfg.EventHandler, "guardrails: reverting protected paths of %s: %v", storyID, err)
+			return
+		}
+		msg := fmt.Sprintf("revert(%s): protected paths changed by the agent", storyID)
+		if _, err := gitops.CommitPaths(ctx, g.runner, msg, protected); err != nil {
+			emitWarn(cfg.EventHandler, "guardrails: committing the revert of %s: %v", storyID, err)
+			return
+		}
+		// Uncommitted edits the human had made to them stay uncommitted.
+		if g.snapshot != "" {
+			if err := gitops.RestoreWorktree(ctx, g.runner, g.snapshot, protected); err != nil {
+				emitWarn(cfg.EventHandler, "guardrails: restoring uncommitted changes to protected paths: %v", err)
+				return
+			}
+		}
+	default:
+		return
+	}
+
+	emitWarn(cfg.EventHandler, "guardrails: %s: %s", storyID, reason)
+	if err := failStory(cfg.PRDPath, storyID, "Guardrails: "+reason); err != nil {
+		emitWarn(cfg.EventHandler, "guardrails: updating %s in the PRD: %v", storyID, err)
+	}
+}
+
+// isProtected reports whether path matches one of the protected globs, or
+// lies under a protected directory.
+func isProtected(patterns []string, path string) bool {
+	for _, pattern := range patterns {
+		if ok, _ := doublestar.Match(pattern, path); ok {
+			return true
+		}
+		if dir := strings.TrimSuffix(pattern, "/"); strings.HasPrefix(path, dir+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// sizeReport describes the size of an attempt against the limits, e.g.
+// "changed lines: 120 (max 100), changed files: 3".
+func sizeReport(lines, files int, gr config.GuardrailsConfig) string {
+	report := func(what string, n, limit int) string {
+		if limit > 0 {
+			return fmt.Sprintf("changed %s: %d (max %d)", what, n, limit)
+		}
+		return fmt.Sprintf("changed %s: %d", what, n)
+	}
+	return report("lines", lines, gr.MaxChangedLines) + ", " + report("files", files, gr.MaxChangedFiles)
+}
+
+// failStory marks the story as not passing and appends note to its notes.
+func failStory(prdPath, storyID, note string) error {
+	return prd.Update(prdPath, func(p *prd.PRD) error {
+		for i := range p.UserStories {
+			s := &p.UserStories[i]
+			if s.ID != storyID {
+				continue
+			}
+			s.Passes = false
+			if !strings.Contains(s.Notes, note) {
+				s.Notes = strings.TrimSpace(s.Notes + "\n" + note)
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,231 @@
+package loop
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/uesteibar/ralph/internal/config"
+	"github.com/uesteibar/ralph/internal/events"
+	"github.com/uesteibar/ralph/internal/prd"
+)
+
+// setupGuardedRepo creates a git repository with a committed migration and a
+// PRD, outside the repository, with a single pending story.
+func setupGuardedRepo(t *testing.T) (dir, prdPath string) {
+	t.Helper()
+	dir = t.TempDir()
+	git := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+	git("init", "-q")
+	git("config", "user.email", "test@test.com")
+	git("config", "user.name", "Test")
+	os.MkdirAll(filepath.Join(dir, "migrations"), 0755)
+	os.WriteFile(filepath.Join(dir, "migrations", "001.sql"), []byte("create table t;\n"), 0644)
+	git("add", "-A")
+	git("commit", "-q", "-m", "initial")
+
+	prdPath = filepath.Join(t.TempDir(), "prd.json")
+	if err := prd.Write(prdPath, &prd.PRD{
+		Project:     "test",
+		UserStories: []prd.Story{{ID: "US-001", Title: "Story 1"}},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	return dir, prdPath
+}
+
+// agentAttempt writes files into dir, commits them and marks US-001 passing,
+// like an agent finishing a story.
+func agentAttempt(t *testing.T, dir, prdPath string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		os.MkdirAll(filepath.Dir(filepath.Join(dir, name)), 0755)
+		os.WriteFile(filepath.Join(dir, name), []byte(content), 0644)
+	}
+	for _, args := range [][]string{{"add", "-A"}, {"commit", "-q", "-m", "feat(US-001): Story 1"}} {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+	p, _ := prd.Read(prdPath)
+	prd.MarkPassing(p, "US-001")
+	prd.Write(prdPath, p)
+}
+
+func TestRun_Guardrails_RevertsProtectedPaths(t *testing.T) {
+	dir, prdPath := setupGuardedRepo(t)
+
+	var attempts int
+	var notes string
+	origInvokeFn := invokeClaudeFn
+	defer func() { invokeClaudeFn = origInvokeFn }()
+	invokeClaudeFn = func(ctx context.Context, opts invokeOpts) (string, error) {
+		attempts++
+		if attempts == 1 {
+			agentAttempt(t, dir, prdPath, map[string]string{
+				"migrations/001.sql": "drop table t;\n",
+				"app.go":             "package app\n",
+			})
+		} else {
+			p, _ := prd.Read(prdPath)
+			notes = p.UserStories[0].Notes
+			agentAttempt(t, dir, prdPath, map[string]string{"app.go": "package app\n\nfunc F() {}\n"})
+		}
+		return "", nil
+	}
+
+	h := &recordingHandler{}
+	err := Run(context.Background(), Config{
+		MaxIterations: 5,
+		WorkDir:       dir,
+		PRDPath:       prdPath,
+		ProgressPath:  filepath.Join(t.TempDir(), "progress.txt"),
+		EventHandler:  h,
+		Guardrails:    config.GuardrailsConfig{Protected: []string{"migrations/**"}},
+	})
+	if err != nil {
+		t.Fatalf("Run returned error: %v", err)
+	}
+
+	if attempts != 2 {
+		t.Errorf("attempts = %d, want the story retried once", attempts)
+	}
+	if !strings.Contains(notes, "protected paths were reverted: migrations/001.sql") {
+		t.Errorf("notes seen by the retry = %q", notes)
+	}
+	if data, _ := os.ReadFile(filepath.Join(dir, "migrations", "001.sql")); string(data) != "create table t;\n" {
+		t.Errorf("migration = %q, want it restored", data)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "app.go")); err != nil {
+		t.Errorf("unprotected changes should be kept: %v", err)
+	}
+	if !hasWarning(h, "guardrails: US-001") {
+		t.Error("expected a guardrails warning event")
+	}
+}
+
+func TestRun_Guardrails_RevertsOversizedAttempt(t *testing.T) {
+	dir, prdPath := setupGuardedRepo(t)
+	start, _ := exec.Command("git", "-C", dir, "rev-parse", "HEAD").Output()
+
+	origInvokeFn := invokeClaudeFn
+	defer func() { invokeClaudeFn = origInvokeFn }()
+	invokeClaudeFn = func(ctx context.Context, opts invokeOpts) (string, error) {
+		agentAttempt(t, dir, prdPath, map[string]string{"big.go": strings.Repeat("x\n", 50)})
+		return "", nil
+	}
+
+	h := &recordingHandler{}
+	err := Run(context.Background(), Config{
+		MaxIterations: 1,
+		WorkDir:       dir,
+		PRDPath:       prdPath,
+		ProgressPath:  filepath.Join(t.TempDir(), "progress.txt"),
+		EventHandler:  h,
+		Guardrails:    config.GuardrailsConfig{MaxChangedLines: 10},
+	})
+	if err == nil {
+		t.Fatal("expected max iterations error, the only attempt was reverted")
+	}
+
+	head, _ := exec.Command("git", "-C", dir, "rev-parse", "HEAD").Output()
+	if string(head) != string(start) {
+		t.Errorf("HEAD = %s, want the attempt's commit discarded", head)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "big.go")); !os.IsNotExist(err) {
+		t.Error("big.go should be removed")
+	}
+	p, _ := prd.Read(prdPath)
+	if s := p.UserStories[0]; s.Passes || !strings.Contains(s.Notes, "too large and was reverted (changed lines: 50 (max 10), changed files: 1)") {
+		t.Errorf("story = %+v, want failing with the reason in its notes", s)
+	}
+	if !hasWarning(h, "guardrails: US-001") {
+		t.Error("expected a guardrails warning event")
+	}
+}
+
+func TestRun_Guardrails_KeepsChangesMadeBeforeTheAttempt(t *testing.T) {
+	dir, prdPath := setupGuardedRepo(t)
+	// The human has uncommitted work in a tracked, protected file.
+	humanEdit := "create table t;\n" + strings.Repeat("-- note\n", 20)
+	os.WriteFile(filepath.Join(dir, "migrations", "001.sql"), []byte(humanEdit), 0644)
+
+	origInvokeFn := invokeClaudeFn
+	defer func() { invokeClaudeFn = origInvokeFn }()
+	invokeClaudeFn = func(ctx context.Context, opts invokeOpts) (string, error) {
+		agentAttempt(t, dir, prdPath, map[string]string{"big.go": strings.Repeat("x\n", 50)})
+		return "", nil
+	}
+
+	Run(context.Background(), Config{
+		MaxIterations: 1,
+		WorkDir:       dir,
+		PRDPath:       prdPath,
+		ProgressPath:  filepath.Join(t.TempDir(), "progress.txt"),
+		EventHandler:  &recordingHandler{},
+		Guardrails:    config.GuardrailsConfig{MaxChangedLines: 10, Protected: []string{"migrations/**"}},
+	})
+
+	if data, _ := os.ReadFile(filepath.Join(dir, "migrations", "001.sql")); string(data) != humanEdit {
+		t.Errorf("migration = %q, want the human's uncommitted edit kept", data)
+	}
+	status, _ := exec.Command("git", "-C", dir, "status", "--porcelain").Output()
+	if strings.TrimSpace(string(status)) != "M migrations/001.sql" {
+		t.Errorf("status = %q, want only the human's edit left uncommitted", status)
+	}
+	p, _ := prd.Read(prdPath)
+	if s := p.UserStories[0]; !strings.Contains(s.Notes, "changed lines: 50 (max 10), changed files: 1") {
+		t.Errorf("notes = %q, want only the agent's changes counted", s.Notes)
+	}
+}
+
+func TestRun_Guardrails_ProtectedRevertKeepsHumanEdit(t *testing.T) {
+	dir, prdPath := setupGuardedRepo(t)
+	humanEdit := "create table t;\n-- wip\n"
+	os.WriteFile(filepath.Join(dir, "migrations", "001.sql"), []byte(humanEdit), 0644)
+
+	origInvokeFn := invokeClaudeFn
+	defer func() { invokeClaudeFn = origInvokeFn }()
+	invokeClaudeFn = func(ctx context.Context, opts invokeOpts) (string, error) {
+		agentAttempt(t, dir, prdPath, map[string]string{"migrations/001.sql": "drop table t;\n"})
+		return "", nil
+	}
+
+	Run(context.Background(), Config{
+		MaxIterations: 1,
+		WorkDir:       dir,
+		PRDPath:       prdPath,
+		ProgressPath:  filepath.Join(t.TempDir(), "progress.txt"),
+		EventHandler:  &recordingHandler{},
+		Guardrails:    config.GuardrailsConfig{Protected: []string{"migrations/**"}},
+	})
+
+	committed, _ := exec.Command("git", "-C", dir, "show", "HEAD:migrations/001.sql").Output()
+	if string(committed) != "create table t;\n" {
+		t.Errorf("committed migration = %q, want the original", committed)
+	}
+	if data, _ := os.ReadFile(filepath.Join(dir, "migrations", "001.sql")); string(data) != humanEdit {
+		t.Errorf("migration = %q, want the human's uncommitted edit back", data)
+	}
+}
+
+func hasWarning(h *recordingHandler, substr string) bool {
+	for _, e := range h.events {
+		if m, ok := e.(events.LogMessage); ok && m.Level == "warning" && strings.Contains(m.Message, substr) {
+			return true
+		}
+	}
+	return false
+}
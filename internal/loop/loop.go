@@ -11,10 +11,14 @@ import (
 	"time"
 
 	"github.com/uesteibar/ralph/internal/claude"
+	"github.com/uesteibar/ralph/internal/config"
 	"github.com/uesteibar/ralph/internal/events"
+	"github.com/uesteibar/ralph/internal/hooks"
 	"github.com/uesteibar/ralph/internal/prd"
 	"github.com/uesteibar/ralph/internal/progress"
 	"github.com/uesteibar/ralph/internal/prompts"
+	"github.com/uesteibar/ralph/internal/sandbox"
+	"github.com/uesteibar/ralph/internal/tracing"
 )
 
 const (
@@ -36,17 +40,22 @@ type invokeOpts struct {
 	eventHandler     events.EventHandler
 	isQAVerification bool
 	isQAFix          bool
+	sandbox          *sandbox.Policy
+	toolPolicy       config.ToolPolicy
 }
 
 // invokeClaudeFn is the function used to invoke Claude. Package-level var for testability.
 var invokeClaudeFn = func(ctx context.Context, opts invokeOpts) (string, error) {
 	return claude.Invoke(ctx, claude.InvokeOpts{
-		Prompt:       opts.prompt,
-		Dir:          opts.dir,
-		Print:        true,
-		Verbose:      opts.verbose,
-		MaxTurns:     opts.maxTurns,
-		EventHandler: opts.eventHandler,
+		Prompt:          opts.prompt,
+		Dir:             opts.dir,
+		Print:           true,
+		Verbose:         opts.verbose,
+		MaxTurns:        opts.maxTurns,
+		AllowedTools:    opts.toolPolicy.AllowRules(),
+		DisallowedTools: opts.toolPolicy.DenyRules(),
+		EventHandler:    opts.eventHandler,
+		Sandbox:         opts.sandbox,
 	})
 }
 
@@ -152,18 +161,80 @@ type Config struct {
 	KnowledgePath string
 	Verbose       bool
 	EventHandler  events.EventHandler
+
+	// Workspace is the workspace name passed to lifecycle hooks.
+	Workspace string
+	// Hooks are the lifecycle hooks from ralph.yaml.
+	Hooks config.HooksConfig
+	// Sandbox confines the Claude invocations when enabled.
+	Sandbox config.SandboxConfig
+	// ToolPolicy restricts the tools Claude may use, keyed by phase.
+	ToolPolicy map[string]config.ToolPolicy
+	// Guardrails limit what a story attempt may change.
+	Guardrails config.GuardrailsConfig
+	// Scope restricts the run to part of the PRD.
+	Scope Scope
 }
 
 // Run executes the Ralph loop: for each iteration, it reads the PRD, picks
 // the next unfinished story, invokes Claude to implement it, and checks for
 // the completion signal. When all stories pass, it invokes QA verification.
-// Returns nil when all stories and integration tests are done or an error
-// if max iterations are reached.
-func Run(ctx context.Context, cfg Config) error {
+// Returns nil when all stories and integration tests are done, or when the
+// part of the PRD in cfg.Scope is, and an error if max iterations are reached.
+// The run_finished hook fires on every exit.
+func Run(ctx context.Context, cfg Config) (err error) {
+	ctx, span := tracing.Start(ctx, "loop.Run",
+		tracing.AttrWorkspace.String(cfg.Workspace),
+		tracing.AttrScope.String(cfg.Scope.String()),
+	)
+	defer func() { tracing.End(span, err) }()
+
+	err = run(ctx, cfg)
+
+	p := cfg.hookPayload(hooks.RunFinished)
+	switch {
+	case err == nil:
+		p.Result = "success"
+	case errors.Is(err, context.Canceled):
+		p.Result = "cancelled"
+	default:
+		p.Result = "failed"
+		p.Error = err.Error()
+	}
+	// Hooks still run after cancellation so they can report it.
+	if hookErr := runHooks(context.WithoutCancel(ctx), cfg, cfg.Hooks.RunFinished, p); hookErr != nil && err == nil {
+		return hookErr
+	}
+	return err
+}
+
+// hookPayload returns the base hook payload for this loop.
+func (cfg Config) hookPayload(event string) hooks.Payload {
+	return hooks.Payload{
+		Event:     event,
+		Workspace: cfg.Workspace,
+		WorkDir:   cfg.WorkDir,
+		PRDPath:   cfg.PRDPath,
+	}
+}
+
+// runHooks runs the given hooks, surfacing fail-open failures as warnings.
+func runHooks(ctx context.Context, cfg Config, hs []config.HookConfig, p hooks.Payload) error {
+	return hooks.Run(ctx, hs, p, func(msg string) {
+		emitWarn(cfg.EventHandler, "%s", msg)
+	})
+}
+
+func run(ctx context.Context, cfg Config) error {
 	if cfg.MaxIterations <= 0 {
 		cfg.MaxIterations = DefaultMaxIterations
 	}
 
+	// Fail before the first story rather than run unconfined.
+	if _, err := sandbox.New(cfg.Sandbox, config.PhaseStory, cfg.WorkDir); err != nil {
+		return err
+	}
+
 	// Ensure the progress file exists (workspace-scoped at
 	// .ralph/workspaces/<name>/progress.txt).
 	if cfg.ProgressPath != "" {
@@ -174,15 +245,31 @@ func Run(ctx context.Context, cfg Config) error {
 		emitEvent(cfg.EventHandler, events.IterationStart{
 			Iteration:     i,
 			MaxIterations: cfg.MaxIterations,
+			Scope:         cfg.Scope.String(),
 		})
 		emitEvent(cfg.EventHandler, events.PRDRefresh{})
 
-		currentPRD, err := prd.Read(cfg.PRDPath)
+		currentPRD, err := readPRDOrRepair(cfg)
 		if err != nil {
 			return fmt.Errorf("reading PRD: %w", err)
 		}
 
-		story := prd.NextUnfinished(currentPRD)
+		// The agent edits prd.json directly; keep its last good version.
+		if err := prd.Backup(cfg.PRDPath); err != nil {
+			emitWarn(cfg.EventHandler, "backing up PRD: %v", err)
+		}
+
+		story, scopeDone := cfg.Scope.next(currentPRD)
+		if scopeDone {
+			if !checkGitClean(ctx, cfg.WorkDir, cfg.EventHandler) {
+				if i < cfg.MaxIterations {
+					time.Sleep(iterationDelay)
+				}
+				continue
+			}
+			emitLog(cfg.EventHandler, "all stories in scope (%s) pass — done", cfg.Scope)
+			return nil
+		}
 		if story == nil {
 			// All user stories pass — check if QA verification is needed
 			if len(currentPRD.IntegrationTests) == 0 {
@@ -209,9 +296,14 @@ func Run(ctx context.Context, cfg Config) error {
 
 			// Run QA verification phase
 			emitEvent(cfg.EventHandler, events.QAPhaseStarted{Phase: "verification"})
+			if err := runQAStartedHooks(ctx, cfg, "verification"); err != nil {
+				return err
+			}
+			qaStart := headCommit(ctx, cfg)
 			if err := runQAVerification(ctx, cfg); err != nil {
 				emitWarn(cfg.EventHandler, "QA verification error: %v", err)
 			}
+			reportCommitsSince(ctx, cfg, qaStart)
 			emitEvent(cfg.EventHandler, events.PRDRefresh{})
 
 			// Re-read PRD after QA verification and check if all tests pass
@@ -233,9 +325,14 @@ func Run(ctx context.Context, cfg Config) error {
 			failedTests := prd.FailedIntegrationTests(verifyPRD)
 			if len(failedTests) > 0 {
 				emitEvent(cfg.EventHandler, events.QAPhaseStarted{Phase: "fix"})
+				if err := runQAStartedHooks(ctx, cfg, "fix"); err != nil {
+					return err
+				}
+				fixStart := headCommit(ctx, cfg)
 				if err := runQAFix(ctx, cfg, failedTests); err != nil {
 					emitWarn(cfg.EventHandler, "QA fix error: %v", err)
 				}
+				reportCommitsSince(ctx, cfg, fixStart)
 				emitEvent(cfg.EventHandler, events.PRDRefresh{})
 			}
 
@@ -251,27 +348,54 @@ func Run(ctx context.Context, cfg Config) error {
 			Title:   story.Title,
 		})
 
+		storyPayload := cfg.hookPayload(hooks.BeforeStory)
+		storyPayload.StoryID = story.ID
+		storyPayload.StoryTitle = story.Title
+		if err := runHooks(ctx, cfg, cfg.Hooks.BeforeStory, storyPayload); err != nil {
+			return err
+		}
+
 		viewPath := writeProgressView(cfg.ProgressPath)
 		prompt, err := prompts.RenderLoopIteration(story, cfg.QualityChecks, viewPath, cfg.PRDPath, cfg.PromptsDir, cfg.KnowledgePath)
 		if err != nil {
 			return fmt.Errorf("rendering prompt for %s: %w", story.ID, err)
 		}
 
-		output, err := invokeWithUsageLimitWait(ctx, invokeOpts{
+		policy, err := sandbox.New(cfg.Sandbox, config.PhaseStory, cfg.WorkDir)
+		if err != nil {
+			return err
+		}
+		g := startGuard(ctx, cfg)
+		start := beginStory(ctx, cfg, story.ID)
+		storyCtx, span := tracing.Start(ctx, "loop.story", tracing.AttrStoryID.String(story.ID))
+		output, err := invokeWithUsageLimitWait(storyCtx, invokeOpts{
 			prompt:       prompt,
 			dir:          cfg.WorkDir,
 			verbose:      cfg.Verbose,
 			maxTurns:     storyMaxTurns,
 			eventHandler: cfg.EventHandler,
+			sandbox:      policy,
+			toolPolicy:   cfg.ToolPolicy[config.PhaseStory],
 		})
 		if err != nil {
 			emitWarn(cfg.EventHandler, "Claude returned error on %s: %v", story.ID, err)
 			// Non-fatal — Claude may have partially succeeded.
 			// The next iteration will re-read prd.json and pick up where we left off.
 		}
+		tracing.End(span, err)
 
+		g.enforce(ctx, cfg, story.ID)
+		endStory(ctx, cfg, story.ID, start)
 		emitEvent(cfg.EventHandler, events.PRDRefresh{})
 
+		if len(cfg.Hooks.AfterStory) > 0 {
+			storyPayload.Event = hooks.AfterStory
+			storyPayload.StoryPasses = storyPasses(cfg.PRDPath, story.ID)
+			if err := runHooks(ctx, cfg, cfg.Hooks.AfterStory, storyPayload); err != nil {
+				return err
+			}
+		}
+
 		if claude.ContainsComplete(output) {
 			emitLog(cfg.EventHandler, "Ralph signaled COMPLETE — verifying PRD state")
 
@@ -322,8 +446,48 @@ func Run(ctx context.Context, cfg Config) error {
 	return fmt.Errorf("max iterations (%d) reached without completing all stories", cfg.MaxIterations)
 }
 
+// readPRDOrRepair reads the PRD and, if it is unreadable (for example
+// truncated by a crash mid-write), restores the newest valid backup and
+// reads that instead.
+func readPRDOrRepair(cfg Config) (*prd.PRD, error) {
+	p, err := prd.Read(cfg.PRDPath)
+	if err == nil {
+		return p, nil
+	}
+	restored, repairErr := prd.Repair(cfg.PRDPath)
+	if repairErr != nil || restored == "" {
+		return nil, err
+	}
+	emitWarn(cfg.EventHandler, "PRD was unreadable (%v) — restored from %s", err, restored)
+	return prd.Read(cfg.PRDPath)
+}
+
+// runQAStartedHooks runs the qa_started hooks for the given QA phase.
+func runQAStartedHooks(ctx context.Context, cfg Config, phase string) error {
+	p := cfg.hookPayload(hooks.QAStarted)
+	p.QAPhase = phase
+	return runHooks(ctx, cfg, cfg.Hooks.QAStarted, p)
+}
+
+// storyPasses re-reads the PRD and reports whether the given story passes.
+func storyPasses(prdPath, storyID string) bool {
+	p, err := prd.Read(prdPath)
+	if err != nil {
+		return false
+	}
+	for _, s := range p.UserStories {
+		if s.ID == storyID {
+			return s.Passes
+		}
+	}
+	return false
+}
+
 // runQAVerification invokes the QA verification agent with the qa_verification.md prompt.
-func runQAVerification(ctx context.Context, cfg Config) error {
+func runQAVerification(ctx context.Context, cfg Config) (err error) {
+	ctx, span := tracing.Start(ctx, "loop.qa", tracing.AttrPhase.String("verification"))
+	defer func() { tracing.End(span, err) }()
+
 	viewPath := writeProgressView(cfg.ProgressPath)
 	prompt, err := prompts.RenderQAVerification(prompts.QAVerificationData{
 		PRDPath:       cfg.PRDPath,
@@ -335,6 +499,10 @@ func runQAVerification(ctx context.Context, cfg Config) error {
 		return fmt.Errorf("rendering QA verification prompt: %w", err)
 	}
 
+	policy, err := sandbox.New(cfg.Sandbox, config.PhaseQA, cfg.WorkDir)
+	if err != nil {
+		return err
+	}
 	_, err = invokeWithUsageLimitWait(ctx, invokeOpts{
 		prompt:           prompt,
 		dir:              cfg.WorkDir,
@@ -342,12 +510,17 @@ func runQAVerification(ctx context.Context, cfg Config) error {
 		maxTurns:         qaVerifyMaxTurns,
 		eventHandler:     cfg.EventHandler,
 		isQAVerification: true,
+		sandbox:          policy,
+		toolPolicy:       cfg.ToolPolicy[config.PhaseQA],
 	})
 	return err
 }
 
 // runQAFix invokes the QA fix agent with the qa_fix.md prompt to resolve failing integration tests.
-func runQAFix(ctx context.Context, cfg Config, failedTests []prd.IntegrationTest) error {
+func runQAFix(ctx context.Context, cfg Config, failedTests []prd.IntegrationTest) (err error) {
+	ctx, span := tracing.Start(ctx, "loop.qa", tracing.AttrPhase.String("fix"))
+	defer func() { tracing.End(span, err) }()
+
 	viewPath := writeProgressView(cfg.ProgressPath)
 	prompt, err := prompts.RenderQAFix(prompts.QAFixData{
 		PRDPath:       cfg.PRDPath,
@@ -360,6 +533,10 @@ func runQAFix(ctx context.Context, cfg Config, failedTests []prd.IntegrationTest
 		return fmt.Errorf("rendering QA fix prompt: %w", err)
 	}
 
+	policy, err := sandbox.New(cfg.Sandbox, config.PhaseQA, cfg.WorkDir)
+	if err != nil {
+		return err
+	}
 	_, err = invokeWithUsageLimitWait(ctx, invokeOpts{
 		prompt:       prompt,
 		dir:          cfg.WorkDir,
@@ -367,6 +544,8 @@ func runQAFix(ctx context.Context, cfg Config, failedTests []prd.IntegrationTest
 		maxTurns:     qaFixMaxTurns,
 		eventHandler: cfg.EventHandler,
 		isQAFix:      true,
+		sandbox:      policy,
+		toolPolicy:   cfg.ToolPolicy[config.PhaseQA],
 	})
 	return err
 }
@@ -10,6 +10,7 @@ import (
 	"time"
 
 	"github.com/uesteibar/ralph/internal/claude"
+	"github.com/uesteibar/ralph/internal/config"
 	"github.com/uesteibar/ralph/internal/events"
 	"github.com/uesteibar/ralph/internal/prd"
 )
@@ -2101,3 +2102,213 @@ func writeProgressWithEntries(t *testing.T, path string, n int) {
 		t.Fatalf("writing progress file: %v", err)
 	}
 }
+
+func TestRun_RunsLifecycleHooks(t *testing.T) {
+	defer mockGitClean()()
+
+	dir := t.TempDir()
+	prdPath := filepath.Join(dir, "prd.json")
+	hookLog := filepath.Join(dir, "hooks.log")
+
+	testPRD := &prd.PRD{
+		Project:     "test",
+		BranchName:  "test/branch",
+		Description: "Test project",
+		UserStories: []prd.Story{
+			{ID: "US-001", Title: "Story 1", Passes: false},
+		},
+	}
+	if err := prd.Write(prdPath, testPRD); err != nil {
+		t.Fatalf("writing test PRD: %v", err)
+	}
+
+	origInvokeFn := invokeClaudeFn
+	defer func() { invokeClaudeFn = origInvokeFn }()
+
+	invokeClaudeFn = func(ctx context.Context, opts invokeOpts) (string, error) {
+		testPRD.UserStories[0].Passes = true
+		prd.Write(prdPath, testPRD)
+		return "", nil
+	}
+
+	logHook := []config.HookConfig{{
+		Command: `echo "$RALPH_HOOK_EVENT ${RALPH_STORY_ID:-} ${RALPH_STORY_PASSES:-} ${RALPH_RESULT:-}" >> ` + hookLog,
+	}}
+
+	err := Run(context.Background(), Config{
+		MaxIterations: 5,
+		WorkDir:       dir,
+		PRDPath:       prdPath,
+		Workspace:     "login",
+		Hooks: config.HooksConfig{
+			BeforeStory: logHook,
+			AfterStory:  logHook,
+			RunFinished: logHook,
+		},
+	})
+	if err != nil {
+		t.Fatalf("Run returned error: %v", err)
+	}
+
+	data, err := os.ReadFile(hookLog)
+	if err != nil {
+		t.Fatalf("reading hook log: %v", err)
+	}
+	var lines []string
+	for _, l := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+		lines = append(lines, strings.Join(strings.Fields(l), " "))
+	}
+	want := []string{
+		"before_story US-001",
+		"after_story US-001 true",
+		"run_finished success",
+	}
+	if strings.Join(lines, "\n") != strings.Join(want, "\n") {
+		t.Errorf("hook log =\n%s\nwant\n%s", strings.Join(lines, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestRun_FailClosedBeforeStoryHookStopsLoop(t *testing.T) {
+	defer mockGitClean()()
+
+	dir := t.TempDir()
+	prdPath := filepath.Join(dir, "prd.json")
+
+	testPRD := &prd.PRD{
+		Project:    "test",
+		BranchName: "test/branch",
+		UserStories: []prd.Story{
+			{ID: "US-001", Title: "Story 1", Passes: false},
+		},
+	}
+	if err := prd.Write(prdPath, testPRD); err != nil {
+		t.Fatalf("writing test PRD: %v", err)
+	}
+
+	origInvokeFn := invokeClaudeFn
+	defer func() { invokeClaudeFn = origInvokeFn }()
+
+	var invocations int
+	invokeClaudeFn = func(ctx context.Context, opts invokeOpts) (string, error) {
+		invocations++
+		return "", nil
+	}
+
+	err := Run(context.Background(), Config{
+		MaxIterations: 5,
+		WorkDir:       dir,
+		PRDPath:       prdPath,
+		Hooks: config.HooksConfig{
+			BeforeStory: []config.HookConfig{{Command: "exit 1", OnFailure: config.HookOnFailureAbort}},
+		},
+	})
+	if err == nil {
+		t.Fatal("expected error from fail-closed before_story hook")
+	}
+	if !strings.Contains(err.Error(), "before_story hook") {
+		t.Errorf("error = %q, want before_story hook", err)
+	}
+	if invocations != 0 {
+		t.Errorf("expected no Claude invocations, got %d", invocations)
+	}
+}
+
+func TestRun_RepairsTruncatedPRDFromBackup(t *testing.T) {
+	dir := t.TempDir()
+	prdPath := filepath.Join(dir, "prd.json")
+
+	testPRD := &prd.PRD{
+		Project:     "test",
+		UserStories: []prd.Story{{ID: "US-001", Title: "Story 1", Passes: true}},
+	}
+	if err := prd.Write(prdPath, testPRD); err != nil {
+		t.Fatalf("writing test PRD: %v", err)
+	}
+	if err := prd.Backup(prdPath); err != nil {
+		t.Fatal(err)
+	}
+	// Simulate a crash that left the PRD truncated.
+	if err := os.WriteFile(prdPath, []byte(`{"project": "te`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	defer mockGitClean()()
+	origInvokeFn := invokeClaudeFn
+	defer func() { invokeClaudeFn = origInvokeFn }()
+	invokeClaudeFn = func(ctx context.Context, opts invokeOpts) (string, error) {
+		t.Error("should not invoke Claude when the restored PRD is complete")
+		return "", nil
+	}
+
+	h := &recordingHandler{}
+	err := Run(context.Background(), Config{
+		MaxIterations: 1,
+		WorkDir:       dir,
+		PRDPath:       prdPath,
+		EventHandler:  h,
+	})
+	if err != nil {
+		t.Fatalf("Run returned error: %v", err)
+	}
+
+	found := false
+	for _, e := range h.events {
+		if msg, ok := e.(events.LogMessage); ok && msg.Level == "warning" && strings.Contains(msg.Message, "restored from") {
+			found = true
+		}
+	}
+	if !found {
+		t.Error("expected a warning about restoring the PRD")
+	}
+	if _, err := prd.Read(prdPath); err != nil {
+		t.Errorf("PRD not repaired on disk: %v", err)
+	}
+}
+
+func TestRun_PassesPhaseToolPolicy(t *testing.T) {
+	defer mockGitClean()()
+
+	dir := t.TempDir()
+	prdPath := filepath.Join(dir, "prd.json")
+	testPRD := &prd.PRD{
+		Project:          "test",
+		UserStories:      []prd.Story{{ID: "US-001", Title: "Story 1"}},
+		IntegrationTests: []prd.IntegrationTest{{ID: "IT-001", Description: "Test 1"}},
+	}
+	if err := prd.Write(prdPath, testPRD); err != nil {
+		t.Fatalf("writing test PRD: %v", err)
+	}
+
+	var denied []string
+	origInvokeFn := invokeClaudeFn
+	defer func() { invokeClaudeFn = origInvokeFn }()
+	invokeClaudeFn = func(ctx context.Context, opts invokeOpts) (string, error) {
+		denied = append(denied, strings.Join(opts.toolPolicy.DenyRules(), ","))
+		if opts.isQAVerification {
+			testPRD.IntegrationTests[0].Passes = true
+		} else {
+			testPRD.UserStories[0].Passes = true
+		}
+		prd.Write(prdPath, testPRD)
+		return "", nil
+	}
+
+	err := Run(context.Background(), Config{
+		MaxIterations: 5,
+		WorkDir:       dir,
+		PRDPath:       prdPath,
+		ProgressPath:  filepath.Join(dir, "progress.txt"),
+		ToolPolicy: map[string]config.ToolPolicy{
+			config.PhaseStory: {DeniedTools: []string{"WebFetch"}},
+			config.PhaseQA:    {Bash: config.BashPolicy{Deny: []string{"git push"}}},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Run returned error: %v", err)
+	}
+
+	want := []string{"WebFetch", "Bash(git push:*)"}
+	if strings.Join(denied, "|") != strings.Join(want, "|") {
+		t.Errorf("deny rules per invocation = %v, want %v", denied, want)
+	}
+}
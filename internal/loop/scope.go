@@ -0,0 +1,135 @@
+package loop
+
+import (
+	"fmt"
+
+	"github.com/uesteibar/ralph/internal/prd"
+)
+
+// Scope restricts a run to part of the PRD. The zero Scope works through
+// every story and then runs the QA phase.
+type Scope struct {
+	// Story limits the run to this one story; QA is skipped.
+	Story string
+	// Until stops the run once this story and every story ahead of it pass;
+	// QA is skipped.
+	Until string
+	// SkipQA ends the run when all stories pass, without the QA phase.
+	SkipQA bool
+	// QAOnly runs the QA phase without working on any story.
+	QAOnly bool
+}
+
+// IsZero reports whether the scope covers the whole PRD.
+func (s Scope) IsZero() bool {
+	return s == Scope{}
+}
+
+// Validate checks that the scope's options can be combined and that the
+// stories it names exist in p.
+func (s Scope) Validate(p *prd.PRD) error {
+	set := 0
+	for _, on := range []bool{s.Story != "", s.Until != "", s.SkipQA, s.QAOnly} {
+		if on {
+			set++
+		}
+	}
+	if set > 1 {
+		return fmt.Errorf("--story, --until, --skip-qa and --qa-only cannot be combined")
+	}
+
+	for _, id := range []string{s.Story, s.Until} {
+		if id != "" && findStory(p, id) == nil {
+			return fmt.Errorf("story %s not found in the PRD", id)
+		}
+	}
+	return nil
+}
+
+// String describes the scope for status lines, e.g. "story US-003". It is
+// empty for the zero Scope.
+func (s Scope) String() string {
+	switch {
+	case s.Story != "":
+		return "story " + s.Story
+	case s.Until != "":
+		return "until " + s.Until
+	case s.SkipQA:
+		return "skip QA"
+	case s.QAOnly:
+		return "QA only"
+	}
+	return ""
+}
+
+// Args returns the command-line flags that reproduce the scope.
+func (s Scope) Args() []string {
+	var args []string
+	if s.Story != "" {
+		args = append(args, "--story", s.Story)
+	}
+	if s.Until != "" {
+		args = append(args, "--until", s.Until)
+	}
+	if s.SkipQA {
+		args = append(args, "--skip-qa")
+	}
+	if s.QAOnly {
+		args = append(args, "--qa-only")
+	}
+	return args
+}
+
+// Done reports whether p has nothing left to do within the scope.
+func (s Scope) Done(p *prd.PRD) bool {
+	switch {
+	case s.QAOnly:
+		return prd.AllIntegrationTestsPass(p)
+	case s.IsZero():
+		return prd.AllPass(p) && prd.AllIntegrationTestsPass(p)
+	}
+	_, done := s.next(p)
+	return done
+}
+
+// next returns the story to work on within the scope, or nil when the QA
+// phase is due. done reports that the scoped stories pass and the run should
+// end without QA.
+func (s Scope) next(p *prd.PRD) (story *prd.Story, done bool) {
+	switch {
+	case s.QAOnly:
+		return nil, false
+	case s.Story != "":
+		story = findStory(p, s.Story)
+		if story == nil || story.Passes || story.Skipped {
+			return nil, true
+		}
+		return story, false
+	case s.Until != "":
+		story = prd.NextUnfinished(&prd.PRD{UserStories: storiesUntil(p, s.Until)})
+		return story, story == nil
+	}
+	story = prd.NextUnfinished(p)
+	return story, story == nil && s.SkipQA
+}
+
+// storiesUntil returns the stories picked up to and including id, in the
+// order prd.NextUnfinished picks them.
+func storiesUntil(p *prd.PRD, id string) []prd.Story {
+	ordered := prd.ByPriority(p.UserStories)
+	for i, s := range ordered {
+		if s.ID == id {
+			return ordered[:i+1]
+		}
+	}
+	return nil
+}
+
+func findStory(p *prd.PRD, id string) *prd.Story {
+	for i := range p.UserStories {
+		if p.UserStories[i].ID == id {
+			return &p.UserStories[i]
+		}
+	}
+	return nil
+}
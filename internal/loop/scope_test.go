@@ -0,0 +1,137 @@
+package loop
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/uesteibar/ralph/internal/events"
+	"github.com/uesteibar/ralph/internal/prd"
+)
+
+func scopePRD() *prd.PRD {
+	return &prd.PRD{
+		Project: "test",
+		UserStories: []prd.Story{
+			{ID: "US-003", Title: "Third", Priority: 3},
+			{ID: "US-001", Title: "First", Priority: 1, Passes: true},
+			{ID: "US-002", Title: "Second", Priority: 2},
+		},
+		IntegrationTests: []prd.IntegrationTest{
+			{ID: "IT-001", Description: "Works"},
+		},
+	}
+}
+
+func TestScope_Next(t *testing.T) {
+	tests := []struct {
+		name      string
+		scope     Scope
+		wantStory string
+		wantDone  bool
+	}{
+		{"full run", Scope{}, "US-002", false},
+		{"story", Scope{Story: "US-003"}, "US-003", false},
+		{"story already passing", Scope{Story: "US-001"}, "", true},
+		{"until", Scope{Until: "US-003"}, "US-002", false},
+		{"until already passing", Scope{Until: "US-001"}, "", true},
+		{"skip QA", Scope{SkipQA: true}, "US-002", false},
+		{"QA only", Scope{QAOnly: true}, "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			story, done := tt.scope.next(scopePRD())
+			var id string
+			if story != nil {
+				id = story.ID
+			}
+			if id != tt.wantStory || done != tt.wantDone {
+				t.Errorf("next() = %q, %v; want %q, %v", id, done, tt.wantStory, tt.wantDone)
+			}
+		})
+	}
+}
+
+func TestScope_SkipQAIsDoneWhenStoriesPass(t *testing.T) {
+	p := scopePRD()
+	for i := range p.UserStories {
+		p.UserStories[i].Passes = true
+	}
+	if story, done := (Scope{}).next(p); story != nil || done {
+		t.Errorf("full run should go on to QA, got %v, %v", story, done)
+	}
+	if _, done := (Scope{SkipQA: true}).next(p); !done {
+		t.Error("--skip-qa should be done once all stories pass")
+	}
+	if (Scope{}).Done(p) {
+		t.Error("full run is not done while integration tests fail")
+	}
+	if !(Scope{SkipQA: true}).Done(p) {
+		t.Error("--skip-qa run should be done")
+	}
+}
+
+func TestScope_Validate(t *testing.T) {
+	p := scopePRD()
+	if err := (Scope{Story: "US-002"}).Validate(p); err != nil {
+		t.Errorf("valid scope: %v", err)
+	}
+	if err := (Scope{Until: "US-404"}).Validate(p); err == nil || !strings.Contains(err.Error(), "story US-404 not found") {
+		t.Errorf("unknown story: err = %v", err)
+	}
+	if err := (Scope{SkipQA: true, QAOnly: true}).Validate(p); err == nil {
+		t.Error("--skip-qa with --qa-only should be rejected")
+	}
+}
+
+func TestRun_StoryScopeStopsWithoutQA(t *testing.T) {
+	defer mockGitClean()()
+
+	dir := t.TempDir()
+	prdPath := filepath.Join(dir, "prd.json")
+	if err := prd.Write(prdPath, scopePRD()); err != nil {
+		t.Fatal(err)
+	}
+
+	var invoked []string
+	origInvokeFn := invokeClaudeFn
+	defer func() { invokeClaudeFn = origInvokeFn }()
+	invokeClaudeFn = func(ctx context.Context, opts invokeOpts) (string, error) {
+		if opts.isQAVerification || opts.isQAFix {
+			invoked = append(invoked, "QA")
+			return "", nil
+		}
+		// Mark the prompted story as passing.
+		p, _ := prd.Read(prdPath)
+		for i, s := range p.UserStories {
+			if strings.Contains(opts.prompt, s.ID) {
+				invoked = append(invoked, s.ID)
+				p.UserStories[i].Passes = true
+			}
+		}
+		prd.Write(prdPath, p)
+		return "", nil
+	}
+
+	h := &recordingHandler{}
+	err := Run(context.Background(), Config{
+		MaxIterations: 5,
+		WorkDir:       dir,
+		PRDPath:       prdPath,
+		ProgressPath:  filepath.Join(dir, "progress.txt"),
+		EventHandler:  h,
+		Scope:         Scope{Story: "US-003"},
+	})
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if strings.Join(invoked, ",") != "US-003" {
+		t.Errorf("invoked = %v, want only US-003", invoked)
+	}
+
+	start, ok := h.events[0].(events.IterationStart)
+	if !ok || start.Scope != "story US-003" {
+		t.Errorf("first event = %+v, want IterationStart with the scope", h.events[0])
+	}
+}
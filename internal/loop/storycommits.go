@@ -0,0 +1,93 @@
+package loop
+
+import (
+	"context"
+
+	"github.com/uesteibar/ralph/internal/events"
+	"github.com/uesteibar/ralph/internal/gitops"
+	"github.com/uesteibar/ralph/internal/prd"
+	"github.com/uesteibar/ralph/internal/shell"
+)
+
+// beginStory marks storyID as the story being worked on, so the commit-msg
+// hook trailers the attempt's commits, and returns HEAD for endStory. It
+// returns "" when the work dir has no commits to start from.
+func beginStory(ctx context.Context, cfg Config, storyID string) string {
+	r := &shell.Runner{Dir: cfg.WorkDir}
+	head := headCommit(ctx, cfg)
+	if head == "" {
+		return ""
+	}
+	if err := gitops.SetCurrentStory(ctx, r, storyID); err != nil {
+		emitWarn(cfg.EventHandler, "marking %s as the current story: %v", storyID, err)
+	}
+	return head
+}
+
+// endStory clears the current story, then reports the commits the attempt
+// left on the branch, if any, and records them next to the PRD.
+func endStory(ctx context.Context, cfg Config, storyID, start string) {
+	if start == "" {
+		return
+	}
+	// Clean up even when the run was cancelled mid-attempt, or later manual
+	// commits would be attributed to the story.
+	ctx = context.WithoutCancel(ctx)
+	r := &shell.Runner{Dir: cfg.WorkDir}
+	if err := gitops.SetCurrentStory(ctx, r, ""); err != nil {
+		emitWarn(cfg.EventHandler, "clearing the current story: %v", err)
+	}
+	end, err := gitops.RevParse(ctx, r, "HEAD")
+	if err != nil || end == start {
+		return
+	}
+	reportCommits(ctx, cfg, storyID, start, end)
+	if err := prd.RecordCommits(prd.CommitsPath(cfg.PRDPath), storyID, prd.CommitRange{Start: start, End: end}); err != nil {
+		emitWarn(cfg.EventHandler, "recording the commits of %s: %v", storyID, err)
+	}
+}
+
+// headCommit returns HEAD, or "" when the work dir has no commits yet.
+func headCommit(ctx context.Context, cfg Config) string {
+	head, err := gitops.RevParse(ctx, &shell.Runner{Dir: cfg.WorkDir}, "HEAD")
+	if err != nil {
+		return ""
+	}
+	return head
+}
+
+// reportCommitsSince reports the commits made since start by an invocation
+// outside a story, such as the QA agents.
+func reportCommitsSince(ctx context.Context, cfg Config, start string) {
+	if start == "" {
+		return
+	}
+	ctx = context.WithoutCancel(ctx)
+	if end := headCommit(ctx, cfg); end != "" && end != start {
+		reportCommits(ctx, cfg, "", start, end)
+	}
+}
+
+// reportCommits emits a CommitCreated event for each commit in start..end,
+// oldest first.
+func reportCommits(ctx context.Context, cfg Config, storyID, start, end string) {
+	r := &shell.Runner{Dir: cfg.WorkDir}
+	commits, err := gitops.CommitsInRange(ctx, r, start, end)
+	if err != nil {
+		emitWarn(cfg.EventHandler, "listing new commits: %v", err)
+		return
+	}
+	for _, c := range commits {
+		e := events.CommitCreated{SHA: c.SHA, Subject: c.Subject, StoryID: storyID}
+		changes, err := gitops.CommitChanges(ctx, r, c.SHA)
+		if err != nil {
+			emitWarn(cfg.EventHandler, "reading the changes of %s: %v", c.SHA, err)
+		}
+		for _, ch := range changes {
+			e.FilesChanged++
+			e.Insertions += ch.Added
+			e.Deletions += ch.Deleted
+		}
+		emitEvent(cfg.EventHandler, e)
+	}
+}
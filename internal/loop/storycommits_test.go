@@ -0,0 +1,91 @@
+package loop
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/uesteibar/ralph/internal/events"
+	"github.com/uesteibar/ralph/internal/prd"
+)
+
+func TestRun_RecordsStoryCommits(t *testing.T) {
+	dir, prdPath := setupGuardedRepo(t)
+	start, _ := exec.Command("git", "-C", dir, "rev-parse", "HEAD").Output()
+
+	var current []byte
+	origInvokeFn := invokeClaudeFn
+	defer func() { invokeClaudeFn = origInvokeFn }()
+	invokeClaudeFn = func(ctx context.Context, opts invokeOpts) (string, error) {
+		current, _ = exec.Command("git", "-C", dir, "rev-parse", "--git-path", "ralph-story").Output()
+		current, _ = os.ReadFile(filepath.Join(dir, strings.TrimSpace(string(current))))
+		agentAttempt(t, dir, prdPath, map[string]string{"app.go": "package app\n"})
+		return "", nil
+	}
+
+	// The single iteration never signals COMPLETE; the error is expected.
+	_ = Run(context.Background(), Config{
+		MaxIterations: 1,
+		WorkDir:       dir,
+		PRDPath:       prdPath,
+		ProgressPath:  filepath.Join(t.TempDir(), "progress.txt"),
+		EventHandler:  &recordingHandler{},
+	})
+
+	if strings.TrimSpace(string(current)) != "US-001" {
+		t.Errorf("current story during the attempt = %q, want US-001", current)
+	}
+	recorded, err := prd.ReadCommits(prd.CommitsPath(prdPath))
+	if err != nil {
+		t.Fatal(err)
+	}
+	end, _ := exec.Command("git", "-C", dir, "rev-parse", "HEAD").Output()
+	want := prd.CommitRange{Start: strings.TrimSpace(string(start)), End: strings.TrimSpace(string(end))}
+	if got := recorded["US-001"]; len(got) != 1 || got[0] != want {
+		t.Errorf("recorded = %v, want %v", got, want)
+	}
+	if _, err := os.Stat(filepath.Join(dir, ".git", "ralph-story")); !os.IsNotExist(err) {
+		t.Error("the current story should be cleared after the attempt")
+	}
+}
+
+func TestRun_EmitsCommitCreated(t *testing.T) {
+	dir, prdPath := setupGuardedRepo(t)
+
+	origInvokeFn := invokeClaudeFn
+	defer func() { invokeClaudeFn = origInvokeFn }()
+	invokeClaudeFn = func(ctx context.Context, opts invokeOpts) (string, error) {
+		agentAttempt(t, dir, prdPath, map[string]string{"app.go": "package app\n\nfunc main() {}\n", "README.md": "# app\n"})
+		return "", nil
+	}
+
+	h := &recordingHandler{}
+	_ = Run(context.Background(), Config{
+		MaxIterations: 1,
+		WorkDir:       dir,
+		PRDPath:       prdPath,
+		ProgressPath:  filepath.Join(t.TempDir(), "progress.txt"),
+		EventHandler:  h,
+	})
+
+	head, _ := exec.Command("git", "-C", dir, "rev-parse", "HEAD").Output()
+	var created []events.CommitCreated
+	for _, e := range h.events {
+		if c, ok := e.(events.CommitCreated); ok {
+			created = append(created, c)
+		}
+	}
+	want := events.CommitCreated{
+		SHA:          strings.TrimSpace(string(head)),
+		Subject:      "feat(US-001): Story 1",
+		StoryID:      "US-001",
+		FilesChanged: 2,
+		Insertions:   4,
+	}
+	if len(created) != 1 || created[0] != want {
+		t.Errorf("CommitCreated events = %+v, want [%+v]", created, want)
+	}
+}
@@ -0,0 +1,222 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/uesteibar/ralph/internal/config"
+	"github.com/uesteibar/ralph/internal/events"
+	"github.com/uesteibar/ralph/internal/retry"
+	"github.com/uesteibar/ralph/internal/runstate"
+)
+
+// DefaultBlockedAfter is how many times a story may be started without
+// passing before it is reported as blocked.
+const DefaultBlockedAfter = 3
+
+// queueSize bounds how many notifications may wait for delivery before new
+// ones are dropped.
+const queueSize = 32
+
+// Notification is the payload delivered to every sink.
+type Notification struct {
+	Event     string    `json:"event"`
+	Project   string    `json:"project,omitempty"`
+	Workspace string    `json:"workspace,omitempty"`
+	Title     string    `json:"title"`
+	Message   string    `json:"message"`
+	StoryID   string    `json:"storyId,omitempty"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// route pairs a sink with the events it subscribed to (empty means all).
+type route struct {
+	sink   Sink
+	events []string
+}
+
+func (r route) wants(event string) bool {
+	return len(r.events) == 0 || slices.Contains(r.events, event)
+}
+
+// Handler turns loop events and the final run result into notifications and
+// delivers them to the configured sinks. It implements events.EventHandler
+// and forwards every event to the upstream handler.
+//
+// Delivery happens in order on a background goroutine, retried with backoff,
+// so a slow sink never stalls the loop. Close drains the queue. Delivery
+// failures are reported through the upstream handler, which is only ever
+// called under mu.
+type Handler struct {
+	project   string
+	workspace string
+	routes    []route
+
+	mu       sync.Mutex
+	upstream events.EventHandler
+
+	blockedAfter int
+	starts       map[string]int
+	backoff      []time.Duration
+	nowFn        func() time.Time
+
+	queue     chan Notification
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// New builds a Handler from the notifications: section of ralph.yaml.
+// The upstream handler is optional (nil-safe).
+func New(cfgs []config.NotificationConfig, project, workspace, workDir string, upstream events.EventHandler) (*Handler, error) {
+	h := &Handler{
+		project:      project,
+		workspace:    workspace,
+		upstream:     upstream,
+		blockedAfter: DefaultBlockedAfter,
+		starts:       make(map[string]int),
+		backoff:      retry.DefaultBackoff,
+		nowFn:        time.Now,
+		queue:        make(chan Notification, queueSize),
+		done:         make(chan struct{}),
+	}
+	for i, c := range cfgs {
+		sink, err := newSink(c, workDir)
+		if err != nil {
+			return nil, fmt.Errorf("notifications[%d]: %w", i, err)
+		}
+		h.routes = append(h.routes, route{sink: sink, events: c.Events})
+	}
+	go h.deliver()
+	return h, nil
+}
+
+// Close stops accepting notifications and waits until every queued one has
+// been delivered or has exhausted its retries. It is safe to call more than
+// once.
+func (h *Handler) Close() {
+	h.closeOnce.Do(func() { close(h.queue) })
+	<-h.done
+}
+
+func newSink(c config.NotificationConfig, workDir string) (Sink, error) {
+	switch c.Type {
+	case config.NotifyWebhook:
+		if c.URL == "" {
+			return nil, fmt.Errorf("webhook requires url")
+		}
+		s := &WebhookSink{URL: c.URL}
+		if c.SecretEnv != "" {
+			s.Secret = []byte(os.Getenv(c.SecretEnv))
+		}
+		return s, nil
+	case config.NotifyDesktop:
+		return &DesktopSink{}, nil
+	case config.NotifyCommand:
+		if c.Command == "" {
+			return nil, fmt.Errorf("command sink requires command")
+		}
+		return &CommandSink{Command: c.Command, Dir: workDir}, nil
+	default:
+		return nil, fmt.Errorf("unknown notification type %q", c.Type)
+	}
+}
+
+func (h *Handler) Handle(e events.Event) {
+	h.forward(e)
+
+	switch ev := e.(type) {
+	case events.UsageLimitWait:
+		h.notify(Notification{
+			Event:   config.NotifyUsageLimitWait,
+			Title:   "Ralph hit a usage limit",
+			Message: fmt.Sprintf("Waiting %s, until %s.", ev.WaitDuration, ev.ResetAt.Format(time.Kitchen)),
+		})
+	case events.StoryStarted:
+		h.starts[ev.StoryID]++
+		if h.starts[ev.StoryID] == h.blockedAfter {
+			h.notify(Notification{
+				Event:   config.NotifyStoryBlocked,
+				Title:   "Ralph is stuck on " + ev.StoryID,
+				Message: fmt.Sprintf("%s: %s has been attempted %d times without passing.", ev.StoryID, ev.Title, h.blockedAfter),
+				StoryID: ev.StoryID,
+			})
+		}
+	}
+}
+
+// RunFinished reports the final outcome of a daemon run.
+func (h *Handler) RunFinished(status runstate.Status) {
+	n := Notification{}
+	switch status.Result {
+	case runstate.ResultSuccess:
+		n.Event = config.NotifyRunSuccess
+		n.Title = "Ralph finished"
+		n.Message = "All stories and integration tests pass."
+	case runstate.ResultCancelled:
+		n.Event = config.NotifyRunCancelled
+		n.Title = "Ralph was stopped"
+		n.Message = "The run was cancelled."
+	default:
+		n.Event = config.NotifyRunFailed
+		n.Title = "Ralph failed"
+		n.Message = status.Error
+	}
+	h.notify(n)
+}
+
+// notify fills in the common fields and queues n for delivery. When the
+// queue is full the notification is dropped with a warning rather than
+// blocking the caller.
+func (h *Handler) notify(n Notification) {
+	n.Project = h.project
+	n.Workspace = h.workspace
+	n.Timestamp = h.nowFn().UTC()
+	if n.Workspace != "" {
+		n.Title = fmt.Sprintf("[%s] %s", n.Workspace, n.Title)
+	}
+
+	select {
+	case h.queue <- n:
+	default:
+		h.warn(fmt.Sprintf("notification %s dropped: delivery queue is full", n.Event))
+	}
+}
+
+// deliver sends each queued notification to every subscribed sink until the
+// queue is closed. Failures after retries are reported upstream as warnings.
+func (h *Handler) deliver() {
+	defer close(h.done)
+	ctx := context.Background()
+	for n := range h.queue {
+		for _, r := range h.routes {
+			if !r.wants(n.Event) {
+				continue
+			}
+			err := retry.Do(ctx, func() error {
+				return r.sink.Send(ctx, n)
+			}, retry.WithBackoff(h.backoff...))
+			if err != nil {
+				h.warn(fmt.Sprintf("notification %s not delivered: %v", n.Event, err))
+			}
+		}
+	}
+}
+
+func (h *Handler) warn(msg string) {
+	h.forward(events.LogMessage{Level: "warning", Message: msg})
+}
+
+// forward passes e to the upstream handler, serialising calls from the loop
+// and from the delivery goroutine.
+func (h *Handler) forward(e events.Event) {
+	if h.upstream == nil {
+		return
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.upstream.Handle(e)
+}
@@ -0,0 +1,297 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/uesteibar/ralph/internal/config"
+	"github.com/uesteibar/ralph/internal/events"
+	"github.com/uesteibar/ralph/internal/runstate"
+)
+
+// recordingHandler captures events forwarded upstream.
+type recordingHandler struct {
+	events []events.Event
+}
+
+func (h *recordingHandler) Handle(e events.Event) {
+	h.events = append(h.events, e)
+}
+
+// webhookStandIn is a local HTTP server that records webhook deliveries and
+// can be told to fail the first N requests with a given status.
+type webhookStandIn struct {
+	mu         sync.Mutex
+	requests   []*http.Request
+	bodies     [][]byte
+	failFirst  int
+	failStatus int
+}
+
+func (w *webhookStandIn) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	body, _ := io.ReadAll(r.Body)
+	w.requests = append(w.requests, r)
+	w.bodies = append(w.bodies, body)
+	if len(w.requests) <= w.failFirst {
+		rw.WriteHeader(w.failStatus)
+		return
+	}
+	rw.WriteHeader(http.StatusNoContent)
+}
+
+func newTestHandler(t *testing.T, cfgs []config.NotificationConfig, upstream events.EventHandler) *Handler {
+	t.Helper()
+	h, err := New(cfgs, "proj", "login", t.TempDir(), upstream)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	h.backoff = []time.Duration{time.Millisecond}
+	h.nowFn = func() time.Time { return time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC) }
+	return h
+}
+
+func TestWebhook_SignsPayload(t *testing.T) {
+	standIn := &webhookStandIn{}
+	srv := httptest.NewServer(standIn)
+	defer srv.Close()
+
+	t.Setenv("TEST_RALPH_WEBHOOK_SECRET", "s3cret")
+	h := newTestHandler(t, []config.NotificationConfig{{
+		Type:      config.NotifyWebhook,
+		URL:       srv.URL,
+		SecretEnv: "TEST_RALPH_WEBHOOK_SECRET",
+	}}, nil)
+
+	h.RunFinished(runstate.Status{Result: runstate.ResultSuccess})
+	h.Close()
+
+	if len(standIn.requests) != 1 {
+		t.Fatalf("expected 1 request, got %d", len(standIn.requests))
+	}
+	req, body := standIn.requests[0], standIn.bodies[0]
+
+	wantSig := "sha256=" + Sign([]byte("s3cret"), body)
+	if got := req.Header.Get(SignatureHeader); got != wantSig {
+		t.Errorf("signature = %q, want %q", got, wantSig)
+	}
+	if got := req.Header.Get(EventHeader); got != config.NotifyRunSuccess {
+		t.Errorf("event header = %q, want %q", got, config.NotifyRunSuccess)
+	}
+
+	var n Notification
+	if err := json.Unmarshal(body, &n); err != nil {
+		t.Fatalf("parsing body: %v", err)
+	}
+	if n.Event != config.NotifyRunSuccess || n.Project != "proj" || n.Workspace != "login" {
+		t.Errorf("notification = %+v", n)
+	}
+	if !strings.Contains(n.Title, "[login]") {
+		t.Errorf("title = %q, want workspace prefix", n.Title)
+	}
+}
+
+func TestWebhook_RetriesServerErrors(t *testing.T) {
+	standIn := &webhookStandIn{failFirst: 2, failStatus: http.StatusBadGateway}
+	srv := httptest.NewServer(standIn)
+	defer srv.Close()
+
+	upstream := &recordingHandler{}
+	h := newTestHandler(t, []config.NotificationConfig{{Type: config.NotifyWebhook, URL: srv.URL}}, upstream)
+
+	h.RunFinished(runstate.Status{Result: runstate.ResultFailed, Error: "boom"})
+	h.Close()
+
+	if len(standIn.requests) != 3 {
+		t.Errorf("expected 3 attempts, got %d", len(standIn.requests))
+	}
+	if len(upstream.events) != 0 {
+		t.Errorf("expected no delivery warning after eventual success, got %v", upstream.events)
+	}
+}
+
+func TestWebhook_DoesNotRetryClientErrors(t *testing.T) {
+	standIn := &webhookStandIn{failFirst: 10, failStatus: http.StatusUnauthorized}
+	srv := httptest.NewServer(standIn)
+	defer srv.Close()
+
+	upstream := &recordingHandler{}
+	h := newTestHandler(t, []config.NotificationConfig{{Type: config.NotifyWebhook, URL: srv.URL}}, upstream)
+
+	h.RunFinished(runstate.Status{Result: runstate.ResultFailed})
+	h.Close()
+
+	if len(standIn.requests) != 1 {
+		t.Errorf("expected 1 attempt, got %d", len(standIn.requests))
+	}
+	if len(upstream.events) != 1 {
+		t.Fatalf("expected 1 warning upstream, got %d", len(upstream.events))
+	}
+	msg, ok := upstream.events[0].(events.LogMessage)
+	if !ok || msg.Level != "warning" || !strings.Contains(msg.Message, "not delivered") {
+		t.Errorf("upstream event = %+v, want delivery warning", upstream.events[0])
+	}
+}
+
+func TestHandler_FiltersByEvent(t *testing.T) {
+	standIn := &webhookStandIn{}
+	srv := httptest.NewServer(standIn)
+	defer srv.Close()
+
+	h := newTestHandler(t, []config.NotificationConfig{{
+		Type:   config.NotifyWebhook,
+		URL:    srv.URL,
+		Events: []string{config.NotifyRunFailed, config.NotifyUsageLimitWait},
+	}}, nil)
+
+	h.RunFinished(runstate.Status{Result: runstate.ResultSuccess})
+	h.Handle(events.UsageLimitWait{WaitDuration: time.Minute, ResetAt: time.Now()})
+	h.RunFinished(runstate.Status{Result: runstate.ResultFailed})
+	h.Close()
+
+	if len(standIn.bodies) != 2 {
+		t.Fatalf("expected 2 deliveries, got %d", len(standIn.bodies))
+	}
+	var first, second Notification
+	json.Unmarshal(standIn.bodies[0], &first)
+	json.Unmarshal(standIn.bodies[1], &second)
+	if first.Event != config.NotifyUsageLimitWait || second.Event != config.NotifyRunFailed {
+		t.Errorf("events = %q, %q", first.Event, second.Event)
+	}
+}
+
+func TestHandler_StoryBlockedAfterRepeatedStarts(t *testing.T) {
+	standIn := &webhookStandIn{}
+	srv := httptest.NewServer(standIn)
+	defer srv.Close()
+
+	upstream := &recordingHandler{}
+	h := newTestHandler(t, []config.NotificationConfig{{Type: config.NotifyWebhook, URL: srv.URL}}, upstream)
+
+	for range DefaultBlockedAfter + 1 {
+		h.Handle(events.StoryStarted{StoryID: "US-002", Title: "Login form"})
+	}
+	h.Handle(events.StoryStarted{StoryID: "US-003", Title: "Logout"})
+	h.Close()
+
+	if len(standIn.bodies) != 1 {
+		t.Fatalf("expected exactly 1 blocked notification, got %d", len(standIn.bodies))
+	}
+	var n Notification
+	json.Unmarshal(standIn.bodies[0], &n)
+	if n.Event != config.NotifyStoryBlocked || n.StoryID != "US-002" {
+		t.Errorf("notification = %+v, want story_blocked for US-002", n)
+	}
+
+	// Every event is still forwarded upstream.
+	if len(upstream.events) != DefaultBlockedAfter+2 {
+		t.Errorf("upstream got %d events, want %d", len(upstream.events), DefaultBlockedAfter+2)
+	}
+}
+
+// blockingSink holds every delivery until release is closed.
+type blockingSink struct {
+	release chan struct{}
+	sent    []Notification
+}
+
+func (s *blockingSink) Send(_ context.Context, n Notification) error {
+	<-s.release
+	s.sent = append(s.sent, n)
+	return nil
+}
+
+func TestHandler_DeliversWithoutBlockingAndDrainsOnClose(t *testing.T) {
+	h := newTestHandler(t, nil, nil)
+	sink := &blockingSink{release: make(chan struct{})}
+	h.routes = append(h.routes, route{sink: sink})
+
+	returned := make(chan struct{})
+	go func() {
+		h.Handle(events.UsageLimitWait{WaitDuration: time.Minute, ResetAt: time.Now()})
+		h.RunFinished(runstate.Status{Result: runstate.ResultSuccess})
+		close(returned)
+	}()
+	select {
+	case <-returned:
+	case <-time.After(5 * time.Second):
+		t.Fatal("notifying blocked on a slow sink")
+	}
+
+	close(sink.release)
+	h.Close()
+
+	if len(sink.sent) != 2 {
+		t.Fatalf("expected 2 deliveries after Close, got %d", len(sink.sent))
+	}
+	if sink.sent[0].Event != config.NotifyUsageLimitWait || sink.sent[1].Event != config.NotifyRunSuccess {
+		t.Errorf("events = %q, %q", sink.sent[0].Event, sink.sent[1].Event)
+	}
+}
+
+func TestCommandSink_ReceivesEnvAndStdin(t *testing.T) {
+	dir := t.TempDir()
+	h, err := New([]config.NotificationConfig{{
+		Type:    config.NotifyCommand,
+		Command: `echo "$RALPH_NOTIFY_EVENT" > event.txt; cat > payload.json`,
+	}}, "proj", "login", dir, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	h.RunFinished(runstate.Status{Result: runstate.ResultCancelled})
+	h.Close()
+
+	event, err := os.ReadFile(filepath.Join(dir, "event.txt"))
+	if err != nil {
+		t.Fatalf("reading event.txt: %v", err)
+	}
+	if got := strings.TrimSpace(string(event)); got != config.NotifyRunCancelled {
+		t.Errorf("RALPH_NOTIFY_EVENT = %q, want %q", got, config.NotifyRunCancelled)
+	}
+	payload, _ := os.ReadFile(filepath.Join(dir, "payload.json"))
+	var n Notification
+	if err := json.Unmarshal(payload, &n); err != nil {
+		t.Fatalf("parsing payload: %v", err)
+	}
+	if n.Workspace != "login" {
+		t.Errorf("payload workspace = %q, want login", n.Workspace)
+	}
+}
+
+func TestDesktopSink_InvokesNotifySend(t *testing.T) {
+	dir := t.TempDir()
+	out := filepath.Join(dir, "args.txt")
+	bin := filepath.Join(dir, "fake-notify-send")
+	script := "#!/bin/sh\necho \"$@\" > " + out + "\n"
+	if err := os.WriteFile(bin, []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	s := &DesktopSink{Bin: bin}
+	if err := s.Send(t.Context(), Notification{Title: "Ralph finished", Message: "All done."}); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+
+	args, _ := os.ReadFile(out)
+	if got := strings.TrimSpace(string(args)); got != "--app-name=Ralph Ralph finished All done." {
+		t.Errorf("args = %q", got)
+	}
+}
+
+func TestNew_RejectsUnknownType(t *testing.T) {
+	_, err := New([]config.NotificationConfig{{Type: "carrier-pigeon"}}, "p", "w", "", nil)
+	if err == nil {
+		t.Fatal("expected error for unknown type")
+	}
+}
@@ -0,0 +1,131 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/uesteibar/ralph/internal/retry"
+	"github.com/uesteibar/ralph/internal/shell"
+)
+
+// SignatureHeader carries the hex HMAC-SHA256 of the webhook body, prefixed
+// with "sha256=".
+const SignatureHeader = "X-Ralph-Signature"
+
+// EventHeader carries the notification event name on webhook requests.
+const EventHeader = "X-Ralph-Event"
+
+// sinkTimeout bounds a single delivery attempt.
+const sinkTimeout = 10 * time.Second
+
+// Sink delivers a notification to one backend.
+type Sink interface {
+	Send(ctx context.Context, n Notification) error
+}
+
+// WebhookSink POSTs the notification as JSON. When Secret is set the body is
+// signed with HMAC-SHA256. 4xx responses are not retried.
+type WebhookSink struct {
+	URL    string
+	Secret []byte
+	Client *http.Client
+}
+
+func (s *WebhookSink) Send(ctx context.Context, n Notification) error {
+	body, err := json.Marshal(n)
+	if err != nil {
+		return retry.Permanent(fmt.Errorf("marshaling notification: %w", err))
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.URL, bytes.NewReader(body))
+	if err != nil {
+		return retry.Permanent(fmt.Errorf("building webhook request: %w", err))
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(EventHeader, n.Event)
+	if len(s.Secret) > 0 {
+		req.Header.Set(SignatureHeader, "sha256="+Sign(s.Secret, body))
+	}
+
+	client := s.Client
+	if client == nil {
+		client = &http.Client{Timeout: sinkTimeout}
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("posting webhook: %w", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode >= 400 && resp.StatusCode < 500 {
+		return retry.Permanent(fmt.Errorf("webhook returned %s", resp.Status))
+	}
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned %s", resp.Status)
+	}
+	return nil
+}
+
+// Sign returns the hex-encoded HMAC-SHA256 of body keyed with secret.
+func Sign(secret, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// DesktopSink shows a desktop notification via notify-send.
+type DesktopSink struct {
+	// Bin overrides the notify-send binary (for tests).
+	Bin string
+}
+
+func (s *DesktopSink) Send(ctx context.Context, n Notification) error {
+	bin := s.Bin
+	if bin == "" {
+		bin = "notify-send"
+	}
+	ctx, cancel := context.WithTimeout(ctx, sinkTimeout)
+	defer cancel()
+
+	r := &shell.Runner{}
+	if _, err := r.Run(ctx, bin, "--app-name=Ralph", n.Title, n.Message); err != nil {
+		return fmt.Errorf("running %s: %w", bin, err)
+	}
+	return nil
+}
+
+// CommandSink runs a shell command with the notification as JSON on stdin
+// and as RALPH_NOTIFY_* environment variables.
+type CommandSink struct {
+	Command string
+	Dir     string
+}
+
+func (s *CommandSink) Send(ctx context.Context, n Notification) error {
+	body, err := json.Marshal(n)
+	if err != nil {
+		return retry.Permanent(fmt.Errorf("marshaling notification: %w", err))
+	}
+	ctx, cancel := context.WithTimeout(ctx, sinkTimeout)
+	defer cancel()
+
+	r := &shell.Runner{Dir: s.Dir, Env: []string{
+		"RALPH_NOTIFY_EVENT=" + n.Event,
+		"RALPH_NOTIFY_TITLE=" + n.Title,
+		"RALPH_NOTIFY_MESSAGE=" + n.Message,
+		"RALPH_WORKSPACE=" + n.Workspace,
+	}}
+	if _, err := r.RunWithStdin(ctx, string(body), "sh", "-c", s.Command); err != nil {
+		return fmt.Errorf("running notification command: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,87 @@
+package prd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/uesteibar/ralph/internal/fsutil"
+)
+
+// BackupCount is the number of known good PRD versions kept next to the PRD
+// as <path>.bak.1 (newest) through <path>.bak.<BackupCount> (oldest).
+const BackupCount = 5
+
+// BackupPath returns the path of the n-th newest backup of the PRD at path.
+func BackupPath(path string, n int) string {
+	return fmt.Sprintf("%s.bak.%d", path, n)
+}
+
+// Backup records the current content of the PRD as the newest backup if it
+// is a valid PRD and differs from the newest backup. Missing or invalid PRDs
+// are left out, so the backups only ever hold known good versions.
+func Backup(path string) error {
+	return fsutil.WithLock(path, func() error {
+		return backupLocked(path)
+	})
+}
+
+func backupLocked(path string) error {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("reading PRD %s: %w", path, err)
+	}
+	if !isValid(data) {
+		return nil
+	}
+	if newest, err := os.ReadFile(BackupPath(path, 1)); err == nil && bytes.Equal(newest, data) {
+		return nil
+	}
+
+	for n := BackupCount - 1; n >= 1; n-- {
+		if _, err := os.Stat(BackupPath(path, n)); err == nil {
+			if err := os.Rename(BackupPath(path, n), BackupPath(path, n+1)); err != nil {
+				return fmt.Errorf("rotating PRD backups: %w", err)
+			}
+		}
+	}
+	if err := fsutil.WriteFileAtomic(BackupPath(path, 1), data, 0644); err != nil {
+		return fmt.Errorf("backing up PRD %s: %w", path, err)
+	}
+	return nil
+}
+
+// Repair restores the newest valid backup over the PRD at path when the PRD
+// is missing or does not parse. It returns the backup it restored from, or
+// "" when the PRD was already valid.
+func Repair(path string) (string, error) {
+	var restored string
+	err := fsutil.WithLock(path, func() error {
+		data, err := os.ReadFile(path)
+		if err == nil && isValid(data) {
+			return nil
+		}
+		for n := 1; n <= BackupCount; n++ {
+			backup, err := os.ReadFile(BackupPath(path, n))
+			if err != nil || !isValid(backup) {
+				continue
+			}
+			if err := fsutil.WriteFileAtomic(path, backup, 0644); err != nil {
+				return fmt.Errorf("restoring PRD %s: %w", path, err)
+			}
+			restored = BackupPath(path, n)
+			return nil
+		}
+		return fmt.Errorf("PRD %s is not valid and no valid backup was found", path)
+	})
+	return restored, err
+}
+
+func isValid(data []byte) bool {
+	var p PRD
+	return json.Unmarshal(data, &p) == nil
+}
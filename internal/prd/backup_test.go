@@ -0,0 +1,195 @@
+package prd
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/uesteibar/ralph/internal/fsutil"
+)
+
+func TestWrite_KeepsPreviousVersionAsBackup(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "prd.json")
+
+	first := samplePRD()
+	if err := Write(path, first); err != nil {
+		t.Fatal(err)
+	}
+	second := samplePRD()
+	second.Description = "changed"
+	if err := Write(path, second); err != nil {
+		t.Fatal(err)
+	}
+
+	backup, err := Read(BackupPath(path, 1))
+	if err != nil {
+		t.Fatalf("reading backup: %v", err)
+	}
+	if backup.Description != first.Description {
+		t.Errorf("backup description = %q, want %q", backup.Description, first.Description)
+	}
+}
+
+func TestBackup_RotatesAndSkipsDuplicates(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "prd.json")
+
+	for i := range BackupCount + 2 {
+		p := samplePRD()
+		p.Description = string(rune('a' + i))
+		if err := Write(path, p); err != nil {
+			t.Fatal(err)
+		}
+		// Backing up unchanged content twice adds nothing.
+		if err := Backup(path); err != nil {
+			t.Fatal(err)
+		}
+		if err := Backup(path); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	newest, _ := Read(BackupPath(path, 1))
+	if want := string(rune('a' + BackupCount + 1)); newest.Description != want {
+		t.Errorf("newest backup = %q, want %q", newest.Description, want)
+	}
+	oldest, _ := Read(BackupPath(path, BackupCount))
+	if want := string(rune('a' + 2)); oldest.Description != want {
+		t.Errorf("oldest backup = %q, want %q", oldest.Description, want)
+	}
+	if _, err := os.Stat(BackupPath(path, BackupCount+1)); !os.IsNotExist(err) {
+		t.Error("more than BackupCount backups kept")
+	}
+}
+
+func TestBackup_IgnoresInvalidPRD(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "prd.json")
+	os.WriteFile(path, []byte(`{"project": "trunc`), 0644)
+
+	if err := Backup(path); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(BackupPath(path, 1)); !os.IsNotExist(err) {
+		t.Error("invalid PRD should not be backed up")
+	}
+}
+
+func TestRepair_RestoresNewestValidBackup(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "prd.json")
+	good := samplePRD()
+	if err := Write(path, good); err != nil {
+		t.Fatal(err)
+	}
+	if err := Backup(path); err != nil {
+		t.Fatal(err)
+	}
+
+	// Simulate a crash mid-write.
+	os.WriteFile(path, []byte(`{"project": "Te`), 0644)
+
+	restored, err := Repair(path)
+	if err != nil {
+		t.Fatalf("Repair: %v", err)
+	}
+	if restored != BackupPath(path, 1) {
+		t.Errorf("restored from %q, want %q", restored, BackupPath(path, 1))
+	}
+	p, err := Read(path)
+	if err != nil {
+		t.Fatalf("PRD still unreadable: %v", err)
+	}
+	if p.Project != good.Project {
+		t.Errorf("Project = %q, want %q", p.Project, good.Project)
+	}
+}
+
+func TestRepair_ValidPRDIsLeftAlone(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "prd.json")
+	if err := Write(path, samplePRD()); err != nil {
+		t.Fatal(err)
+	}
+	restored, err := Repair(path)
+	if err != nil || restored != "" {
+		t.Errorf("Repair = %q, %v; want nothing restored", restored, err)
+	}
+}
+
+func TestRepair_NoBackup(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "prd.json")
+	os.WriteFile(path, []byte("{"), 0644)
+	if _, err := Repair(path); err == nil {
+		t.Fatal("expected error when no backup exists")
+	}
+}
+
+func TestWriteIfUnchanged_DetectsConcurrentEdit(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "prd.json")
+	if err := Write(path, samplePRD()); err != nil {
+		t.Fatal(err)
+	}
+
+	p, version, err := ReadVersion(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The agent edits the file behind our back.
+	edited := samplePRD()
+	edited.Description = "edited by agent"
+	if err := Write(path, edited); err != nil {
+		t.Fatal(err)
+	}
+
+	p.Description = "stale write"
+	err = WriteIfUnchanged(path, p, version)
+	if !errors.Is(err, fsutil.ErrModified) {
+		t.Fatalf("err = %v, want ErrModified", err)
+	}
+	current, _ := Read(path)
+	if current.Description != "edited by agent" {
+		t.Errorf("concurrent edit was overwritten: %q", current.Description)
+	}
+
+	// With a fresh version the write goes through.
+	p, version, _ = ReadVersion(path)
+	p.Description = "fresh write"
+	if err := WriteIfUnchanged(path, p, version); err != nil {
+		t.Fatalf("WriteIfUnchanged: %v", err)
+	}
+	current, _ = Read(path)
+	if current.Description != "fresh write" {
+		t.Errorf("Description = %q, want fresh write", current.Description)
+	}
+}
+
+func TestUpdate_ReappliesOnConcurrentEdit(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "prd.json")
+	if err := Write(path, samplePRD()); err != nil {
+		t.Fatal(err)
+	}
+
+	calls := 0
+	err := Update(path, func(p *PRD) error {
+		calls++
+		if calls == 1 {
+			// The agent edits the file while the change is being made.
+			edited := samplePRD()
+			edited.Description = "edited by agent"
+			if err := Write(path, edited); err != nil {
+				t.Fatal(err)
+			}
+		}
+		p.UserStories[0].Passes = true
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	if calls != 2 {
+		t.Errorf("fn called %d times, want 2", calls)
+	}
+	current, _ := Read(path)
+	if current.Description != "edited by agent" || !current.UserStories[0].Passes {
+		t.Errorf("got description %q, passes %v; want both edits kept", current.Description, current.UserStories[0].Passes)
+	}
+}
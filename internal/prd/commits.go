@@ -0,0 +1,71 @@
+package prd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/uesteibar/ralph/internal/fsutil"
+)
+
+// CommitsFile is the sidecar next to prd.json recording which commits each
+// story produced. It is kept out of the PRD so the agent, which edits the
+// PRD, cannot lose or rewrite it.
+const CommitsFile = "story-commits.json"
+
+// CommitRange is the commits one story attempt added to the branch: those
+// reachable from End but not from Start.
+type CommitRange struct {
+	Start string `json:"start"`
+	End   string `json:"end"`
+}
+
+// CommitsPath returns the story commits file for the PRD at prdPath.
+func CommitsPath(prdPath string) string {
+	return filepath.Join(filepath.Dir(prdPath), CommitsFile)
+}
+
+// ReadCommits loads the commit ranges recorded per story ID, oldest first.
+// A missing file yields an empty map.
+func ReadCommits(path string) (map[string][]CommitRange, error) {
+	commits := map[string][]CommitRange{}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return commits, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading story commits %s: %w", path, err)
+	}
+	if err := json.Unmarshal(data, &commits); err != nil {
+		return nil, fmt.Errorf("parsing story commits %s: %w", path, err)
+	}
+	return commits, nil
+}
+
+// UpdateCommits applies fn to the recorded commit ranges and writes them
+// back, serialised with other ralph processes.
+func UpdateCommits(path string, fn func(map[string][]CommitRange)) error {
+	return fsutil.WithLock(path, func() error {
+		commits, err := ReadCommits(path)
+		if err != nil {
+			return err
+		}
+		fn(commits)
+		data, err := json.MarshalIndent(commits, "", "  ")
+		if err != nil {
+			return fmt.Errorf("marshaling story commits: %w", err)
+		}
+		if err := fsutil.WriteFileAtomic(path, append(data, '\n'), 0644); err != nil {
+			return fmt.Errorf("writing story commits %s: %w", path, err)
+		}
+		return nil
+	})
+}
+
+// RecordCommits appends the range of one attempt at storyID.
+func RecordCommits(path, storyID string, r CommitRange) error {
+	return UpdateCommits(path, func(commits map[string][]CommitRange) {
+		commits[storyID] = append(commits[storyID], r)
+	})
+}
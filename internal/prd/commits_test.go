@@ -0,0 +1,39 @@
+package prd
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestRecordCommits_AppendsPerStory(t *testing.T) {
+	path := CommitsPath(filepath.Join(t.TempDir(), "prd.json"))
+
+	got, err := ReadCommits(path)
+	if err != nil || len(got) != 0 {
+		t.Fatalf("ReadCommits on a missing file = %v, %v; want empty", got, err)
+	}
+
+	for _, rec := range []struct {
+		id string
+		r  CommitRange
+	}{
+		{"US-001", CommitRange{Start: "a", End: "b"}},
+		{"US-002", CommitRange{Start: "b", End: "c"}},
+		{"US-001", CommitRange{Start: "c", End: "d"}},
+	} {
+		if err := RecordCommits(path, rec.id, rec.r); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got, err = ReadCommits(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got["US-001"]) != 2 || got["US-001"][1] != (CommitRange{Start: "c", End: "d"}) {
+		t.Errorf("US-001 = %v, want both attempts in order", got["US-001"])
+	}
+	if len(got["US-002"]) != 1 {
+		t.Errorf("US-002 = %v, want one range", got["US-002"])
+	}
+}
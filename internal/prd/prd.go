@@ -3,9 +3,14 @@ package prd
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
+	"slices"
 	"sort"
+	"strings"
+
+	"github.com/uesteibar/ralph/internal/fsutil"
 )
 
 type PRD struct {
@@ -25,7 +30,9 @@ type Story struct {
 	AcceptanceCriteria []string `json:"acceptanceCriteria"`
 	Priority           int      `json:"priority"`
 	Passes             bool     `json:"passes"`
-	Notes              string   `json:"notes"`
+	// Skipped stories are left out of the loop and count as done.
+	Skipped bool   `json:"skipped,omitempty"`
+	Notes   string `json:"notes"`
 }
 
 type IntegrationTest struct {
@@ -52,45 +59,116 @@ func Read(path string) (*PRD, error) {
 	return &p, nil
 }
 
-// Write persists a PRD as formatted JSON.
+// Write persists a PRD as formatted JSON. The write is atomic and serialised
+// with other ralph processes through an advisory lock, and the previous
+// content is kept as a backup if it was a valid PRD.
 func Write(path string, p *PRD) error {
+	return fsutil.WithLock(path, func() error {
+		return writeLocked(path, p)
+	})
+}
+
+// ReadVersion loads a PRD together with a version identifying the content
+// it was read from, for use with WriteIfUnchanged.
+func ReadVersion(path string) (*PRD, string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, "", fmt.Errorf("reading PRD %s: %w", path, err)
+	}
+	var p PRD
+	if err := json.Unmarshal(data, &p); err != nil {
+		return nil, "", fmt.Errorf("parsing PRD %s: %w", path, err)
+	}
+	return &p, fsutil.HashBytes(data), nil
+}
+
+// WriteIfUnchanged writes p only if the file still holds the version
+// returned by ReadVersion. Otherwise it returns an error wrapping
+// fsutil.ErrModified and leaves the file alone, so edits made meanwhile by
+// the agent or another process are not lost.
+func WriteIfUnchanged(path string, p *PRD, version string) error {
+	return fsutil.WithLock(path, func() error {
+		current, err := fsutil.Hash(path)
+		if err != nil {
+			return fmt.Errorf("reading PRD %s: %w", path, err)
+		}
+		if current != version {
+			return fmt.Errorf("writing PRD %s: %w", path, fsutil.ErrModified)
+		}
+		return writeLocked(path, p)
+	})
+}
+
+// updateAttempts bounds how many times Update re-applies its change when
+// the PRD keeps being modified underneath it.
+const updateAttempts = 3
+
+// Update applies fn to the PRD on disk and writes the result with
+// WriteIfUnchanged. If the file changed in between, fn is re-applied to the
+// fresh content, so a concurrent edit by the agent or another process is
+// kept rather than overwritten. An error from fn is returned as is and
+// nothing is written.
+func Update(path string, fn func(p *PRD) error) error {
+	var err error
+	for range updateAttempts {
+		var p *PRD
+		var version string
+		p, version, err = ReadVersion(path)
+		if err != nil {
+			return err
+		}
+		if err := fn(p); err != nil {
+			return err
+		}
+		err = WriteIfUnchanged(path, p, version)
+		if !errors.Is(err, fsutil.ErrModified) {
+			return err
+		}
+	}
+	return err
+}
+
+func writeLocked(path string, p *PRD) error {
 	data, err := json.MarshalIndent(p, "", "  ")
 	if err != nil {
 		return fmt.Errorf("marshaling PRD: %w", err)
 	}
 
-	if err := os.WriteFile(path, append(data, '\n'), 0644); err != nil {
+	if err := backupLocked(path); err != nil {
+		return err
+	}
+	if err := fsutil.WriteFileAtomic(path, append(data, '\n'), 0644); err != nil {
 		return fmt.Errorf("writing PRD %s: %w", path, err)
 	}
 
 	return nil
 }
 
-// NextUnfinished returns the highest-priority story where Passes is false.
-// Returns nil when all stories pass.
+// NextUnfinished returns the highest-priority story that neither passes nor
+// is skipped. Returns nil when there is none.
 func NextUnfinished(p *PRD) *Story {
-	pending := make([]Story, 0)
-	for _, s := range p.UserStories {
-		if !s.Passes {
-			pending = append(pending, s)
+	for _, s := range ByPriority(p.UserStories) {
+		if !s.Passes && !s.Skipped {
+			return &s
 		}
 	}
+	return nil
+}
 
-	if len(pending) == 0 {
-		return nil
-	}
-
-	sort.Slice(pending, func(i, j int) bool {
-		return pending[i].Priority < pending[j].Priority
+// ByPriority returns a copy of stories in the order the loop works through
+// them: by priority, with ties kept in file order.
+func ByPriority(stories []Story) []Story {
+	ordered := slices.Clone(stories)
+	sort.SliceStable(ordered, func(i, j int) bool {
+		return ordered[i].Priority < ordered[j].Priority
 	})
-
-	return &pending[0]
+	return ordered
 }
 
-// AllPass returns true when every story has Passes set to true.
+// AllPass returns true when every story passes or is skipped.
 func AllPass(p *PRD) bool {
 	for _, s := range p.UserStories {
-		if !s.Passes {
+		if !s.Passes && !s.Skipped {
 			return false
 		}
 	}
@@ -119,6 +197,65 @@ func MarkPassing(p *PRD, storyID string) bool {
 	return false
 }
 
+// MoveStory moves the story with the given ID delta places in priority
+// order, renumbering priorities from 1 and ordering UserStories to match.
+// It returns false when the story is unknown or already at that end.
+func MoveStory(p *PRD, storyID string, delta int) bool {
+	ordered := ByPriority(p.UserStories)
+	from := slices.IndexFunc(ordered, func(s Story) bool { return s.ID == storyID })
+	to := from + delta
+	if from < 0 || to < 0 || to >= len(ordered) || to == from {
+		return false
+	}
+	s := ordered[from]
+	ordered = slices.Insert(slices.Delete(ordered, from, from+1), to, s)
+	for i := range ordered {
+		ordered[i].Priority = i + 1
+	}
+	p.UserStories = ordered
+	return true
+}
+
+// NextStoryID returns an ID for a new story, one past the highest numbered
+// US-NNN story.
+func NextStoryID(p *PRD) string {
+	highest := 0
+	for _, s := range p.UserStories {
+		var n int
+		if _, err := fmt.Sscanf(s.ID, "US-%d", &n); err == nil && n > highest {
+			highest = n
+		}
+	}
+	return fmt.Sprintf("US-%03d", highest+1)
+}
+
+// Validate checks that every story and integration test has a unique ID,
+// and every story a title.
+func Validate(p *PRD) error {
+	seen := make(map[string]bool)
+	for i, s := range p.UserStories {
+		switch {
+		case s.ID == "":
+			return fmt.Errorf("story %d has no id", i+1)
+		case seen[s.ID]:
+			return fmt.Errorf("duplicate id %s", s.ID)
+		case strings.TrimSpace(s.Title) == "":
+			return fmt.Errorf("story %s has no title", s.ID)
+		}
+		seen[s.ID] = true
+	}
+	for i, t := range p.IntegrationTests {
+		switch {
+		case t.ID == "":
+			return fmt.Errorf("integration test %d has no id", i+1)
+		case seen[t.ID]:
+			return fmt.Errorf("duplicate id %s", t.ID)
+		}
+		seen[t.ID] = true
+	}
+	return nil
+}
+
 // FailedIntegrationTests returns all integration tests where Passes is false.
 func FailedIntegrationTests(p *PRD) []IntegrationTest {
 	var failed []IntegrationTest
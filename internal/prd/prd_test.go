@@ -66,6 +66,20 @@ func TestNextUnfinished_ReturnsByPriority(t *testing.T) {
 	}
 }
 
+func TestNextUnfinished_TiesKeepFileOrder(t *testing.T) {
+	p := &PRD{
+		UserStories: []Story{
+			{ID: "US-001", Priority: 2},
+			{ID: "US-002", Priority: 1},
+			{ID: "US-003", Priority: 1},
+			{ID: "US-004", Priority: 1},
+		},
+	}
+	if next := NextUnfinished(p); next == nil || next.ID != "US-002" {
+		t.Errorf("NextUnfinished = %v, want US-002 (first of the tied stories)", next)
+	}
+}
+
 func TestNextUnfinished_AllPassing_ReturnsNil(t *testing.T) {
 	p := &PRD{
 		UserStories: []Story{
@@ -435,3 +449,78 @@ func TestFailedIntegrationTests_AllPassing_ReturnsNil(t *testing.T) {
 		t.Errorf("expected nil when all tests pass, got %v", failed)
 	}
 }
+
+func TestNextUnfinished_IgnoresSkipped(t *testing.T) {
+	p := samplePRD()
+	p.UserStories[1].Skipped = true
+	next := NextUnfinished(p)
+	if next == nil || next.ID != "US-001" {
+		t.Errorf("NextUnfinished = %v, want US-001 (US-002 is skipped)", next)
+	}
+}
+
+func TestAllPass_SkippedCountsAsDone(t *testing.T) {
+	p := &PRD{
+		UserStories: []Story{
+			{ID: "US-001", Passes: true},
+			{ID: "US-002", Skipped: true},
+		},
+	}
+	if !AllPass(p) {
+		t.Error("expected AllPass to treat skipped stories as done")
+	}
+}
+
+func TestMoveStory_RenumbersPriorities(t *testing.T) {
+	p := samplePRD()
+	if !MoveStory(p, "US-003", -1) {
+		t.Fatal("expected US-003 to move up")
+	}
+	var got []string
+	for _, s := range p.UserStories {
+		got = append(got, s.ID)
+		if want := len(got); s.Priority != want {
+			t.Errorf("%s priority = %d, want %d", s.ID, s.Priority, want)
+		}
+	}
+	if strings.Join(got, ",") != "US-002,US-003,US-001" {
+		t.Errorf("order = %v, want US-002,US-003,US-001", got)
+	}
+	if MoveStory(p, "US-002", -1) || MoveStory(p, "US-999", 1) {
+		t.Error("expected no move past the top or for an unknown story")
+	}
+}
+
+func TestNextStoryID(t *testing.T) {
+	if got := NextStoryID(samplePRD()); got != "US-004" {
+		t.Errorf("NextStoryID = %q, want US-004", got)
+	}
+	if got := NextStoryID(&PRD{}); got != "US-001" {
+		t.Errorf("NextStoryID of an empty PRD = %q, want US-001", got)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	if err := Validate(samplePRD()); err != nil {
+		t.Errorf("Validate(samplePRD) = %v", err)
+	}
+
+	tests := []struct {
+		name string
+		prd  *PRD
+		want string
+	}{
+		{"missing id", &PRD{UserStories: []Story{{Title: "x"}}}, "no id"},
+		{"missing title", &PRD{UserStories: []Story{{ID: "US-001", Title: " "}}}, "no title"},
+		{"duplicate story", &PRD{UserStories: []Story{{ID: "US-001", Title: "a"}, {ID: "US-001", Title: "b"}}}, "duplicate id US-001"},
+		{"duplicate test", &PRD{IntegrationTests: []IntegrationTest{{ID: "IT-001"}, {ID: "IT-001"}}}, "duplicate id IT-001"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Validate(tt.prd)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Validate = %v, want error containing %q", err, tt.want)
+			}
+		})
+	}
+}
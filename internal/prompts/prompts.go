@@ -36,6 +36,7 @@ type LoopIterationData struct {
 	StoryTitle         string
 	StoryDescription   string
 	AcceptanceCriteria []string
+	StoryNotes         string
 	QualityChecks      []string
 	ProgressPath       string
 	PRDPath            string
@@ -51,6 +52,7 @@ func RenderLoopIteration(story *prd.Story, qualityChecks []string, progressPath,
 		StoryTitle:         story.Title,
 		StoryDescription:   story.Description,
 		AcceptanceCriteria: story.AcceptanceCriteria,
+		StoryNotes:         story.Notes,
 		QualityChecks:      qualityChecks,
 		ProgressPath:       progressPath,
 		PRDPath:            prdPath,
@@ -64,6 +66,11 @@ type PRDNewData struct {
 	ProjectName     string
 	PRDPath         string
 	WorkspaceBranch string
+	// Adopted is set when the workspace took over an existing branch, so the
+	// PRD can be drafted from the work already on it.
+	Adopted    bool
+	PRNumber   int
+	BaseBranch string
 }
 
 // RenderPRDNew renders the prompt for interactive PRD creation.
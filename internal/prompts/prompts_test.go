@@ -278,6 +278,39 @@ func TestRenderPRDNew_BaseMode_NoBranch(t *testing.T) {
 	}
 }
 
+func TestRenderPRDNew_AdoptedBranch(t *testing.T) {
+	out, err := RenderPRDNew(PRDNewData{
+		ProjectName:     "MyProject",
+		PRDPath:         "/repo/.ralph/workspaces/fix-login/prd.json",
+		WorkspaceBranch: "fix-login",
+		Adopted:         true,
+		PRNumber:        42,
+		BaseBranch:      "main",
+	}, "")
+	if err != nil {
+		t.Fatalf("RenderPRDNew failed: %v", err)
+	}
+
+	checks := []string{
+		"Existing Work",
+		"pull request #42",
+		"git diff main...HEAD",
+	}
+	for _, want := range checks {
+		if !strings.Contains(out, want) {
+			t.Errorf("output should contain %q", want)
+		}
+	}
+
+	fresh, err := RenderPRDNew(PRDNewData{ProjectName: "MyProject", PRDPath: "prd.json"}, "")
+	if err != nil {
+		t.Fatalf("RenderPRDNew failed: %v", err)
+	}
+	if strings.Contains(fresh, "Existing Work") {
+		t.Error("output should not contain existing work section for fresh workspaces")
+	}
+}
+
 func TestRenderChatSystem_ContainsProjectName(t *testing.T) {
 	out, err := RenderChatSystem(ChatSystemData{ProjectName: "ChatProject"}, "")
 	if err != nil {
@@ -0,0 +1,98 @@
+package report
+
+import (
+	"embed"
+	"fmt"
+	htmltemplate "html/template"
+	"io"
+	"regexp"
+	"strings"
+	"text/template"
+	"time"
+)
+
+//go:embed templates/*.tmpl
+var templateFS embed.FS
+
+var funcs = map[string]any{
+	"duration": formatDuration,
+	"shortSHA": func(sha string) string { return sha[:min(len(sha), 7)] },
+	"tokens":   formatTokens,
+	"cost":     func(usd float64) string { return fmt.Sprintf("$%.2f", usd) },
+	"tools":    formatToolCounts,
+	"date":     func(t time.Time) string { return t.Format("2006-01-02 15:04 MST") },
+	"blockquote": func(s string) string {
+		return "> " + strings.ReplaceAll(s, "\n", "\n> ")
+	},
+}
+
+var (
+	markdownTmpl = template.Must(template.New("report.md.tmpl").Funcs(funcs).ParseFS(templateFS, "templates/report.md.tmpl"))
+	summaryTmpl  = template.Must(template.New("summary.md.tmpl").Funcs(funcs).ParseFS(templateFS, "templates/summary.md.tmpl"))
+	htmlTmpl     = htmltemplate.Must(htmltemplate.New("report.html.tmpl").Funcs(funcs).ParseFS(templateFS, "templates/report.html.tmpl"))
+)
+
+// blankLines matches the runs of blank lines the optional sections of the
+// Markdown template leave behind.
+var blankLines = regexp.MustCompile(`\n{3,}`)
+
+// WriteMarkdown writes r as a Markdown document.
+func WriteMarkdown(w io.Writer, r *Report) error {
+	var b strings.Builder
+	if err := markdownTmpl.Execute(&b, r); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, blankLines.ReplaceAllString(b.String(), "\n\n"))
+	return err
+}
+
+// WriteHTML writes r as a single HTML page with its styles inlined.
+func WriteHTML(w io.Writer, r *Report) error {
+	return htmlTmpl.Execute(w, r)
+}
+
+// Summary renders a condensed Markdown version of r, short enough for a pull
+// request description: a table of stories with their commits and tokens,
+// followed by totals.
+func Summary(r *Report) (string, error) {
+	var b strings.Builder
+	if err := summaryTmpl.Execute(&b, r); err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(b.String()), nil
+}
+
+// formatDuration rounds d for humans, e.g. 1h04m or 3m12s.
+func formatDuration(d time.Duration) string {
+	switch {
+	case d <= 0:
+		return "—"
+	case d < time.Minute:
+		return fmt.Sprintf("%ds", int(d.Seconds()))
+	case d < time.Hour:
+		return fmt.Sprintf("%dm%02ds", int(d.Minutes()), int(d.Seconds())%60)
+	default:
+		return fmt.Sprintf("%dh%02dm", int(d.Hours()), int(d.Minutes())%60)
+	}
+}
+
+// formatTokens abbreviates a token count, e.g. 12.3k or 1.2M.
+func formatTokens(n int) string {
+	switch {
+	case n < 1000:
+		return fmt.Sprintf("%d", n)
+	case n < 1_000_000:
+		return fmt.Sprintf("%.1fk", float64(n)/1000)
+	default:
+		return fmt.Sprintf("%.1fM", float64(n)/1_000_000)
+	}
+}
+
+// formatToolCounts lists tool calls, most used first, e.g. "Read 12, Bash 4".
+func formatToolCounts(counts []ToolCount) string {
+	parts := make([]string, len(counts))
+	for i, c := range counts {
+		parts[i] = fmt.Sprintf("%s %d", c.Name, c.Count)
+	}
+	return strings.Join(parts, ", ")
+}
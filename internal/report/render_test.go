@@ -0,0 +1,94 @@
+package report
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func testReport() *Report {
+	r := Build(testPRD(), testRecords())
+	r.Workspace = "login"
+	r.Generated = t0
+	r.Stories[0].Commits = []Commit{{SHA: "0123456789abcdef", Subject: "feat(US-001): Login form", Files: 2, Insertions: 11, Deletions: 2}}
+	r.DiffStat = " login.go | 13 +++++++++--\n 1 file changed"
+	r.Progress = "## US-001\nAdded the form."
+	return r
+}
+
+func TestWriteMarkdown(t *testing.T) {
+	var b strings.Builder
+	if err := WriteMarkdown(&b, testReport()); err != nil {
+		t.Fatal(err)
+	}
+	out := b.String()
+
+	for _, want := range []string{
+		"# Ralph run report: login",
+		"| Stories passing | 1/2 |",
+		"### ✓ US-001: Login form",
+		"### ✗ US-002: Logout",
+		"- ✗ `go test ./...`",
+		"- `login.go` +11 −2",
+		"> Login form done.",
+		"- `0123456` feat(US-001): Login form (2 files, +11 −2)",
+		"## QA",
+		"- ✗ **IT-002** User logs out — button missing",
+		"## Progress log",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("missing %q in:\n%s", want, out)
+		}
+	}
+}
+
+func TestWriteHTML_EscapesContent(t *testing.T) {
+	r := testReport()
+	r.Stories[1].Title = "<script>alert(1)</script>"
+
+	var b strings.Builder
+	if err := WriteHTML(&b, r); err != nil {
+		t.Fatal(err)
+	}
+	out := b.String()
+
+	if strings.Contains(out, "<script>alert(1)") {
+		t.Error("expected the story title to be escaped")
+	}
+	for _, want := range []string{"<!DOCTYPE html>", "<style>", "US-001: Login form", "<code>go test ./...</code>"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("missing %q", want)
+		}
+	}
+}
+
+func TestSummary(t *testing.T) {
+	s, err := Summary(testReport())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"<details>",
+		"1/2 stories passing, 1/2 integration tests",
+		"| US-001: Login form | ✓ | 1 | 5m00s | 1 | 1.5k / 200 |",
+		"| QA |",
+		"**Total:** 3 invocations",
+	} {
+		if !strings.Contains(s, want) {
+			t.Errorf("missing %q in:\n%s", want, s)
+		}
+	}
+}
+
+func TestFormatDuration(t *testing.T) {
+	for d, want := range map[time.Duration]string{
+		0:                             "—",
+		42 * time.Second:              "42s",
+		3*time.Minute + 7*time.Second: "3m07s",
+		2*time.Hour + 5*time.Minute:   "2h05m",
+	} {
+		if got := formatDuration(d); got != want {
+			t.Errorf("formatDuration(%v) = %q, want %q", d, got, want)
+		}
+	}
+}
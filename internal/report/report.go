@@ -0,0 +1,363 @@
+// Package report builds a readable account of a workspace's run — stories,
+// what the agent did for each, commands, token usage, commits and diff
+// stats — from its PRD, progress file, JSONL logs and git history.
+package report
+
+import (
+	"context"
+	"errors"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/uesteibar/ralph/internal/events"
+	"github.com/uesteibar/ralph/internal/gitops"
+	"github.com/uesteibar/ralph/internal/prd"
+	"github.com/uesteibar/ralph/internal/shell"
+)
+
+// QAStory is the story ID activity of the QA phases is filed under.
+const QAStory = "QA"
+
+// maxMessageLen caps the agent's last message kept per story.
+const maxMessageLen = 600
+
+// Report is everything known about a workspace's run.
+type Report struct {
+	Workspace   string
+	Project     string
+	Branch      string
+	Description string
+	Generated   time.Time
+
+	Stories          []Story
+	QA               Activity
+	IntegrationTests []prd.IntegrationTest
+	// Total sums the usage of every story and the QA phases.
+	Total Usage
+	// DiffStat is git's --stat of the branch against its base.
+	DiffStat string
+	Progress string
+}
+
+// Story is a PRD story with what the run did for it.
+type Story struct {
+	ID       string
+	Title    string
+	Passes   bool
+	Activity Activity
+	Commits  []Commit
+}
+
+// Activity is what the agent did for one story, or during QA.
+type Activity struct {
+	Started time.Time
+	Ended   time.Time
+	// Attempts counts how many times the loop started the story.
+	Attempts  int
+	Usage     Usage
+	ToolCalls []ToolCount
+	Commands  []Command
+	Edits     []FileEdit
+	Denied    int
+	// LastMessage is the start of the agent's final message.
+	LastMessage string
+
+	toolCalls map[string]int
+	edits     map[string]*FileEdit
+}
+
+// Usage is what Claude invocations consumed.
+type Usage struct {
+	Invocations  int
+	InputTokens  int
+	OutputTokens int
+	CostUSD      float64
+}
+
+// ToolCount is how often a tool was called.
+type ToolCount struct {
+	Name  string
+	Count int
+}
+
+// Command is a shell command the agent ran.
+type Command struct {
+	Command string
+	Failed  bool
+}
+
+// FileEdit sums the lines the agent changed in a file.
+type FileEdit struct {
+	Path    string
+	Added   int
+	Removed int
+}
+
+// Commit is a commit of the branch with its line counts.
+type Commit struct {
+	SHA        string
+	Subject    string
+	Files      int
+	Insertions int
+	Deletions  int
+}
+
+// Duration is how long the activity ran, from its first logged event to its
+// last. It is zero when the logs carry no times.
+func (a Activity) Duration() time.Duration {
+	if a.Started.IsZero() || a.Ended.IsZero() {
+		return 0
+	}
+	return a.Ended.Sub(a.Started)
+}
+
+// Empty reports whether nothing was logged for the activity.
+func (a Activity) Empty() bool {
+	return a.Attempts == 0 && a.Usage.Invocations == 0 && len(a.ToolCalls) == 0
+}
+
+// FailedCommands counts the commands that failed.
+func (a Activity) FailedCommands() int {
+	n := 0
+	for _, c := range a.Commands {
+		if c.Failed {
+			n++
+		}
+	}
+	return n
+}
+
+// PassingStories counts the stories that pass.
+func (r *Report) PassingStories() int {
+	n := 0
+	for _, s := range r.Stories {
+		if s.Passes {
+			n++
+		}
+	}
+	return n
+}
+
+// PassingTests counts the integration tests that pass.
+func (r *Report) PassingTests() int {
+	n := 0
+	for _, t := range r.IntegrationTests {
+		if t.Passes {
+			n++
+		}
+	}
+	return n
+}
+
+// Source locates the inputs of a report.
+type Source struct {
+	Workspace    string
+	PRDPath      string
+	ProgressPath string
+	LogsDir      string
+	// WorkDir is the worktree git history is read from.
+	WorkDir string
+	// Base is the ref the branch started from. With no base, in base mode,
+	// there is no diff and commits are only found through StoryCommits.
+	Base string
+	// StoryCommits lists a story's commits, oldest first. When nil, they
+	// are looked up by their Ralph-Story trailer or subject since Base.
+	StoryCommits func(ctx context.Context, storyID string) ([]gitops.CommitSummary, error)
+}
+
+// Collect reads the inputs at src and builds their report. Missing logs and
+// progress files leave their parts of the report empty.
+func Collect(ctx context.Context, src Source, now time.Time) (*Report, error) {
+	p, err := prd.Read(src.PRDPath)
+	if err != nil {
+		return nil, err
+	}
+
+	var recs []events.Record
+	if err := events.ReadRecords(src.LogsDir, func(rec events.Record) bool {
+		recs = append(recs, rec)
+		return true
+	}); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+
+	r := Build(p, recs)
+	r.Workspace = src.Workspace
+	r.Generated = now
+
+	if src.ProgressPath != "" {
+		if data, err := os.ReadFile(src.ProgressPath); err == nil {
+			r.Progress = strings.TrimSpace(string(data))
+		}
+	}
+
+	git := &shell.Runner{Dir: src.WorkDir}
+	storyCommits := src.StoryCommits
+	if storyCommits == nil {
+		storyCommits = func(ctx context.Context, storyID string) ([]gitops.CommitSummary, error) {
+			if src.Base == "" {
+				return nil, nil
+			}
+			return gitops.StoryCommits(ctx, git, src.Base, storyID)
+		}
+	}
+	for i := range r.Stories {
+		commits, err := storyCommits(ctx, r.Stories[i].ID)
+		if err != nil {
+			return nil, err
+		}
+		for _, c := range commits {
+			r.Stories[i].Commits = append(r.Stories[i].Commits, commitStats(ctx, git, c))
+		}
+	}
+
+	if src.Base != "" {
+		if stat, err := gitops.DiffStats(ctx, git, src.Base); err == nil {
+			r.DiffStat = stat
+		}
+	}
+	return r, nil
+}
+
+// commitStats adds c's line counts. A commit git can't describe has none.
+func commitStats(ctx context.Context, r *shell.Runner, c gitops.CommitSummary) Commit {
+	commit := Commit{SHA: c.SHA, Subject: c.Subject}
+	changes, err := gitops.CommitChanges(ctx, r, c.SHA)
+	if err != nil {
+		return commit
+	}
+	commit.Files = len(changes)
+	for _, ch := range changes {
+		commit.Insertions += ch.Added
+		commit.Deletions += ch.Deleted
+	}
+	return commit
+}
+
+// Build reports on p from its logged events, given in log order. Events are
+// filed under the story last started, or under QA once a QA phase starts;
+// those logged before any story are only counted in the total.
+func Build(p *prd.PRD, recs []events.Record) *Report {
+	r := &Report{
+		Project:          p.Project,
+		Branch:           p.BranchName,
+		Description:      p.Description,
+		IntegrationTests: p.IntegrationTests,
+	}
+
+	activities := map[string]*Activity{QAStory: &r.QA}
+	for _, s := range p.UserStories {
+		r.Stories = append(r.Stories, Story{ID: s.ID, Title: s.Title, Passes: s.Passes})
+	}
+	for i := range r.Stories {
+		activities[r.Stories[i].ID] = &r.Stories[i].Activity
+	}
+
+	var current *Activity
+	for _, rec := range recs {
+		switch e := rec.Event.(type) {
+		case events.StoryStarted:
+			current = activities[e.StoryID]
+			if current != nil {
+				current.Attempts++
+			}
+		case events.QAPhaseStarted:
+			current = &r.QA
+			current.Attempts++
+		case events.InvocationDone:
+			r.Total.add(e)
+		}
+		if current != nil {
+			current.record(rec)
+		}
+	}
+
+	for _, a := range activities {
+		a.finish()
+	}
+	return r
+}
+
+func (u *Usage) add(e events.InvocationDone) {
+	u.Invocations++
+	u.InputTokens += e.TotalInputTokens()
+	u.OutputTokens += e.OutputTokens
+	u.CostUSD += e.CostUSD
+}
+
+// record adds one logged event to the activity.
+func (a *Activity) record(rec events.Record) {
+	if !rec.Time.IsZero() {
+		if a.Started.IsZero() {
+			a.Started = rec.Time
+		}
+		a.Ended = rec.Time
+	}
+
+	switch e := rec.Event.(type) {
+	case events.ToolUse:
+		if a.toolCalls == nil {
+			a.toolCalls = make(map[string]int)
+		}
+		a.toolCalls[e.Name]++
+		if e.Name == "Bash" && e.Detail != "" {
+			a.Commands = append(a.Commands, Command{Command: e.Detail})
+		}
+	case events.ToolResult:
+		if e.Name == "Bash" && e.IsError {
+			// Mark the latest run of the command as failed.
+			for i := len(a.Commands) - 1; i >= 0; i-- {
+				if a.Commands[i].Command == e.Detail {
+					a.Commands[i].Failed = true
+					break
+				}
+			}
+		}
+	case events.ToolDenied:
+		a.Denied++
+	case events.FileEdited:
+		if a.edits == nil {
+			a.edits = make(map[string]*FileEdit)
+		}
+		edit := a.edits[e.Path]
+		if edit == nil {
+			edit = &FileEdit{Path: e.Path}
+			a.edits[e.Path] = edit
+		}
+		edit.Added += e.Added
+		edit.Removed += e.Removed
+	case events.AgentText:
+		a.LastMessage = truncate(strings.TrimSpace(e.Text), maxMessageLen)
+	case events.InvocationDone:
+		a.Usage.add(e)
+	}
+}
+
+// finish sorts what record gathered into the exported fields: tools by call
+// count, edited files by path.
+func (a *Activity) finish() {
+	for name, n := range a.toolCalls {
+		a.ToolCalls = append(a.ToolCalls, ToolCount{Name: name, Count: n})
+	}
+	sort.Slice(a.ToolCalls, func(i, j int) bool {
+		if a.ToolCalls[i].Count != a.ToolCalls[j].Count {
+			return a.ToolCalls[i].Count > a.ToolCalls[j].Count
+		}
+		return a.ToolCalls[i].Name < a.ToolCalls[j].Name
+	})
+	for _, edit := range a.edits {
+		a.Edits = append(a.Edits, *edit)
+	}
+	sort.Slice(a.Edits, func(i, j int) bool { return a.Edits[i].Path < a.Edits[j].Path })
+}
+
+// truncate cuts s to at most n runes.
+func truncate(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	return strings.TrimSpace(string(runes[:n])) + "…"
+}
@@ -0,0 +1,159 @@
+package report
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/uesteibar/ralph/internal/events"
+	"github.com/uesteibar/ralph/internal/prd"
+)
+
+var t0 = time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+
+func testPRD() *prd.PRD {
+	return &prd.PRD{
+		Project:    "shop",
+		BranchName: "ralph/login",
+		UserStories: []prd.Story{
+			{ID: "US-001", Title: "Login form", Passes: true},
+			{ID: "US-002", Title: "Logout", Passes: false},
+		},
+		IntegrationTests: []prd.IntegrationTest{
+			{ID: "IT-001", Description: "User logs in", Passes: true},
+			{ID: "IT-002", Description: "User logs out", Failure: "button missing"},
+		},
+	}
+}
+
+func testRecords() []events.Record {
+	at := func(min int, e events.Event) events.Record {
+		return events.Record{Time: t0.Add(time.Duration(min) * time.Minute), Event: e}
+	}
+	return []events.Record{
+		at(0, events.IterationStart{Iteration: 1, MaxIterations: 5}),
+		at(0, events.StoryStarted{StoryID: "US-001", Title: "Login form"}),
+		at(1, events.ToolUse{Name: "Read", Detail: "login.go"}),
+		at(2, events.ToolUse{Name: "Bash", Detail: "go test ./..."}),
+		at(2, events.ToolResult{Name: "Bash", Detail: "go test ./...", IsError: true, Output: "FAIL"}),
+		at(3, events.FileEdited{Path: "login.go", Added: 10, Removed: 2}),
+		at(3, events.FileEdited{Path: "login.go", Added: 1}),
+		at(4, events.ToolUse{Name: "Bash", Detail: "go test ./..."}),
+		at(5, events.AgentText{Text: "Login form done."}),
+		at(5, events.InvocationDone{InputTokens: 1000, CacheReadTokens: 500, OutputTokens: 200, CostUSD: 0.5}),
+		at(6, events.StoryStarted{StoryID: "US-002", Title: "Logout"}),
+		at(7, events.ToolDenied{Name: "WebFetch"}),
+		at(8, events.InvocationDone{InputTokens: 100, OutputTokens: 10}),
+		at(9, events.QAPhaseStarted{Phase: "verification"}),
+		at(20, events.InvocationDone{InputTokens: 10, OutputTokens: 1}),
+	}
+}
+
+func TestBuild_FilesEventsUnderStories(t *testing.T) {
+	r := Build(testPRD(), testRecords())
+
+	if len(r.Stories) != 2 {
+		t.Fatalf("expected 2 stories, got %d", len(r.Stories))
+	}
+	a := r.Stories[0].Activity
+	if a.Attempts != 1 || a.Duration() != 5*time.Minute {
+		t.Errorf("attempts = %d, duration = %v", a.Attempts, a.Duration())
+	}
+	if a.Usage != (Usage{Invocations: 1, InputTokens: 1500, OutputTokens: 200, CostUSD: 0.5}) {
+		t.Errorf("usage = %+v", a.Usage)
+	}
+	if len(a.ToolCalls) != 2 || a.ToolCalls[0] != (ToolCount{Name: "Bash", Count: 2}) {
+		t.Errorf("tool calls = %+v", a.ToolCalls)
+	}
+	if len(a.Commands) != 2 || !a.Commands[0].Failed || a.Commands[1].Failed {
+		t.Errorf("commands = %+v", a.Commands)
+	}
+	if len(a.Edits) != 1 || a.Edits[0] != (FileEdit{Path: "login.go", Added: 11, Removed: 2}) {
+		t.Errorf("edits = %+v", a.Edits)
+	}
+	if a.LastMessage != "Login form done." {
+		t.Errorf("last message = %q", a.LastMessage)
+	}
+
+	if d := r.Stories[1].Activity.Denied; d != 1 {
+		t.Errorf("US-002 denied = %d, want 1", d)
+	}
+	if r.QA.Attempts != 1 || r.QA.Usage.Invocations != 1 {
+		t.Errorf("QA = %+v", r.QA)
+	}
+	if r.Total.Invocations != 3 || r.Total.InputTokens != 1610 {
+		t.Errorf("total = %+v", r.Total)
+	}
+	if r.PassingStories() != 1 || r.PassingTests() != 1 {
+		t.Errorf("passing stories = %d, tests = %d", r.PassingStories(), r.PassingTests())
+	}
+}
+
+func TestBuild_IgnoresStoriesNotInPRD(t *testing.T) {
+	r := Build(testPRD(), []events.Record{
+		{Event: events.StoryStarted{StoryID: "US-009"}},
+		{Event: events.ToolUse{Name: "Read"}},
+	})
+	for _, s := range r.Stories {
+		if !s.Activity.Empty() {
+			t.Errorf("%s: expected no activity, got %+v", s.ID, s.Activity)
+		}
+	}
+}
+
+func TestCollect_ReadsPRDLogsAndProgress(t *testing.T) {
+	dir := t.TempDir()
+	prdPath := filepath.Join(dir, "prd.json")
+	if err := prd.Write(prdPath, testPRD()); err != nil {
+		t.Fatal(err)
+	}
+	progressPath := filepath.Join(dir, "progress.txt")
+	os.WriteFile(progressPath, []byte("## US-001\nAdded the form.\n"), 0644)
+
+	logsDir := filepath.Join(dir, "logs")
+	os.MkdirAll(logsDir, 0755)
+	f, err := os.Create(filepath.Join(logsDir, "US-001-20260310T120000Z.jsonl"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, rec := range testRecords() {
+		data, _ := events.MarshalEventAt(rec.Event, rec.Time)
+		f.Write(append(data, '\n'))
+	}
+	f.Close()
+
+	r, err := Collect(context.Background(), Source{
+		Workspace:    "login",
+		PRDPath:      prdPath,
+		ProgressPath: progressPath,
+		LogsDir:      logsDir,
+		WorkDir:      dir,
+	}, t0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Workspace != "login" || r.Progress != "## US-001\nAdded the form." {
+		t.Errorf("workspace = %q, progress = %q", r.Workspace, r.Progress)
+	}
+	if r.Stories[0].Activity.Usage.Invocations != 1 {
+		t.Errorf("expected the logs to be read, got %+v", r.Stories[0].Activity)
+	}
+}
+
+func TestCollect_MissingLogs(t *testing.T) {
+	dir := t.TempDir()
+	prdPath := filepath.Join(dir, "prd.json")
+	if err := prd.Write(prdPath, testPRD()); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := Collect(context.Background(), Source{PRDPath: prdPath, LogsDir: filepath.Join(dir, "logs"), WorkDir: dir}, t0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !r.Stories[0].Activity.Empty() {
+		t.Errorf("expected no activity, got %+v", r.Stories[0].Activity)
+	}
+}
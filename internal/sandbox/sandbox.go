@@ -0,0 +1,308 @@
+// Package sandbox confines the processes ralph starts (Claude and quality
+// checks) with Linux namespaces, using bubblewrap or util-linux unshare. The
+// whole filesystem is mounted read-only except for the workspace, the parts
+// of the git directory commits write to, Claude's own state and a few scratch
+// directories. Git config and hooks, and Claude's settings, instructions,
+// commands and agents, stay read-only, since the human's unconfined git and
+// claude commands run them.
+package sandbox
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/uesteibar/ralph/internal/config"
+	"github.com/uesteibar/ralph/internal/shell"
+)
+
+// Policy describes how a process is confined. A nil *Policy runs commands
+// unchanged.
+type Policy struct {
+	Tool     string   // config.SandboxBwrap or config.SandboxUnshare
+	Writable []string // paths mounted read-write; everything else is read-only
+	ReadOnly []string // paths inside writable ones that stay read-only
+	Network  bool     // keep network access
+}
+
+// lookPath finds the sandbox tool. Overridable in tests.
+var lookPath = exec.LookPath
+
+// New returns the policy for running phase in workDir, or nil when the
+// sandbox is disabled. It fails when the configured tool is not installed,
+// rather than silently running unconfined.
+func New(cfg config.SandboxConfig, phase, workDir string) (*Policy, error) {
+	if !cfg.Enabled {
+		return nil, nil
+	}
+
+	tool := cfg.Tool
+	if tool == "" {
+		tool = config.SandboxBwrap
+	}
+	if _, err := lookPath(tool); err != nil {
+		return nil, fmt.Errorf("sandbox is enabled but %s is not installed: %w", tool, err)
+	}
+
+	if workDir == "" {
+		wd, err := os.Getwd()
+		if err != nil {
+			return nil, fmt.Errorf("getting working directory: %w", err)
+		}
+		workDir = wd
+	}
+	workDir, _ = filepath.Abs(workDir)
+
+	home, _ := os.UserHomeDir()
+	gitWritable, gitReadOnly := gitPaths(workDir)
+	candidates := append([]string{writableRoot(workDir)}, gitWritable...)
+	candidates = append(candidates, os.TempDir())
+	readOnly := gitReadOnly
+	if home != "" {
+		claudeWritable, claudeReadOnly := claudePaths(home)
+		candidates = append(candidates, claudeWritable...)
+		readOnly = append(readOnly, claudeReadOnly...)
+	}
+	// Build and module caches, so quality checks can run.
+	if cache, err := os.UserCacheDir(); err == nil {
+		candidates = append(candidates, cache)
+	}
+	for _, w := range cfg.Writable {
+		candidates = append(candidates, expandHome(w, home))
+	}
+
+	p := &Policy{Tool: tool, Network: cfg.NetworkAllowed(phase)}
+	seen := map[string]bool{}
+	for _, c := range candidates {
+		if c == "" || seen[c] {
+			continue
+		}
+		seen[c] = true
+		// Mounting requires the path to exist.
+		if _, err := os.Stat(c); err != nil {
+			continue
+		}
+		p.Writable = append(p.Writable, c)
+	}
+	for _, r := range readOnly {
+		if _, err := os.Stat(r); err == nil {
+			p.ReadOnly = append(p.ReadOnly, r)
+		}
+	}
+	return p, nil
+}
+
+// Runner returns a shell.Runner for dir whose commands run inside the sandbox
+// of phase, or unconfined when the sandbox is disabled.
+func Runner(cfg config.SandboxConfig, phase, dir string) (*shell.Runner, error) {
+	p, err := New(cfg, phase, dir)
+	if err != nil {
+		return nil, err
+	}
+	r := &shell.Runner{Dir: dir}
+	if p != nil {
+		r.Sandbox = p
+	}
+	return r, nil
+}
+
+// Wrap returns the command line that runs name with args inside the sandbox.
+func (p *Policy) Wrap(name string, args []string) (string, []string) {
+	if p == nil {
+		return name, args
+	}
+	if p.Tool == config.SandboxUnshare {
+		return "unshare", p.unshareArgs(name, args)
+	}
+	return "bwrap", p.bwrapArgs(name, args)
+}
+
+func (p *Policy) bwrapArgs(name string, args []string) []string {
+	out := []string{
+		"--die-with-parent",
+		"--unshare-user", "--unshare-pid", "--unshare-ipc", "--unshare-uts",
+	}
+	if !p.Network {
+		out = append(out, "--unshare-net")
+	}
+	out = append(out, "--ro-bind", "/", "/", "--dev", "/dev", "--proc", "/proc")
+	for _, w := range p.Writable {
+		out = append(out, "--bind", w, w)
+	}
+	for _, r := range p.ReadOnly {
+		out = append(out, "--ro-bind", r, r)
+	}
+	out = append(out, "--", name)
+	return append(out, args...)
+}
+
+// unshareArgs builds a two-step unshare: the outer namespace maps the user to
+// root so it can bind the writable paths and remount everything else
+// read-only, then an inner namespace maps the real uid back before running
+// the command (Claude refuses to skip permissions as root).
+func (p *Policy) unshareArgs(name string, args []string) []string {
+	out := []string{"--user", "--map-root-user", "--mount"}
+	if !p.Network {
+		out = append(out, "--net")
+	}
+	out = append(out, "--", "sh", "-c", p.unshareScript(), "sh", name)
+	return append(out, args...)
+}
+
+func (p *Policy) unshareScript() string {
+	var b strings.Builder
+	b.WriteString("set -e\n")
+	for _, w := range p.Writable {
+		fmt.Fprintf(&b, "mount --bind %s %s\n", quote(w), quote(w))
+	}
+	// Bound on their own so the loop below remounts them read-only.
+	for _, r := range p.ReadOnly {
+		fmt.Fprintf(&b, "mount --bind %s %s\n", quote(r), quote(r))
+	}
+	// A remount inside a user namespace must keep the mount's locked flags
+	// (nosuid, nodev, noexec, atime), so each one carries its current flags
+	// from mountinfo. A mount that can't be made read-only aborts the run
+	// rather than leaving it writable.
+	b.WriteString("awk '{n = split($6, o, \",\"); f = \"\"; for (i = 1; i <= n; i++) if (o[i] != \"rw\" && o[i] != \"ro\") f = f \",\" o[i]; print $5, f}' /proc/self/mountinfo |\n")
+	b.WriteString("while read -r m flags; do\n")
+	b.WriteString("  case \"$m\" in /proc|/proc/*|/sys|/sys/*|/dev|/dev/*")
+	for _, w := range p.Writable {
+		b.WriteString("|" + quote(w))
+	}
+	b.WriteString(") continue ;; esac\n")
+	b.WriteString("  mount -o \"remount,bind,ro$flags\" \"$m\" || { echo \"sandbox: cannot make $m read-only\" >&2; exit 1; }\n")
+	b.WriteString("done\n")
+	// Re-enter the working directory so it resolves through the new mounts.
+	b.WriteString("cd \"$(pwd)\"\n")
+	fmt.Fprintf(&b, "exec unshare --user --map-user=%d --map-group=%d -- \"$@\"\n", os.Getuid(), os.Getgid())
+	return b.String()
+}
+
+// writableRoot returns the directory the process may write to: the whole
+// workspace directory (tree, prd.json, progress.txt) for a workspace tree,
+// otherwise workDir itself.
+func writableRoot(workDir string) string {
+	parent := filepath.Dir(workDir)
+	if filepath.Base(workDir) == "tree" {
+		if _, err := os.Stat(filepath.Join(parent, "workspace.json")); err == nil {
+			return parent
+		}
+	}
+	return workDir
+}
+
+// claudeConfig are the files and directories in ~/.claude that configure
+// what Claude runs. They stay read-only so a sandboxed agent can't plant
+// hooks, commands or instructions for the next unsandboxed claude run.
+var claudeConfig = []struct {
+	name string
+	dir  bool
+}{
+	{"settings.json", false},
+	{"settings.local.json", false},
+	{"CLAUDE.md", false},
+	{"commands", true},
+	{"agents", true},
+	{"hooks", true},
+}
+
+// claudePaths returns Claude's session state in home, which must stay
+// writable, and its configuration inside it, which must not. Missing
+// configuration is created empty (settings as "{}") so it can be mounted
+// read-only rather than created by the agent.
+func claudePaths(home string) (writable, readOnly []string) {
+	dir := filepath.Join(home, ".claude")
+	writable = []string{dir, filepath.Join(home, ".claude.json")}
+	if _, err := os.Stat(dir); err != nil {
+		return writable, nil
+	}
+	for _, c := range claudeConfig {
+		path := filepath.Join(dir, c.name)
+		if _, err := os.Lstat(path); os.IsNotExist(err) {
+			switch {
+			case c.dir:
+				os.Mkdir(path, 0755)
+			case strings.HasSuffix(c.name, ".json"):
+				os.WriteFile(path, []byte("{}\n"), 0644)
+			default:
+				os.WriteFile(path, nil, 0644)
+			}
+		}
+		readOnly = append(readOnly, path)
+	}
+	return writable, readOnly
+}
+
+// gitPaths returns the parts of the git directory commits made in workDir
+// write to, and the config and hooks git runs commands from, which must stay
+// read-only even when they lie inside a writable path.
+func gitPaths(workDir string) (writable, readOnly []string) {
+	gitDir, common := gitDirs(workDir)
+	if common == "" {
+		return nil, nil
+	}
+	for _, name := range []string{"objects", "refs", "logs", "packed-refs"} {
+		writable = append(writable, filepath.Join(common, name))
+	}
+	// ralph/ holds the hooks ralph installs for workspaces.
+	readOnly = []string{filepath.Join(common, "config"), filepath.Join(common, "hooks"), filepath.Join(common, "ralph")}
+	if gitDir != common {
+		// A worktree's HEAD, index and per-worktree config.
+		writable = append(writable, gitDir)
+		readOnly = append(readOnly, filepath.Join(gitDir, "config.worktree"))
+	}
+	return writable, readOnly
+}
+
+// gitDirs returns the git directory of workDir and the common directory
+// commits are written to. They differ for worktrees, which point at both
+// through a .git file and a commondir file.
+func gitDirs(workDir string) (gitDir, common string) {
+	dotGit := filepath.Join(workDir, ".git")
+	info, err := os.Stat(dotGit)
+	if err != nil {
+		return "", ""
+	}
+	if info.IsDir() {
+		return dotGit, dotGit
+	}
+
+	data, err := os.ReadFile(dotGit)
+	if err != nil {
+		return "", ""
+	}
+	gitDir, ok := strings.CutPrefix(strings.TrimSpace(string(data)), "gitdir: ")
+	if !ok {
+		return "", ""
+	}
+	if !filepath.IsAbs(gitDir) {
+		gitDir = filepath.Join(workDir, gitDir)
+	}
+	gitDir = filepath.Clean(gitDir)
+	data, err = os.ReadFile(filepath.Join(gitDir, "commondir"))
+	if err != nil {
+		return gitDir, gitDir
+	}
+	common = strings.TrimSpace(string(data))
+	if !filepath.IsAbs(common) {
+		common = filepath.Join(gitDir, common)
+	}
+	return gitDir, filepath.Clean(common)
+}
+
+func expandHome(path, home string) string {
+	if path == "~" {
+		return home
+	}
+	if rest, ok := strings.CutPrefix(path, "~/"); ok && home != "" {
+		return filepath.Join(home, rest)
+	}
+	return path
+}
+
+// quote single-quotes s for sh.
+func quote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
@@ -0,0 +1,236 @@
+package sandbox
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/uesteibar/ralph/internal/config"
+)
+
+func stubLookPath(t *testing.T, err error) {
+	t.Helper()
+	old := lookPath
+	lookPath = func(file string) (string, error) { return "/usr/bin/" + file, err }
+	t.Cleanup(func() { lookPath = old })
+	// Keep New from touching the real ~/.claude.
+	t.Setenv("HOME", t.TempDir())
+}
+
+func TestNew_Disabled_ReturnsNil(t *testing.T) {
+	p, err := New(config.SandboxConfig{}, config.PhaseStory, t.TempDir())
+	if err != nil || p != nil {
+		t.Fatalf("New = %v, %v; want nil, nil", p, err)
+	}
+
+	name, args := p.Wrap("claude", []string{"--print"})
+	if name != "claude" || !slices.Equal(args, []string{"--print"}) {
+		t.Errorf("nil policy Wrap = %s %v, want the command unchanged", name, args)
+	}
+}
+
+func TestNew_ToolMissing_Errors(t *testing.T) {
+	stubLookPath(t, errors.New("not found"))
+
+	_, err := New(config.SandboxConfig{Enabled: true}, config.PhaseStory, t.TempDir())
+	if err == nil || !strings.Contains(err.Error(), "bwrap is not installed") {
+		t.Fatalf("expected missing tool error, got %v", err)
+	}
+}
+
+func TestNew_WorkspaceTree_WritesWorkspaceAndGitDir(t *testing.T) {
+	stubLookPath(t, nil)
+	root := t.TempDir()
+
+	// <repo>/.git and a worktree at <repo>/.ralph/workspaces/login/tree.
+	repo := filepath.Join(root, "repo")
+	gitDir := filepath.Join(repo, ".git", "worktrees", "login")
+	wsDir := filepath.Join(repo, ".ralph", "workspaces", "login")
+	tree := filepath.Join(wsDir, "tree")
+	common := filepath.Join(repo, ".git")
+	for _, d := range []string{gitDir, tree, filepath.Join(common, "objects"), filepath.Join(common, "refs"),
+		filepath.Join(common, "hooks"), filepath.Join(common, "ralph", "hooks", "login")} {
+		if err := os.MkdirAll(d, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	os.WriteFile(filepath.Join(wsDir, "workspace.json"), []byte("{}"), 0644)
+	os.WriteFile(filepath.Join(tree, ".git"), []byte("gitdir: "+gitDir+"\n"), 0644)
+	os.WriteFile(filepath.Join(gitDir, "commondir"), []byte("../..\n"), 0644)
+	os.WriteFile(filepath.Join(gitDir, "config.worktree"), []byte(""), 0644)
+	os.WriteFile(filepath.Join(common, "config"), []byte(""), 0644)
+
+	extra := filepath.Join(root, "extra")
+	os.MkdirAll(extra, 0755)
+
+	cfg := config.SandboxConfig{
+		Enabled:  true,
+		Writable: []string{extra, filepath.Join(root, "missing")},
+		Network:  map[string]string{config.PhaseStory: config.NetworkDeny},
+	}
+	p, err := New(cfg, config.PhaseStory, tree)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	for _, want := range []string{wsDir, filepath.Join(common, "objects"), filepath.Join(common, "refs"), gitDir, extra} {
+		if !slices.Contains(p.Writable, want) {
+			t.Errorf("Writable = %v, want it to contain %s", p.Writable, want)
+		}
+	}
+	for _, unwanted := range []string{repo, common, filepath.Join(root, "missing")} {
+		if slices.Contains(p.Writable, unwanted) {
+			t.Errorf("Writable = %v, want it not to contain %s", p.Writable, unwanted)
+		}
+	}
+	for _, want := range []string{filepath.Join(common, "config"), filepath.Join(common, "hooks"),
+		filepath.Join(gitDir, "config.worktree"), filepath.Join(common, "ralph")} {
+		if !slices.Contains(p.ReadOnly, want) {
+			t.Errorf("ReadOnly = %v, want it to contain %s", p.ReadOnly, want)
+		}
+	}
+	if p.Network {
+		t.Error("network should be denied for the story phase")
+	}
+}
+
+func TestNew_ClaudeConfigStaysReadOnly(t *testing.T) {
+	stubLookPath(t, nil)
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	claudeDir := filepath.Join(home, ".claude")
+	os.MkdirAll(filepath.Join(claudeDir, "projects"), 0755)
+	os.WriteFile(filepath.Join(claudeDir, "settings.json"), []byte(`{"model":"opus"}`), 0644)
+
+	p, err := New(config.SandboxConfig{Enabled: true}, config.PhaseStory, t.TempDir())
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	if !slices.Contains(p.Writable, claudeDir) {
+		t.Errorf("Writable = %v, want Claude's state in %s", p.Writable, claudeDir)
+	}
+	_, args := p.Wrap("claude", nil)
+	joined := strings.Join(args, " ")
+	for _, name := range []string{"settings.json", "settings.local.json", "CLAUDE.md", "commands", "agents", "hooks"} {
+		path := filepath.Join(claudeDir, name)
+		if !strings.Contains(joined, "--ro-bind "+path+" "+path) {
+			t.Errorf("%s is not bound read-only: %s", name, joined)
+		}
+	}
+	if data, _ := os.ReadFile(filepath.Join(claudeDir, "settings.json")); string(data) != `{"model":"opus"}` {
+		t.Errorf("existing settings.json changed to %q", data)
+	}
+	if data, _ := os.ReadFile(filepath.Join(claudeDir, "settings.local.json")); string(data) != "{}\n" {
+		t.Errorf("missing settings.local.json created as %q, want {}", data)
+	}
+}
+
+func TestWrap_Bwrap(t *testing.T) {
+	p := &Policy{Tool: config.SandboxBwrap, Writable: []string{"/ws"}, ReadOnly: []string{"/ws/hooks"}}
+
+	name, args := p.Wrap("claude", []string{"--print"})
+	if name != "bwrap" {
+		t.Fatalf("name = %q, want bwrap", name)
+	}
+	got := strings.Join(args, " ")
+	for _, want := range []string{"--ro-bind / /", "--bind /ws /ws", "--unshare-net", "-- claude --print"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("args = %q, want %q", got, want)
+		}
+	}
+	if strings.Index(got, "--ro-bind / /") > strings.Index(got, "--bind /ws /ws") {
+		t.Error("writable binds must come after the read-only root")
+	}
+	if strings.Index(got, "--bind /ws /ws") > strings.Index(got, "--ro-bind /ws/hooks /ws/hooks") {
+		t.Error("read-only paths must be bound over the writable ones")
+	}
+
+	p.Network = true
+	_, args = p.Wrap("claude", nil)
+	if slices.Contains(args, "--unshare-net") {
+		t.Error("network should be shared when allowed")
+	}
+}
+
+func TestWrap_Unshare(t *testing.T) {
+	p := &Policy{Tool: config.SandboxUnshare, Writable: []string{"/it's here"}, Network: true}
+
+	name, args := p.Wrap("claude", []string{"--print"})
+	if name != "unshare" {
+		t.Fatalf("name = %q, want unshare", name)
+	}
+	if slices.Contains(args, "--net") {
+		t.Error("network should be shared when allowed")
+	}
+	if !slices.Equal(args[len(args)-3:], []string{"sh", "claude", "--print"}) {
+		t.Errorf("args end = %v, want the command as positional parameters", args[len(args)-3:])
+	}
+	script := args[slices.Index(args, "-c")+1]
+	if !strings.Contains(script, `mount --bind '/it'\''s here' '/it'\''s here'`) {
+		t.Errorf("script does not bind the quoted writable path:\n%s", script)
+	}
+	if !strings.Contains(script, `remount,bind,ro$flags`) || strings.Contains(script, "|| true") {
+		t.Errorf("script must keep each mount's flags and fail when a remount fails:\n%s", script)
+	}
+}
+
+// TestUnshare_ConfinesWrites runs a real sandbox when the host allows
+// unprivileged user namespaces.
+func TestUnshare_ConfinesWrites(t *testing.T) {
+	if err := exec.Command("unshare", "--user", "--map-root-user", "--mount", "true").Run(); err != nil {
+		t.Skipf("user namespaces unavailable: %v", err)
+	}
+
+	root := t.TempDir()
+	writable := filepath.Join(root, "ws")
+	readOnly := filepath.Join(root, "ro")
+	hooks := filepath.Join(writable, "hooks")
+	os.MkdirAll(hooks, 0755)
+	os.MkdirAll(readOnly, 0755)
+
+	p := &Policy{Tool: config.SandboxUnshare, Writable: []string{writable}, ReadOnly: []string{hooks}}
+	r, err := Runner(config.SandboxConfig{}, config.PhaseChecks, writable)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.Sandbox = p
+
+	script := "touch ok && ! touch " + readOnly + "/nope 2>/dev/null && ! touch hooks/pre-commit 2>/dev/null"
+	if _, err := r.Run(context.Background(), "sh", "-c", script); err != nil {
+		t.Fatalf("sandboxed run: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(writable, "ok")); err != nil {
+		t.Errorf("write inside the writable path did not land: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(readOnly, "nope")); !os.IsNotExist(err) {
+		t.Error("write outside the writable paths should have failed")
+	}
+	if _, err := os.Stat(filepath.Join(hooks, "pre-commit")); !os.IsNotExist(err) {
+		t.Error("write to a read-only path inside a writable one should have failed")
+	}
+}
+
+// TestUnshare_RemountsLockedMountsReadOnly runs the sandbox over a mount with
+// nosuid and nodev, which a user namespace may only remount keeping them.
+func TestUnshare_RemountsLockedMountsReadOnly(t *testing.T) {
+	if err := exec.Command("unshare", "--user", "--map-root-user", "--mount", "true").Run(); err != nil {
+		t.Skipf("user namespaces unavailable: %v", err)
+	}
+
+	locked := t.TempDir()
+	p := &Policy{Tool: config.SandboxUnshare, Writable: []string{t.TempDir()}}
+	name, args := p.Wrap("sh", []string{"-c", "! touch " + locked + "/nope 2>/dev/null"})
+
+	// The outer namespace mounts a tmpfs the sandbox's namespace inherits locked.
+	outer := append([]string{"--user", "--map-root-user", "--mount", "sh", "-c",
+		`mount -t tmpfs -o nosuid,nodev tmpfs "$0" && exec "$@"`, locked, name}, args...)
+	if out, err := exec.Command("unshare", outer...).CombinedOutput(); err != nil {
+		t.Fatalf("sandboxed run: %v\n%s", err, out)
+	}
+}
@@ -0,0 +1,282 @@
+// Package secrets scans diffs for credentials that should never be committed:
+// well-known token formats, private keys, and high-entropy values assigned to
+// secret-looking names.
+package secrets
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/bmatcuk/doublestar/v4"
+	"github.com/uesteibar/ralph/internal/gitops"
+	"github.com/uesteibar/ralph/internal/shell"
+)
+
+// AllowlistFile is the allowlist's file name inside the project's .ralph/.
+const AllowlistFile = "secrets-allowlist"
+
+// minEntropy is the Shannon entropy, in bits per character, above which a
+// value assigned to a secret-looking name is reported. Random hex sits between
+// 3.5 and 4, base64 above 4. Identifiers and prose score similarly, which is
+// why the value must also mix letters and digits.
+const minEntropy = 3.3
+
+// minSecretLen is the shortest value the entropy rule considers.
+const minSecretLen = 16
+
+// Finding is a likely secret on an added line of a diff.
+type Finding struct {
+	Path  string
+	Line  int
+	Rule  string
+	Match string
+}
+
+// String formats the finding with the matched text redacted, e.g.
+// "config/prod.go:12: AWS access key (AKIA…, 20 chars)".
+func (f Finding) String() string {
+	return fmt.Sprintf("%s:%d: %s (%s)", f.Path, f.Line, f.Rule, Redact(f.Match))
+}
+
+// Redact keeps the first four characters of a secret, enough to recognise it
+// without leaking it into logs.
+func Redact(s string) string {
+	if len(s) <= 4 {
+		return strings.Repeat("*", len(s))
+	}
+	return fmt.Sprintf("%s…, %d chars", s[:4], len(s))
+}
+
+type rule struct {
+	name string
+	re   *regexp.Regexp
+}
+
+var rules = []rule{
+	{"AWS access key", regexp.MustCompile(`\b(?:AKIA|ASIA)[0-9A-Z]{16}\b`)},
+	{"GitHub token", regexp.MustCompile(`\bgh[pousr]_[A-Za-z0-9]{36,}\b`)},
+	{"GitHub token", regexp.MustCompile(`\bgithub_pat_[A-Za-z0-9_]{60,}`)},
+	{"Slack token", regexp.MustCompile(`\bxox[abprs]-[A-Za-z0-9-]{10,}`)},
+	{"Stripe live key", regexp.MustCompile(`\b[rs]k_live_[A-Za-z0-9]{20,}`)},
+	{"Anthropic API key", regexp.MustCompile(`\bsk-ant-[A-Za-z0-9_-]{20,}`)},
+	{"OpenAI API key", regexp.MustCompile(`\bsk-(?:proj-)?[A-Za-z0-9]{32,}`)},
+	{"Google API key", regexp.MustCompile(`\bAIza[0-9A-Za-z_-]{35}`)},
+	{"Linear API key", regexp.MustCompile(`\blin_api_[A-Za-z0-9]{40}`)},
+	{"private key", regexp.MustCompile(`-----BEGIN (?:RSA |EC |DSA |OPENSSH |PGP |ENCRYPTED )?PRIVATE KEY(?: BLOCK)?-----`)},
+}
+
+// assignment matches a secret-looking name assigned a literal value, e.g.
+// `apiKey: "…"`, `DB_PASSWORD=…` or `token := "…"`.
+var assignment = regexp.MustCompile(`(?i)([a-z0-9_.-]*(?:secret|token|passwd|password|api[_-]?key|access[_-]?key|private[_-]?key|credential)[a-z0-9_.-]*)["']?\s*(?::=|=|:)\s*["'\x60]?([A-Za-z0-9+/=_\-.~]{16,})`)
+
+// ScanLine returns the findings on a single added line.
+func ScanLine(path string, lineNo int, line string) []Finding {
+	var findings []Finding
+	seen := map[string]bool{}
+	for _, r := range rules {
+		for _, m := range r.re.FindAllString(line, -1) {
+			if seen[m] {
+				continue
+			}
+			seen[m] = true
+			findings = append(findings, Finding{Path: path, Line: lineNo, Rule: r.name, Match: m})
+		}
+	}
+	for _, m := range assignment.FindAllStringSubmatch(line, -1) {
+		name, value := m[1], m[2]
+		if seen[value] || !looksRandom(value) {
+			continue
+		}
+		seen[value] = true
+		findings = append(findings, Finding{
+			Path:  path,
+			Line:  lineNo,
+			Rule:  "high-entropy value assigned to " + name,
+			Match: value,
+		})
+	}
+	return findings
+}
+
+// looksRandom reports whether value is long, mixes letters and digits, and
+// has high entropy, like a generated key rather than a name or a sentence.
+func looksRandom(value string) bool {
+	if len(value) < minSecretLen || entropy(value) < minEntropy {
+		return false
+	}
+	return strings.ContainsAny(value, "0123456789") &&
+		strings.IndexFunc(value, func(c rune) bool { return ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z') }) >= 0
+}
+
+// entropy returns the Shannon entropy of s in bits per character.
+func entropy(s string) float64 {
+	counts := map[rune]int{}
+	for _, c := range s {
+		counts[c]++
+	}
+	n := float64(len([]rune(s)))
+	var h float64
+	for _, c := range counts {
+		p := float64(c) / n
+		h -= p * math.Log2(p)
+	}
+	return h
+}
+
+// ScanDiff scans the added lines of a unified diff, such as the output of
+// git diff or git log -p, skipping whatever allow permits.
+func ScanDiff(diff string, allow *Allowlist) []Finding {
+	var findings []Finding
+	var path string
+	var lineNo int
+	inHunk := false
+
+	sc := bufio.NewScanner(strings.NewReader(diff))
+	sc.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for sc.Scan() {
+		line := sc.Text()
+		switch {
+		case strings.HasPrefix(line, "diff --git "):
+			path, inHunk = "", false
+		case !inHunk && strings.HasPrefix(line, "+++ "):
+			path = strings.TrimPrefix(strings.TrimPrefix(line, "+++ "), "b/")
+			if path == "/dev/null" {
+				path = ""
+			}
+		case strings.HasPrefix(line, "@@ "):
+			inHunk = true
+			lineNo = hunkStart(line)
+		case inHunk && strings.HasPrefix(line, "+"):
+			if path != "" && !allow.SkipsPath(path) {
+				for _, f := range ScanLine(path, lineNo, line[1:]) {
+					if !allow.Permits(f.Match) {
+						findings = append(findings, f)
+					}
+				}
+			}
+			lineNo++
+		case inHunk && strings.HasPrefix(line, " "):
+			lineNo++
+		}
+	}
+	return findings
+}
+
+// hunkStart parses the first new-file line number of a hunk header like
+// "@@ -10,2 +12,3 @@".
+func hunkStart(header string) int {
+	fields := strings.Fields(header)
+	if len(fields) < 3 {
+		return 0
+	}
+	start, _, _ := strings.Cut(strings.TrimPrefix(fields[2], "+"), ",")
+	n, _ := strconv.Atoi(start)
+	return n
+}
+
+// ScanStaged scans the changes staged in the repository r runs in.
+func ScanStaged(ctx context.Context, r *shell.Runner, allow *Allowlist) ([]Finding, error) {
+	out, err := r.Run(ctx, "git", "diff", "--cached", "-U0", "--no-color", "--no-ext-diff")
+	if err != nil {
+		return nil, fmt.Errorf("reading staged changes: %w", err)
+	}
+	return ScanDiff(out, allow), nil
+}
+
+// ScanUnpushed scans every commit reachable from ref that is on no
+// remote-tracking branch, one by one: those are the commits a push of ref
+// would publish. A secret added and removed again within them is still
+// reported, since it would be pushed with the history.
+func ScanUnpushed(ctx context.Context, r *shell.Runner, ref string, allow *Allowlist) ([]Finding, error) {
+	out, err := r.Run(ctx, "git", "log", "-p", "-U0", "--no-color", "--no-ext-diff", "--format=%H", ref, "--not", "--remotes")
+	if err != nil {
+		return nil, fmt.Errorf("reading the commits of %s not pushed yet: %w", ref, err)
+	}
+	return ScanDiff(out, allow), nil
+}
+
+// Allowlist silences known false positives. A nil Allowlist allows nothing.
+type Allowlist struct {
+	paths    []string
+	patterns []*regexp.Regexp
+}
+
+// LoadAllowlist reads an allowlist file. Each non-empty line that does not
+// start with # is either "path:<glob>", skipping matching files entirely, or
+// a regular expression; findings whose matched text it matches are ignored.
+// A missing file is an empty allowlist.
+func LoadAllowlist(path string) (*Allowlist, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return &Allowlist{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading secrets allowlist: %w", err)
+	}
+
+	a := &Allowlist{}
+	for i, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if glob, ok := strings.CutPrefix(line, "path:"); ok {
+			glob = strings.TrimSpace(glob)
+			if !doublestar.ValidatePattern(glob) {
+				return nil, fmt.Errorf("%s:%d: invalid path glob %q", path, i+1, glob)
+			}
+			a.paths = append(a.paths, glob)
+			continue
+		}
+		re, err := regexp.Compile(line)
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", path, i+1, err)
+		}
+		a.patterns = append(a.patterns, re)
+	}
+	return a, nil
+}
+
+// LoadRepoAllowlist reads the allowlist of the project r runs in. Workspace
+// trees resolve to their main repository, so edits to the allowlist apply to
+// existing workspaces without recreating them.
+func LoadRepoAllowlist(ctx context.Context, r *shell.Runner) (*Allowlist, error) {
+	root, err := gitops.MainRepoPath(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+	return LoadAllowlist(filepath.Join(root, ".ralph", AllowlistFile))
+}
+
+// SkipsPath reports whether path is excluded from scanning.
+func (a *Allowlist) SkipsPath(path string) bool {
+	if a == nil {
+		return false
+	}
+	for _, glob := range a.paths {
+		if ok, _ := doublestar.Match(glob, path); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// Permits reports whether match is a known false positive.
+func (a *Allowlist) Permits(match string) bool {
+	if a == nil {
+		return false
+	}
+	for _, re := range a.patterns {
+		if re.MatchString(match) {
+			return true
+		}
+	}
+	return false
+}
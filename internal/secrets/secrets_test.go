@@ -0,0 +1,160 @@
+package secrets
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/uesteibar/ralph/internal/shell"
+)
+
+// Fake credentials are assembled at runtime so this file does not trip
+// secret scanners itself.
+var (
+	awsKey   = "AKIA" + "IOSFODNN7EXAMPLE"
+	ghToken  = "ghp_" + strings.Repeat("a1B2", 9)
+	randomPW = "x9F" + "q2Lm8vTz4Rw7Kp1N"
+)
+
+func TestScanLine(t *testing.T) {
+	tests := []struct {
+		name string
+		line string
+		want string // rule of the single expected finding, "" for none
+	}{
+		{"aws key", `aws_key = "` + awsKey + `"`, "AWS access key"},
+		{"github token", `export GH=` + ghToken, "GitHub token"},
+		{"private key", "-----BEGIN OPENSSH " + "PRIVATE KEY-----", "private key"},
+		{"random password", `dbPassword: "` + randomPW + `"`, "high-entropy value assigned to dbPassword"},
+		{"env lookup", `token := os.Getenv("API_TOKEN")`, ""},
+		{"prose value", `password = "correct_horse_battery_staple"`, ""},
+		{"plain code", `func tokenize(s string) []string {`, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ScanLine("f.go", 1, tt.line)
+			if tt.want == "" {
+				if len(got) != 0 {
+					t.Fatalf("findings = %v, want none", got)
+				}
+				return
+			}
+			if len(got) != 1 || got[0].Rule != tt.want {
+				t.Fatalf("findings = %v, want one %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestScanDiff_AddedLinesOnly(t *testing.T) {
+	diff := strings.Join([]string{
+		"diff --git a/config.go b/config.go",
+		"index 1111111..2222222 100644",
+		"--- a/config.go",
+		"+++ b/config.go",
+		"@@ -3 +3,2 @@ package config",
+		`-const key = "` + awsKey + `"`,
+		`+const key = ""`,
+		`+const gh = "` + ghToken + `"`,
+		"diff --git a/fixtures/keys.txt b/fixtures/keys.txt",
+		"--- /dev/null",
+		"+++ b/fixtures/keys.txt",
+		"@@ -0,0 +1 @@",
+		"+" + awsKey,
+	}, "\n")
+
+	got := ScanDiff(diff, nil)
+	if len(got) != 2 {
+		t.Fatalf("findings = %v, want 2", got)
+	}
+	if got[0].Path != "config.go" || got[0].Line != 4 || got[0].Rule != "GitHub token" {
+		t.Errorf("first finding = %+v", got[0])
+	}
+	if got[1].Path != "fixtures/keys.txt" || got[1].Line != 1 {
+		t.Errorf("second finding = %+v", got[1])
+	}
+}
+
+func TestScanDiff_Allowlist(t *testing.T) {
+	path := filepath.Join(t.TempDir(), AllowlistFile)
+	os.WriteFile(path, []byte("# test fixtures\npath: fixtures/**\nEXAMPLE$\n"), 0644)
+	allow, err := LoadAllowlist(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	diff := strings.Join([]string{
+		"diff --git a/fixtures/keys.txt b/fixtures/keys.txt",
+		"+++ b/fixtures/keys.txt",
+		"@@ -0,0 +1 @@",
+		"+" + ghToken,
+		"diff --git a/main.go b/main.go",
+		"+++ b/main.go",
+		"@@ -0,0 +1,2 @@",
+		"+" + awsKey,
+		"+" + ghToken,
+	}, "\n")
+
+	got := ScanDiff(diff, allow)
+	if len(got) != 1 || got[0].Path != "main.go" || got[0].Rule != "GitHub token" {
+		t.Errorf("findings = %v, want only the token in main.go", got)
+	}
+}
+
+func TestLoadAllowlist(t *testing.T) {
+	allow, err := LoadAllowlist(filepath.Join(t.TempDir(), "missing"))
+	if err != nil || allow.Permits("x") || allow.SkipsPath("x") {
+		t.Errorf("missing file = %+v, %v; want an empty allowlist", allow, err)
+	}
+
+	path := filepath.Join(t.TempDir(), AllowlistFile)
+	os.WriteFile(path, []byte("ok\n(unclosed\n"), 0644)
+	if _, err := LoadAllowlist(path); err == nil || !strings.Contains(err.Error(), ":2:") {
+		t.Errorf("err = %v, want the invalid line reported", err)
+	}
+}
+
+func TestRedact(t *testing.T) {
+	f := Finding{Path: "a.go", Line: 3, Rule: "AWS access key", Match: awsKey}
+	if got, want := f.String(), "a.go:3: AWS access key (AKIA…, 20 chars)"; got != want {
+		t.Errorf("String = %q, want %q", got, want)
+	}
+	if strings.Contains(f.String(), awsKey) {
+		t.Error("the secret must not appear in the output")
+	}
+}
+
+func TestScanUnpushed_FindsSecretsRemovedLater(t *testing.T) {
+	dir := t.TempDir()
+	git := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+	git("init", "-q")
+	git("config", "user.email", "test@test.com")
+	git("config", "user.name", "Test")
+	os.WriteFile(filepath.Join(dir, "old.env"), []byte("KEY="+awsKey+"\n"), 0644)
+	git("add", "-A")
+	git("commit", "-q", "-m", "initial")
+	git("update-ref", "refs/remotes/origin/main", "HEAD")
+	os.WriteFile(filepath.Join(dir, ".env"), []byte("KEY="+awsKey+"\n"), 0644)
+	git("add", "-A")
+	git("commit", "-q", "-m", "add key")
+	os.WriteFile(filepath.Join(dir, ".env"), []byte("KEY=\n"), 0644)
+	git("commit", "-q", "-am", "remove key")
+
+	got, err := ScanUnpushed(context.Background(), &shell.Runner{Dir: dir}, "HEAD", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 || got[0].Path != ".env" || got[0].Rule != "AWS access key" {
+		t.Errorf("findings = %v, want only the key from the unpushed commit", got)
+	}
+}
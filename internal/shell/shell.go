@@ -21,18 +21,35 @@ func (e *ExitError) Error() string {
 	return fmt.Sprintf("%s exited with code %d: %s", e.Cmd, e.Code, e.Stderr)
 }
 
+// Wrapper rewrites a command line before it runs, e.g. to start it inside a
+// sandbox.
+type Wrapper interface {
+	Wrap(name string, args []string) (string, []string)
+}
+
 // Runner executes shell commands with a shared working directory and environment.
 type Runner struct {
 	Dir string
 	Env []string
+	// Sandbox, when set, wraps every command the runner starts.
+	Sandbox Wrapper
 }
 
-// Run executes a command and returns its stdout. Stderr is captured and
-// included in the error on non-zero exit.
-func (r *Runner) Run(ctx context.Context, name string, args ...string) (string, error) {
+// command builds the exec.Cmd for name and args, wrapped by the sandbox if any.
+func (r *Runner) command(ctx context.Context, name string, args ...string) *exec.Cmd {
+	if r.Sandbox != nil {
+		name, args = r.Sandbox.Wrap(name, args)
+	}
 	cmd := exec.CommandContext(ctx, name, args...)
 	cmd.Dir = r.Dir
 	cmd.Env = r.environ()
+	return cmd
+}
+
+// Run executes a command and returns its stdout. Stderr is captured and
+// included in the error on non-zero exit.
+func (r *Runner) Run(ctx context.Context, name string, args ...string) (string, error) {
+	cmd := r.command(ctx, name, args...)
 
 	var stdout, stderr bytes.Buffer
 	cmd.Stdout = &stdout
@@ -55,9 +72,7 @@ func (r *Runner) Run(ctx context.Context, name string, args ...string) (string,
 // RunInteractive executes a command with stdin/stdout/stderr connected to the
 // terminal. Used for interactive sessions (e.g., claude chat).
 func (r *Runner) RunInteractive(ctx context.Context, name string, args ...string) error {
-	cmd := exec.CommandContext(ctx, name, args...)
-	cmd.Dir = r.Dir
-	cmd.Env = r.environ()
+	cmd := r.command(ctx, name, args...)
 	cmd.Stdin = os.Stdin
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
@@ -77,9 +92,7 @@ func (r *Runner) RunInteractive(ctx context.Context, name string, args ...string
 // RunWithStdin executes a command, piping the given string to stdin, and
 // returns stdout.
 func (r *Runner) RunWithStdin(ctx context.Context, stdin string, name string, args ...string) (string, error) {
-	cmd := exec.CommandContext(ctx, name, args...)
-	cmd.Dir = r.Dir
-	cmd.Env = r.environ()
+	cmd := r.command(ctx, name, args...)
 	cmd.Stdin = strings.NewReader(stdin)
 
 	var stdout, stderr bytes.Buffer
@@ -103,9 +116,7 @@ func (r *Runner) RunWithStdin(ctx context.Context, stdin string, name string, ar
 // RunWithStdinStreaming executes a command, piping the given string to stdin,
 // streams stdout to the terminal in real-time, and also returns the full output.
 func (r *Runner) RunWithStdinStreaming(ctx context.Context, stdin string, name string, args ...string) (string, error) {
-	cmd := exec.CommandContext(ctx, name, args...)
-	cmd.Dir = r.Dir
-	cmd.Env = r.environ()
+	cmd := r.command(ctx, name, args...)
 	cmd.Stdin = strings.NewReader(stdin)
 
 	var stdout bytes.Buffer
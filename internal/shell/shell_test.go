@@ -128,3 +128,22 @@ func TestRunWithPTY_WorkingDirectory(t *testing.T) {
 		t.Errorf("pwd = %q, want /tmp or /private/tmp", got)
 	}
 }
+
+// prefixWrapper runs every command through "echo", so the wrapped command
+// line is printed instead of executed.
+type prefixWrapper struct{}
+
+func (prefixWrapper) Wrap(name string, args []string) (string, []string) {
+	return "echo", append([]string{"wrapped", name}, args...)
+}
+
+func TestRun_Sandbox_WrapsCommand(t *testing.T) {
+	r := &Runner{Sandbox: prefixWrapper{}}
+	out, err := r.Run(context.Background(), "false", "x")
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if got := strings.TrimSpace(out); got != "wrapped false x" {
+		t.Errorf("output = %q, want %q", got, "wrapped false x")
+	}
+}
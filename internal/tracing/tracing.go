@@ -0,0 +1,134 @@
+// Package tracing exports OpenTelemetry traces of Ralph's work: the loop,
+// each Claude invocation and tool call, and AutoRalph's transition actions.
+// Tracing is off unless an OTLP endpoint or a trace file is configured; the
+// spans are then no-ops.
+package tracing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/uesteibar/ralph/internal/config"
+)
+
+// Environment variables that turn tracing on when the config doesn't.
+const (
+	EnvEndpoint       = "OTEL_EXPORTER_OTLP_ENDPOINT"
+	EnvTracesEndpoint = "OTEL_EXPORTER_OTLP_TRACES_ENDPOINT"
+	EnvFile           = "RALPH_TRACE_FILE"
+)
+
+const tracerName = "github.com/uesteibar/ralph"
+
+// Span attribute keys.
+const (
+	AttrWorkspace       = attribute.Key("ralph.workspace")
+	AttrScope           = attribute.Key("ralph.scope")
+	AttrStoryID         = attribute.Key("ralph.story.id")
+	AttrPhase           = attribute.Key("ralph.phase")
+	AttrIssueID         = attribute.Key("autoralph.issue.id")
+	AttrIssueIdentifier = attribute.Key("autoralph.issue.identifier")
+	AttrFromState       = attribute.Key("autoralph.state.from")
+	AttrToState         = attribute.Key("autoralph.state.to")
+	AttrModel           = attribute.Key("claude.model")
+	AttrSessionID       = attribute.Key("claude.session_id")
+	AttrTurns           = attribute.Key("claude.turns")
+	AttrInputTokens     = attribute.Key("claude.tokens.input")
+	AttrOutputTokens    = attribute.Key("claude.tokens.output")
+	AttrCacheTokens     = attribute.Key("claude.tokens.cache_read")
+	AttrCostUSD         = attribute.Key("claude.cost_usd")
+	AttrToolName        = attribute.Key("claude.tool.name")
+	AttrToolDetail      = attribute.Key("claude.tool.detail")
+	AttrSubagent        = attribute.Key("claude.tool.subagent")
+)
+
+// Setup installs the global tracer provider for service, exporting to the
+// OTLP endpoint and the file in cfg, or else to those named by the
+// environment. The returned function flushes and closes the exporters; it
+// must be called before the process exits. With nothing configured, Setup
+// changes nothing and the shutdown function is a no-op.
+func Setup(ctx context.Context, cfg config.TracingConfig, service string) (func(context.Context) error, error) {
+	var opts []sdktrace.TracerProviderOption
+	var closers []func() error
+
+	endpoint := cfg.Endpoint
+	if endpoint != "" || os.Getenv(EnvEndpoint) != "" || os.Getenv(EnvTracesEndpoint) != "" {
+		var httpOpts []otlptracehttp.Option
+		if endpoint != "" {
+			httpOpts = append(httpOpts, otlptracehttp.WithEndpointURL(endpoint))
+		}
+		exp, err := otlptracehttp.New(ctx, httpOpts...)
+		if err != nil {
+			return nil, fmt.Errorf("creating OTLP exporter: %w", err)
+		}
+		opts = append(opts, sdktrace.WithBatcher(exp))
+	}
+
+	path := cfg.File
+	if path == "" {
+		path = os.Getenv(EnvFile)
+	}
+	if path != "" {
+		f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+		if err != nil {
+			return nil, fmt.Errorf("opening trace file: %w", err)
+		}
+		exp, err := stdouttrace.New(stdouttrace.WithWriter(f))
+		if err != nil {
+			f.Close()
+			return nil, fmt.Errorf("creating file exporter: %w", err)
+		}
+		opts = append(opts, sdktrace.WithBatcher(exp))
+		closers = append(closers, f.Close)
+	}
+
+	if len(opts) == 0 {
+		return func(context.Context) error { return nil }, nil
+	}
+
+	// OTEL_SERVICE_NAME and OTEL_RESOURCE_ATTRIBUTES override the defaults.
+	res, err := resource.New(ctx,
+		resource.WithAttributes(attribute.String("service.name", service)),
+		resource.WithFromEnv(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("building trace resource: %w", err)
+	}
+	opts = append(opts, sdktrace.WithResource(res))
+
+	tp := sdktrace.NewTracerProvider(opts...)
+	otel.SetTracerProvider(tp)
+
+	return func(ctx context.Context) error {
+		err := tp.Shutdown(ctx)
+		for _, c := range closers {
+			err = errors.Join(err, c())
+		}
+		return err
+	}, nil
+}
+
+// Start starts a span named name as a child of the span in ctx.
+func Start(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return otel.Tracer(tracerName).Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// End ends span, marking it as failed when err is non-nil.
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
@@ -0,0 +1,162 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	collectortrace "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/uesteibar/ralph/internal/config"
+)
+
+// collector is an in-process OTLP/HTTP trace collector.
+type collector struct {
+	mu    sync.Mutex
+	spans []*tracepb.Span
+	attrs map[string]string
+}
+
+func newCollector(t *testing.T) (*collector, *httptest.Server) {
+	t.Helper()
+	c := &collector{attrs: make(map[string]string)}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/traces" {
+			http.NotFound(w, r)
+			return
+		}
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		var req collectortrace.ExportTraceServiceRequest
+		if err := proto.Unmarshal(body, &req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		c.mu.Lock()
+		for _, rs := range req.ResourceSpans {
+			for _, kv := range rs.Resource.GetAttributes() {
+				c.attrs[kv.Key] = kv.Value.GetStringValue()
+			}
+			for _, ss := range rs.ScopeSpans {
+				c.spans = append(c.spans, ss.Spans...)
+			}
+		}
+		c.mu.Unlock()
+		w.Header().Set("Content-Type", "application/x-protobuf")
+		resp, _ := proto.Marshal(&collectortrace.ExportTraceServiceResponse{})
+		w.Write(resp)
+	}))
+	t.Cleanup(srv.Close)
+	return c, srv
+}
+
+// keepGlobalProvider restores the global tracer provider Setup replaces.
+func keepGlobalProvider(t *testing.T) {
+	prev := otel.GetTracerProvider()
+	t.Cleanup(func() { otel.SetTracerProvider(prev) })
+}
+
+func TestSetup_ExportsToOTLPEndpoint(t *testing.T) {
+	keepGlobalProvider(t)
+	c, srv := newCollector(t)
+
+	shutdown, err := Setup(context.Background(), config.TracingConfig{Endpoint: srv.URL}, "ralph")
+	if err != nil {
+		t.Fatalf("Setup: %v", err)
+	}
+
+	ctx, run := Start(context.Background(), "loop.Run", AttrWorkspace.String("login"))
+	_, story := Start(ctx, "loop.story", AttrStoryID.String("US-001"))
+	End(story, errors.New("quality checks failed"))
+	End(run, nil)
+
+	if err := shutdown(context.Background()); err != nil {
+		t.Fatalf("shutdown: %v", err)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.attrs["service.name"] != "ralph" {
+		t.Errorf("service.name = %q, want ralph", c.attrs["service.name"])
+	}
+	if len(c.spans) != 2 {
+		t.Fatalf("expected 2 spans, got %d", len(c.spans))
+	}
+	byName := make(map[string]*tracepb.Span)
+	for _, s := range c.spans {
+		byName[s.Name] = s
+	}
+	storySpan, runSpan := byName["loop.story"], byName["loop.Run"]
+	if storySpan == nil || runSpan == nil {
+		t.Fatalf("unexpected spans: %v", c.spans)
+	}
+	if string(storySpan.ParentSpanId) != string(runSpan.SpanId) {
+		t.Error("expected the story span to be a child of the run span")
+	}
+	if storySpan.Status.GetCode() != tracepb.Status_STATUS_CODE_ERROR {
+		t.Errorf("story status = %v, want error", storySpan.Status.GetCode())
+	}
+	if got := storySpan.Attributes[0]; got.Key != "ralph.story.id" || got.Value.GetStringValue() != "US-001" {
+		t.Errorf("story attribute = %v", got)
+	}
+}
+
+func TestSetup_ExportsToFile(t *testing.T) {
+	keepGlobalProvider(t)
+	t.Setenv(EnvEndpoint, "")
+	t.Setenv(EnvTracesEndpoint, "")
+	path := filepath.Join(t.TempDir(), "traces.json")
+	t.Setenv(EnvFile, path)
+
+	shutdown, err := Setup(context.Background(), config.TracingConfig{}, "autoralph")
+	if err != nil {
+		t.Fatalf("Setup: %v", err)
+	}
+	_, span := Start(context.Background(), "orchestrator.Transition", AttrIssueIdentifier.String("PROJ-42"))
+	End(span, nil)
+	if err := shutdown(context.Background()); err != nil {
+		t.Fatalf("shutdown: %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{`"Name":"orchestrator.Transition"`, "PROJ-42", "autoralph"} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("trace file lacks %s:\n%s", want, data)
+		}
+	}
+}
+
+func TestSetup_DisabledLeavesProviderAlone(t *testing.T) {
+	keepGlobalProvider(t)
+	t.Setenv(EnvEndpoint, "")
+	t.Setenv(EnvTracesEndpoint, "")
+	t.Setenv(EnvFile, "")
+	before := otel.GetTracerProvider()
+
+	shutdown, err := Setup(context.Background(), config.TracingConfig{}, "ralph")
+	if err != nil {
+		t.Fatalf("Setup: %v", err)
+	}
+	if err := shutdown(context.Background()); err != nil {
+		t.Errorf("shutdown: %v", err)
+	}
+	if otel.GetTracerProvider() != before {
+		t.Error("expected Setup to leave the tracer provider alone when nothing is configured")
+	}
+}
@@ -0,0 +1,271 @@
+package tui
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/uesteibar/ralph/internal/gitops"
+	"github.com/uesteibar/ralph/internal/shell"
+)
+
+// diffLoadedMsg is sent when the files changed against the workspace base
+// have been listed.
+type diffLoadedMsg struct {
+	ref   string
+	files []gitops.FileChange
+	err   error
+}
+
+// fileDiffMsg is sent when the diff of a single file has been read.
+type fileDiffMsg struct {
+	path string
+	diff string
+	err  error
+}
+
+// diffPane shows what the workspace changed against its base: a file tree
+// with git diff --stat counts above the unified diff of the selected file.
+type diffPane struct {
+	visible bool
+	dir     string // workspace tree the diff is taken in
+	base    string // ref the workspace branched from, "" to diff against HEAD
+	ref     string // commit the diff was last taken against
+
+	files  []gitops.FileChange // sorted by path
+	cursor int
+	rows   []diffRow
+	err    error
+
+	viewport viewport.Model
+	width    int
+	height   int
+}
+
+// diffRow is a line of the file tree: a directory, or the file at index file.
+type diffRow struct {
+	text string
+	file int // -1 for directories
+}
+
+var (
+	diffTitleStyle  = sidebarTitleStyle
+	diffDirStyle    = lipgloss.NewStyle().Foreground(lipgloss.AdaptiveColor{Light: "#57606a", Dark: "#8b949e"})
+	diffHeaderStyle = lipgloss.NewStyle().Bold(true)
+	diffHunkStyle   = lipgloss.NewStyle().Foreground(lipgloss.AdaptiveColor{Light: "#0550ae", Dark: "#58a6ff"})
+)
+
+// loadDiffCmd lists the files changed in dir since it branched from base,
+// committed or not.
+func loadDiffCmd(dir, base string) tea.Cmd {
+	return func() tea.Msg {
+		ctx := context.Background()
+		r := &shell.Runner{Dir: dir}
+		ref := "HEAD"
+		if base != "" {
+			mb, err := gitops.MergeBase(ctx, r, base, "HEAD")
+			if err != nil {
+				return diffLoadedMsg{err: err}
+			}
+			ref = mb
+		}
+		files, err := gitops.ChangesSince(ctx, r, ref)
+		if err != nil {
+			return diffLoadedMsg{err: err}
+		}
+		sort.Slice(files, func(i, j int) bool { return files[i].Path < files[j].Path })
+		return diffLoadedMsg{ref: ref, files: files}
+	}
+}
+
+// fileDiffCmd reads the diff of path in dir against ref.
+func fileDiffCmd(dir, ref, path string) tea.Cmd {
+	return func() tea.Msg {
+		diff, err := gitops.FileDiff(context.Background(), &shell.Runner{Dir: dir}, ref, path)
+		return fileDiffMsg{path: path, diff: diff, err: err}
+	}
+}
+
+// setSize lays the pane out in width by height cells.
+func (d *diffPane) setSize(width, height int) {
+	d.width = width
+	d.height = height
+	d.viewport.Width = width
+	d.viewport.Height = max(height-d.treeHeight()-1, 1)
+}
+
+// treeHeight is how many lines the title and file tree take: up to a third
+// of the pane, the rest going to the diff.
+func (d diffPane) treeHeight() int {
+	return min(len(d.rows)+1, max(d.height/3, 3))
+}
+
+// selected returns the path of the selected file, or "" if there is none.
+func (d diffPane) selected() string {
+	if d.cursor < len(d.files) {
+		return d.files[d.cursor].Path
+	}
+	return ""
+}
+
+// load replaces the changed files, keeping the selection on the same file
+// when it is still changed. It returns the command reading the selected
+// file's diff.
+func (d *diffPane) load(msg diffLoadedMsg) tea.Cmd {
+	d.err = msg.err
+	if msg.err != nil {
+		return nil
+	}
+	prev := d.selected()
+	d.ref = msg.ref
+	d.files = msg.files
+	d.cursor = 0
+	for i, f := range d.files {
+		if f.Path == prev {
+			d.cursor = i
+		}
+	}
+	d.rows = fileTree(d.files)
+	d.setSize(d.width, d.height)
+	if len(d.files) == 0 {
+		d.viewport.SetContent("")
+		return nil
+	}
+	return fileDiffCmd(d.dir, d.ref, d.selected())
+}
+
+// move selects the file delta positions away and returns the command reading
+// its diff.
+func (d *diffPane) move(delta int) tea.Cmd {
+	if len(d.files) == 0 {
+		return nil
+	}
+	cursor := max(0, min(d.cursor+delta, len(d.files)-1))
+	if cursor == d.cursor {
+		return nil
+	}
+	d.cursor = cursor
+	return fileDiffCmd(d.dir, d.ref, d.selected())
+}
+
+// showFile displays a file's diff unless another file was selected since it
+// was requested.
+func (d *diffPane) showFile(msg fileDiffMsg) {
+	if msg.path != d.selected() {
+		return
+	}
+	if msg.err != nil {
+		d.viewport.SetContent(failStyle.Render(msg.err.Error()))
+		return
+	}
+	d.viewport.SetContent(colorDiff(msg.diff))
+	d.viewport.GotoTop()
+}
+
+// fileTree lays out files as a tree, each directory on its own line above
+// the files it holds.
+func fileTree(files []gitops.FileChange) []diffRow {
+	var rows []diffRow
+	var prevDirs []string
+	for i, f := range files {
+		dir, name := path.Split(f.Path)
+		dirs := strings.Split(strings.TrimSuffix(dir, "/"), "/")
+		if dir == "" {
+			dirs = nil
+		}
+		shared := 0
+		for shared < len(dirs) && shared < len(prevDirs) && dirs[shared] == prevDirs[shared] {
+			shared++
+		}
+		for depth := shared; depth < len(dirs); depth++ {
+			rows = append(rows, diffRow{text: strings.Repeat("  ", depth) + dirs[depth] + "/", file: -1})
+		}
+		prevDirs = dirs
+		rows = append(rows, diffRow{
+			text: fmt.Sprintf("%s%s %s %s", strings.Repeat("  ", len(dirs)), name,
+				passStyle.Render(fmt.Sprintf("+%d", f.Added)), failStyle.Render(fmt.Sprintf("-%d", f.Deleted))),
+			file: i,
+		})
+	}
+	return rows
+}
+
+// colorDiff colours a unified diff: headers bold, hunk markers blue,
+// additions green and deletions red.
+func colorDiff(diff string) string {
+	lines := strings.Split(strings.TrimRight(diff, "\n"), "\n")
+	for i, line := range lines {
+		line = strings.ReplaceAll(line, "\t", "    ")
+		switch {
+		case strings.HasPrefix(line, "diff "), strings.HasPrefix(line, "index "),
+			strings.HasPrefix(line, "+++"), strings.HasPrefix(line, "---"),
+			strings.HasPrefix(line, "new file"), strings.HasPrefix(line, "deleted file"):
+			line = diffHeaderStyle.Render(line)
+		case strings.HasPrefix(line, "@@"):
+			line = diffHunkStyle.Render(line)
+		case strings.HasPrefix(line, "+"):
+			line = passStyle.Render(line)
+		case strings.HasPrefix(line, "-"):
+			line = failStyle.Render(line)
+		}
+		lines[i] = line
+	}
+	return strings.Join(lines, "\n")
+}
+
+func (d diffPane) view() string {
+	var added, deleted int
+	for _, f := range d.files {
+		added += f.Added
+		deleted += f.Deleted
+	}
+	against := d.base
+	if against == "" {
+		against = "HEAD"
+	}
+	title := diffTitleStyle.Width(d.width).Render(fmt.Sprintf("Changes vs %s · %d files +%d -%d",
+		against, len(d.files), added, deleted))
+
+	var body string
+	switch {
+	case d.err != nil:
+		body = failStyle.Render(d.err.Error())
+	case len(d.files) == 0:
+		body = "No changes"
+	default:
+		body = d.treeView() + "\n" + strings.Repeat("─", d.width) + "\n" + d.viewport.View()
+	}
+	return lipgloss.NewStyle().Width(d.width).Height(d.height).MaxHeight(d.height).Render(title + "\n" + body)
+}
+
+// treeView renders the visible part of the file tree, scrolled to keep the
+// selected file in view.
+func (d diffPane) treeView() string {
+	height := d.treeHeight() - 1
+	selectedRow := 0
+	for i, row := range d.rows {
+		if row.file == d.cursor {
+			selectedRow = i
+		}
+	}
+	offset := max(selectedRow-height+1, 0)
+
+	var lines []string
+	for i := offset; i < min(offset+height, len(d.rows)); i++ {
+		row := d.rows[i]
+		switch {
+		case row.file < 0:
+			lines = append(lines, "  "+diffDirStyle.Render(row.text))
+		case row.file == d.cursor:
+			lines = append(lines, cursorStyle.Render("▸ ")+row.text)
+		default:
+			lines = append(lines, "  "+row.text)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,167 @@
+package tui
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/uesteibar/ralph/internal/events"
+	"github.com/uesteibar/ralph/internal/gitops"
+	"github.com/uesteibar/ralph/internal/shell"
+)
+
+// diffRepo creates a repo with a "main" branch and a feature branch checked
+// out on top of it, one commit and one uncommitted file ahead.
+func diffRepo(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	r := &shell.Runner{Dir: dir}
+	ctx := context.Background()
+	run := func(args ...string) {
+		t.Helper()
+		if _, err := r.Run(ctx, "git", args...); err != nil {
+			t.Fatalf("git %v: %v", args, err)
+		}
+	}
+	run("init", "-b", "main")
+	run("config", "user.email", "test@test.com")
+	run("config", "user.name", "Test")
+	os.WriteFile(filepath.Join(dir, "README.md"), []byte("# test\n"), 0644)
+	run("add", "-A")
+	run("commit", "-m", "initial")
+	run("checkout", "-b", "feature")
+	os.MkdirAll(filepath.Join(dir, "internal", "auth"), 0755)
+	os.WriteFile(filepath.Join(dir, "internal", "auth", "login.go"), []byte("package auth\n"), 0644)
+	run("add", "-A")
+	run("commit", "-m", "add login")
+	os.WriteFile(filepath.Join(dir, "README.md"), []byte("# login\n"), 0644)
+	return dir
+}
+
+// drain runs cmd and feeds the messages it produces back into m until no
+// more commands are returned.
+func drain(t *testing.T, m Model, cmd tea.Cmd) Model {
+	t.Helper()
+	for cmd != nil {
+		msg := cmd()
+		if batch, ok := msg.(tea.BatchMsg); ok {
+			for _, c := range batch {
+				m = drain(t, m, c)
+			}
+			return m
+		}
+		var updated tea.Model
+		updated, cmd = m.Update(msg)
+		m = updated.(Model)
+	}
+	return m
+}
+
+func TestModel_G_TogglesDiffPane(t *testing.T) {
+	dir := diffRepo(t)
+	m := NewModel("ws", "")
+	m.SetDiffSource(dir, "main")
+	ready, _ := m.Update(tea.WindowSizeMsg{Width: 160, Height: 40})
+	m = ready.(Model)
+
+	updated, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'g'}})
+	m = drain(t, updated.(Model), cmd)
+
+	if !m.DiffVisible() {
+		t.Fatal("expected g to show the diff pane")
+	}
+	if got := len(m.diff.files); got != 2 {
+		t.Fatalf("files = %+v, want README.md and internal/auth/login.go", m.diff.files)
+	}
+	view := m.View()
+	for _, want := range []string{"Changes vs main", "internal/", "login.go", "README.md", "+# login"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("view lacks %q:\n%s", want, view)
+		}
+	}
+
+	updated, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'g'}})
+	if updated.(Model).DiffVisible() {
+		t.Error("expected a second g to hide the diff pane")
+	}
+}
+
+func TestModel_DiffPane_DownSelectsNextFile(t *testing.T) {
+	dir := diffRepo(t)
+	m := NewModel("ws", "")
+	m.SetDiffSource(dir, "main")
+	ready, _ := m.Update(tea.WindowSizeMsg{Width: 160, Height: 40})
+	updated, cmd := ready.(Model).Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'g'}})
+	m = drain(t, updated.(Model), cmd)
+
+	updated, cmd = m.Update(tea.KeyMsg{Type: tea.KeyDown})
+	m = drain(t, updated.(Model), cmd)
+
+	if got := m.diff.selected(); got != "internal/auth/login.go" {
+		t.Errorf("selected = %q, want internal/auth/login.go", got)
+	}
+	if !strings.Contains(m.View(), "+package auth") {
+		t.Error("expected the view to show the selected file's diff")
+	}
+}
+
+func TestModel_DiffPane_RefreshesOnCommit(t *testing.T) {
+	m := NewModel("ws", "")
+	m.SetDiffSource(t.TempDir(), "main")
+
+	if _, cmd := m.Update(eventMsg{event: events.CommitCreated{SHA: "abc1234"}}); cmd != nil {
+		t.Error("expected no diff refresh while the pane is hidden")
+	}
+
+	m.diff.visible = true
+	_, cmd := m.Update(eventMsg{event: events.CommitCreated{SHA: "abc1234"}})
+	if cmd == nil {
+		t.Fatal("expected a commit to refresh the visible diff pane")
+	}
+	if _, ok := cmd().(diffLoadedMsg); !ok {
+		t.Error("expected the refresh to reload the changed files")
+	}
+}
+
+func TestModel_G_WithoutDiffSource_DoesNothing(t *testing.T) {
+	m := NewModel("ws", "")
+	updated, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'g'}})
+	if updated.(Model).DiffVisible() || cmd != nil {
+		t.Error("expected g to do nothing without a diff source")
+	}
+}
+
+func TestFileTree_GroupsFilesUnderDirectories(t *testing.T) {
+	rows := fileTree([]gitops.FileChange{
+		{Path: "README.md", Added: 1},
+		{Path: "internal/auth/login.go", Added: 3},
+		{Path: "internal/auth/logout.go", Deleted: 2},
+		{Path: "internal/db.go"},
+	})
+
+	var got []string
+	for _, r := range rows {
+		text, _, _ := strings.Cut(r.text, " +")
+		got = append(got, text)
+	}
+	want := []string{"README.md", "internal/", "  auth/", "    login.go", "    logout.go", "  db.go"}
+	if strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("tree = %q, want %q", got, want)
+	}
+	if rows[1].file != -1 || rows[3].file != 1 {
+		t.Errorf("rows = %+v, want directories unselectable and files indexed", rows)
+	}
+}
+
+func TestColorDiff_KeepsEveryLine(t *testing.T) {
+	diff := "diff --git a/x b/x\n@@ -1 +1 @@\n-old\n+new\n\tctx\n"
+	got := colorDiff(diff)
+	for _, want := range []string{"diff --git", "@@ -1 +1 @@", "-old", "+new", "    ctx"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("colorDiff lacks %q:\n%s", want, got)
+		}
+	}
+}
@@ -39,10 +39,12 @@ type Model struct {
 	overlay     overlay
 	helpOverlay overlay
 	focus       int // focusLeft or focusRight
+	diff        diffPane
 
 	// PRD path for file-based refresh
-	prdPath    string
-	currentPRD *prd.PRD // cached PRD for overlay lookups
+	prdPath     string
+	currentPRD  *prd.PRD // cached PRD for overlay lookups
+	prdEditable bool     // sidebar keys edit the PRD
 
 	// Status bar fields
 	workspaceName string
@@ -50,6 +52,7 @@ type Model struct {
 	activeStoryID string
 	iteration     int
 	maxIterations int
+	scope         string
 
 	// Stop / detach
 	quitting       bool
@@ -88,6 +91,21 @@ func (m *Model) SetStopDaemonFn(fn func()) {
 	m.stopDaemonFn = fn
 }
 
+// EnablePRDEditing lets the sidebar keys edit the PRD: reorder stories,
+// toggle passes and skipped, and edit or add items in $EDITOR.
+func (m *Model) EnablePRDEditing() {
+	m.prdEditable = m.prdPath != ""
+}
+
+// SetDiffSource enables the git diff pane, showing the changes in the
+// workspace tree at dir since it branched from base. An empty base shows
+// uncommitted changes only.
+func (m *Model) SetDiffSource(dir, base string) {
+	m.diff.dir = dir
+	m.diff.base = base
+	m.diff.viewport = viewport.New(0, 0)
+}
+
 // readPRDCmd returns a tea.Cmd that reads the PRD from disk.
 func readPRDCmd(path string) tea.Cmd {
 	return func() tea.Msg {
@@ -191,16 +209,35 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				m.openOverlay()
 				return m, nil
 			}
+		case "g":
+			if m.diff.dir == "" {
+				return m, nil
+			}
+			m.diff.visible = !m.diff.visible
+			if m.diff.visible {
+				return m, loadDiffCmd(m.diff.dir, m.diff.base)
+			}
+			return m, nil
+		case "K", "J", "p", "s", "e", "a":
+			if m.focus == focusLeft && m.prdEditable {
+				return m, m.editPRD(msg.String())
+			}
 		case "up", "k":
 			if m.focus == focusLeft {
 				m.sidebar.moveUp()
 				return m, nil
 			}
+			if m.diff.visible {
+				return m, m.diff.move(-1)
+			}
 		case "down", "j":
 			if m.focus == focusLeft {
 				m.sidebar.moveDown()
 				return m, nil
 			}
+			if m.diff.visible {
+				return m, m.diff.move(1)
+			}
 		}
 
 	case daemonStoppedMsg:
@@ -225,6 +262,7 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 		m.sidebar.width = sidebarWidth
 		m.sidebar.height = contentHeight
+		m.diff.setSize(viewportWidth, contentHeight)
 
 	case prdLoadedMsg:
 		if msg.prd != nil {
@@ -233,25 +271,72 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 		return m, nil
 
+	case editorDoneMsg:
+		return m, m.applyEditorForm(msg)
+
+	case prdEditedMsg:
+		if msg.err != nil {
+			m.appendLine(fmt.Sprintf("  ✗ PRD edit failed: %v", msg.err))
+			return m, nil
+		}
+		if msg.prd != nil {
+			m.currentPRD = msg.prd
+			m.sidebar.updateFromPRD(msg.prd, m.activeStoryID)
+			m.sidebar.selectID(msg.id)
+			m.appendLine(fmt.Sprintf("  ✎ PRD: %s %s — applies from the next iteration", msg.id, msg.note))
+		}
+		return m, nil
+
+	case diffLoadedMsg:
+		return m, m.diff.load(msg)
+
+	case fileDiffMsg:
+		m.diff.showFile(msg)
+		return m, nil
+
 	case eventMsg:
 		m.handleEvent(msg.event)
 		if m.ready {
 			m.viewport.SetContent(strings.Join(m.lines, "\n"))
 			m.viewport.GotoBottom()
 		}
+		var cmds []tea.Cmd
 		// If this was a PRDRefresh event, trigger a file read
 		if _, ok := msg.event.(events.PRDRefresh); ok && m.prdPath != "" {
-			return m, readPRDCmd(m.prdPath)
+			cmds = append(cmds, readPRDCmd(m.prdPath))
+		}
+		// The agent's changes show up in the diff once the PRD is updated or
+		// a commit lands.
+		switch msg.event.(type) {
+		case events.PRDRefresh, events.CommitCreated:
+			if m.diff.visible {
+				cmds = append(cmds, loadDiffCmd(m.diff.dir, m.diff.base))
+			}
+		}
+		if len(cmds) > 0 {
+			return m, tea.Batch(cmds...)
 		}
 	}
 
-	if m.ready {
+	if m.diff.visible {
+		// Keys the file tree doesn't take scroll the diff.
+		m.diff.viewport, cmd = m.diff.viewport.Update(msg)
+	} else if m.ready {
 		m.viewport, cmd = m.viewport.Update(msg)
 	}
 
 	return m, cmd
 }
 
+// appendLine adds a line to the log, following it to the bottom.
+func (m *Model) appendLine(line string) {
+	m.lines = append(m.lines, line)
+	if m.ready {
+		m.viewport.SetContent(strings.Join(m.lines, "\n"))
+		m.viewport.GotoBottom()
+	}
+}
+
 // waitForDaemonExit returns a tea.Cmd that signals daemon has stopped.
 // The actual SIGTERM was already sent synchronously; this just signals the TUI.
 func waitForDaemonExit() tea.Cmd {
@@ -297,11 +382,24 @@ func (m *Model) openOverlay() {
 func (m *Model) handleEvent(e events.Event) {
 	switch e := e.(type) {
 	case events.ToolUse:
-		line := fmt.Sprintf("  → %s", e.Name)
-		if e.Detail != "" {
-			line += " " + e.Detail
+		m.lines = append(m.lines, toolUseLine(e))
+
+	case events.ToolDenied:
+		m.lines = append(m.lines, deniedLine(e))
+
+	case events.ToolResult:
+		if e.IsError {
+			m.lines = append(m.lines, toolErrorLine(e))
 		}
-		m.lines = append(m.lines, line)
+
+	case events.FileEdited:
+		m.lines = append(m.lines, fileEditedLine(e))
+
+	case events.CommitCreated:
+		m.lines = append(m.lines, commitLine(e))
+
+	case events.SessionInit:
+		m.lines = append(m.lines, sessionLine(e))
 
 	case events.AgentText:
 		text := strings.TrimSpace(e.Text)
@@ -310,12 +408,12 @@ func (m *Model) handleEvent(e events.Event) {
 		}
 
 	case events.InvocationDone:
-		durationSec := e.DurationMS / 1000
-		m.lines = append(m.lines, fmt.Sprintf("  ✓ Done (%d turns, %ds)", e.NumTurns, durationSec))
+		m.lines = append(m.lines, doneLine(e))
 
 	case events.IterationStart:
 		m.iteration = e.Iteration
 		m.maxIterations = e.MaxIterations
+		m.scope = e.Scope
 		m.lines = append(m.lines, fmt.Sprintf("iteration %d/%d", e.Iteration, e.MaxIterations))
 
 	case events.StoryStarted:
@@ -340,6 +438,54 @@ func (m *Model) handleEvent(e events.Event) {
 	}
 }
 
+// toolUseLine renders a tool call, nesting those a subagent made.
+func toolUseLine(e events.ToolUse) string {
+	line := fmt.Sprintf("  → %s", e.Name)
+	if e.Subagent != "" {
+		line = fmt.Sprintf("    ↳ %s", e.Name)
+	}
+	if e.Detail != "" {
+		line += " " + e.Detail
+	}
+	return line
+}
+
+// toolErrorLine renders a failed tool call with the first line of its output.
+func toolErrorLine(e events.ToolResult) string {
+	output, _, _ := strings.Cut(e.Output, "\n")
+	return fmt.Sprintf("  ✗ %s failed: %s", e.Name, output)
+}
+
+func fileEditedLine(e events.FileEdited) string {
+	return fmt.Sprintf("  ✎ %s +%d -%d", e.Path, e.Added, e.Removed)
+}
+
+func commitLine(e events.CommitCreated) string {
+	return fmt.Sprintf("  ● commit %s %s (%d files, +%d -%d)",
+		e.SHA[:min(len(e.SHA), 7)], e.Subject, e.FilesChanged, e.Insertions, e.Deletions)
+}
+
+func sessionLine(e events.SessionInit) string {
+	return fmt.Sprintf("  session %s (%s)", e.SessionID, e.Model)
+}
+
+func doneLine(e events.InvocationDone) string {
+	durationSec := e.DurationMS / 1000
+	if e.CostUSD > 0 {
+		return fmt.Sprintf("  ✓ Done (%d turns, %ds, $%.2f)", e.NumTurns, durationSec, e.CostUSD)
+	}
+	return fmt.Sprintf("  ✓ Done (%d turns, %ds)", e.NumTurns, durationSec)
+}
+
+// deniedLine renders a tool call denied by the tool policy.
+func deniedLine(e events.ToolDenied) string {
+	line := fmt.Sprintf("  ✗ %s", e.Name)
+	if e.Detail != "" {
+		line += " " + e.Detail
+	}
+	return line + " (denied by tool policy)"
+}
+
 func (m Model) View() string {
 	if !m.ready {
 		return "Initializing..."
@@ -347,6 +493,9 @@ func (m Model) View() string {
 
 	left := m.sidebar.view()
 	right := m.viewport.View()
+	if m.diff.visible {
+		right = m.diff.view()
+	}
 
 	content := lipgloss.JoinHorizontal(lipgloss.Top, left, right)
 	base := content + "\n" + m.statusBar()
@@ -381,6 +530,12 @@ var attachedStyle = lipgloss.NewStyle().
 	Padding(0, 1).
 	Bold(true)
 
+var scopeStyle = lipgloss.NewStyle().
+	Background(lipgloss.AdaptiveColor{Light: "#9a6700", Dark: "#d29922"}).
+	Foreground(lipgloss.Color("#ffffff")).
+	Padding(0, 1).
+	Bold(true)
+
 var confirmPromptStyle = lipgloss.NewStyle().
 	Border(lipgloss.RoundedBorder()).
 	BorderForeground(lipgloss.AdaptiveColor{Light: "#9a6700", Dark: "#d29922"}).
@@ -407,6 +562,9 @@ func (m Model) statusBar() string {
 	if iter != "" {
 		left += " " + iter
 	}
+	if m.scope != "" {
+		left += " " + scopeStyle.Render(m.scope)
+	}
 	if m.attached {
 		left += " " + attachedStyle.Render("ATTACHED")
 	}
@@ -421,6 +579,11 @@ func (m Model) statusBar() string {
 	return bar
 }
 
+// DiffVisible returns whether the git diff pane is shown (for testing).
+func (m Model) DiffVisible() bool {
+	return m.diff.visible
+}
+
 // Lines returns the current log lines (for testing).
 func (m Model) Lines() []string {
 	return m.lines
@@ -446,6 +609,11 @@ func (m Model) MaxIterations() int {
 	return m.maxIterations
 }
 
+// Scope returns the scope of the run, empty for a full run (for testing).
+func (m Model) Scope() string {
+	return m.scope
+}
+
 // Focus returns the current focus pane (for testing).
 func (m Model) Focus() int {
 	return m.focus
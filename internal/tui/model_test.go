@@ -55,6 +55,18 @@ func TestModel_HandleEvent_ToolUse_WithoutDetail(t *testing.T) {
 	}
 }
 
+func TestModel_HandleEvent_ToolDenied(t *testing.T) {
+	m := NewModel("ws", "")
+	m.handleEvent(events.ToolDenied{Name: "Bash", Detail: "git push"})
+
+	if len(m.Lines()) != 1 {
+		t.Fatalf("expected 1 line, got %d", len(m.Lines()))
+	}
+	if want := "✗ Bash git push (denied by tool policy)"; !strings.Contains(m.Lines()[0], want) {
+		t.Errorf("expected line to contain %q, got %q", want, m.Lines()[0])
+	}
+}
+
 func TestModel_HandleEvent_AgentText(t *testing.T) {
 	m := NewModel("ws", "")
 	m.handleEvent(events.AgentText{Text: "Hello\nWorld"})
@@ -89,6 +101,29 @@ func TestModel_HandleEvent_InvocationDone(t *testing.T) {
 	}
 }
 
+func TestModel_HandleEvent_StreamDetails(t *testing.T) {
+	m := NewModel("ws", "")
+	m.handleEvent(events.SessionInit{SessionID: "sess-1", Model: "claude-sonnet-4-5"})
+	m.handleEvent(events.ToolUse{Name: "Glob", Detail: "*.go", Subagent: "Explore"})
+	m.handleEvent(events.ToolResult{Name: "Glob", Output: "main.go"})
+	m.handleEvent(events.ToolResult{Name: "Bash", IsError: true, Output: "exit status 1\ndetails"})
+	m.handleEvent(events.FileEdited{Path: "main.go", Added: 2, Removed: 1})
+	m.handleEvent(events.CommitCreated{SHA: "abc1234def", Subject: "feat(US-001): Auth", StoryID: "US-001", FilesChanged: 1, Insertions: 2, Deletions: 1})
+	m.handleEvent(events.InvocationDone{NumTurns: 3, DurationMS: 4000, CostUSD: 0.5})
+
+	want := []string{
+		"  session sess-1 (claude-sonnet-4-5)",
+		"    ↳ Glob *.go",
+		"  ✗ Bash failed: exit status 1",
+		"  ✎ main.go +2 -1",
+		"  ● commit abc1234 feat(US-001): Auth (1 files, +2 -1)",
+		"  ✓ Done (3 turns, 4s, $0.50)",
+	}
+	if strings.Join(m.Lines(), "\n") != strings.Join(want, "\n") {
+		t.Errorf("lines = %q, want %q", m.Lines(), want)
+	}
+}
+
 func TestModel_HandleEvent_IterationStart(t *testing.T) {
 	m := NewModel("ws", "")
 	m.handleEvent(events.IterationStart{Iteration: 3, MaxIterations: 20})
@@ -383,6 +418,19 @@ func TestModel_StatusBar_ShowsIteration(t *testing.T) {
 	}
 }
 
+func TestModel_StatusBar_ShowsScope(t *testing.T) {
+	m := NewModel("ws", "")
+	m.width = 80
+	m.handleEvent(events.IterationStart{Iteration: 1, MaxIterations: 20, Scope: "story US-003"})
+	if m.Scope() != "story US-003" {
+		t.Errorf("expected scope 'story US-003', got %q", m.Scope())
+	}
+	bar := m.statusBar()
+	if !strings.Contains(bar, "story US-003") {
+		t.Errorf("expected status bar to contain 'story US-003', got %q", bar)
+	}
+}
+
 func TestHandler_ImplementsEventHandler(t *testing.T) {
 	var _ events.EventHandler = &Handler{}
 }
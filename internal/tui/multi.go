@@ -318,19 +318,26 @@ func (m *MultiModel) handleLogEvent(index int, e events.Event) {
 	lines := m.logLines[index]
 	switch e := e.(type) {
 	case events.ToolUse:
-		line := fmt.Sprintf("  → %s", e.Name)
-		if e.Detail != "" {
-			line += " " + e.Detail
+		lines = append(lines, toolUseLine(e))
+	case events.ToolDenied:
+		lines = append(lines, deniedLine(e))
+	case events.ToolResult:
+		if e.IsError {
+			lines = append(lines, toolErrorLine(e))
 		}
-		lines = append(lines, line)
+	case events.FileEdited:
+		lines = append(lines, fileEditedLine(e))
+	case events.CommitCreated:
+		lines = append(lines, commitLine(e))
+	case events.SessionInit:
+		lines = append(lines, sessionLine(e))
 	case events.AgentText:
 		text := strings.TrimSpace(e.Text)
 		for line := range strings.SplitSeq(text, "\n") {
 			lines = append(lines, "  "+line)
 		}
 	case events.InvocationDone:
-		durationSec := e.DurationMS / 1000
-		lines = append(lines, fmt.Sprintf("  ✓ Done (%d turns, %ds)", e.NumTurns, durationSec))
+		lines = append(lines, doneLine(e))
 	case events.IterationStart:
 		lines = append(lines, fmt.Sprintf("iteration %d/%d", e.Iteration, e.MaxIterations))
 	case events.StoryStarted:
@@ -79,7 +79,10 @@ func renderStoryOverlay(s prd.Story) string {
 	b.WriteString("\n\n")
 
 	// Status
-	if s.Passes {
+	if s.Skipped {
+		b.WriteString(overlayLabelStyle.Render("Status: "))
+		b.WriteString(skipStyle.Render("SKIPPED"))
+	} else if s.Passes {
 		b.WriteString(overlayLabelStyle.Render("Status: "))
 		b.WriteString(overlayPassStyle.Render("PASS"))
 	} else {
@@ -186,6 +189,12 @@ func renderHelpOverlay() string {
 		{"↑/k", "Navigate up (sidebar items / scroll)"},
 		{"↓/j", "Navigate down (sidebar items / scroll)"},
 		{"Enter", "Open detail overlay for selected item"},
+		{"g", "Toggle the git diff pane (↑/↓ pick a file, PgUp/PgDn scroll)"},
+		{"K/J", "Move the selected story up/down in priority"},
+		{"p", "Toggle passes on the selected story or test"},
+		{"s", "Toggle skipped on the selected story"},
+		{"e", "Edit the selected story or test in $EDITOR"},
+		{"a", "Add a story in $EDITOR"},
 		{"Esc", "Close overlay / detach from TUI"},
 		{"?", "Toggle this help overlay"},
 		{"d", "Detach from TUI (daemon keeps running)"},
@@ -0,0 +1,411 @@
+package tui
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"slices"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/uesteibar/ralph/internal/fsutil"
+	"github.com/uesteibar/ralph/internal/prd"
+)
+
+// prdEditedMsg is sent when an edit made from the TUI has been written to
+// the PRD, or failed to be.
+type prdEditedMsg struct {
+	prd  *prd.PRD
+	id   string // item to keep selected
+	note string // what changed, for the log
+	err  error
+}
+
+// editorDoneMsg is sent when the editor opened on a PRD item form exits.
+type editorDoneMsg struct {
+	file string // form the user edited
+	form string // form as it was opened, to tell which fields the user changed
+	id   string // item edited, "" when adding a story
+	test bool
+	err  error
+}
+
+// prdEdit changes p, returning the ID of the item changed and a description
+// of the change. An empty note means nothing changed and nothing is written.
+type prdEdit func(p *prd.PRD) (id, note string, err error)
+
+// errNoEdit stops prd.Update from writing when an edit changed nothing.
+var errNoEdit = errors.New("nothing to edit")
+
+// editPRDCmd applies edit to the PRD on disk and writes it back once it
+// validates. If the agent writes the PRD meanwhile, the edit is re-applied
+// on top of its changes; if the file keeps changing the edit is reported as
+// not applied. The loop picks the change up on its next iteration.
+func editPRDCmd(path string, edit prdEdit) tea.Cmd {
+	return func() tea.Msg {
+		var edited *prd.PRD
+		var id, note string
+		err := prd.Update(path, func(p *prd.PRD) error {
+			var err error
+			id, note, err = edit(p)
+			if err != nil {
+				return err
+			}
+			if note == "" {
+				return errNoEdit
+			}
+			edited = p
+			return prd.Validate(p)
+		})
+		switch {
+		case errors.Is(err, errNoEdit):
+			return prdEditedMsg{}
+		case errors.Is(err, fsutil.ErrModified):
+			return prdEditedMsg{err: fmt.Errorf("the PRD kept changing while it was being edited, try again: %w", err)}
+		case err != nil:
+			return prdEditedMsg{err: err}
+		}
+		return prdEditedMsg{prd: edited, id: id, note: note}
+	}
+}
+
+// editPRD runs the PRD action bound to key on the selected sidebar item.
+func (m *Model) editPRD(key string) tea.Cmd {
+	if key == "a" {
+		return m.openEditor("", false)
+	}
+	items := m.sidebar.Items()
+	cursor := m.sidebar.Cursor()
+	if cursor < 0 || cursor >= len(items) {
+		return nil
+	}
+	item := items[cursor]
+
+	switch key {
+	case "K", "J":
+		if item.isTest {
+			return nil
+		}
+		delta, dir := -1, "up"
+		if key == "J" {
+			delta, dir = 1, "down"
+		}
+		return editPRDCmd(m.prdPath, func(p *prd.PRD) (string, string, error) {
+			if !prd.MoveStory(p, item.id, delta) {
+				return "", "", nil
+			}
+			return item.id, fmt.Sprintf("moved %s", dir), nil
+		})
+	case "p":
+		return editPRDCmd(m.prdPath, func(p *prd.PRD) (string, string, error) {
+			passes := findPasses(p, item.id, item.isTest)
+			if passes == nil {
+				return "", "", fmt.Errorf("%s is no longer in the PRD", item.id)
+			}
+			*passes = !*passes
+			if *passes {
+				return item.id, "marked passing", nil
+			}
+			return item.id, "marked failing", nil
+		})
+	case "s":
+		if item.isTest {
+			return nil
+		}
+		return editPRDCmd(m.prdPath, func(p *prd.PRD) (string, string, error) {
+			s := findStory(p, item.id)
+			if s == nil {
+				return "", "", fmt.Errorf("%s is no longer in the PRD", item.id)
+			}
+			s.Skipped = !s.Skipped
+			if s.Skipped {
+				return item.id, "skipped", nil
+			}
+			return item.id, "no longer skipped", nil
+		})
+	case "e":
+		return m.openEditor(item.id, item.isTest)
+	}
+	return nil
+}
+
+// openEditor writes the form for the item with the given ID, or a blank
+// story form when id is empty, and opens it in the user's editor.
+func (m *Model) openEditor(id string, test bool) tea.Cmd {
+	var form string
+	switch {
+	case id == "":
+		form = storyForm(prd.Story{})
+	case m.currentPRD == nil:
+		return nil
+	case test:
+		t := findTest(m.currentPRD, id)
+		if t == nil {
+			return nil
+		}
+		form = testForm(*t)
+	default:
+		s := findStory(m.currentPRD, id)
+		if s == nil {
+			return nil
+		}
+		form = storyForm(*s)
+	}
+
+	f, err := os.CreateTemp("", "ralph-prd-*.txt")
+	if err != nil {
+		return func() tea.Msg { return prdEditedMsg{err: err} }
+	}
+	_, err = f.WriteString(form)
+	f.Close()
+	if err != nil {
+		os.Remove(f.Name())
+		return func() tea.Msg { return prdEditedMsg{err: err} }
+	}
+	return tea.ExecProcess(editorCommand(f.Name()), func(err error) tea.Msg {
+		return editorDoneMsg{file: f.Name(), form: form, id: id, test: test, err: err}
+	})
+}
+
+// applyEditorForm writes back the fields the user changed in the form, so
+// notes the agent added while the editor was open are kept. A field both
+// changed is not overwritten; the edit is refused instead.
+func (m *Model) applyEditorForm(msg editorDoneMsg) tea.Cmd {
+	data, err := os.ReadFile(msg.file)
+	os.Remove(msg.file)
+	if msg.err != nil {
+		err = fmt.Errorf("running editor: %w", msg.err)
+	}
+	if err != nil {
+		return func() tea.Msg { return prdEditedMsg{err: err} }
+	}
+	fields := parseForm(string(data))
+
+	if msg.id == "" {
+		if fields["Title"] == "" {
+			return nil // an untitled new story means the user changed their mind
+		}
+		return editPRDCmd(m.prdPath, func(p *prd.PRD) (string, string, error) {
+			s := prd.Story{ID: prd.NextStoryID(p)}
+			for _, existing := range p.UserStories {
+				s.Priority = max(s.Priority, existing.Priority)
+			}
+			s.Priority++
+			applyStoryForm(&s, fields)
+			p.UserStories = append(p.UserStories, s)
+			return s.ID, "added", nil
+		})
+	}
+
+	opened := parseForm(msg.form)
+	return editPRDCmd(m.prdPath, func(p *prd.PRD) (string, string, error) {
+		var changed map[string]string
+		var err error
+		if msg.test {
+			t := findTest(p, msg.id)
+			if t == nil {
+				return "", "", fmt.Errorf("%s is no longer in the PRD", msg.id)
+			}
+			if changed, err = changedFields(opened, fields, parseForm(testForm(*t))); err != nil {
+				return "", "", fmt.Errorf("%s %w", msg.id, err)
+			}
+			applyTestForm(t, changed)
+		} else {
+			s := findStory(p, msg.id)
+			if s == nil {
+				return "", "", fmt.Errorf("%s is no longer in the PRD", msg.id)
+			}
+			if changed, err = changedFields(opened, fields, parseForm(storyForm(*s))); err != nil {
+				return "", "", fmt.Errorf("%s %w", msg.id, err)
+			}
+			applyStoryForm(s, changed)
+		}
+		if len(changed) == 0 {
+			return "", "", nil
+		}
+		return msg.id, "edited", nil
+	})
+}
+
+// changedFields returns the fields that differ between the form as opened
+// and as edited. It fails if any of them also changed in the PRD, given as
+// the form current renders, since writing them would drop that change.
+func changedFields(opened, edited, current map[string]string) (map[string]string, error) {
+	changed := make(map[string]string)
+	var conflicts []string
+	for _, name := range formFields {
+		if edited[name] == opened[name] {
+			continue
+		}
+		if current[name] != opened[name] && current[name] != edited[name] {
+			conflicts = append(conflicts, strings.ToLower(name))
+			continue
+		}
+		changed[name] = edited[name]
+	}
+	if len(conflicts) > 0 {
+		return nil, fmt.Errorf("%s changed in the PRD while it was being edited, reopen it and edit again", strings.Join(conflicts, ", "))
+	}
+	return changed, nil
+}
+
+// editorCommand opens path in $VISUAL or $EDITOR, falling back to vi.
+func editorCommand(path string) *exec.Cmd {
+	editor := os.Getenv("VISUAL")
+	if editor == "" {
+		editor = os.Getenv("EDITOR")
+	}
+	args := strings.Fields(editor)
+	if len(args) == 0 {
+		args = []string{"vi"}
+	}
+	return exec.Command(args[0], append(args[1:], path)...)
+}
+
+const formHelp = `# Lines starting with # are ignored. Write one list item per "- " line.
+# Save and quit to apply the changes to the PRD.
+`
+
+// storyForm renders a story as the plain-text form edited in the editor.
+func storyForm(s prd.Story) string {
+	var b strings.Builder
+	if s.ID != "" {
+		fmt.Fprintf(&b, "# Editing %s.\n", s.ID)
+	} else {
+		b.WriteString("# New story. Leave the title empty to cancel.\n")
+	}
+	b.WriteString(formHelp)
+	fmt.Fprintf(&b, "\nTitle: %s\n\nDescription:\n%s\n\nAcceptance criteria:\n", s.Title, s.Description)
+	for _, ac := range s.AcceptanceCriteria {
+		fmt.Fprintf(&b, "- %s\n", ac)
+	}
+	fmt.Fprintf(&b, "\nNotes:\n%s\n", s.Notes)
+	return b.String()
+}
+
+// testForm renders an integration test as the plain-text form edited in the
+// editor.
+func testForm(t prd.IntegrationTest) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Editing %s.\n", t.ID)
+	b.WriteString(formHelp)
+	fmt.Fprintf(&b, "\nDescription:\n%s\n\nSteps:\n", t.Description)
+	for _, step := range t.Steps {
+		fmt.Fprintf(&b, "- %s\n", step)
+	}
+	fmt.Fprintf(&b, "\nNotes:\n%s\n", t.Notes)
+	return b.String()
+}
+
+// formSections are the headings a form's fields sit under.
+var formSections = []string{"Description", "Acceptance criteria", "Steps", "Notes"}
+
+// formFields are all the fields of a form, in the order they are shown.
+var formFields = append([]string{"Title"}, formSections...)
+
+// parseForm reads the fields of an edited form, keyed by heading. Title sits
+// on its heading's line; the other fields span the lines below theirs.
+func parseForm(text string) map[string]string {
+	fields := make(map[string]string)
+	var section string
+	var body []string
+	flush := func() {
+		if section != "" {
+			fields[section] = strings.TrimSpace(strings.Join(body, "\n"))
+		}
+		body = nil
+	}
+	for line := range strings.SplitSeq(text, "\n") {
+		if strings.HasPrefix(line, "#") {
+			continue
+		}
+		if title, ok := strings.CutPrefix(line, "Title:"); ok {
+			flush()
+			section = ""
+			fields["Title"] = strings.TrimSpace(title)
+			continue
+		}
+		if heading, ok := strings.CutSuffix(strings.TrimSpace(line), ":"); ok && slices.Contains(formSections, heading) {
+			flush()
+			section = heading
+			continue
+		}
+		body = append(body, line)
+	}
+	flush()
+	return fields
+}
+
+// formList splits a list field into its "- " items.
+func formList(field string) []string {
+	var items []string
+	for line := range strings.SplitSeq(field, "\n") {
+		line = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(line), "- "))
+		if line != "" {
+			items = append(items, line)
+		}
+	}
+	return items
+}
+
+// applyStoryForm sets the story fields present in fields.
+func applyStoryForm(s *prd.Story, fields map[string]string) {
+	if v, ok := fields["Title"]; ok {
+		s.Title = v
+	}
+	if v, ok := fields["Description"]; ok {
+		s.Description = v
+	}
+	if v, ok := fields["Acceptance criteria"]; ok {
+		s.AcceptanceCriteria = formList(v)
+	}
+	if v, ok := fields["Notes"]; ok {
+		s.Notes = v
+	}
+}
+
+// applyTestForm sets the integration test fields present in fields.
+func applyTestForm(t *prd.IntegrationTest, fields map[string]string) {
+	if v, ok := fields["Description"]; ok {
+		t.Description = v
+	}
+	if v, ok := fields["Steps"]; ok {
+		t.Steps = formList(v)
+	}
+	if v, ok := fields["Notes"]; ok {
+		t.Notes = v
+	}
+}
+
+func findStory(p *prd.PRD, id string) *prd.Story {
+	for i := range p.UserStories {
+		if p.UserStories[i].ID == id {
+			return &p.UserStories[i]
+		}
+	}
+	return nil
+}
+
+func findTest(p *prd.PRD, id string) *prd.IntegrationTest {
+	for i := range p.IntegrationTests {
+		if p.IntegrationTests[i].ID == id {
+			return &p.IntegrationTests[i]
+		}
+	}
+	return nil
+}
+
+// findPasses returns the passes flag of the story or test with the given ID.
+func findPasses(p *prd.PRD, id string, test bool) *bool {
+	if test {
+		if t := findTest(p, id); t != nil {
+			return &t.Passes
+		}
+		return nil
+	}
+	if s := findStory(p, id); s != nil {
+		return &s.Passes
+	}
+	return nil
+}
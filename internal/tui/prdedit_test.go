@@ -0,0 +1,248 @@
+package tui
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/uesteibar/ralph/internal/prd"
+)
+
+// editableModel returns a ready model editing a PRD on disk, with the
+// sidebar focused on the story or test with the given ID.
+func editableModel(t *testing.T, id string) (Model, string) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "prd.json")
+	p := &prd.PRD{
+		UserStories: []prd.Story{
+			{ID: "US-001", Title: "Schema", Priority: 1, Passes: true},
+			{ID: "US-002", Title: "Endpoint", Priority: 2, AcceptanceCriteria: []string{"Returns 200"}},
+			{ID: "US-003", Title: "UI", Priority: 3},
+		},
+		IntegrationTests: []prd.IntegrationTest{{ID: "IT-001", Description: "Login flow"}},
+	}
+	if err := prd.Write(path, p); err != nil {
+		t.Fatal(err)
+	}
+
+	m := NewModel("ws", path)
+	m.EnablePRDEditing()
+	ready, _ := m.Update(tea.WindowSizeMsg{Width: 120, Height: 30})
+	m = ready.(Model)
+	loaded, _ := m.Update(prdLoadedMsg{prd: p})
+	m = loaded.(Model)
+	m.focus = focusLeft
+	m.sidebar.selectID(id)
+	return m, path
+}
+
+func pressKey(t *testing.T, m Model, key string) Model {
+	t.Helper()
+	updated, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(key)})
+	return drain(t, updated.(Model), cmd)
+}
+
+func readPRD(t *testing.T, path string) *prd.PRD {
+	t.Helper()
+	p, err := prd.Read(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func TestModel_PRDEdit_MovesStoryUp(t *testing.T) {
+	m, path := editableModel(t, "US-003")
+
+	m = pressKey(t, m, "K")
+
+	p := readPRD(t, path)
+	if p.UserStories[1].ID != "US-003" || p.UserStories[1].Priority != 2 {
+		t.Errorf("stories = %+v, want US-003 second with priority 2", p.UserStories)
+	}
+	if got := m.Sidebar().Items()[m.Sidebar().Cursor()].id; got != "US-003" {
+		t.Errorf("cursor on %s, want it to follow US-003", got)
+	}
+	if last := m.Lines()[len(m.Lines())-1]; !strings.Contains(last, "US-003 moved up") {
+		t.Errorf("last line = %q, want the move logged", last)
+	}
+}
+
+func TestModel_PRDEdit_TogglesPassesAndSkipped(t *testing.T) {
+	m, path := editableModel(t, "US-002")
+
+	m = pressKey(t, m, "p")
+	m = pressKey(t, m, "s")
+
+	s := readPRD(t, path).UserStories[1]
+	if !s.Passes || !s.Skipped {
+		t.Errorf("US-002 = %+v, want passing and skipped", s)
+	}
+
+	m.sidebar.selectID("IT-001")
+	pressKey(t, m, "p")
+	if !readPRD(t, path).IntegrationTests[0].Passes {
+		t.Error("expected p to toggle passes on an integration test")
+	}
+}
+
+func TestModel_PRDEdit_AppliesEditorForm(t *testing.T) {
+	m, path := editableModel(t, "US-002")
+	form := filepath.Join(t.TempDir(), "form.txt")
+	opened := storyForm(m.CurrentPRD().UserStories[1])
+	edited := strings.Replace(opened, "- Returns 200\n", "- Returns 200\n- Rejects bad input\n", 1)
+	edited = strings.Replace(edited, "Notes:\n", "Notes:\nUse the existing router\n", 1)
+	os.WriteFile(form, []byte(edited), 0644)
+
+	updated, cmd := m.Update(editorDoneMsg{file: form, form: opened, id: "US-002"})
+	drain(t, updated.(Model), cmd)
+
+	s := readPRD(t, path).UserStories[1]
+	if len(s.AcceptanceCriteria) != 2 || s.AcceptanceCriteria[1] != "Rejects bad input" {
+		t.Errorf("criteria = %q", s.AcceptanceCriteria)
+	}
+	if s.Notes != "Use the existing router" || s.Title != "Endpoint" {
+		t.Errorf("US-002 = %+v", s)
+	}
+	if _, err := os.Stat(form); !os.IsNotExist(err) {
+		t.Error("expected the form to be removed")
+	}
+}
+
+func TestModel_PRDEdit_KeepsNotesAddedWhileEditing(t *testing.T) {
+	m, path := editableModel(t, "US-002")
+	form := filepath.Join(t.TempDir(), "form.txt")
+	opened := storyForm(m.CurrentPRD().UserStories[1])
+	os.WriteFile(form, []byte(strings.Replace(opened, "Title: Endpoint", "Title: Login endpoint", 1)), 0644)
+
+	// The agent records a note while the editor is open.
+	p := readPRD(t, path)
+	p.UserStories[1].Notes = "Router already has auth middleware"
+	prd.Write(path, p)
+
+	updated, cmd := m.Update(editorDoneMsg{file: form, form: opened, id: "US-002"})
+	drain(t, updated.(Model), cmd)
+
+	s := readPRD(t, path).UserStories[1]
+	if s.Title != "Login endpoint" || s.Notes != "Router already has auth middleware" {
+		t.Errorf("US-002 = %+v, want the new title and the agent's note", s)
+	}
+}
+
+func TestModel_PRDEdit_RefusesConflictingEdit(t *testing.T) {
+	m, path := editableModel(t, "US-002")
+	form := filepath.Join(t.TempDir(), "form.txt")
+	opened := storyForm(m.CurrentPRD().UserStories[1])
+	os.WriteFile(form, []byte(strings.Replace(opened, "Notes:\n", "Notes:\nUse the existing router\n", 1)), 0644)
+
+	p := readPRD(t, path)
+	p.UserStories[1].Notes = "Router already has auth middleware"
+	prd.Write(path, p)
+	before, _ := os.ReadFile(path)
+
+	updated, cmd := m.Update(editorDoneMsg{file: form, form: opened, id: "US-002"})
+	m = drain(t, updated.(Model), cmd)
+
+	if after, _ := os.ReadFile(path); string(after) != string(before) {
+		t.Error("expected a conflicting edit not to be written")
+	}
+	if last := m.Lines()[len(m.Lines())-1]; !strings.Contains(last, "notes changed in the PRD") {
+		t.Errorf("last line = %q, want the conflict reported", last)
+	}
+}
+
+func TestModel_PRDEdit_AddsStory(t *testing.T) {
+	m, path := editableModel(t, "US-001")
+	form := filepath.Join(t.TempDir(), "form.txt")
+	os.WriteFile(form, []byte(strings.Replace(storyForm(prd.Story{}), "Title: ", "Title: Logout", 1)), 0644)
+
+	updated, cmd := m.Update(editorDoneMsg{file: form})
+	m = drain(t, updated.(Model), cmd)
+
+	p := readPRD(t, path)
+	added := p.UserStories[len(p.UserStories)-1]
+	if added.ID != "US-004" || added.Title != "Logout" || added.Priority != 4 {
+		t.Errorf("added story = %+v, want US-004 Logout with priority 4", added)
+	}
+	if got := m.Sidebar().Items()[m.Sidebar().Cursor()].id; got != "US-004" {
+		t.Errorf("cursor on %s, want the new story", got)
+	}
+}
+
+func TestModel_PRDEdit_InvalidEditLeavesPRDAlone(t *testing.T) {
+	m, path := editableModel(t, "US-002")
+	before, _ := os.ReadFile(path)
+	form := filepath.Join(t.TempDir(), "form.txt")
+	opened := storyForm(m.CurrentPRD().UserStories[1])
+	os.WriteFile(form, []byte(strings.Replace(opened, "Title: Endpoint", "Title:", 1)), 0644)
+
+	updated, cmd := m.Update(editorDoneMsg{file: form, form: opened, id: "US-002"})
+	m = drain(t, updated.(Model), cmd)
+
+	if after, _ := os.ReadFile(path); string(after) != string(before) {
+		t.Error("expected an invalid edit not to be written")
+	}
+	if last := m.Lines()[len(m.Lines())-1]; !strings.Contains(last, "PRD edit failed") || !strings.Contains(last, "no title") {
+		t.Errorf("last line = %q, want the validation error", last)
+	}
+}
+
+func TestEditPRDCmd_KeepsConcurrentAgentEdit(t *testing.T) {
+	_, path := editableModel(t, "US-002")
+
+	calls := 0
+	msg := editPRDCmd(path, func(p *prd.PRD) (string, string, error) {
+		calls++
+		if calls == 1 {
+			// The agent marks US-001 failing while the edit is applied.
+			agent := readPRD(t, path)
+			agent.UserStories[0].Passes = false
+			if err := prd.Write(path, agent); err != nil {
+				t.Fatal(err)
+			}
+		}
+		findStory(p, "US-002").Skipped = true
+		return "US-002", "skipped", nil
+	})().(prdEditedMsg)
+
+	if msg.err != nil {
+		t.Fatalf("edit failed: %v", msg.err)
+	}
+	p := readPRD(t, path)
+	if p.UserStories[0].Passes || !p.UserStories[1].Skipped {
+		t.Errorf("stories = %+v, want the agent's edit and the skip both kept", p.UserStories)
+	}
+}
+
+func TestModel_PRDEdit_IgnoredUnlessEnabled(t *testing.T) {
+	m, path := editableModel(t, "US-002")
+	m.prdEditable = false
+	before, _ := os.ReadFile(path)
+
+	updated, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("p")})
+	if cmd != nil {
+		drain(t, updated.(Model), cmd)
+	}
+	if after, _ := os.ReadFile(path); string(after) != string(before) {
+		t.Error("expected p not to edit the PRD unless editing is enabled")
+	}
+}
+
+func TestParseForm_RoundTripsStory(t *testing.T) {
+	s := prd.Story{
+		Title:              "Endpoint",
+		Description:        "Add the login endpoint.\nKeep it small.",
+		AcceptanceCriteria: []string{"Returns 200", "Rejects bad input"},
+		Notes:              "Use JWT",
+	}
+
+	var got prd.Story
+	applyStoryForm(&got, parseForm(storyForm(s)))
+
+	if got.Title != s.Title || got.Description != s.Description || got.Notes != s.Notes ||
+		strings.Join(got.AcceptanceCriteria, "|") != strings.Join(s.AcceptanceCriteria, "|") {
+		t.Errorf("round trip = %+v, want %+v", got, s)
+	}
+}
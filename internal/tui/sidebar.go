@@ -16,11 +16,12 @@ const (
 
 // sidebarItem represents a single entry in the sidebar (story or test).
 type sidebarItem struct {
-	id     string
-	title  string
-	passes bool
-	active bool // currently being worked on
-	isTest bool // true for integration tests
+	id      string
+	title   string
+	passes  bool
+	skipped bool
+	active  bool // currently being worked on
+	isTest  bool // true for integration tests
 }
 
 // sidebar holds the state for the left pane story/test list.
@@ -42,6 +43,7 @@ var (
 
 	passStyle = lipgloss.NewStyle().Foreground(lipgloss.AdaptiveColor{Light: "#1a7f37", Dark: "#3fb950"})
 	failStyle = lipgloss.NewStyle().Foreground(lipgloss.AdaptiveColor{Light: "#cf222e", Dark: "#f85149"})
+	skipStyle = lipgloss.NewStyle().Foreground(lipgloss.AdaptiveColor{Light: "#57606a", Dark: "#8b949e"})
 
 	activeStyle = lipgloss.NewStyle().
 		Foreground(lipgloss.AdaptiveColor{Light: "#9a6700", Dark: "#d29922"}).
@@ -68,8 +70,9 @@ func (s *sidebar) updateFromPRD(p *prd.PRD, activeStoryID string) {
 		s.items = append(s.items, sidebarItem{
 			id:     story.ID,
 			title:  story.Title,
-			passes: story.Passes,
-			active: story.ID == activeStoryID,
+			passes:  story.Passes,
+			skipped: story.Skipped,
+			active:  story.ID == activeStoryID,
 		})
 	}
 
@@ -95,6 +98,16 @@ func (s *sidebar) setActiveStory(storyID string) {
 	}
 }
 
+// selectID moves the cursor to the item with the given ID, if any.
+func (s *sidebar) selectID(id string) {
+	for i, item := range s.items {
+		if item.id == id {
+			s.cursor = i
+			return
+		}
+	}
+}
+
 func (s *sidebar) moveUp() {
 	if s.cursor > 0 {
 		s.cursor--
@@ -172,7 +185,9 @@ func (s *sidebar) adjustScroll(contentHeight int) {
 func (s sidebar) renderItem(idx int, item sidebarItem) string {
 	// Status indicator
 	var indicator string
-	if item.passes {
+	if item.skipped {
+		indicator = skipStyle.Render("–")
+	} else if item.passes {
 		indicator = passStyle.Render("✓")
 	} else {
 		indicator = failStyle.Render("✗")
@@ -0,0 +1,293 @@
+package workspace
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/uesteibar/ralph/internal/gitops"
+	"github.com/uesteibar/ralph/internal/prd"
+	"github.com/uesteibar/ralph/internal/shell"
+)
+
+// Entries of a workspace archive. Logs are stored under logs/.
+const (
+	archiveEntry  = "workspace.json"
+	archiveBundle = "branch.bundle"
+	archiveLogs   = "logs"
+)
+
+// archiveStateFiles are copied verbatim between the workspace directory and
+// the archive when they exist.
+var archiveStateFiles = []string{"prd.json", prd.CommitsFile, "progress.txt", "run.status.json"}
+
+// Archive writes workspace name to w as a gzipped tarball holding its
+// registry entry, a git bundle of its branch, prd.json, story-commits.json,
+// progress.txt, run.status.json and the JSONL logs. Uncommitted changes in the tree are
+// not included.
+func Archive(ctx context.Context, runner *shell.Runner, repoPath, name string, w io.Writer) error {
+	ws, err := RegistryGet(repoPath, name)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.MkdirTemp("", "ralph-archive-*")
+	if err != nil {
+		return fmt.Errorf("creating temp directory: %w", err)
+	}
+	defer os.RemoveAll(tmp)
+
+	bundlePath := filepath.Join(tmp, archiveBundle)
+	if err := gitops.CreateBundle(ctx, runner, bundlePath, ws.Branch); err != nil {
+		return err
+	}
+
+	entry, err := json.MarshalIndent(ws, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshaling registry entry: %w", err)
+	}
+
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+
+	if err := addArchiveBytes(tw, archiveEntry, entry); err != nil {
+		return err
+	}
+	if err := addArchiveFile(tw, archiveBundle, bundlePath); err != nil {
+		return err
+	}
+
+	wsDir := WorkspacePath(repoPath, name)
+	for _, f := range archiveStateFiles {
+		src := filepath.Join(wsDir, f)
+		if _, err := os.Stat(src); os.IsNotExist(err) {
+			continue
+		}
+		if err := addArchiveFile(tw, f, src); err != nil {
+			return err
+		}
+	}
+
+	logs := archivedLogs(filepath.Join(wsDir, archiveLogs))
+	for _, src := range logs {
+		if err := addArchiveFile(tw, path.Join(archiveLogs, filepath.Base(src)), src); err != nil {
+			return err
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return fmt.Errorf("writing archive: %w", err)
+	}
+	if err := gz.Close(); err != nil {
+		return fmt.Errorf("writing archive: %w", err)
+	}
+	return nil
+}
+
+func addArchiveBytes(tw *tar.Writer, name string, data []byte) error {
+	hdr := &tar.Header{Name: name, Mode: 0644, Size: int64(len(data))}
+	if err := tw.WriteHeader(hdr); err != nil {
+		return fmt.Errorf("writing %s to archive: %w", name, err)
+	}
+	if _, err := tw.Write(data); err != nil {
+		return fmt.Errorf("writing %s to archive: %w", name, err)
+	}
+	return nil
+}
+
+func addArchiveFile(tw *tar.Writer, name, src string) error {
+	f, err := os.Open(src)
+	if err != nil {
+		return fmt.Errorf("reading %s: %w", src, err)
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return fmt.Errorf("reading %s: %w", src, err)
+	}
+	hdr := &tar.Header{Name: name, Mode: 0644, Size: info.Size(), ModTime: info.ModTime()}
+	if err := tw.WriteHeader(hdr); err != nil {
+		return fmt.Errorf("writing %s to archive: %w", name, err)
+	}
+	if _, err := io.Copy(tw, f); err != nil {
+		return fmt.Errorf("writing %s to archive: %w", name, err)
+	}
+	return nil
+}
+
+// Restore recreates a workspace from an archive written by Archive. The
+// branch is restored from the bundle, the state files and logs are put back
+// in the workspace directory, and the worktree and registry entry are created
+// through CreateWorkspace with the archived metadata. It refuses to overwrite
+// an existing workspace, or a local branch pointing at a different commit.
+func Restore(ctx context.Context, runner *shell.Runner, repoPath string, r io.Reader, base string, opts CreateOptions) (*Workspace, error) {
+	tmp, err := os.MkdirTemp("", "ralph-restore-*")
+	if err != nil {
+		return nil, fmt.Errorf("creating temp directory: %w", err)
+	}
+	defer os.RemoveAll(tmp)
+
+	if err := extractArchive(r, tmp); err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(filepath.Join(tmp, archiveEntry))
+	if err != nil {
+		return nil, fmt.Errorf("archive has no %s", archiveEntry)
+	}
+	var ws Workspace
+	if err := json.Unmarshal(data, &ws); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", archiveEntry, err)
+	}
+	if err := ValidateName(ws.Name); err != nil {
+		return nil, err
+	}
+	if ws.Branch == "" {
+		return nil, fmt.Errorf("archived workspace %q has no branch", ws.Name)
+	}
+
+	entries, err := readRegistry(repoPath)
+	if err != nil {
+		return nil, err
+	}
+	for _, e := range entries {
+		if e.Name == ws.Name {
+			return nil, fmt.Errorf("workspace %q already exists", ws.Name)
+		}
+	}
+	wsDir := WorkspacePath(repoPath, ws.Name)
+	if _, err := os.Stat(wsDir); err == nil {
+		return nil, fmt.Errorf("workspace directory %s already exists", wsDir)
+	}
+
+	head, err := gitops.FetchBundle(ctx, runner, filepath.Join(tmp, archiveBundle), ws.Branch)
+	if err != nil {
+		return nil, err
+	}
+	createdBranch := false
+	if gitops.BranchExistsLocally(ctx, runner, ws.Branch) {
+		local, err := gitops.RevParse(ctx, runner, ws.Branch)
+		if err != nil {
+			return nil, err
+		}
+		if local != head {
+			return nil, fmt.Errorf("branch %q already exists locally at a different commit", ws.Branch)
+		}
+	} else {
+		if _, err := runner.Run(ctx, "git", "branch", ws.Branch, head); err != nil {
+			return nil, fmt.Errorf("creating branch %s: %w", ws.Branch, err)
+		}
+		createdBranch = true
+	}
+
+	if err := restoreStateFiles(tmp, wsDir); err != nil {
+		os.RemoveAll(wsDir)
+		return nil, err
+	}
+
+	if err := CreateWorkspace(ctx, repoPath, ws, base, opts); err != nil {
+		// A failing hook keeps the workspace; only undo the branch when the
+		// worktree was never created.
+		if _, statErr := os.Stat(TreePath(repoPath, ws.Name)); createdBranch && os.IsNotExist(statErr) {
+			_ = gitops.DeleteBranch(ctx, runner, ws.Branch)
+		}
+		return nil, err
+	}
+	return &ws, nil
+}
+
+// extractArchive unpacks the known entries of a workspace archive into dir.
+// Anything else in the tarball is ignored.
+func extractArchive(r io.Reader, dir string) error {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return fmt.Errorf("reading archive: %w", err)
+	}
+	defer gz.Close()
+
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("reading archive: %w", err)
+		}
+		if hdr.Typeflag != tar.TypeReg || !isArchiveEntry(hdr.Name) {
+			continue
+		}
+
+		dst := filepath.Join(dir, filepath.FromSlash(hdr.Name))
+		if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+			return fmt.Errorf("extracting %s: %w", hdr.Name, err)
+		}
+		f, err := os.Create(dst)
+		if err != nil {
+			return fmt.Errorf("extracting %s: %w", hdr.Name, err)
+		}
+		_, err = io.Copy(f, tr)
+		f.Close()
+		if err != nil {
+			return fmt.Errorf("extracting %s: %w", hdr.Name, err)
+		}
+	}
+}
+
+func isArchiveEntry(name string) bool {
+	switch name {
+	case archiveEntry, archiveBundle:
+		return true
+	}
+	for _, f := range archiveStateFiles {
+		if name == f {
+			return true
+		}
+	}
+	dir, file := path.Split(name)
+	return dir == archiveLogs+"/" && (strings.HasSuffix(file, ".jsonl") || strings.HasSuffix(file, ".jsonl.gz")) && !strings.HasPrefix(file, ".")
+}
+
+// archivedLogs lists the event logs in dir, compressed or not.
+func archivedLogs(dir string) []string {
+	plain, _ := filepath.Glob(filepath.Join(dir, "*.jsonl"))
+	compressed, _ := filepath.Glob(filepath.Join(dir, "*.jsonl.gz"))
+	return append(plain, compressed...)
+}
+
+func restoreStateFiles(extracted, wsDir string) error {
+	if err := os.MkdirAll(wsDir, 0755); err != nil {
+		return fmt.Errorf("creating workspace directory: %w", err)
+	}
+	files := append([]string{}, archiveStateFiles...)
+	logs := archivedLogs(filepath.Join(extracted, archiveLogs))
+	if len(logs) > 0 {
+		if err := os.MkdirAll(filepath.Join(wsDir, archiveLogs), 0755); err != nil {
+			return fmt.Errorf("creating logs directory: %w", err)
+		}
+	}
+	for _, l := range logs {
+		files = append(files, filepath.Join(archiveLogs, filepath.Base(l)))
+	}
+
+	for _, f := range files {
+		data, err := os.ReadFile(filepath.Join(extracted, f))
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("reading archived %s: %w", f, err)
+		}
+		if err := os.WriteFile(filepath.Join(wsDir, f), data, 0644); err != nil {
+			return fmt.Errorf("restoring %s: %w", f, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,166 @@
+package workspace
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/uesteibar/ralph/internal/gitops"
+	"github.com/uesteibar/ralph/internal/shell"
+)
+
+// setupArchivable creates a workspace "login" with one commit on its branch
+// and every kind of state file, returning the repo runner and base branch.
+func setupArchivable(t *testing.T, dir string) (*shell.Runner, string) {
+	t.Helper()
+	r := initRepo(t, dir)
+	ctx := context.Background()
+
+	base, err := gitops.CurrentBranch(ctx, r)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ws := Workspace{
+		Name:      "login",
+		Branch:    "ralph/login",
+		CreatedAt: time.Date(2026, 3, 4, 5, 6, 7, 0, time.UTC),
+		Parent:    "auth",
+	}
+	if err := CreateWorkspace(ctx, dir, ws, base, CreateOptions{}); err != nil {
+		t.Fatalf("CreateWorkspace: %v", err)
+	}
+
+	tree := &shell.Runner{Dir: TreePath(dir, "login")}
+	if err := os.WriteFile(filepath.Join(tree.Dir, "login.go"), []byte("package login\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	for _, c := range [][]string{{"add", "-A"}, {"commit", "-m", "feat(US-001): login"}} {
+		if _, err := tree.Run(ctx, "git", c...); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	wsDir := WorkspacePath(dir, "login")
+	files := map[string]string{
+		"prd.json":               `{"project":"test"}`,
+		"story-commits.json":     `{"US-001":[{"start":"a","end":"b"}]}`,
+		"progress.txt":           "learned things\n",
+		"run.status.json":        `{"result":"success"}`,
+		"logs/20260304.jsonl":    `{"type":"story_started"}` + "\n",
+		"logs/20260303.jsonl.gz": "compressed",
+		"logs/ignored.txt":       "not a log",
+	}
+	for name, content := range files {
+		p := filepath.Join(wsDir, name)
+		os.MkdirAll(filepath.Dir(p), 0755)
+		if err := os.WriteFile(p, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return r, base
+}
+
+func TestArchiveRestore_RoundTrip(t *testing.T) {
+	src := realPath(t, t.TempDir())
+	srcRunner, base := setupArchivable(t, src)
+	ctx := context.Background()
+
+	var archive bytes.Buffer
+	if err := Archive(ctx, srcRunner, src, "login", &archive); err != nil {
+		t.Fatalf("Archive: %v", err)
+	}
+
+	// Restore into a fresh clone, as a teammate would.
+	dst := filepath.Join(realPath(t, t.TempDir()), "clone")
+	if _, err := (&shell.Runner{Dir: src}).Run(ctx, "git", "clone", "-q", src, dst); err != nil {
+		t.Fatal(err)
+	}
+	dstRunner := &shell.Runner{Dir: dst}
+
+	ws, err := Restore(ctx, dstRunner, dst, &archive, base, CreateOptions{})
+	if err != nil {
+		t.Fatalf("Restore: %v", err)
+	}
+
+	got, err := RegistryGet(dst, "login")
+	if err != nil {
+		t.Fatalf("RegistryGet: %v", err)
+	}
+	want := Workspace{Name: "login", Branch: "ralph/login", CreatedAt: time.Date(2026, 3, 4, 5, 6, 7, 0, time.UTC), Parent: "auth"}
+	if *got != want || *ws != want {
+		t.Errorf("registry entry = %+v, want %+v", *got, want)
+	}
+
+	srcHead, _ := gitops.RevParse(ctx, srcRunner, "ralph/login")
+	dstHead, err := gitops.RevParse(ctx, &shell.Runner{Dir: TreePath(dst, "login")}, "HEAD")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if srcHead != dstHead {
+		t.Errorf("restored tree HEAD = %s, want %s", dstHead, srcHead)
+	}
+	if _, err := os.Stat(filepath.Join(TreePath(dst, "login"), "login.go")); err != nil {
+		t.Errorf("committed file missing from restored tree: %v", err)
+	}
+
+	for _, name := range []string{"prd.json", "story-commits.json", "progress.txt", "run.status.json", "logs/20260304.jsonl", "logs/20260303.jsonl.gz"} {
+		want, _ := os.ReadFile(filepath.Join(WorkspacePath(src, "login"), name))
+		got, err := os.ReadFile(filepath.Join(WorkspacePath(dst, "login"), name))
+		if err != nil {
+			t.Errorf("%s not restored: %v", name, err)
+			continue
+		}
+		if !bytes.Equal(got, want) {
+			t.Errorf("%s = %q, want %q", name, got, want)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(WorkspacePath(dst, "login"), "logs", "ignored.txt")); !os.IsNotExist(err) {
+		t.Error("non-JSONL files in logs/ should not be archived")
+	}
+}
+
+func TestRestore_RefusesExistingWorkspace(t *testing.T) {
+	dir := realPath(t, t.TempDir())
+	r, base := setupArchivable(t, dir)
+	ctx := context.Background()
+
+	var archive bytes.Buffer
+	if err := Archive(ctx, r, dir, "login", &archive); err != nil {
+		t.Fatalf("Archive: %v", err)
+	}
+
+	_, err := Restore(ctx, r, dir, &archive, base, CreateOptions{})
+	if err == nil || !strings.Contains(err.Error(), "already exists") {
+		t.Fatalf("expected already exists error, got %v", err)
+	}
+}
+
+func TestRestore_RefusesDivergedBranch(t *testing.T) {
+	src := realPath(t, t.TempDir())
+	srcRunner, base := setupArchivable(t, src)
+	ctx := context.Background()
+
+	var archive bytes.Buffer
+	if err := Archive(ctx, srcRunner, src, "login", &archive); err != nil {
+		t.Fatalf("Archive: %v", err)
+	}
+
+	dst := realPath(t, t.TempDir())
+	dstRunner := initRepo(t, dst)
+	if _, err := dstRunner.Run(ctx, "git", "branch", "ralph/login"); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := Restore(ctx, dstRunner, dst, &archive, base, CreateOptions{})
+	if err == nil || !strings.Contains(err.Error(), "different commit") {
+		t.Fatalf("expected diverged branch error, got %v", err)
+	}
+	if _, statErr := os.Stat(WorkspacePath(dst, "login")); !os.IsNotExist(statErr) {
+		t.Error("workspace directory should not be created on failure")
+	}
+}
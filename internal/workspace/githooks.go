@@ -0,0 +1,114 @@
+package workspace
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/uesteibar/ralph/internal/shell"
+)
+
+// gitHookNames are the client-side hooks git may run in a worktree. Pointing
+// core.hooksPath at the workspace's hooks directory replaces the repository's
+// hooks, so each one is forwarded.
+var gitHookNames = []string{
+	"applypatch-msg", "pre-applypatch", "post-applypatch",
+	"pre-commit", "pre-merge-commit", "prepare-commit-msg", "commit-msg", "post-commit",
+	"pre-rebase", "post-checkout", "post-merge", "pre-push", "post-rewrite",
+	"pre-auto-gc", "reference-transaction", "sendemail-validate", "post-index-change",
+}
+
+// ralphGitHooks are the hooks ralph runs itself, through the hidden
+// "ralph _hook <name>" command, before the repository's own hook.
+var ralphGitHooks = map[string]bool{
+	"pre-commit": true,
+	"commit-msg": true,
+}
+
+// ralphBinary locates the binary the hook scripts call. ralph and AutoRalph
+// both serve "_hook", so the running binary is used when it is one of them;
+// otherwise ralph is looked up on PATH. Overridable in tests.
+var ralphBinary = func() (string, error) {
+	if exe, err := os.Executable(); err == nil {
+		if base := filepath.Base(exe); base == "ralph" || base == "autoralph" {
+			return exe, nil
+		}
+	}
+	return exec.LookPath("ralph")
+}
+
+// hooksPath returns the git hooks directory of a workspace. It lives in the
+// repository's git directory rather than the workspace, so an agent
+// sandboxed in the workspace cannot rewrite the hooks git runs on the
+// human's commits.
+func hooksPath(ctx context.Context, repoPath, name string) (string, error) {
+	out, err := (&shell.Runner{Dir: repoPath}).Run(ctx, "git", "rev-parse", "--path-format=absolute", "--git-common-dir")
+	if err != nil {
+		return "", fmt.Errorf("locating the git directory: %w", err)
+	}
+	return filepath.Join(strings.TrimSpace(out), "ralph", "hooks", name), nil
+}
+
+// installGitHooks points the worktree's core.hooksPath at the workspace's
+// hooks directory, filled with scripts that run ralph's hooks and then forward to the hooks the
+// repository had configured. The setting is per worktree, so the main
+// checkout and other worktrees are unaffected.
+func installGitHooks(ctx context.Context, repoPath, name, treePath string) error {
+	ralph, err := ralphBinary()
+	if err != nil {
+		return fmt.Errorf("locating the ralph binary: %w", err)
+	}
+	hooksDir, err := hooksPath(ctx, repoPath, name)
+	if err != nil {
+		return err
+	}
+
+	treeRunner := &shell.Runner{Dir: treePath}
+	out, err := treeRunner.Run(ctx, "git", "rev-parse", "--path-format=absolute", "--git-path", "hooks")
+	if err != nil {
+		return fmt.Errorf("locating the repository hooks: %w", err)
+	}
+	original := strings.TrimSpace(out)
+
+	if err := os.MkdirAll(hooksDir, 0755); err != nil {
+		return err
+	}
+	for _, name := range gitHookNames {
+		script := hookScript(ralph, name, filepath.Join(original, name))
+		if err := os.WriteFile(filepath.Join(hooksDir, name), []byte(script), 0755); err != nil {
+			return fmt.Errorf("writing %s hook: %w", name, err)
+		}
+	}
+
+	repoRunner := &shell.Runner{Dir: repoPath}
+	if _, err := repoRunner.Run(ctx, "git", "config", "extensions.worktreeConfig", "true"); err != nil {
+		return fmt.Errorf("enabling per-worktree config: %w", err)
+	}
+	if _, err := treeRunner.Run(ctx, "git", "config", "--worktree", "core.hooksPath", hooksDir); err != nil {
+		return fmt.Errorf("setting core.hooksPath: %w", err)
+	}
+	return nil
+}
+
+// hookScript renders the script for one hook.
+func hookScript(ralph, name, original string) string {
+	var b strings.Builder
+	b.WriteString("#!/bin/sh\n")
+	b.WriteString("# Installed by ralph for this workspace. Runs ralph's checks, then the\n")
+	b.WriteString("# repository's own hook, if any.\n")
+	if ralphGitHooks[name] {
+		fmt.Fprintf(&b, "%s _hook %s \"$@\" || exit $?\n", quote(ralph), name)
+	}
+	fmt.Fprintf(&b, "hook=%s\n", quote(original))
+	b.WriteString("[ -x \"$hook\" ] || exit 0\n")
+	b.WriteString("exec \"$hook\" \"$@\"\n")
+	return b.String()
+}
+
+// quote single-quotes s for sh.
+func quote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
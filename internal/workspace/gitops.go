@@ -6,16 +6,34 @@ import (
 	"os"
 	"path/filepath"
 
+	"github.com/uesteibar/ralph/internal/config"
 	"github.com/uesteibar/ralph/internal/gitops"
+	"github.com/uesteibar/ralph/internal/hooks"
 	"github.com/uesteibar/ralph/internal/shell"
 )
 
+// CreateOptions holds the project settings applied when creating a
+// workspace.
+type CreateOptions struct {
+	// CopyPatterns are the copy_to_worktree globs copied into the worktree.
+	CopyPatterns []string
+	// Hooks are the workspace_created hooks run once the workspace exists.
+	Hooks []config.HookConfig
+	// ToolPolicy is the story phase's tool policy.
+	ToolPolicy config.ToolPolicy
+}
+
 // CreateWorkspace creates a workspace with its full directory structure:
 // .ralph/workspaces/<name>/ directory, workspace.json metadata, git worktree
 // at .ralph/workspaces/<name>/tree/, copies .ralph/ (skipping worktrees/,
-// state/, workspaces/), .claude/ if exists, and copy_to_worktree patterns.
-// Finally it updates the registry.
-func CreateWorkspace(ctx context.Context, runner *shell.Runner, repoPath string, ws Workspace, base string, copyPatterns []string) error {
+// state/, workspaces/), .claude/ if exists, and opts.CopyPatterns.
+// Stacked workspaces (ws.Parent set) pass the parent's branch as base, and
+// forks (ws.ForkOf set) pass the commit to branch from. The story tool policy
+// is written to the worktree's .claude/settings.local.json, and the worktree's git
+// hooks run ralph's secret scanner before the repository's own hooks.
+// It then updates the registry and runs the workspace_created hooks; a
+// failing fail-closed hook is returned as an error but the workspace is kept.
+func CreateWorkspace(ctx context.Context, repoPath string, ws Workspace, base string, opts CreateOptions) error {
 	wsDir := WorkspacePath(repoPath, ws.Name)
 	treePath := TreePath(repoPath, ws.Name)
 
@@ -34,7 +52,9 @@ func CreateWorkspace(ctx context.Context, runner *shell.Runner, repoPath string,
 	repoRunner := &shell.Runner{Dir: repoPath}
 
 	// Fetch latest from origin (best effort).
-	_, _ = repoRunner.Run(ctx, "git", "fetch", "origin", base)
+	if ws.Parent == "" && ws.ForkOf == "" {
+		_, _ = repoRunner.Run(ctx, "git", "fetch", "origin", base)
+	}
 
 	// Check if branch exists locally or on remote.
 	existsLocally := gitops.BranchExistsLocally(ctx, repoRunner, ws.Branch)
@@ -45,6 +65,10 @@ func CreateWorkspace(ctx context.Context, runner *shell.Runner, repoPath string,
 	if existsLocally || existsRemote {
 		// Branch already exists — check it out directly (resume scenario).
 		_, err = repoRunner.Run(ctx, "git", "worktree", "add", treePath, ws.Branch)
+	} else if ws.Parent != "" || ws.ForkOf != "" {
+		// Stacked or forked workspace — branch off a local ref (the parent's
+		// branch or the fork point), which may not exist on origin.
+		_, err = repoRunner.Run(ctx, "git", "worktree", "add", "-b", ws.Branch, treePath, base)
 	} else {
 		// New branch — create from base.
 		// Try origin/<base> first, fall back to local <base>.
@@ -71,14 +95,30 @@ func CreateWorkspace(ctx context.Context, runner *shell.Runner, repoPath string,
 		return fmt.Errorf("copying .ralph: %w", err)
 	}
 
-	// Copy .claude/ if it exists.
-	if err := gitops.CopyDotClaude(repoPath, treePath); err != nil {
+	// Copy .claude/ if it exists, adding the tool policy's permission rules
+	// to the local settings, which must never be committed with the work.
+	perms := gitops.ClaudePermissions{Allow: opts.ToolPolicy.AllowRules(), Deny: opts.ToolPolicy.DenyRules()}
+	if err := gitops.CopyDotClaude(repoPath, treePath, perms); err != nil {
 		return fmt.Errorf("copying .claude: %w", err)
 	}
+	if err := gitops.ExcludeFromGit(ctx, &shell.Runner{Dir: treePath}, gitops.ClaudeLocalSettings); err != nil {
+		return fmt.Errorf("excluding %s from git: %w", gitops.ClaudeLocalSettings, err)
+	}
+
+	// Run ralph's git hooks (the secret scanner) in the worktree. Without
+	// them the workspace still works, so a failure is recorded in
+	// workspace.json for ralph run to warn about on every run.
+	if err := installGitHooks(ctx, repoPath, ws.Name, treePath); err != nil {
+		fmt.Fprintf(os.Stderr, "warning: git hooks not installed, commits will not be scanned for secrets: %v\n", err)
+		ws.HooksError = err.Error()
+		if err := WriteWorkspaceJSON(repoPath, ws.Name, ws); err != nil {
+			return fmt.Errorf("writing workspace.json: %w", err)
+		}
+	}
 
 	// Copy user-specified patterns.
-	if len(copyPatterns) > 0 {
-		if err := gitops.CopyGlobPatterns(repoPath, treePath, copyPatterns, func(msg string) {
+	if len(opts.CopyPatterns) > 0 {
+		if err := gitops.CopyGlobPatterns(repoPath, treePath, opts.CopyPatterns, func(msg string) {
 			fmt.Fprintf(os.Stderr, "warning: %s\n", msg)
 		}); err != nil {
 			return fmt.Errorf("copying patterns: %w", err)
@@ -90,11 +130,24 @@ func CreateWorkspace(ctx context.Context, runner *shell.Runner, repoPath string,
 		return fmt.Errorf("updating registry: %w", err)
 	}
 
+	if err := hooks.Run(ctx, opts.Hooks, hooks.Payload{
+		Event:     hooks.WorkspaceCreated,
+		Workspace: ws.Name,
+		Branch:    ws.Branch,
+		RepoPath:  repoPath,
+		WorkDir:   treePath,
+		PRDPath:   PRDPathForWorkspace(repoPath, ws.Name),
+	}, func(msg string) {
+		fmt.Fprintf(os.Stderr, "warning: %s\n", msg)
+	}); err != nil {
+		return err
+	}
+
 	return nil
 }
 
 // RemoveWorkspace removes a workspace: git worktree, workspace directory,
-// registry entry, and the git branch.
+// registry entry, and the git branch unless the workspace adopted it.
 func RemoveWorkspace(ctx context.Context, runner *shell.Runner, repoPath, name string) error {
 	treePath := TreePath(repoPath, name)
 	wsDir := WorkspacePath(repoPath, name)
@@ -109,7 +162,8 @@ func RemoveWorkspace(ctx context.Context, runner *shell.Runner, repoPath, name s
 		}
 		for _, e := range entries {
 			if e.Name == name {
-				ws = &Workspace{Name: e.Name, Branch: e.Branch, CreatedAt: e.CreatedAt}
+				w := e.workspace()
+				ws = &w
 				break
 			}
 		}
@@ -131,13 +185,21 @@ func RemoveWorkspace(ctx context.Context, runner *shell.Runner, repoPath, name s
 		return fmt.Errorf("removing workspace directory: %w", err)
 	}
 
+	// Remove its git hooks (best effort — they may never have been installed).
+	if dir, err := hooksPath(ctx, repoPath, name); err == nil {
+		os.RemoveAll(dir)
+	}
+
 	// Remove registry entry.
 	if err := RegistryRemove(repoPath, name); err != nil {
 		return fmt.Errorf("removing registry entry: %w", err)
 	}
 
-	// Delete git branch (best effort — may already be gone or checked out elsewhere).
-	_ = gitops.DeleteBranch(ctx, repoRunner, ws.Branch)
+	// Delete git branch (best effort — may already be gone or checked out
+	// elsewhere). An adopted branch was not ralph's to begin with, so it stays.
+	if !ws.Adopted {
+		_ = gitops.DeleteBranch(ctx, repoRunner, ws.Branch)
+	}
 
 	return nil
 }
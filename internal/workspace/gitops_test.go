@@ -2,12 +2,14 @@ package workspace
 
 import (
 	"context"
+	"errors"
 	"os"
 	"path/filepath"
 	"strings"
 	"testing"
 	"time"
 
+	"github.com/uesteibar/ralph/internal/config"
 	"github.com/uesteibar/ralph/internal/shell"
 )
 
@@ -104,7 +106,7 @@ func TestCreateWorkspace_CreatesStructure(t *testing.T) {
 	now := time.Date(2026, 2, 1, 12, 0, 0, 0, time.UTC)
 	ws := Workspace{Name: "test-ws", Branch: "ralph/test-ws", CreatedAt: now}
 
-	err = CreateWorkspace(ctx, r, dir, ws, defaultBranch, nil)
+	err = CreateWorkspace(ctx, dir, ws, defaultBranch, CreateOptions{})
 	if err != nil {
 		t.Fatalf("CreateWorkspace error: %v", err)
 	}
@@ -197,7 +199,7 @@ func TestCreateWorkspace_CopiesDotClaude(t *testing.T) {
 
 	ws := Workspace{Name: "claude-ws", Branch: "ralph/claude-ws", CreatedAt: time.Now()}
 
-	err := CreateWorkspace(ctx, r, dir, ws, defaultBranch, nil)
+	err := CreateWorkspace(ctx, dir, ws, defaultBranch, CreateOptions{})
 	if err != nil {
 		t.Fatalf("CreateWorkspace error: %v", err)
 	}
@@ -219,6 +221,87 @@ func TestCreateWorkspace_CopiesDotClaude(t *testing.T) {
 	}
 }
 
+func TestCreateWorkspace_ToolPolicy_LeavesTreeClean(t *testing.T) {
+	dir := realPath(t, t.TempDir())
+	r := initRepo(t, dir)
+	ctx := context.Background()
+
+	os.MkdirAll(filepath.Join(dir, ".claude"), 0755)
+	os.WriteFile(filepath.Join(dir, ".claude", "settings.json"), []byte(`{"k":"v"}`), 0644)
+	r.Run(ctx, "git", "add", "-A")
+	r.Run(ctx, "git", "commit", "-m", "add claude")
+
+	branchOut, _ := r.Run(ctx, "git", "rev-parse", "--abbrev-ref", "HEAD")
+	ws := Workspace{Name: "policy", Branch: "ralph/policy", CreatedAt: time.Now()}
+	policy := config.ToolPolicy{Bash: config.BashPolicy{Deny: []string{"git push"}}}
+	if err := CreateWorkspace(ctx, dir, ws, strings.TrimSpace(branchOut), CreateOptions{ToolPolicy: policy}); err != nil {
+		t.Fatalf("CreateWorkspace: %v", err)
+	}
+
+	treePath := TreePath(dir, "policy")
+	data, err := os.ReadFile(filepath.Join(treePath, ".claude", "settings.local.json"))
+	if err != nil || !strings.Contains(string(data), "Bash(git push:*)") {
+		t.Errorf("settings.local.json = %s, %v; want the deny rule", data, err)
+	}
+	out, err := (&shell.Runner{Dir: treePath}).Run(ctx, "git", "status", "--porcelain")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.TrimSpace(out) != "" {
+		t.Errorf("worktree status = %q, want it clean so the policy is never committed", out)
+	}
+}
+
+func TestCreateWorkspace_RunsWorkspaceCreatedHooks(t *testing.T) {
+	dir := realPath(t, t.TempDir())
+	r := initRepo(t, dir)
+	ctx := context.Background()
+
+	branchOut, _ := r.Run(ctx, "git", "rev-parse", "--abbrev-ref", "HEAD")
+	defaultBranch := strings.TrimSpace(branchOut)
+
+	ws := Workspace{Name: "hook-ws", Branch: "ralph/hook-ws", CreatedAt: time.Now()}
+	hooks := []config.HookConfig{{Command: `echo "$RALPH_WORKSPACE $RALPH_BRANCH" > hook.txt`}}
+
+	if err := CreateWorkspace(ctx, dir, ws, defaultBranch, CreateOptions{Hooks: hooks}); err != nil {
+		t.Fatalf("CreateWorkspace error: %v", err)
+	}
+
+	// Hooks run inside the new tree.
+	data, err := os.ReadFile(filepath.Join(TreePath(dir, "hook-ws"), "hook.txt"))
+	if err != nil {
+		t.Fatalf("expected hook output in tree: %v", err)
+	}
+	if got := strings.TrimSpace(string(data)); got != "hook-ws ralph/hook-ws" {
+		t.Errorf("hook output = %q, want %q", got, "hook-ws ralph/hook-ws")
+	}
+}
+
+func TestCreateWorkspace_FailClosedHookReturnsError(t *testing.T) {
+	dir := realPath(t, t.TempDir())
+	r := initRepo(t, dir)
+	ctx := context.Background()
+
+	branchOut, _ := r.Run(ctx, "git", "rev-parse", "--abbrev-ref", "HEAD")
+	defaultBranch := strings.TrimSpace(branchOut)
+
+	ws := Workspace{Name: "hook-fail", Branch: "ralph/hook-fail", CreatedAt: time.Now()}
+	hooks := []config.HookConfig{{Command: "exit 1", OnFailure: config.HookOnFailureAbort}}
+
+	err := CreateWorkspace(ctx, dir, ws, defaultBranch, CreateOptions{Hooks: hooks})
+	if err == nil {
+		t.Fatal("expected error from fail-closed workspace_created hook")
+	}
+	if !strings.Contains(err.Error(), "workspace_created hook") {
+		t.Errorf("error = %q, want workspace_created hook", err)
+	}
+
+	// The workspace itself is kept so the user can fix and retry the hook.
+	if _, err := RegistryGet(dir, "hook-fail"); err != nil {
+		t.Errorf("expected workspace to remain registered: %v", err)
+	}
+}
+
 func TestCreateWorkspace_CopiesGlobPatterns(t *testing.T) {
 	dir := realPath(t, t.TempDir())
 	r := initRepo(t, dir)
@@ -246,7 +329,7 @@ func TestCreateWorkspace_CopiesGlobPatterns(t *testing.T) {
 
 	ws := Workspace{Name: "glob-ws", Branch: "ralph/glob-ws", CreatedAt: time.Now()}
 
-	err := CreateWorkspace(ctx, r, dir, ws, defaultBranch, []string{"scripts/setup.sh"})
+	err := CreateWorkspace(ctx, dir, ws, defaultBranch, CreateOptions{CopyPatterns: []string{"scripts/setup.sh"}})
 	if err != nil {
 		t.Fatalf("CreateWorkspace error: %v", err)
 	}
@@ -274,7 +357,7 @@ func TestRemoveWorkspace_Cleanup(t *testing.T) {
 	ws := Workspace{Name: "remove-me", Branch: "ralph/remove-me", CreatedAt: time.Now()}
 
 	// Create workspace first.
-	if err := CreateWorkspace(ctx, r, dir, ws, defaultBranch, nil); err != nil {
+	if err := CreateWorkspace(ctx, dir, ws, defaultBranch, CreateOptions{}); err != nil {
 		t.Fatalf("CreateWorkspace error: %v", err)
 	}
 
@@ -311,6 +394,33 @@ func TestRemoveWorkspace_Cleanup(t *testing.T) {
 	}
 }
 
+func TestRemoveWorkspace_Adopted_KeepsBranch(t *testing.T) {
+	dir := realPath(t, t.TempDir())
+	r := initRepo(t, dir)
+	ctx := context.Background()
+
+	branchOut, _ := r.Run(ctx, "git", "rev-parse", "--abbrev-ref", "HEAD")
+	if _, err := r.Run(ctx, "git", "branch", "feature/theirs"); err != nil {
+		t.Fatal(err)
+	}
+	ws := Workspace{Name: "adopted", Branch: "feature/theirs", CreatedAt: time.Now(), Adopted: true}
+	if err := CreateWorkspace(ctx, dir, ws, strings.TrimSpace(branchOut), CreateOptions{}); err != nil {
+		t.Fatalf("CreateWorkspace error: %v", err)
+	}
+	// A missing workspace directory falls back to the registry, which must
+	// still know the branch was adopted.
+	os.RemoveAll(WorkspacePath(dir, "adopted"))
+	r.Run(ctx, "git", "worktree", "prune")
+
+	if err := RemoveWorkspace(ctx, r, dir, "adopted"); err != nil {
+		t.Fatalf("RemoveWorkspace error: %v", err)
+	}
+
+	if _, err := r.Run(ctx, "git", "rev-parse", "--verify", "refs/heads/feature/theirs"); err != nil {
+		t.Error("expected the adopted branch to be kept")
+	}
+}
+
 func TestCreateWorkspace_ExistingBranch(t *testing.T) {
 	dir := realPath(t, t.TempDir())
 	r := initRepo(t, dir)
@@ -340,7 +450,7 @@ func TestCreateWorkspace_ExistingBranch(t *testing.T) {
 
 	// Create workspace from existing branch (resume scenario).
 	ws := Workspace{Name: "existing", Branch: "ralph/existing", CreatedAt: time.Now()}
-	if err := CreateWorkspace(ctx, r, dir, ws, defaultBranch, nil); err != nil {
+	if err := CreateWorkspace(ctx, dir, ws, defaultBranch, CreateOptions{}); err != nil {
 		t.Fatalf("CreateWorkspace error: %v", err)
 	}
 
@@ -392,7 +502,7 @@ func TestCreateWorkspace_NoDotRalphState(t *testing.T) {
 	defaultBranch := strings.TrimSpace(branchOut)
 
 	ws := Workspace{Name: "no-state", Branch: "ralph/no-state", CreatedAt: time.Now()}
-	if err := CreateWorkspace(ctx, r, dir, ws, defaultBranch, nil); err != nil {
+	if err := CreateWorkspace(ctx, dir, ws, defaultBranch, CreateOptions{}); err != nil {
 		t.Fatalf("CreateWorkspace error: %v", err)
 	}
 
@@ -403,3 +513,113 @@ func TestCreateWorkspace_NoDotRalphState(t *testing.T) {
 		t.Errorf("tree/.ralph/state/ should NOT exist, got err: %v", err)
 	}
 }
+
+func TestCreateWorkspace_InstallsGitHooks(t *testing.T) {
+	dir := realPath(t, t.TempDir())
+	r := initRepo(t, dir)
+	ctx := context.Background()
+
+	// A fake ralph that records its calls, and a pre-existing repo hook.
+	calls := filepath.Join(t.TempDir(), "calls")
+	fake := filepath.Join(t.TempDir(), "ralph")
+	os.WriteFile(fake, []byte("#!/bin/sh\necho \"$@\" >> "+quote(calls)+"\n"), 0755)
+	orig := ralphBinary
+	ralphBinary = func() (string, error) { return fake, nil }
+	t.Cleanup(func() { ralphBinary = orig })
+
+	repoHook := filepath.Join(dir, ".git", "hooks", "pre-commit")
+	os.WriteFile(repoHook, []byte("#!/bin/sh\necho repo >> "+quote(calls)+"\n"), 0755)
+
+	branchOut, _ := r.Run(ctx, "git", "rev-parse", "--abbrev-ref", "HEAD")
+	ws := Workspace{Name: "hooked", Branch: "ralph/hooked", CreatedAt: time.Now()}
+	if err := CreateWorkspace(ctx, dir, ws, strings.TrimSpace(branchOut), CreateOptions{}); err != nil {
+		t.Fatalf("CreateWorkspace: %v", err)
+	}
+
+	tree := &shell.Runner{Dir: TreePath(dir, "hooked")}
+	if _, err := tree.Run(ctx, "git", "commit", "--allow-empty", "-m", "in tree"); err != nil {
+		t.Fatalf("commit in tree: %v", err)
+	}
+	data, _ := os.ReadFile(calls)
+	if got := string(data); !strings.HasPrefix(got, "_hook pre-commit\nrepo\n_hook commit-msg ") {
+		t.Errorf("hook calls = %q, want ralph's hook then the repository's", got)
+	}
+
+	// The main checkout keeps its own hooks only.
+	os.Remove(calls)
+	if _, err := r.Run(ctx, "git", "commit", "--allow-empty", "-m", "in main"); err != nil {
+		t.Fatalf("commit in main: %v", err)
+	}
+	data, _ = os.ReadFile(calls)
+	if got := string(data); got != "repo\n" {
+		t.Errorf("main checkout hook calls = %q, want only the repository's hook", got)
+	}
+
+	// The hooks sit in the git directory, out of the sandboxed workspace's
+	// reach, and go with the workspace.
+	hooksDir := filepath.Join(dir, ".git", "ralph", "hooks", "hooked")
+	if out, _ := tree.Run(ctx, "git", "config", "core.hooksPath"); strings.TrimSpace(out) != hooksDir {
+		t.Errorf("core.hooksPath = %q, want %s", strings.TrimSpace(out), hooksDir)
+	}
+	if err := RemoveWorkspace(ctx, r, dir, "hooked"); err != nil {
+		t.Fatalf("RemoveWorkspace: %v", err)
+	}
+	if _, err := os.Stat(hooksDir); !os.IsNotExist(err) {
+		t.Errorf("expected %s to be removed with the workspace", hooksDir)
+	}
+}
+
+func TestCreateWorkspace_RecordsHooksFailure(t *testing.T) {
+	dir := realPath(t, t.TempDir())
+	r := initRepo(t, dir)
+	ctx := context.Background()
+
+	orig := ralphBinary
+	ralphBinary = func() (string, error) { return "", errors.New("ralph not found") }
+	t.Cleanup(func() { ralphBinary = orig })
+
+	branchOut, _ := r.Run(ctx, "git", "rev-parse", "--abbrev-ref", "HEAD")
+	ws := Workspace{Name: "unhooked", Branch: "ralph/unhooked", CreatedAt: time.Now()}
+	if err := CreateWorkspace(ctx, dir, ws, strings.TrimSpace(branchOut), CreateOptions{}); err != nil {
+		t.Fatalf("CreateWorkspace: %v", err)
+	}
+
+	got, err := ReadWorkspaceJSON(dir, "unhooked")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(got.HooksError, "ralph not found") {
+		t.Errorf("HooksError = %q, want the install failure recorded", got.HooksError)
+	}
+}
+
+func TestRegistryUpdate_KeepsHooksFailure(t *testing.T) {
+	dir := realPath(t, t.TempDir())
+	r := initRepo(t, dir)
+	ctx := context.Background()
+
+	orig := ralphBinary
+	ralphBinary = func() (string, error) { return "", errors.New("ralph not found") }
+	t.Cleanup(func() { ralphBinary = orig })
+
+	branchOut, _ := r.Run(ctx, "git", "rev-parse", "--abbrev-ref", "HEAD")
+	ws := Workspace{Name: "unhooked", Branch: "ralph/unhooked", CreatedAt: time.Now()}
+	if err := CreateWorkspace(ctx, dir, ws, strings.TrimSpace(branchOut), CreateOptions{}); err != nil {
+		t.Fatalf("CreateWorkspace: %v", err)
+	}
+
+	if err := RegistryUpdate(dir, "unhooked", func(ws *Workspace) { ws.Status = StatusInReview }); err != nil {
+		t.Fatalf("RegistryUpdate: %v", err)
+	}
+
+	got, err := ReadWorkspaceJSON(dir, "unhooked")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Status != StatusInReview {
+		t.Errorf("Status = %q, want %q", got.Status, StatusInReview)
+	}
+	if !strings.Contains(got.HooksError, "ralph not found") {
+		t.Errorf("HooksError = %q, want it kept across the update", got.HooksError)
+	}
+}
@@ -0,0 +1,61 @@
+package workspace
+
+import "fmt"
+
+// Children returns the workspaces stacked directly on name, in registry order.
+func Children(repoPath, name string) ([]Workspace, error) {
+	entries, err := readRegistry(repoPath)
+	if err != nil {
+		return nil, err
+	}
+	var children []Workspace
+	for _, e := range entries {
+		if e.Parent == name {
+			children = append(children, e.workspace())
+		}
+	}
+	return children, nil
+}
+
+// Stack returns every workspace in the stack containing name, ordered so
+// that each parent comes before its children. The first element is the
+// root of the stack. A parent that is no longer registered is treated as the
+// default base.
+func Stack(repoPath, name string) ([]Workspace, error) {
+	entries, err := readRegistry(repoPath)
+	if err != nil {
+		return nil, err
+	}
+	byName := make(map[string]registryEntry, len(entries))
+	for _, e := range entries {
+		byName[e.Name] = e
+	}
+
+	root, ok := byName[name]
+	if !ok {
+		return nil, fmt.Errorf("workspace %q not found", name)
+	}
+	seen := map[string]bool{root.Name: true}
+	for {
+		parent, ok := byName[root.Parent]
+		if !ok {
+			break
+		}
+		if seen[parent.Name] {
+			return nil, fmt.Errorf("workspace %q is part of a parent cycle", name)
+		}
+		seen[parent.Name] = true
+		root = parent
+	}
+
+	// Breadth-first from the root keeps parents ahead of children.
+	stack := []Workspace{root.workspace()}
+	for i := 0; i < len(stack); i++ {
+		for _, e := range entries {
+			if e.Parent == stack[i].Name && e.Name != root.Name {
+				stack = append(stack, e.workspace())
+			}
+		}
+	}
+	return stack, nil
+}
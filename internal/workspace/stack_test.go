@@ -0,0 +1,104 @@
+package workspace
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+// createStack registers: api <- ui <- docs, api <- cli, and an unrelated other.
+func createStack(t *testing.T, dir string) {
+	t.Helper()
+	for _, ws := range []Workspace{
+		{Name: "api", Branch: "ralph/api"},
+		{Name: "other", Branch: "ralph/other"},
+		{Name: "ui", Branch: "ralph/ui", Parent: "api"},
+		{Name: "docs", Branch: "ralph/docs", Parent: "ui"},
+		{Name: "cli", Branch: "ralph/cli", Parent: "api"},
+	} {
+		ws.CreatedAt = time.Now()
+		if err := RegistryCreate(dir, ws); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func names(wss []Workspace) []string {
+	var out []string
+	for _, ws := range wss {
+		out = append(out, ws.Name)
+	}
+	return out
+}
+
+func TestStack_ParentsBeforeChildren(t *testing.T) {
+	dir := t.TempDir()
+	createStack(t, dir)
+
+	// The stack is the same whichever member it is resolved from.
+	for _, from := range []string{"api", "ui", "docs", "cli"} {
+		got, err := Stack(dir, from)
+		if err != nil {
+			t.Fatalf("Stack(%s): %v", from, err)
+		}
+		want := []string{"api", "ui", "cli", "docs"}
+		if !slices.Equal(names(got), want) {
+			t.Errorf("Stack(%s) = %v, want %v", from, names(got), want)
+		}
+	}
+
+	got, err := Stack(dir, "other")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(names(got), []string{"other"}) {
+		t.Errorf("Stack(other) = %v, want [other]", names(got))
+	}
+}
+
+func TestStack_NotFound(t *testing.T) {
+	if _, err := Stack(t.TempDir(), "nope"); err == nil {
+		t.Fatal("expected error for unknown workspace")
+	}
+}
+
+func TestChildren(t *testing.T) {
+	dir := t.TempDir()
+	createStack(t, dir)
+
+	got, err := Children(dir, "api")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(names(got), []string{"ui", "cli"}) {
+		t.Errorf("Children(api) = %v, want [ui cli]", names(got))
+	}
+}
+
+func TestRegistryRemove_ReparentsChildren(t *testing.T) {
+	dir := t.TempDir()
+	createStack(t, dir)
+
+	if err := RegistryRemove(dir, "ui"); err != nil {
+		t.Fatal(err)
+	}
+	list, err := RegistryList(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, ws := range list {
+		if ws.Name == "docs" && ws.Parent != "api" {
+			t.Errorf("docs parent = %q, want api", ws.Parent)
+		}
+	}
+
+	if err := RegistryRemove(dir, "api"); err != nil {
+		t.Fatal(err)
+	}
+	list, _ = RegistryList(dir)
+	for _, ws := range list {
+		if ws.Parent != "" {
+			t.Errorf("%s parent = %q, want empty after root removed", ws.Name, ws.Parent)
+		}
+	}
+}
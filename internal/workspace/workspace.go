@@ -8,6 +8,8 @@ import (
 	"regexp"
 	"strings"
 	"time"
+
+	"github.com/uesteibar/ralph/internal/fsutil"
 )
 
 // Workspace represents a named workspace with metadata.
@@ -15,8 +17,28 @@ type Workspace struct {
 	Name      string    `json:"name"`
 	Branch    string    `json:"branch"`
 	CreatedAt time.Time `json:"createdAt"`
+	// Adopted is true when the workspace took over an existing branch
+	// (--from-branch or --from-pr) instead of creating a fresh one.
+	Adopted bool `json:"adopted,omitempty"`
+	// PR is the pull request number of the branch, either the one it was
+	// adopted from or the one opened by ralph done --pr.
+	PR int `json:"pr,omitempty"`
+	// Parent is the workspace this one is stacked on (--on). Empty means the
+	// workspace branches off the default base.
+	Parent string `json:"parent,omitempty"`
+	// ForkOf is the workspace this one was forked from as a variant.
+	ForkOf string `json:"forkOf,omitempty"`
+	// Status is empty for workspaces being worked on, or StatusInReview once
+	// a pull request has been opened for them.
+	Status string `json:"status,omitempty"`
+	// HooksError records why ralph's git hooks, and with them the secret
+	// scanner, could not be installed in the worktree.
+	HooksError string `json:"hooksError,omitempty"`
 }
 
+// StatusInReview marks a workspace whose pull request is awaiting review.
+const StatusInReview = "in_review"
+
 // WorkContext holds the resolved context for the current working environment.
 type WorkContext struct {
 	Name         string // workspace name or "base"
@@ -105,9 +127,33 @@ type registryEntry struct {
 	Name      string    `json:"name"`
 	Branch    string    `json:"branch"`
 	CreatedAt time.Time `json:"createdAt"`
+	Adopted   bool      `json:"adopted,omitempty"`
+	PR        int       `json:"pr,omitempty"`
+	Parent    string    `json:"parent,omitempty"`
+	ForkOf    string    `json:"forkOf,omitempty"`
+	Status    string    `json:"status,omitempty"`
 	Missing   bool      `json:"missing,omitempty"`
 }
 
+func (e registryEntry) workspace() Workspace {
+	var ws Workspace
+	e.applyTo(&ws)
+	return ws
+}
+
+// applyTo copies the fields the registry owns onto ws, leaving the ones only
+// workspace.json records, such as HooksError, alone.
+func (e registryEntry) applyTo(ws *Workspace) {
+	ws.Name = e.Name
+	ws.Branch = e.Branch
+	ws.CreatedAt = e.CreatedAt
+	ws.Adopted = e.Adopted
+	ws.PR = e.PR
+	ws.Parent = e.Parent
+	ws.ForkOf = e.ForkOf
+	ws.Status = e.Status
+}
+
 func registryPath(repoPath string) string {
 	return filepath.Join(repoPath, ".ralph", "state", "workspaces.json")
 }
@@ -136,26 +182,44 @@ func writeRegistry(repoPath string, entries []registryEntry) error {
 	if err != nil {
 		return fmt.Errorf("marshaling workspaces registry: %w", err)
 	}
-	return os.WriteFile(registryPath(repoPath), data, 0644)
+	return fsutil.WriteFileAtomic(registryPath(repoPath), data, 0644)
+}
+
+// updateRegistry runs a read-modify-write of the registry while holding its
+// lock, so concurrent ralph processes don't overwrite each other's changes.
+func updateRegistry(repoPath string, fn func([]registryEntry) ([]registryEntry, error)) error {
+	return fsutil.WithLock(registryPath(repoPath), func() error {
+		entries, err := readRegistry(repoPath)
+		if err != nil {
+			return err
+		}
+		entries, err = fn(entries)
+		if err != nil {
+			return err
+		}
+		return writeRegistry(repoPath, entries)
+	})
 }
 
 // RegistryCreate adds a workspace to the registry.
 func RegistryCreate(repoPath string, ws Workspace) error {
-	entries, err := readRegistry(repoPath)
-	if err != nil {
-		return err
-	}
-	for _, e := range entries {
-		if e.Name == ws.Name {
-			return fmt.Errorf("workspace %q already exists in registry", ws.Name)
+	return updateRegistry(repoPath, func(entries []registryEntry) ([]registryEntry, error) {
+		for _, e := range entries {
+			if e.Name == ws.Name {
+				return nil, fmt.Errorf("workspace %q already exists in registry", ws.Name)
+			}
 		}
-	}
-	entries = append(entries, registryEntry{
-		Name:      ws.Name,
-		Branch:    ws.Branch,
-		CreatedAt: ws.CreatedAt,
+		return append(entries, registryEntry{
+			Name:      ws.Name,
+			Branch:    ws.Branch,
+			CreatedAt: ws.CreatedAt,
+			Adopted:   ws.Adopted,
+			PR:        ws.PR,
+			Parent:    ws.Parent,
+			ForkOf:    ws.ForkOf,
+			Status:    ws.Status,
+		}), nil
 	})
-	return writeRegistry(repoPath, entries)
 }
 
 // RegistryList returns all registered workspaces, detecting missing directories.
@@ -166,12 +230,7 @@ func RegistryList(repoPath string) ([]Workspace, error) {
 	}
 	var result []Workspace
 	for _, e := range entries {
-		ws := Workspace{
-			Name:      e.Name,
-			Branch:    e.Branch,
-			CreatedAt: e.CreatedAt,
-		}
-		result = append(result, ws)
+		result = append(result, e.workspace())
 	}
 	return result, nil
 }
@@ -180,6 +239,10 @@ func RegistryList(repoPath string) ([]Workspace, error) {
 type WorkspaceEntry struct {
 	Name    string
 	Branch  string
+	Parent  string
+	ForkOf  string
+	Status  string
+	PR      int
 	Missing bool
 }
 
@@ -195,6 +258,10 @@ func RegistryListWithMissing(repoPath string) ([]WorkspaceEntry, error) {
 		entry := WorkspaceEntry{
 			Name:   e.Name,
 			Branch: e.Branch,
+			Parent: e.Parent,
+			ForkOf: e.ForkOf,
+			Status: e.Status,
+			PR:     e.PR,
 		}
 		wsDir := WorkspacePath(repoPath, e.Name)
 		if _, statErr := os.Stat(wsDir); os.IsNotExist(statErr) {
@@ -213,11 +280,7 @@ func RegistryGet(repoPath, name string) (*Workspace, error) {
 	}
 	for _, e := range entries {
 		if e.Name == name {
-			ws := Workspace{
-				Name:      e.Name,
-				Branch:    e.Branch,
-				CreatedAt: e.CreatedAt,
-			}
+			ws := e.workspace()
 			// Detect missing directory
 			wsDir := WorkspacePath(repoPath, name)
 			if _, statErr := os.Stat(wsDir); os.IsNotExist(statErr) {
@@ -229,28 +292,65 @@ func RegistryGet(repoPath, name string) (*Workspace, error) {
 	return nil, fmt.Errorf("workspace %q not found", name)
 }
 
-// RegistryRemove removes a workspace from the registry by name.
+// RegistryRemove removes a workspace from the registry by name. Workspaces
+// stacked on it are moved onto its parent.
 func RegistryRemove(repoPath, name string) error {
-	entries, err := readRegistry(repoPath)
+	return updateRegistry(repoPath, func(entries []registryEntry) ([]registryEntry, error) {
+		var removed *registryEntry
+		remaining := []registryEntry{}
+		for _, e := range entries {
+			if e.Name == name {
+				removed = &e
+				continue
+			}
+			remaining = append(remaining, e)
+		}
+		if removed == nil {
+			return nil, fmt.Errorf("workspace %q not found in registry", name)
+		}
+		for i := range remaining {
+			if remaining[i].Parent == name {
+				remaining[i].Parent = removed.Parent
+			}
+		}
+		return remaining, nil
+	})
+}
+
+// RegistryUpdate applies fn to the registry entry of workspace name and
+// mirrors the result into its workspace.json, keeping the fields the registry
+// does not track. The name and branch cannot be changed.
+func RegistryUpdate(repoPath, name string, fn func(*Workspace)) error {
+	var updated registryEntry
+	err := updateRegistry(repoPath, func(entries []registryEntry) ([]registryEntry, error) {
+		for i, e := range entries {
+			if e.Name != name {
+				continue
+			}
+			ws := e.workspace()
+			fn(&ws)
+			entries[i].Adopted = ws.Adopted
+			entries[i].PR = ws.PR
+			entries[i].Parent = ws.Parent
+			entries[i].ForkOf = ws.ForkOf
+			entries[i].Status = ws.Status
+			updated = entries[i]
+			return entries, nil
+		}
+		return nil, fmt.Errorf("workspace %q not found in registry", name)
+	})
 	if err != nil {
 		return err
 	}
-	found := false
-	var remaining []registryEntry
-	for _, e := range entries {
-		if e.Name == name {
-			found = true
-			continue
-		}
-		remaining = append(remaining, e)
-	}
-	if !found {
-		return fmt.Errorf("workspace %q not found in registry", name)
+	if _, statErr := os.Stat(WorkspacePath(repoPath, name)); statErr != nil {
+		return nil
 	}
-	if remaining == nil {
-		remaining = []registryEntry{}
+	ws, err := ReadWorkspaceJSON(repoPath, name)
+	if err != nil {
+		ws = &Workspace{}
 	}
-	return writeRegistry(repoPath, remaining)
+	updated.applyTo(ws)
+	return WriteWorkspaceJSON(repoPath, name, *ws)
 }
 
 // ReadWorkspaceJSON reads the workspace.json file from a workspace directory.
@@ -277,7 +377,7 @@ func WriteWorkspaceJSON(repoPath, name string, ws Workspace) error {
 	if err != nil {
 		return fmt.Errorf("marshaling workspace.json: %w", err)
 	}
-	return os.WriteFile(filepath.Join(dir, "workspace.json"), data, 0644)
+	return fsutil.WriteFileAtomic(filepath.Join(dir, "workspace.json"), data, 0644)
 }
 
 // ResolveWorkContext resolves the current work context using the following priority:
@@ -1,6 +1,7 @@
 package workspace
 
 import (
+	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
@@ -303,6 +304,48 @@ func TestRegistry_Remove_NotFound(t *testing.T) {
 	}
 }
 
+func TestRegistry_Update(t *testing.T) {
+	dir := t.TempDir()
+	ws := Workspace{Name: "review-me", Branch: "ralph/review-me", CreatedAt: time.Now().UTC()}
+	if err := RegistryCreate(dir, ws); err != nil {
+		t.Fatal(err)
+	}
+	if err := WriteWorkspaceJSON(dir, "review-me", ws); err != nil {
+		t.Fatal(err)
+	}
+
+	err := RegistryUpdate(dir, "review-me", func(w *Workspace) {
+		w.Status = StatusInReview
+		w.PR = 42
+	})
+	if err != nil {
+		t.Fatalf("RegistryUpdate error: %v", err)
+	}
+
+	got, err := RegistryGet(dir, "review-me")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Status != StatusInReview || got.PR != 42 {
+		t.Errorf("registry entry = %+v, want status %q and PR 42", got, StatusInReview)
+	}
+	onDisk, err := ReadWorkspaceJSON(dir, "review-me")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if onDisk.Status != StatusInReview || onDisk.PR != 42 {
+		t.Errorf("workspace.json = %+v, want status %q and PR 42", onDisk, StatusInReview)
+	}
+}
+
+func TestRegistry_Update_NotFound(t *testing.T) {
+	dir := t.TempDir()
+	err := RegistryUpdate(dir, "nope", func(*Workspace) {})
+	if err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Errorf("expected not found error, got %v", err)
+	}
+}
+
 func TestRegistry_MissingFile_ReturnsEmptyList(t *testing.T) {
 	dir := t.TempDir()
 	list, err := RegistryList(dir)
@@ -476,3 +519,27 @@ func TestResolveWorkContext_InvalidName_Error(t *testing.T) {
 		t.Fatal("expected error for invalid workspace name")
 	}
 }
+
+func TestRegistry_ConcurrentCreatesAreNotLost(t *testing.T) {
+	dir := t.TempDir()
+
+	errs := make(chan error, 10)
+	for i := range 10 {
+		go func() {
+			errs <- RegistryCreate(dir, Workspace{Name: fmt.Sprintf("ws-%d", i), Branch: "b", CreatedAt: time.Now()})
+		}()
+	}
+	for range 10 {
+		if err := <-errs; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	entries, err := readRegistry(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 10 {
+		t.Errorf("registry has %d entries, want 10", len(entries))
+	}
+}
@@ -31,6 +31,7 @@ function eventIcon(eventType: string): string {
     case 'changes_requested': return '\u21BB'
     case 'feedback_addressed': return '\u2714'
     case 'workspace_created': return '\uD83D\uDCC1'
+    case 'commit_created': return '\u25C9'
     default: return '\u25CF'
   }
 }